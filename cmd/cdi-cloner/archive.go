@@ -0,0 +1,308 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog"
+)
+
+const (
+	tarBlockSize = 512
+	// largest values of the ustar octal fields, larger values are stored in PAX records
+	maxOctalID   = 07777777
+	maxOctalSize = 077777777777
+	// largest length of the ustar user and group name fields
+	maxNameLength = 32
+	// names of the entries of sparse files, GNU tar uses the GNU.sparse.name record instead
+	sparseEntryName    = "./GNUSparseFile.0/sparse"
+	sparsePAXEntryName = "./GNUSparseFile.0/PaxHeaders.0/sparse"
+
+	// lseek whence values, missing from the vendored unix package
+	seekData = 3
+	seekHole = 4
+)
+
+// extent is a range of a file containing data
+type extent struct {
+	offset int64
+	length int64
+}
+
+// dirSize returns the apparent and allocated size of the files in dir
+func dirSize(dir string) (int64, int64, error) {
+	var apparent, allocated int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		apparent += info.Size()
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			allocated += stat.Blocks * 512
+		} else {
+			allocated += info.Size()
+		}
+		return nil
+	})
+	return apparent, allocated, err
+}
+
+// writeArchive writes the contents of dir to w as a tar archive. Files with holes are written in the
+// PAX 1.0 sparse format so only their data is read and sent.
+func writeArchive(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if info.Mode()&os.ModeSocket != 0 {
+			klog.Infof("Skipping socket %s", path)
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return errors.Wrapf(err, "unable to read link %s", path)
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return errors.Wrapf(err, "unable to create header for %s", path)
+		}
+		hdr.Name = "./" + filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		klog.V(3).Infof("Archiving %s", hdr.Name)
+
+		if !info.Mode().IsRegular() {
+			return tw.WriteHeader(hdr)
+		}
+		return writeArchiveFile(tw, w, hdr, path)
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func writeArchiveFile(tw *tar.Writer, w io.Writer, hdr *tar.Header, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open %s", path)
+	}
+	defer f.Close()
+
+	extents, err := dataExtents(f, hdr.Size)
+	if err != nil {
+		return errors.Wrapf(err, "unable to find data extents of %s", path)
+	}
+
+	if len(extents) == 1 && extents[0].length == hdr.Size {
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		// dataExtents moved the file offset
+		_, err = io.CopyN(tw, io.NewSectionReader(f, 0, hdr.Size), hdr.Size)
+		return errors.Wrapf(err, "unable to read %s", path)
+	}
+
+	return writeSparseFile(tw, w, hdr, f, extents)
+}
+
+// writeSparseFile writes the file in the PAX 1.0 sparse format. archive/tar can't write sparse files, it drops
+// GNU.sparse records, so the headers are written directly to w. The entries have short names, the name of the
+// file is in the GNU.sparse.name record, and fields not fitting the ustar header are in PAX records.
+func writeSparseFile(tw *tar.Writer, w io.Writer, hdr *tar.Header, f *os.File, extents []extent) error {
+	// a zero length extent at the end keeps the size for tars that ignore GNU.sparse.realsize
+	if len(extents) == 0 || extents[len(extents)-1].offset+extents[len(extents)-1].length < hdr.Size {
+		extents = append(extents, extent{offset: hdr.Size})
+	}
+	var sparseMap bytes.Buffer
+	var dataSize int64
+	fmt.Fprintf(&sparseMap, "%d\n", len(extents))
+	for _, e := range extents {
+		fmt.Fprintf(&sparseMap, "%d\n%d\n", e.offset, e.length)
+		dataSize += e.length
+	}
+	sparseMap.Write(make([]byte, blockPadding(int64(sparseMap.Len()))))
+	size := int64(sparseMap.Len()) + dataSize
+
+	records := map[string]string{
+		"GNU.sparse.major":    "1",
+		"GNU.sparse.minor":    "0",
+		"GNU.sparse.name":     hdr.Name,
+		"GNU.sparse.realsize": strconv.FormatInt(hdr.Size, 10),
+	}
+	if size > maxOctalSize {
+		records["size"] = strconv.FormatInt(size, 10)
+	}
+	if hdr.Uid > maxOctalID {
+		records["uid"] = strconv.Itoa(hdr.Uid)
+	}
+	if hdr.Gid > maxOctalID {
+		records["gid"] = strconv.Itoa(hdr.Gid)
+	}
+	if len(hdr.Uname) > maxNameLength {
+		records["uname"] = hdr.Uname
+	}
+	if len(hdr.Gname) > maxNameLength {
+		records["gname"] = hdr.Gname
+	}
+	if modTime := hdr.ModTime.Unix(); modTime < 0 || modTime > maxOctalSize {
+		records["mtime"] = strconv.FormatInt(modTime, 10)
+	}
+	var paxData bytes.Buffer
+	keys := make([]string, 0, len(records))
+	for k := range records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		paxData.WriteString(formatPAXRecord(k, records[k]))
+	}
+
+	// finish the previous entry before writing to w directly
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	paxHdr := &tar.Header{
+		Typeflag: tar.TypeXHeader,
+		Name:     sparsePAXEntryName,
+		Mode:     0644,
+		Size:     int64(paxData.Len()),
+		ModTime:  hdr.ModTime,
+	}
+	if err := writeBlocks(w, headerBlock(paxHdr), paxData.Bytes()); err != nil {
+		return err
+	}
+	fileHdr := *hdr
+	fileHdr.Name = sparseEntryName
+	fileHdr.Size = size
+	if err := writeBlocks(w, headerBlock(&fileHdr), sparseMap.Bytes()); err != nil {
+		return err
+	}
+	for _, e := range extents {
+		if _, err := io.CopyN(w, io.NewSectionReader(f, e.offset, e.length), e.length); err != nil {
+			return errors.Wrapf(err, "unable to read %s at offset %d", f.Name(), e.offset)
+		}
+	}
+	_, err := w.Write(make([]byte, blockPadding(dataSize)))
+	return err
+}
+
+// dataExtents uses SEEK_DATA and SEEK_HOLE to find the extents of the file containing data
+func dataExtents(f *os.File, size int64) ([]extent, error) {
+	var extents []extent
+	fd := int(f.Fd())
+	for offset := int64(0); offset < size; {
+		data, err := unix.Seek(fd, offset, seekData)
+		if err == unix.ENXIO {
+			// only a hole left
+			break
+		}
+		if err == unix.EINVAL && offset == 0 {
+			// not supported by the filesystem, assume the whole file has data
+			return []extent{{length: size}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		hole, err := unix.Seek(fd, data, seekHole)
+		if err != nil {
+			return nil, err
+		}
+		if hole > size {
+			hole = size
+		}
+		if hole > data {
+			extents = append(extents, extent{offset: data, length: hole - data})
+		}
+		offset = hole
+	}
+	return extents, nil
+}
+
+// headerBlock encodes a ustar header. Numeric fields not fitting the octal fields are left 0 and names too long
+// are left empty, the caller stores them in PAX records.
+func headerBlock(hdr *tar.Header) []byte {
+	block := make([]byte, tarBlockSize)
+	copy(block[0:100], hdr.Name)
+	formatOctal(block[100:108], hdr.Mode&07777)
+	formatOctal(block[108:116], int64(hdr.Uid))
+	formatOctal(block[116:124], int64(hdr.Gid))
+	formatOctal(block[124:136], hdr.Size)
+	formatOctal(block[136:148], hdr.ModTime.Unix())
+	block[156] = hdr.Typeflag
+	copy(block[257:], "ustar\x0000")
+	if len(hdr.Uname) <= maxNameLength {
+		copy(block[265:297], hdr.Uname)
+	}
+	if len(hdr.Gname) <= maxNameLength {
+		copy(block[297:329], hdr.Gname)
+	}
+	copy(block[148:156], "        ")
+	var sum int64
+	for _, b := range block {
+		sum += int64(b)
+	}
+	copy(block[148:], fmt.Sprintf("%06o\x00 ", sum))
+	return block
+}
+
+// formatOctal writes v as a NUL terminated octal number filling field, or 0 if it doesn't fit
+func formatOctal(field []byte, v int64) {
+	s := fmt.Sprintf("%0*o", len(field)-1, v)
+	if v < 0 || len(s) >= len(field) {
+		s = fmt.Sprintf("%0*o", len(field)-1, 0)
+	}
+	copy(field, s+"\x00")
+}
+
+// writeBlocks writes the header and the data padded to the block size
+func writeBlocks(w io.Writer, header, data []byte) error {
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err := w.Write(make([]byte, blockPadding(int64(len(data)))))
+	return err
+}
+
+// formatPAXRecord formats a record, its length includes the length field itself
+func formatPAXRecord(k, v string) string {
+	size := len(k) + len(v) + 3
+	size += len(strconv.Itoa(size))
+	record := fmt.Sprintf("%d %s=%s\n", size, k, v)
+	if len(record) != size {
+		size = len(record)
+		record = fmt.Sprintf("%d %s=%s\n", size, k, v)
+	}
+	return record
+}
+
+func blockPadding(size int64) int64 {
+	return -size & (tarBlockSize - 1)
+}
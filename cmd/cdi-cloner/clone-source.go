@@ -5,35 +5,70 @@ import (
 	"compress/gzip"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/klog"
 
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
 	"kubevirt.io/containerized-data-importer/pkg/common"
 	"kubevirt.io/containerized-data-importer/pkg/util"
 	prometheusutil "kubevirt.io/containerized-data-importer/pkg/util/prometheus"
 )
 
-var (
-	contentType string
-	uploadBytes uint64
+// cloneCheckpoint is the checkpoint returned by the upload server
+type cloneCheckpoint struct {
+	Offset int64 `json:"offset"`
+}
+
+const (
+	filesystemCloneContentType  = "filesystem-clone"
+	blockdeviceCloneContentType = "blockdevice-clone"
+
+	// termination message reasons of a failed clone
+	reasonInvalidConfiguration = "CloneSourceInvalidConfiguration"
+	reasonSourceUnavailable    = "CloneSourceUnavailable"
+	reasonSourceReadFailed     = "CloneSourceReadFailed"
+	reasonTargetUnavailable    = "CloneTargetUnavailable"
+	reasonTargetFailed         = "CloneTargetFailed"
+
+	checkpointTimeout = 10 * time.Second
 )
 
+// checkpoint returns how far the target of a resumable clone got, nil if the clone isn't resumable
+var checkpoint func() int64
+
 func init() {
-	flag.StringVar(&contentType, "content_type", "", "archive|kubevirt|filesystem-clone|blockdevice-clone")
-	flag.Uint64Var(&uploadBytes, "upload_bytes", 0, "approx number of bytes in input")
 	klog.InitFlags(nil)
 }
 
+// failClone reports the failure in the termination message and exits
+func failClone(reason, format string, args ...interface{}) {
+	msg := &util.TerminationMessage{Reason: reason, Message: fmt.Sprintf(format, args...)}
+	klog.Errorf("%s: %s", reason, msg.Message)
+	if checkpoint != nil {
+		msg.Checkpoint = checkpoint()
+	}
+	if err := util.WriteFailureTerminationMessage(msg); err != nil {
+		klog.Errorf("%+v", err)
+	}
+	klog.Flush()
+	os.Exit(1)
+}
+
 func getEnvVarOrDie(name string) string {
 	value := os.Getenv(name)
 	if value == "" {
-		klog.Fatalf("Error geting env var %s", name)
+		failClone(reasonInvalidConfiguration, "Error getting env var %s", name)
 	}
 	return value
 }
@@ -41,7 +76,7 @@ func getEnvVarOrDie(name string) string {
 func createHTTPClient(clientKey, clientCert, serverCert []byte) *http.Client {
 	clientKeyPair, err := tls.X509KeyPair(clientCert, clientKey)
 	if err != nil {
-		klog.Fatalf("Error %s creating client keypair", err)
+		failClone(reasonInvalidConfiguration, "Error creating client keypair: %v", err)
 	}
 
 	caCertPool := x509.NewCertPool()
@@ -68,7 +103,7 @@ func startPrometheus() {
 	prometheusutil.StartPrometheusEndpoint(certsDirectory)
 }
 
-func createProgressReader(readCloser io.ReadCloser, ownerUID string, totalBytes uint64) io.ReadCloser {
+func createProgressReader(readCloser io.ReadCloser, ownerUID string, totalBytes, currentBytes uint64) io.ReadCloser {
 	progress := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "clone_progress",
@@ -79,6 +114,7 @@ func createProgressReader(readCloser io.ReadCloser, ownerUID string, totalBytes
 	prometheus.MustRegister(progress)
 
 	promReader := prometheusutil.NewProgressReader(readCloser, totalBytes, progress, ownerUID)
+	promReader.Current = currentBytes
 	promReader.StartTimedUpdate()
 
 	return promReader
@@ -91,7 +127,7 @@ func pipeToGzip(reader io.ReadCloser) io.ReadCloser {
 	go func() {
 		n, err := io.Copy(gzw, reader)
 		if err != nil {
-			klog.Fatalf("Error %s piping to gzip", err)
+			failClone(reasonSourceReadFailed, "Error piping to gzip: %v", err)
 		}
 		gzw.Close()
 		pw.Close()
@@ -101,12 +137,111 @@ func pipeToGzip(reader io.ReadCloser) io.ReadCloser {
 	return pr
 }
 
+func pipeToSparse(reader io.ReadCloser, start int64) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		n, err := util.WriteSparseStream(pw, reader, start)
+		if err != nil {
+			failClone(reasonSourceReadFailed, "Error writing sparse stream: %v", err)
+		}
+		pw.Close()
+		klog.Infof("Read source up to offset %d\n", n)
+	}()
+
+	return pr
+}
+
+func pipeFromArchive(dir string) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		if err := writeArchive(pw, dir); err != nil {
+			failClone(reasonSourceReadFailed, "Error archiving %s: %v", dir, err)
+		}
+		pw.Close()
+	}()
+
+	return pr
+}
+
+// getCheckpoint asks the upload server how far a previous attempt got, 0 if it doesn't know
+func getCheckpoint(client *http.Client, url string) int64 {
+	// don't hang reporting a failure when the target is gone
+	checkpointClient := *client
+	checkpointClient.Timeout = checkpointTimeout
+	response, err := checkpointClient.Get(url)
+	if err != nil {
+		klog.Errorf("Error getting checkpoint from %s: %v", url, err)
+		return 0
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		klog.Errorf("Unexpected status code %d getting checkpoint", response.StatusCode)
+		return 0
+	}
+	cp := &cloneCheckpoint{}
+	if err = json.NewDecoder(response.Body).Decode(cp); err != nil {
+		klog.Errorf("Error decoding checkpoint: %v", err)
+		return 0
+	}
+	return cp.Offset
+}
+
+// openSource opens the source volume, it returns the stream, its content type and approximate size. The image of a
+// filesystem source is sent raw to a block target.
+func openSource(volumeMode, targetVolumeMode, mountPoint string) (io.ReadCloser, string, uint64) {
+	switch volumeMode {
+	case "block":
+		source, size := openRawSource(mountPoint)
+		klog.Infof("Source device size is %d", size)
+		return source, blockdeviceCloneContentType, size
+	case "filesystem":
+		if targetVolumeMode == "block" {
+			source, size := openRawSource(filepath.Join(mountPoint, common.DiskImageName))
+			klog.Infof("Source image size is %d", size)
+			return source, blockdeviceCloneContentType, size
+		}
+		apparent, allocated, err := dirSize(mountPoint)
+		if err != nil {
+			failClone(reasonSourceUnavailable, "Error getting size of source volume %s: %v", mountPoint, err)
+		}
+		// only the allocated data of sparse files is read
+		klog.Infof("Source volume apparent size is %d, allocated size is %d", apparent, allocated)
+		return pipeFromArchive(mountPoint), filesystemCloneContentType, uint64(allocated)
+	}
+	failClone(reasonInvalidConfiguration, "Invalid volume mode %q", volumeMode)
+	return nil, "", 0
+}
+
+// openRawSource opens a block device or an image file, it returns the stream and its size
+func openRawSource(path string) (io.ReadCloser, uint64) {
+	source, err := os.Open(path)
+	if err != nil {
+		failClone(reasonSourceUnavailable, "Error opening source %s: %v", path, err)
+	}
+	size, err := source.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = source.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		failClone(reasonSourceUnavailable, "Error getting size of source %s: %v", path, err)
+	}
+	return source, uint64(size)
+}
+
 func main() {
 	flag.Parse()
 	defer klog.Flush()
 
-	klog.Infof("content_type is %q\n", contentType)
-	klog.Infof("upload_bytes is %d", uploadBytes)
+	volumeMode := getEnvVarOrDie("VOLUME_MODE")
+	mountPoint := getEnvVarOrDie("MOUNT_POINT")
+	// older controllers only clone between volumes of the same mode
+	targetVolumeMode := os.Getenv(common.ClonerTargetVolumeMode)
+	if targetVolumeMode == "" {
+		targetVolumeMode = volumeMode
+	}
+	klog.Infof("VOLUME_MODE=%s MOUNT_POINT=%s TARGET_VOLUME_MODE=%s", volumeMode, mountPoint, targetVolumeMode)
 
 	ownerUID := getEnvVarOrDie(common.OwnerUID)
 
@@ -115,35 +250,63 @@ func main() {
 	serverCert := []byte(getEnvVarOrDie("SERVER_CA_CERT"))
 
 	url := getEnvVarOrDie("UPLOAD_URL")
+	compression := cdiv1.CloneCompression(os.Getenv(common.ClonerCompression))
+	klog.Infof("compression is %q", compression)
 
 	klog.V(1).Infoln("Starting cloner target")
 
-	reader := pipeToGzip(createProgressReader(os.Stdin, ownerUID, uploadBytes))
-
-	startPrometheus()
-
 	client := createHTTPClient(clientKey, clientCert, serverCert)
 
-	req, _ := http.NewRequest("POST", url, reader)
+	source, contentType, uploadBytes := openSource(volumeMode, targetVolumeMode, mountPoint)
+	var start int64
+	if contentType == blockdeviceCloneContentType {
+		checkpointURL := getEnvVarOrDie("CHECKPOINT_URL")
+		// resume where a previous attempt left off
+		start = getCheckpoint(client, checkpointURL)
+		if start > 0 && uint64(start) < uploadBytes {
+			klog.Infof("Resuming clone at offset %d", start)
+			if _, err := source.(io.Seeker).Seek(start, io.SeekStart); err != nil {
+				failClone(reasonSourceReadFailed, "Error seeking source to offset %d: %v", start, err)
+			}
+		} else {
+			start = 0
+		}
+		checkpoint = func() int64 {
+			if offset := getCheckpoint(client, checkpointURL); offset > start {
+				return offset
+			}
+			return start
+		}
+	}
 
-	if contentType != "" {
-		req.Header.Set("x-cdi-content-type", contentType)
-		klog.Infof("Set header to %s", contentType)
+	reader := createProgressReader(source, ownerUID, uploadBytes, uint64(start))
+	if contentType == blockdeviceCloneContentType {
+		// zero extents are left out and written sparse on the target
+		reader = pipeToSparse(reader, start)
+	}
+	if compression != cdiv1.CloneCompressionNone {
+		reader = pipeToGzip(reader)
 	}
 
+	startPrometheus()
+
+	req, _ := http.NewRequest("POST", url, reader)
+	req.Header.Set("x-cdi-content-type", contentType)
+	klog.Infof("Set header to %s", contentType)
+
 	response, err := client.Do(req)
 	if err != nil {
-		klog.Fatalf("Error %s POSTing to %s", err, url)
-	}
-
-	if response.StatusCode < 200 || response.StatusCode >= 300 {
-		klog.Fatalf("Unexpected status code %d", response.StatusCode)
+		failClone(reasonTargetUnavailable, "Error POSTing to %s: %v", url, err)
 	}
 
 	var buf bytes.Buffer
 	_, err = io.Copy(&buf, response.Body)
 	if err != nil {
-		klog.Fatalf("Error %s copying response body", err)
+		failClone(reasonTargetUnavailable, "Error copying response body: %v", err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		failClone(reasonTargetFailed, "Upload server returned status %d: %s", response.StatusCode, strings.TrimSpace(buf.String()))
 	}
 
 	klog.V(1).Infof("Response body:\n%s", buf.String())
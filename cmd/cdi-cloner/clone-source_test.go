@@ -1,14 +1,19 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"kubevirt.io/containerized-data-importer/pkg/util"
 	prometheusutil "kubevirt.io/containerized-data-importer/pkg/util/prometheus"
 )
 
@@ -42,3 +47,133 @@ func isDirEmpty(dirName string) (bool, error) {
 	}
 	return false, err
 }
+
+var _ = Describe("Archive", func() {
+	var sourceDir, targetDir string
+
+	BeforeEach(func() {
+		var err error
+		sourceDir, err = ioutil.TempDir("", "clonesource")
+		Expect(err).NotTo(HaveOccurred())
+		targetDir, err = ioutil.TempDir("", "clonetarget")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(sourceDir)
+		os.RemoveAll(targetDir)
+	})
+
+	createSparseFile := func(name string, size int64, data map[int64]string) {
+		f, err := os.Create(filepath.Join(sourceDir, name))
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		Expect(f.Truncate(size)).To(Succeed())
+		for offset, content := range data {
+			_, err = f.WriteAt([]byte(content), offset)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	}
+
+	archive := func() *bytes.Buffer {
+		var buf bytes.Buffer
+		Expect(writeArchive(&buf, sourceDir)).To(Succeed())
+		return &buf
+	}
+
+	It("Should compute the apparent and allocated size", func() {
+		createSparseFile("disk.img", 64*1024*1024, map[int64]string{32 * 1024 * 1024: "data"})
+		apparent, allocated, err := dirSize(sourceDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(apparent).To(Equal(int64(64 * 1024 * 1024)))
+		Expect(allocated).To(BeNumerically("<", 1024*1024))
+	})
+
+	It("Should only archive the data of sparse files", func() {
+		createSparseFile("disk.img", 64*1024*1024, map[int64]string{0: "start", 32 * 1024 * 1024: "middle"})
+		buf := archive()
+		Expect(buf.Len()).To(BeNumerically("<", 1024*1024))
+
+		By("Reading the archive with archive/tar")
+		tr := tar.NewReader(buf)
+		hdr, err := tr.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hdr.Name).To(Equal("./disk.img"))
+		Expect(hdr.Size).To(Equal(int64(64 * 1024 * 1024)))
+		content, err := ioutil.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content[:5])).To(Equal("start"))
+		Expect(string(content[32*1024*1024 : 32*1024*1024+6])).To(Equal("middle"))
+	})
+
+	It("Should archive sparse files with long names and large ids", func() {
+		name := strings.Repeat("d", 60) + "/" + strings.Repeat("f", 60) + ".img"
+		Expect(os.Mkdir(filepath.Join(sourceDir, strings.Repeat("d", 60)), 0755)).To(Succeed())
+		createSparseFile(name, 16*1024*1024, map[int64]string{8 * 1024 * 1024: "data"})
+		f, err := os.Open(filepath.Join(sourceDir, name))
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		info, err := f.Stat()
+		Expect(err).NotTo(HaveOccurred())
+		hdr, err := tar.FileInfoHeader(info, "")
+		Expect(err).NotTo(HaveOccurred())
+		hdr.Name = "./" + name
+		hdr.Uid, hdr.Gid = 1000680000, 1000680000
+
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		Expect(writeSparseFile(tw, &buf, hdr, f, []extent{{offset: 8 * 1024 * 1024, length: 4}})).To(Succeed())
+		Expect(tw.Close()).To(Succeed())
+
+		tr := tar.NewReader(&buf)
+		hdr, err = tr.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hdr.Name).To(Equal("./" + name))
+		Expect(hdr.Uid).To(Equal(1000680000))
+		Expect(hdr.Gid).To(Equal(1000680000))
+		Expect(hdr.Size).To(Equal(int64(16 * 1024 * 1024)))
+		content, err := ioutil.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content[8*1024*1024 : 8*1024*1024+4])).To(Equal("data"))
+		_, err = tr.Next()
+		Expect(err).To(Equal(io.EOF))
+	})
+
+	It("Should be extracted by tar", func() {
+		createSparseFile("disk.img", 16*1024*1024, map[int64]string{8 * 1024 * 1024: "data"})
+		createSparseFile("empty.img", 1024*1024, nil)
+		Expect(os.Mkdir(filepath.Join(sourceDir, "dir"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(sourceDir, "dir", "file"), []byte("content"), 0644)).To(Succeed())
+		Expect(os.Symlink("dir/file", filepath.Join(sourceDir, "link"))).To(Succeed())
+
+		Expect(util.UnArchiveTar(archive(), targetDir)).To(Succeed())
+
+		for _, name := range []string{"disk.img", "empty.img", "dir/file"} {
+			expected, err := ioutil.ReadFile(filepath.Join(sourceDir, name))
+			Expect(err).NotTo(HaveOccurred())
+			actual, err := ioutil.ReadFile(filepath.Join(targetDir, name))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		}
+		link, err := os.Readlink(filepath.Join(targetDir, "link"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link).To(Equal("dir/file"))
+	})
+
+	It("Should send the image of a filesystem source raw to a block target", func() {
+		createSparseFile("disk.img", 16*1024*1024, map[int64]string{8 * 1024 * 1024: "data"})
+		source, contentType, size := openSource("filesystem", "block", sourceDir)
+		defer source.Close()
+		Expect(contentType).To(Equal(blockdeviceCloneContentType))
+		Expect(size).To(Equal(uint64(16 * 1024 * 1024)))
+		content, err := ioutil.ReadAll(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content[8*1024*1024 : 8*1024*1024+4])).To(Equal("data"))
+
+		By("Archiving the volume for a filesystem target")
+		archiveSource, contentType, _ := openSource("filesystem", "filesystem", sourceDir)
+		defer archiveSource.Close()
+		Expect(contentType).To(Equal(filesystemCloneContentType))
+		Expect(util.UnArchiveTar(archiveSource, targetDir)).To(Succeed())
+	})
+})
@@ -23,6 +23,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
 
@@ -31,6 +32,8 @@ import (
 	"kubevirt.io/containerized-data-importer/pkg/util"
 	"kubevirt.io/containerized-data-importer/pkg/util/cert/fetcher"
 	"kubevirt.io/containerized-data-importer/pkg/util/cert/generator"
+	"kubevirt.io/containerized-data-importer/pkg/util/progress"
+	prometheusutil "kubevirt.io/containerized-data-importer/pkg/util/prometheus"
 )
 
 const (
@@ -114,7 +117,8 @@ func start(cfg *rest.Config, stopCh <-chan struct{}) {
 		klog.Fatalf("Error building extClient: %s", err.Error())
 	}
 
-	mgr, err := manager.New(config.GetConfigOrDie(), manager.Options{})
+	// the metrics are served over TLS like the metrics of the worker pods, not by the manager
+	mgr, err := manager.New(config.GetConfigOrDie(), manager.Options{MetricsBindAddress: "0"})
 	if err != nil {
 		klog.Errorf("Unable to setup controller manager: %v", err)
 		os.Exit(1)
@@ -136,13 +140,15 @@ func start(cfg *rest.Config, stopCh <-chan struct{}) {
 		Client: client.CoreV1().ConfigMaps(namespace),
 	}
 	uploadServerCertGenerator := &generator.FetchCertGenerator{Fetcher: uploadServerCAFetcher}
+	apiServerKey := getAPIServerPublicKey()
 
 	if _, err := controller.NewConfigController(mgr, log, uploadProxyServiceName, configName); err != nil {
 		klog.Errorf("Unable to setup config controller: %v", err)
 		os.Exit(1)
 	}
 	// TODO: Current DV controller had threadiness 3, should we do the same here, defaults to one thread.
-	if _, err := controller.NewDatavolumeController(mgr, extClient, log); err != nil {
+	progressStore := progress.NewStore()
+	if _, err := controller.NewDatavolumeController(mgr, extClient, log, apiServerKey, progressStore); err != nil {
 		klog.Errorf("Unable to setup datavolume controller: %v", err)
 		os.Exit(1)
 	}
@@ -152,7 +158,7 @@ func start(cfg *rest.Config, stopCh <-chan struct{}) {
 		os.Exit(1)
 	}
 
-	if _, err := controller.NewCloneController(mgr, log, clonerImage, pullPolicy, verbose, uploadClientCertGenerator, uploadServerBundleFetcher, getAPIServerPublicKey()); err != nil {
+	if _, err := controller.NewCloneController(mgr, log, clonerImage, pullPolicy, verbose, uploadClientCertGenerator, uploadServerBundleFetcher, apiServerKey); err != nil {
 		klog.Errorf("Unable to setup clone controller: %v", err)
 		os.Exit(1)
 	}
@@ -162,8 +168,31 @@ func start(cfg *rest.Config, stopCh <-chan struct{}) {
 		os.Exit(1)
 	}
 
+	if _, err := controller.NewImportCacheController(mgr, log); err != nil {
+		klog.Errorf("Unable to setup import cache controller: %v", err)
+		os.Exit(1)
+	}
+
+	if _, err := controller.NewDataImportCronController(mgr, log, importerImage, pullPolicy, verbose); err != nil {
+		klog.Errorf("Unable to setup dataimportcron controller: %v", err)
+		os.Exit(1)
+	}
+
 	klog.V(1).Infoln("created cdi controllers")
 
+	controller.RegisterDataVolumeCollector(mgr.GetClient())
+	certsDirectory, err := ioutil.TempDir("", "certsdir")
+	if err != nil {
+		klog.Errorf("Unable to create the certificate directory: %v", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(certsDirectory)
+	prometheusutil.StartGathererPrometheusEndpoint(certsDirectory, metrics.Registry)
+	if err := progress.StartServer(certsDirectory, progressStore); err != nil {
+		klog.Errorf("Unable to start the progress server: %v", err)
+		os.Exit(1)
+	}
+
 	go crdInformerFactory.Start(stopCh)
 
 	// Add Crd informer, so we can start the smart clone controller if we detect the CSI CRDs being installed.
@@ -242,7 +271,7 @@ func addCrdInformerEventHandlers(crdInformer cache.SharedIndexInformer, extclien
 func startSmartController(extclient extclientset.Interface, mgr manager.Manager, log logr.Logger) {
 	if controller.IsCsiCrdsDeployed(extclient) {
 		log.Info("CSI CRDs detected, starting smart clone controller")
-		if _, err := controller.NewSmartCloneController(mgr, log); err != nil {
+		if _, err := controller.NewSmartCloneController(mgr, log, importerImage, pullPolicy, verbose); err != nil {
 			log.Error(err, "Unable to setup smart clone controller: %v")
 		}
 	}
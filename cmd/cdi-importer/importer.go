@@ -19,8 +19,6 @@ import (
 	"os"
 	"strconv"
 
-	"github.com/pkg/errors"
-
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog"
@@ -56,14 +54,21 @@ func main() {
 	source, _ := util.ParseEnvVar(common.ImporterSource, false)
 	contentType, _ := util.ParseEnvVar(common.ImporterContentType, false)
 	imageSize, _ := util.ParseEnvVar(common.ImporterImageSize, false)
+	filesystemOverhead, _ := util.ParseEnvVar(common.FilesystemOverheadVar, false)
 	certDir, _ := util.ParseEnvVar(common.ImporterCertDirVar, false)
 	insecureTLS, _ := strconv.ParseBool(os.Getenv(common.InsecureTLSVar))
 	diskID, _ := util.ParseEnvVar(common.ImporterDiskID, false)
+	checksum, _ := util.ParseEnvVar(common.ImporterChecksum, false)
+	pollDigest, _ := strconv.ParseBool(os.Getenv(common.ImporterPollDigest))
+
+	if pollDigest {
+		pollSourceDigest(source, ep, acc, sec, certDir, insecureTLS)
+		return
+	}
 
 	//Registry import currently support kubevirt content type only
 	if contentType != string(cdiv1.DataVolumeKubeVirt) && (source == controller.SourceRegistry || source == controller.SourceImageio) {
-		klog.Errorf("Unsupported content type %s when importing from %s", contentType, source)
-		os.Exit(1)
+		failImport(importer.ReasonInvalidConfiguration, "Unsupported content type %s when importing from %s", contentType, source)
 	}
 
 	volumeMode := v1.PersistentVolumeBlock
@@ -83,89 +88,73 @@ func main() {
 	dataDir := common.ImporterDataDir
 	availableDestSpace, err := util.GetAvailableSpaceByVolumeMode(volumeMode)
 	if err != nil {
-		klog.Errorf("%+v", err)
-		os.Exit(1)
+		failImport(importer.FailureReason(err), "Unable to determine available space: %+v", err)
 	}
 	if source == controller.SourceNone && contentType == string(cdiv1.DataVolumeKubeVirt) {
+		usableSpace := util.GetUsableSpace(util.ParseFilesystemOverhead(filesystemOverhead), availableDestSpace)
 		requestImageSizeQuantity := resource.MustParse(imageSize)
-		minSizeQuantity := util.MinQuantity(resource.NewScaledQuantity(availableDestSpace, 0), &requestImageSizeQuantity)
+		minSizeQuantity := util.MinQuantity(resource.NewScaledQuantity(usableSpace, 0), &requestImageSizeQuantity)
 		if minSizeQuantity.Cmp(requestImageSizeQuantity) != 0 {
 			// Available dest space is smaller than the size we want to create
 			klog.Warningf("Available space less than requested size, creating blank image sized to available space: %s.\n", minSizeQuantity.String())
 		}
 		err := image.CreateBlankImage(common.ImporterWritePath, minSizeQuantity)
 		if err != nil {
-			klog.Errorf("%+v", err)
-			err = util.WriteTerminationMessage(fmt.Sprintf("Unable to create blank image: %+v", err))
-			if err != nil {
-				klog.Errorf("%+v", err)
-			}
-			os.Exit(1)
+			failImport(importer.FailureReason(err), "Unable to create blank image: %+v", err)
 		}
-	} else if source == controller.SourceNone && contentType == string(cdiv1.DataVolumeArchive) {
-		klog.Errorf("%+v", errors.New("Cannot create empty disk with content type archive"))
-		err = util.WriteTerminationMessage("Cannot create empty disk with content type archive")
+	} else if source == controller.SourceResize {
+		// the image may grow into the available space and the space it already uses
+		allocatedSpace, err := util.GetAllocatedSize(common.ImporterWritePath)
+		if err == nil {
+			usableSpace := util.GetUsableSpace(util.ParseFilesystemOverhead(filesystemOverhead), availableDestSpace+allocatedSpace)
+			err = importer.ResizeImage(common.ImporterWritePath, imageSize, usableSpace)
+		}
+		if err != nil {
+			failImport(importer.FailureReason(err), "Unable to resize image: %+v", err)
+		}
+		err = util.WriteTerminationMessage("Resize Complete")
 		if err != nil {
 			klog.Errorf("%+v", err)
+			os.Exit(1)
 		}
-		os.Exit(1)
+		klog.V(1).Infoln("Resize complete")
+		return
+	} else if source == controller.SourceNone && contentType == string(cdiv1.DataVolumeArchive) {
+		failImport(importer.ReasonInvalidConfiguration, "Cannot create empty disk with content type archive")
 	} else {
 		klog.V(1).Infoln("begin import process")
 		var dp importer.DataSourceInterface
 		switch source {
 		case controller.SourceHTTP:
-			dp, err = importer.NewHTTPDataSource(ep, acc, sec, certDir, cdiv1.DataVolumeContentType(contentType))
+			dp, err = importer.NewHTTPDataSource(ep, acc, sec, certDir, cdiv1.DataVolumeContentType(contentType), checksum)
 			if err != nil {
-				klog.Errorf("%+v", err)
-				err = util.WriteTerminationMessage(fmt.Sprintf("Unable to connect to http data source: %+v", err))
-				if err != nil {
-					klog.Errorf("%+v", err)
-				}
-				os.Exit(1)
+				failImport(importer.FailureReason(err), "Unable to connect to http data source: %+v", err)
 			}
 		case controller.SourceImageio:
 			dp, err = importer.NewImageioDataSource(ep, acc, sec, certDir, diskID)
 			if err != nil {
-				klog.Errorf("%+v", err)
-				err = util.WriteTerminationMessage(fmt.Sprintf("Unable to connect to imageio data source: %+v", err))
-				if err != nil {
-					klog.Errorf("%+v", err)
-				}
-				os.Exit(1)
+				failImport(importer.FailureReason(err), "Unable to connect to imageio data source: %+v", err)
 			}
 		case controller.SourceRegistry:
 			dp = importer.NewRegistryDataSource(ep, acc, sec, certDir, insecureTLS)
 		case controller.SourceS3:
 			dp, err = importer.NewS3DataSource(ep, acc, sec)
 			if err != nil {
-				klog.Errorf("%+v", err)
-				err = util.WriteTerminationMessage(fmt.Sprintf("Unable to connect to s3 data source: %+v", err))
-				if err != nil {
-					klog.Errorf("%+v", err)
-				}
-				os.Exit(1)
+				failImport(importer.FailureReason(err), "Unable to connect to s3 data source: %+v", err)
 			}
 		default:
-			klog.Errorf("Unknown source type %s\n", source)
-			err = util.WriteTerminationMessage(fmt.Sprintf("Unknown data source: %s", source))
-			if err != nil {
-				klog.Errorf("%+v", err)
-			}
-			os.Exit(1)
+			failImport(importer.ReasonInvalidConfiguration, "Unknown data source: %s", source)
 		}
 		defer dp.Close()
-		processor := importer.NewDataProcessor(dp, dest, dataDir, common.ScratchDataDir, imageSize)
+		processor := importer.NewDataProcessor(dp, dest, dataDir, common.ScratchDataDir, imageSize, filesystemOverhead)
 		err = processor.ProcessData()
 		if err != nil {
-			klog.Errorf("%+v", err)
 			if err == importer.ErrRequiresScratchSpace {
+				requireScratchSpace(dp, err)
+				klog.Flush()
 				os.Exit(common.ScratchSpaceNeededExitCode)
 			}
-			err = util.WriteTerminationMessage(fmt.Sprintf("Unable to process data: %+v", err))
-			if err != nil {
-				klog.Errorf("%+v", err)
-			}
-			os.Exit(1)
+			failImport(importer.FailureReason(err), "Unable to process data: %+v", err)
 		}
 	}
 	err = util.WriteTerminationMessage("Import Complete")
@@ -175,3 +164,55 @@ func main() {
 	}
 	klog.V(1).Infoln("Import complete")
 }
+
+// pollSourceDigest reports the digest of the source in the termination message, the source isn't imported
+func pollSourceDigest(source, ep, acc, sec, certDir string, insecureTLS bool) {
+	var digest string
+	var err error
+	switch source {
+	case controller.SourceHTTP:
+		digest, err = importer.HTTPSourceDigest(ep, acc, sec, certDir)
+	case controller.SourceRegistry:
+		digest, err = importer.RegistrySourceDigest(ep, acc, sec, certDir, insecureTLS)
+	default:
+		failImport(importer.ReasonInvalidConfiguration, "Cannot poll the digest of data source: %s", source)
+	}
+	if err != nil {
+		failImport(importer.FailureReason(err), "Unable to poll the source digest: %+v", err)
+	}
+	if err := util.WriteDigestTerminationMessage(digest); err != nil {
+		klog.Errorf("%+v", err)
+		os.Exit(1)
+	}
+	klog.V(1).Infof("Polled the source digest %q", digest)
+}
+
+// reportFailure reports the reason the import failed in the termination message
+func reportFailure(reason, format string, args ...interface{}) {
+	msg := &util.TerminationMessage{Reason: reason, Message: fmt.Sprintf(format, args...)}
+	klog.Errorf("%s: %s", reason, msg.Message)
+	if err := util.WriteFailureTerminationMessage(msg); err != nil {
+		klog.Errorf("%+v", err)
+	}
+}
+
+// requireScratchSpace reports the size of the source in the termination message, the controller sizes the scratch space
+// after it
+func requireScratchSpace(dp importer.DataSourceInterface, err error) {
+	msg := &util.TerminationMessage{Reason: importer.ReasonScratchRequired, Message: fmt.Sprintf("%+v", err)}
+	if sized, ok := dp.(importer.SizedDataSource); ok {
+		msg.ScratchSize = sized.SourceSize()
+		msg.ScratchCompressed = sized.SourceCompressed()
+	}
+	klog.Infof("%s: %s, source size %d, compressed %t", msg.Reason, msg.Message, msg.ScratchSize, msg.ScratchCompressed)
+	if err := util.WriteFailureTerminationMessage(msg); err != nil {
+		klog.Errorf("%+v", err)
+	}
+}
+
+// failImport reports the failure in the termination message and exits
+func failImport(reason, format string, args ...interface{}) {
+	reportFailure(reason, format, args...)
+	klog.Flush()
+	os.Exit(1)
+}
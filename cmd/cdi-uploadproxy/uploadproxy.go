@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 
 	"github.com/pkg/errors"
@@ -11,10 +12,12 @@ import (
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
 
+	cdiclient "kubevirt.io/containerized-data-importer/pkg/client/clientset/versioned"
 	"kubevirt.io/containerized-data-importer/pkg/uploadproxy"
 	"kubevirt.io/containerized-data-importer/pkg/util"
 	certfetcher "kubevirt.io/containerized-data-importer/pkg/util/cert/fetcher"
 	certwatcher "kubevirt.io/containerized-data-importer/pkg/util/cert/watcher"
+	prometheusutil "kubevirt.io/containerized-data-importer/pkg/util/prometheus"
 )
 
 const (
@@ -24,6 +27,9 @@ const (
 	// Default address api listens on.
 	defaultHost = "0.0.0.0"
 
+	// Port the metrics are served on, apart from the uploads exposed to users.
+	metricsPort = 8444
+
 	serverCertDir  = "/var/run/certs/cdi-uploadproxy-server-cert/"
 	serverCertFile = serverCertDir + "tls.crt"
 	serverKeyFile  = serverCertDir + "tls.key"
@@ -69,6 +75,10 @@ func main() {
 	if err != nil {
 		klog.Fatalf("Unable to get kube client: %v\n", errors.WithStack(err))
 	}
+	cdiClient, err := cdiclient.NewForConfig(cfg)
+	if err != nil {
+		klog.Fatalf("Unable to get cdi client: %v\n", errors.WithStack(err))
+	}
 	apiServerPublicKey, err := getAPIServerPublicKey()
 	if err != nil {
 		klog.Fatalf("Unable to get apiserver public key %v\n", errors.WithStack(err))
@@ -84,18 +94,29 @@ func main() {
 		Client: client.CoreV1().ConfigMaps(namespace),
 	}
 
+	stopCh := signals.SetupSignalHandler()
+
 	uploadProxy, err := uploadproxy.NewUploadProxy(defaultHost,
 		defaultPort,
 		apiServerPublicKey,
 		certWatcher,
 		clientCertFetcher,
 		serverCAFetcher,
-		client)
+		client,
+		cdiClient,
+		stopCh)
 	if err != nil {
 		klog.Fatalf("UploadProxy failed to initialize: %v\n", errors.WithStack(err))
 	}
 
-	go certWatcher.Start(signals.SetupSignalHandler())
+	go certWatcher.Start(stopCh)
+
+	certsDirectory, err := ioutil.TempDir("", "certsdir")
+	if err != nil {
+		klog.Fatalf("Unable to create certs dir: %v\n", errors.WithStack(err))
+	}
+	defer os.RemoveAll(certsDirectory)
+	prometheusutil.StartPrometheusEndpointOnPort(certsDirectory, metricsPort)
 
 	err = uploadProxy.Start()
 	if err != nil {
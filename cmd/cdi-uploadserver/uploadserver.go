@@ -27,6 +27,7 @@ import (
 	"k8s.io/klog"
 
 	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/importer"
 	"kubevirt.io/containerized-data-importer/pkg/uploadserver"
 	"kubevirt.io/containerized-data-importer/pkg/util"
 )
@@ -59,25 +60,35 @@ func main() {
 		os.Getenv("CLIENT_CERT"),
 		os.Getenv("CLIENT_NAME"),
 		os.Getenv(common.UploadImageSize),
+		os.Getenv(common.FilesystemOverheadVar),
 	)
 
 	klog.Infof("Upload destination: %s", destination)
 
+	if val, exists := os.LookupEnv(common.UploadCloneCheckpoint); exists {
+		// the controller knows how far a previous target pod got
+		offset, err := strconv.ParseInt(val, 10, 64)
+		if err == nil {
+			err = uploadserver.InitCloneCheckpoint(offset)
+		}
+		if err != nil {
+			klog.Errorf("Unable to initialize clone checkpoint %q: %v", val, err)
+		}
+	}
+
 	klog.Infof("Running server on %s:%d", listenAddress, listenPort)
 
 	err := server.Run()
 	if err != nil {
 		klog.Errorf("UploadServer failed: %s", err)
+		if err == importer.ErrRequiresScratchSpace {
+			os.Exit(common.ScratchSpaceNeededExitCode)
+		}
 		os.Exit(1)
 	}
 
-	// Check if cloning or uploading based on the existance of the scratch space. Clone won't have scratch space
-	clone := false
-	_, err = os.OpenFile(common.ScratchDataDir, os.O_RDONLY, 0600)
-	if err != nil {
-		// Cloning instead of uploading.
-		clone = true
-	}
+	// Uploads may not have scratch space either, so the controller tells us if this is a clone target.
+	clone, _ := strconv.ParseBool(os.Getenv(common.UploadCloneTarget))
 	if clone {
 		err = util.WriteTerminationMessage("Clone Complete")
 	} else {
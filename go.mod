@@ -38,6 +38,7 @@ require (
 	github.com/ulikunitz/xz v0.5.6
 	go.uber.org/multierr v1.3.0 // indirect
 	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd
+	golang.org/x/time v0.0.0-20190308202827-9d24e82272b4
 	gopkg.in/fsnotify.v1 v1.4.7
 	gopkg.in/ini.v1 v1.48.0 // indirect
 	gopkg.in/square/go-jose.v2 v2.3.1
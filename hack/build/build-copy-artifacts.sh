@@ -27,12 +27,6 @@ for tgt in ${targets}; do
     bin_path="${tgt%/}"
     dest_dir="${OUT_DIR}/${bin_path}/"
     echo "$dest_dir"
-    # Cloner has no build artifact, copy cloner_startup.sh as well
-    if [[ "${bin_name}" == "${CLONER}" ]]; then
-        mkdir -p "${CMD_OUT_DIR}/${bin_name}/"
-        cp -f "${CDI_DIR}/cmd/${bin_name}/cloner_startup.sh" "${dest_dir}"
-    fi
-
     # Copy respective docker files to the directory of the build artifact
     cp -f "${BUILD_DIR}/docker/${bin_name}/"* "${dest_dir}"
 done
@@ -36,6 +36,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&DataVolume{},
 		&DataVolumeList{},
+		&DataImportCron{},
+		&DataImportCronList{},
 		&CDIConfig{},
 		&CDIConfigList{},
 		&CDI{},
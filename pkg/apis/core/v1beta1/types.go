@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	conditions "github.com/openshift/custom-resource-status/conditions/v1"
@@ -46,10 +47,78 @@ type DataVolumeSpec struct {
 	//Source is the src of the data for the requested DataVolume
 	Source DataVolumeSource `json:"source"`
 	//PVC is the PVC specification
-	PVC *corev1.PersistentVolumeClaimSpec `json:"pvc"`
+	// +optional
+	PVC *corev1.PersistentVolumeClaimSpec `json:"pvc,omitempty"`
+	//Storage is a simplified PVC specification, only the size is required. The access mode, volume mode and storage class are resolved from the storage class defaults and storage profiles, the size of clones from the source PVC. Either PVC or Storage must be set
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
 	//DataVolumeContentType options: "kubevirt", "archive"
 	// +kubebuilder:validation:Enum="kubevirt";"archive"
 	ContentType DataVolumeContentType `json:"contentType,omitempty"`
+	//RetryPolicy bounds the retries of the pods populating the DataVolume, failed pods are restarted forever if it isn't set
+	// +optional
+	RetryPolicy *DataVolumeRetryPolicy `json:"retryPolicy,omitempty"`
+	//Deadline is the maximum time populating the DataVolume may take, counted from the creation of its PVC. The pods populating it are terminated and the DataVolume fails once it passes. Defaults to the dataVolumeDeadline of the CDIConfig, unlimited if neither is set
+	// +optional
+	Deadline *metav1.Duration `json:"deadline,omitempty"`
+	//Action cancels populating the DataVolume: Cancel removes the pods populating it for good and leaves the DataVolume Cancelled. DataVolumes can't be paused, the pods populating them can't resume where they stopped
+	// +kubebuilder:validation:Enum="Cancel"
+	// +optional
+	Action DataVolumeAction `json:"action,omitempty"`
+	//Placement overrides the workloads placement of the CDIConfig for the pods populating the DataVolume, each field that is set replaces the field of the CDIConfig
+	// +optional
+	Placement *NodePlacement `json:"placement,omitempty"`
+}
+
+// StorageSpec is the simplified PVC specification of a DataVolume, the settings it omits are resolved by CDI
+type StorageSpec struct {
+	// AccessModes contains the desired access modes the volume should have, resolved from the storage profile if not set
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// Resources represents the minimum resources the volume should have, the size of the source PVC of clones if not set
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// StorageClassName is the name of the StorageClass of the volume, the default storage class if not set
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// VolumeMode defines what type of volume is required, resolved from the storage profile if not set
+	// +optional
+	VolumeMode *corev1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+}
+
+// NodePlacement describes the node scheduling configuration of the CDI worker pods
+type NodePlacement struct {
+	// NodeSelector is the node selector of the worker pods
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Affinity is the affinity of the worker pods
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Tolerations are the tolerations of the worker pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// PriorityClassName is the priority class of the worker pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// DataVolumeAction is an action on a DataVolume being populated
+type DataVolumeAction string
+
+const (
+	// DataVolumeCancel stops populating the DataVolume for good
+	DataVolumeCancel DataVolumeAction = "Cancel"
+)
+
+// DataVolumeRetryPolicy bounds the retries of the pods populating a DataVolume, permanent errors like a missing source or invalid credentials are never retried
+type DataVolumeRetryPolicy struct {
+	// MaxRetries is the number of times a failed pod is recreated before the DataVolume fails, 0 fails the DataVolume on the first error. Failed pods are recreated forever if it isn't set
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+	// Backoff is the delay before the first retry, it doubles with every retry up to 5 minutes. Defaults to 10s
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
 }
 
 // DataVolumeContentType represents the types of the imported data
@@ -138,6 +207,34 @@ type DataVolumeStatus struct {
 	// RestartCount is the number of times the pod populating the DataVolume has restarted
 	RestartCount int32                 `json:"restartCount,omitempty"`
 	Conditions   []DataVolumeCondition `json:"conditions,omitempty" optional:"true"`
+	// CloneStrategy is the strategy chosen to clone the source PVC
+	CloneStrategy CloneStrategy `json:"cloneStrategy,omitempty"`
+	// CloneStrategyReason explains why the clone strategy was chosen
+	CloneStrategyReason string `json:"cloneStrategyReason,omitempty"`
+	// ImportCache is the import cache PVC the DataVolume is cloned from, set if the import is served by the cache
+	ImportCache *DataVolumeImportCacheStatus `json:"importCache,omitempty"`
+	// Transfer is the transfer reported by the pod populating the DataVolume
+	Transfer *DataVolumeTransferStatus `json:"transfer,omitempty"`
+}
+
+// DataVolumeTransferStatus describes the transfer reported by the pod populating a DataVolume
+type DataVolumeTransferStatus struct {
+	// Phase is the phase of the transfer, as reported by the pod
+	Phase string `json:"phase,omitempty"`
+	// Bytes is the number of bytes transferred
+	Bytes int64 `json:"bytes,omitempty"`
+	// TotalBytes is the number of bytes to transfer, if known
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+}
+
+// DataVolumeImportCacheStatus describes the import cache PVC a DataVolume is cloned from
+type DataVolumeImportCacheStatus struct {
+	// Namespace of the cache PVC
+	Namespace string `json:"namespace"`
+	// Name of the cache PVC
+	Name string `json:"name"`
+	// Hit is true if the cache PVC was already populated, false if the DataVolume populated it
+	Hit bool `json:"hit"`
 }
 
 //DataVolumeList provides the needed parameters to do request a list of Data Volumes from the system
@@ -152,7 +249,7 @@ type DataVolumeList struct {
 
 // DataVolumeCondition represents the state of a data volume condition.
 type DataVolumeCondition struct {
-	Type               DataVolumeConditionType `json:"type" description:"type of condition ie. Ready|Bound|Running."`
+	Type               DataVolumeConditionType `json:"type" description:"type of condition ie. Ready|Bound|Running|CloneStrategySelected."`
 	Status             corev1.ConditionStatus  `json:"status" description:"status of the condition, one of True, False, Unknown"`
 	LastTransitionTime metav1.Time             `json:"lastTransitionTime,omitempty"`
 	LastHeartbeatTime  metav1.Time             `json:"lastHeartbeatTime,omitempty"`
@@ -169,6 +266,20 @@ type DataVolumeProgress string
 // DataVolumeConditionType is the string representation of known condition types
 type DataVolumeConditionType string
 
+// CloneStrategy is the strategy used to clone a PVC
+type CloneStrategy string
+
+const (
+	// CloneStrategyCsiClone clones with the volume cloning of the CSI driver, the target PVC has the source PVC as data source
+	CloneStrategyCsiClone CloneStrategy = "csi-clone"
+
+	// CloneStrategySnapshot clones by restoring a snapshot of the source PVC
+	CloneStrategySnapshot CloneStrategy = "snapshot"
+
+	// CloneStrategyHostAssisted clones by copying the data from a source pod to a target pod
+	CloneStrategyHostAssisted CloneStrategy = "host-assisted"
+)
+
 const (
 	// PhaseUnset represents a data volume with no current phase
 	PhaseUnset DataVolumePhase = ""
@@ -196,6 +307,9 @@ const (
 	// SmartClonePVCInProgress represents a data volume with a current phase of SmartClonePVCInProgress
 	SmartClonePVCInProgress DataVolumePhase = "SmartClonePVCInProgress"
 
+	// CSICloneInProgress represents a data volume with a current phase of CSICloneInProgress
+	CSICloneInProgress DataVolumePhase = "CSICloneInProgress"
+
 	// UploadScheduled represents a data volume with a current phase of UploadScheduled
 	UploadScheduled DataVolumePhase = "UploadScheduled"
 
@@ -211,6 +325,8 @@ const (
 	Failed DataVolumePhase = "Failed"
 	// Unknown represents a DataVolumePhase of Unknown
 	Unknown DataVolumePhase = "Unknown"
+	// Cancelled represents a DataVolumePhase of Cancelled
+	Cancelled DataVolumePhase = "Cancelled"
 
 	// DataVolumeReady is the condition that indicates if the data volume is ready to be consumed.
 	DataVolumeReady DataVolumeConditionType = "Ready"
@@ -218,11 +334,92 @@ const (
 	DataVolumeBound DataVolumeConditionType = "Bound"
 	// DataVolumeRunning is the condition that indicates if the import/upload/clone container is running.
 	DataVolumeRunning DataVolumeConditionType = "Running"
+	// DataVolumeCloneStrategySelected is the condition that indicates which clone strategy is used and why, false if the requested strategy is not possible.
+	DataVolumeCloneStrategySelected DataVolumeConditionType = "CloneStrategySelected"
 )
 
 // DataVolumeCloneSourceSubresource is the subresource checked for permission to clone
 const DataVolumeCloneSourceSubresource = "source"
 
+// DataImportCron re-imports a registry or http source on a schedule, keeps the last imported PVCs and points to the newest one
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=dic;dics,categories=all
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="The schedule the source is polled on"
+// +kubebuilder:printcolumn:name="Last Imported PVC",type="string",JSONPath=".status.lastImportedPVC.name",description="The PVC of the newest import"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type DataImportCron struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DataImportCronSpec   `json:"spec"`
+	Status DataImportCronStatus `json:"status,omitempty"`
+}
+
+// DataImportCronSpec defines the DataImportCron type specification
+type DataImportCronSpec struct {
+	// Template is the DataVolume created for every new digest of the source, its source must be a registry or http source
+	Template DataVolume `json:"template"`
+	// Schedule is the cron schedule the source digest is polled on, e.g. "0 */12 * * *" or "@daily"
+	Schedule string `json:"schedule"`
+	// ImportsToKeep is the number of successfully imported PVCs kept, the older ones are garbage collected once they are not in use by pods. Defaults to 3
+	// +kubebuilder:validation:Minimum=1
+	ImportsToKeep *int32 `json:"importsToKeep,omitempty"`
+}
+
+// DataImportCronStatus provides the most recently observed status of the DataImportCron
+type DataImportCronStatus struct {
+	// LastImportedPVC is the PVC of the newest successful import, it is updated once the import of a new digest succeeds
+	LastImportedPVC *DataVolumeSourcePVC `json:"lastImportedPVC,omitempty"`
+	// LastImportedDigest is the source digest imported to LastImportedPVC
+	LastImportedDigest string `json:"lastImportedDigest,omitempty"`
+	// LastImportTimestamp is the time the newest successful import was started
+	LastImportTimestamp *metav1.Time `json:"lastImportTimestamp,omitempty"`
+	// LastExecutionTimestamp is the time the source digest was last polled
+	LastExecutionTimestamp *metav1.Time `json:"lastExecutionTimestamp,omitempty"`
+	// CurrentImports are the imports in progress
+	CurrentImports []DataImportCronImport    `json:"currentImports,omitempty"`
+	Conditions     []DataImportCronCondition `json:"conditions,omitempty" optional:"true"`
+}
+
+// DataImportCronImport describes an import started by the DataImportCron
+type DataImportCronImport struct {
+	// DataVolumeName is the name of the DataVolume importing the digest
+	DataVolumeName string `json:"dataVolumeName"`
+	// Digest is the source digest being imported
+	Digest string `json:"digest"`
+}
+
+// DataImportCronCondition represents the state of a DataImportCron condition
+type DataImportCronCondition struct {
+	Type               DataImportCronConditionType `json:"type" description:"type of condition ie. UpToDate"`
+	Status             corev1.ConditionStatus      `json:"status" description:"status of the condition, one of True, False, Unknown"`
+	LastTransitionTime metav1.Time                 `json:"lastTransitionTime,omitempty"`
+	LastHeartbeatTime  metav1.Time                 `json:"lastHeartbeatTime,omitempty"`
+	Reason             string                      `json:"reason,omitempty" description:"reason for the condition's last transition"`
+	Message            string                      `json:"message,omitempty" description:"human-readable message indicating details about last transition"`
+}
+
+// DataImportCronConditionType is the string representation of known condition types
+type DataImportCronConditionType string
+
+const (
+	// DataImportCronUpToDate is the condition that indicates if the last imported PVC holds the latest polled digest of the source
+	DataImportCronUpToDate DataImportCronConditionType = "UpToDate"
+)
+
+//DataImportCronList provides the needed parameters to do request a list of DataImportCrons from the system
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type DataImportCronList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	// Items provides a list of DataImportCrons
+	Items []DataImportCron `json:"items"`
+}
+
 // this has to be here otherwise informer-gen doesn't recognize it
 // see https://github.com/kubernetes/code-generator/issues/59
 // +genclient:nonNamespaced
@@ -340,6 +537,121 @@ type CDIConfigSpec struct {
 	PodResourceRequirements  *corev1.ResourceRequirements `json:"podResourceRequirements,omitempty"`
 	// FeatureGates are a list of specific enabled feature gates
 	FeatureGates []string `json:"featureGates,omitempty"`
+	// UploadProxyLimits throttles the uploads forwarded by the upload proxy
+	UploadProxyLimits *UploadProxyLimits `json:"uploadProxyLimits,omitempty"`
+	// CloneCompression is the compression used to transfer host assisted clones, options: "gzip", "none", defaults to "gzip".
+	// zstd isn't supported, no zstd implementation is available to the cloner and the upload server
+	// +kubebuilder:validation:Enum="gzip";"none"
+	CloneCompression CloneCompression `json:"cloneCompression,omitempty"`
+	// CloneStrategies overrides the order clone strategies are tried in for storage classes. Storage classes that aren't listed try "snapshot", then "host-assisted"
+	CloneStrategies []StorageClassCloneStrategies `json:"cloneStrategies,omitempty"`
+	// ImportCache enables the cache of http and registry imports, DataVolumes importing a cached source are cloned from the cache
+	ImportCache *ImportCacheSpec `json:"importCache,omitempty"`
+	// DataVolumeDeadline is the default deadline of DataVolumes which don't set one, unlimited if not set
+	DataVolumeDeadline *metav1.Duration `json:"dataVolumeDeadline,omitempty"`
+	// Workloads is the default placement of the importer, cloner and upload server pods
+	Workloads *NodePlacement `json:"workloads,omitempty"`
+	// WorkloadSecurity is the user and group the importer, cloner and upload server pods run as on filesystem volumes.
+	// Pods on block volumes and clone source pods still run as root, they are rejected by the restricted Pod Security Standard
+	WorkloadSecurity *WorkloadSecurityPolicy `json:"workloadSecurity,omitempty"`
+	// ScratchSpace sizes the scratch space of imports and uploads after the size of their source
+	ScratchSpace *ScratchSpacePolicy `json:"scratchSpace,omitempty"`
+	// FilesystemOverhead is the fraction of filesystem PVCs reserved for filesystem metadata, the image is sized to the rest
+	FilesystemOverhead *FilesystemOverhead `json:"filesystemOverhead,omitempty"`
+	// StorageProfiles are the defaults of the PVCs of DataVolumes in specific storage classes
+	StorageProfiles []StorageProfile `json:"storageProfiles,omitempty"`
+}
+
+// StorageProfile defines the defaults of the PVCs of DataVolumes in a storage class, the DataVolume webhook fills in
+// the fields a DataVolume omits
+type StorageProfile struct {
+	// StorageClass is the name of the storage class
+	StorageClass string `json:"storageClass"`
+	// AccessModes are the access modes of PVCs which don't set any
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// VolumeMode is the volume mode of PVCs which don't set one
+	VolumeMode *corev1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+	// CloneStrategy is tried before "host-assisted" when cloneStrategies doesn't list the storage class. Options: "csi-clone", "snapshot", "host-assisted"
+	CloneStrategy *CloneStrategy `json:"cloneStrategy,omitempty"`
+	// FilesystemOverhead is the overhead of the storage class when filesystemOverhead doesn't list it
+	FilesystemOverhead *Percent `json:"filesystemOverhead,omitempty"`
+}
+
+// Percent is a fraction in [0, 1), with up to three decimals
+// +kubebuilder:validation:Pattern=`^0(\.[0-9]{1,3})?$`
+type Percent string
+
+// FilesystemOverhead defines the fraction of filesystem PVCs the importer and upload server leave to filesystem
+// metadata when sizing the image
+type FilesystemOverhead struct {
+	// Global is the overhead of storage classes not listed in StorageClass, defaults to "0.055"
+	Global Percent `json:"global,omitempty"`
+	// StorageClass is the overhead of specific storage classes
+	StorageClass map[string]Percent `json:"storageClass,omitempty"`
+	// InflateRequests makes DataVolumes request filesystem PVCs larger by the overhead, so the image is the requested size
+	InflateRequests bool `json:"inflateRequests,omitempty"`
+}
+
+// ScratchSpacePolicy defines the size of the scratch space: the size of the source plus an overhead when the importer or
+// upload server reported it, capped by the size of the target, the size of the target otherwise
+type ScratchSpacePolicy struct {
+	// OverheadPercent is the percentage of the source size added for the expansion of uncompressed sources, defaults to 100
+	// +kubebuilder:validation:Minimum=0
+	OverheadPercent *int32 `json:"overheadPercent,omitempty"`
+	// CompressedOverheadPercent is the percentage of the source size added for gz and xz compressed sources, which are
+	// decompressed into scratch space, defaults to 400
+	// +kubebuilder:validation:Minimum=0
+	CompressedOverheadPercent *int32 `json:"compressedOverheadPercent,omitempty"`
+	// EmptyDirLimit is the largest scratch space backed by an emptyDir of the pod instead of a PVC, scratch space is always a PVC if not set
+	EmptyDirLimit *resource.Quantity `json:"emptyDirLimit,omitempty"`
+}
+
+// WorkloadSecurityPolicy defines the user and group of the worker pods on filesystem volumes, pods on block volumes run as root to access the device.
+// The pods running as root are rejected in namespaces enforcing the restricted Pod Security Standard
+type WorkloadSecurityPolicy struct {
+	// RunAsUser is the non root UID of the worker pods, defaults to 107 (qemu)
+	// +kubebuilder:validation:Minimum=1
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// FSGroup is the GID owning the volumes of the worker pods, defaults to 107 (qemu)
+	// +kubebuilder:validation:Minimum=0
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+}
+
+// ImportCacheSpec defines the import cache, its PVCs are evicted when they are older than MaxAge, or when their total size exceeds MaxSize
+type ImportCacheSpec struct {
+	// Namespace holding the cache PVCs
+	Namespace string `json:"namespace"`
+	// MaxAge is the time a cache PVC is kept after it was created, unlimited if not set
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+	// MaxSize is the total requested storage of the cache PVCs, the least recently used PVCs are evicted when it is exceeded, unlimited if not set
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+}
+
+// StorageClassCloneStrategies defines the order clone strategies are tried in for a storage class
+type StorageClassCloneStrategies struct {
+	// StorageClass is the name of the storage class of the target PVC
+	StorageClass string `json:"storageClass"`
+	// Strategies are tried in order, the first possible strategy is used, "host-assisted" if none is possible. Options: "csi-clone", "snapshot", "host-assisted"
+	Strategies []CloneStrategy `json:"strategies"`
+}
+
+// CloneCompression represents the compression of host assisted clone data
+type CloneCompression string
+
+const (
+	// CloneCompressionGzip compresses the clone data with gzip, this is the default
+	CloneCompressionGzip CloneCompression = "gzip"
+	// CloneCompressionNone transfers the clone data uncompressed
+	CloneCompressionNone CloneCompression = "none"
+)
+
+// UploadProxyLimits defines the per namespace limits enforced by the upload proxy, an unset limit is not enforced
+type UploadProxyLimits struct {
+	// MaxConcurrentUploadsPerNamespace is the maximum number of uploads that can be in progress in a namespace at the same time
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrentUploadsPerNamespace *int32 `json:"maxConcurrentUploadsPerNamespace,omitempty"`
+	// BandwidthPerNamespace is the maximum rate, in bytes per second, shared by all uploads in a namespace
+	BandwidthPerNamespace *resource.Quantity `json:"bandwidthPerNamespace,omitempty"`
 }
 
 //CDIConfigStatus provides the most recently observed status of the CDI Config resource
@@ -12,8 +12,41 @@ func (DataVolumeSpec) SwaggerDoc() map[string]string {
 	return map[string]string{
 		"":            "DataVolumeSpec defines the DataVolume type specification",
 		"source":      "Source is the src of the data for the requested DataVolume",
-		"pvc":         "PVC is the PVC specification",
+		"pvc":         "PVC is the PVC specification\n+optional",
+		"storage":     "Storage is a simplified PVC specification, only the size is required. The access mode, volume mode and storage class are resolved from the storage class defaults and storage profiles, the size of clones from the source PVC. Either PVC or Storage must be set\n+optional",
 		"contentType": "DataVolumeContentType options: \"kubevirt\", \"archive\"\n+kubebuilder:validation:Enum=\"kubevirt\";\"archive\"",
+		"retryPolicy": "RetryPolicy bounds the retries of the pods populating the DataVolume, failed pods are restarted forever if it isn't set\n+optional",
+		"deadline":    "Deadline is the maximum time populating the DataVolume may take, counted from the creation of its PVC. The pods populating it are terminated and the DataVolume fails once it passes. Defaults to the dataVolumeDeadline of the CDIConfig, unlimited if neither is set\n+optional",
+		"action":      "Action cancels populating the DataVolume: Cancel removes the pods populating it for good and leaves the DataVolume Cancelled. DataVolumes can't be paused, the pods populating them can't resume where they stopped\n+kubebuilder:validation:Enum=\"Cancel\"\n+optional",
+		"placement":   "Placement overrides the workloads placement of the CDIConfig for the pods populating the DataVolume, each field that is set replaces the field of the CDIConfig\n+optional",
+	}
+}
+
+func (StorageSpec) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":                 "StorageSpec is the simplified PVC specification of a DataVolume, the settings it omits are resolved by CDI",
+		"accessModes":      "AccessModes contains the desired access modes the volume should have, resolved from the storage profile if not set\n+optional",
+		"resources":        "Resources represents the minimum resources the volume should have, the size of the source PVC of clones if not set\n+optional",
+		"storageClassName": "StorageClassName is the name of the StorageClass of the volume, the default storage class if not set\n+optional",
+		"volumeMode":       "VolumeMode defines what type of volume is required, resolved from the storage profile if not set\n+optional",
+	}
+}
+
+func (NodePlacement) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":                  "NodePlacement describes the node scheduling configuration of the CDI worker pods",
+		"nodeSelector":      "NodeSelector is the node selector of the worker pods\n+optional",
+		"affinity":          "Affinity is the affinity of the worker pods\n+optional",
+		"tolerations":       "Tolerations are the tolerations of the worker pods\n+optional",
+		"priorityClassName": "PriorityClassName is the priority class of the worker pods\n+optional",
+	}
+}
+
+func (DataVolumeRetryPolicy) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":           "DataVolumeRetryPolicy bounds the retries of the pods populating a DataVolume, permanent errors like a missing source or invalid credentials are never retried",
+		"maxRetries": "MaxRetries is the number of times a failed pod is recreated before the DataVolume fails, 0 fails the DataVolume on the first error. Failed pods are recreated forever if it isn't set\n+kubebuilder:validation:Minimum=0\n+optional",
+		"backoff":    "Backoff is the delay before the first retry, it doubles with every retry up to 5 minutes. Defaults to 10s\n+optional",
 	}
 }
 
@@ -81,9 +114,31 @@ func (DataVolumeSourceImageIO) SwaggerDoc() map[string]string {
 
 func (DataVolumeStatus) SwaggerDoc() map[string]string {
 	return map[string]string{
-		"":             "DataVolumeStatus contains the current status of the DataVolume",
-		"phase":        "Phase is the current phase of the data volume",
-		"restartCount": "RestartCount is the number of times the pod populating the DataVolume has restarted",
+		"":                    "DataVolumeStatus contains the current status of the DataVolume",
+		"phase":               "Phase is the current phase of the data volume",
+		"restartCount":        "RestartCount is the number of times the pod populating the DataVolume has restarted",
+		"cloneStrategy":       "CloneStrategy is the strategy chosen to clone the source PVC",
+		"cloneStrategyReason": "CloneStrategyReason explains why the clone strategy was chosen",
+		"importCache":         "ImportCache is the import cache PVC the DataVolume is cloned from, set if the import is served by the cache",
+		"transfer":            "Transfer is the transfer reported by the pod populating the DataVolume",
+	}
+}
+
+func (DataVolumeTransferStatus) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":           "DataVolumeTransferStatus describes the transfer reported by the pod populating a DataVolume",
+		"phase":      "Phase is the phase of the transfer, as reported by the pod",
+		"bytes":      "Bytes is the number of bytes transferred",
+		"totalBytes": "TotalBytes is the number of bytes to transfer, if known",
+	}
+}
+
+func (DataVolumeImportCacheStatus) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":          "DataVolumeImportCacheStatus describes the import cache PVC a DataVolume is cloned from",
+		"namespace": "Namespace of the cache PVC",
+		"name":      "Name of the cache PVC",
+		"hit":       "Hit is true if the cache PVC was already populated, false if the DataVolume populated it",
 	}
 }
 
@@ -100,6 +155,53 @@ func (DataVolumeCondition) SwaggerDoc() map[string]string {
 	}
 }
 
+func (DataImportCron) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"": "DataImportCron re-imports a registry or http source on a schedule, keeps the last imported PVCs and points to the newest one\n+genclient\n+k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object\n+kubebuilder:object:root=true\n+kubebuilder:storageversion\n+kubebuilder:resource:shortName=dic;dics,categories=all\n+kubebuilder:printcolumn:name=\"Schedule\",type=\"string\",JSONPath=\".spec.schedule\",description=\"The schedule the source is polled on\"\n+kubebuilder:printcolumn:name=\"Last Imported PVC\",type=\"string\",JSONPath=\".status.lastImportedPVC.name\",description=\"The PVC of the newest import\"\n+kubebuilder:printcolumn:name=\"Age\",type=\"date\",JSONPath=\".metadata.creationTimestamp\"",
+	}
+}
+
+func (DataImportCronSpec) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":              "DataImportCronSpec defines the DataImportCron type specification",
+		"template":      "Template is the DataVolume created for every new digest of the source, its source must be a registry or http source",
+		"schedule":      "Schedule is the cron schedule the source digest is polled on, e.g. \"0 */12 * * *\" or \"@daily\"",
+		"importsToKeep": "ImportsToKeep is the number of successfully imported PVCs kept, the older ones are garbage collected once they are not in use by pods. Defaults to 3\n+kubebuilder:validation:Minimum=1",
+	}
+}
+
+func (DataImportCronStatus) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":                       "DataImportCronStatus provides the most recently observed status of the DataImportCron",
+		"lastImportedPVC":        "LastImportedPVC is the PVC of the newest successful import, it is updated once the import of a new digest succeeds",
+		"lastImportedDigest":     "LastImportedDigest is the source digest imported to LastImportedPVC",
+		"lastImportTimestamp":    "LastImportTimestamp is the time the newest successful import was started",
+		"lastExecutionTimestamp": "LastExecutionTimestamp is the time the source digest was last polled",
+		"currentImports":         "CurrentImports are the imports in progress",
+	}
+}
+
+func (DataImportCronImport) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":               "DataImportCronImport describes an import started by the DataImportCron",
+		"dataVolumeName": "DataVolumeName is the name of the DataVolume importing the digest",
+		"digest":         "Digest is the source digest being imported",
+	}
+}
+
+func (DataImportCronCondition) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"": "DataImportCronCondition represents the state of a DataImportCron condition",
+	}
+}
+
+func (DataImportCronList) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":      "DataImportCronList provides the needed parameters to do request a list of DataImportCrons from the system\n+k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object",
+		"items": "Items provides a list of DataImportCrons",
+	}
+}
+
 func (CDI) SwaggerDoc() map[string]string {
 	return map[string]string{
 		"":       "CDI is the CDI Operator CRD\n+genclient\n+k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object\n+kubebuilder:object:root=true\n+kubebuilder:storageversion\n+kubebuilder:resource:shortName=cdi;cdis,scope=Cluster\n+kubebuilder:printcolumn:name=\"Age\",type=\"date\",JSONPath=\".metadata.creationTimestamp\"\n+kubebuilder:printcolumn:name=\"Phase\",type=\"string\",JSONPath=\".status.phase\"",
@@ -144,6 +246,78 @@ func (CDIConfigSpec) SwaggerDoc() map[string]string {
 		"uploadProxyURLOverride":   "Override the URL used when uploading to a DataVolume",
 		"scratchSpaceStorageClass": "Override the storage class to used for scratch space during transfer operations. The scratch space storage class is determined in the following order: 1. value of scratchSpaceStorageClass, if that doesn't exist, use the default storage class, if there is no default storage class, use the storage class of the DataVolume, if no storage class specified, use no storage class for scratch space",
 		"featureGates":             "FeatureGates are a list of specific enabled feature gates",
+		"uploadProxyLimits":        "UploadProxyLimits throttles the uploads forwarded by the upload proxy",
+		"cloneCompression":         "CloneCompression is the compression used to transfer host assisted clones, options: \"gzip\", \"none\", defaults to \"gzip\".\nzstd isn't supported, no zstd implementation is available to the cloner and the upload server\n+kubebuilder:validation:Enum=\"gzip\";\"none\"",
+		"cloneStrategies":          "CloneStrategies overrides the order clone strategies are tried in for storage classes. Storage classes that aren't listed try \"snapshot\", then \"host-assisted\"",
+		"importCache":              "ImportCache enables the cache of http and registry imports, DataVolumes importing a cached source are cloned from the cache",
+		"dataVolumeDeadline":       "DataVolumeDeadline is the default deadline of DataVolumes which don't set one, unlimited if not set",
+		"workloads":                "Workloads is the default placement of the importer, cloner and upload server pods",
+		"workloadSecurity":         "WorkloadSecurity is the user and group the importer, cloner and upload server pods run as on filesystem volumes.\nPods on block volumes and clone source pods still run as root, they are rejected by the restricted Pod Security Standard",
+		"scratchSpace":             "ScratchSpace sizes the scratch space of imports and uploads after the size of their source",
+		"filesystemOverhead":       "FilesystemOverhead is the fraction of filesystem PVCs reserved for filesystem metadata, the image is sized to the rest",
+		"storageProfiles":          "StorageProfiles are the defaults of the PVCs of DataVolumes in specific storage classes",
+	}
+}
+
+func (StorageProfile) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":                   "StorageProfile defines the defaults of the PVCs of DataVolumes in a storage class, the DataVolume webhook fills in\nthe fields a DataVolume omits",
+		"storageClass":       "StorageClass is the name of the storage class",
+		"accessModes":        "AccessModes are the access modes of PVCs which don't set any",
+		"volumeMode":         "VolumeMode is the volume mode of PVCs which don't set one",
+		"cloneStrategy":      "CloneStrategy is tried before \"host-assisted\" when cloneStrategies doesn't list the storage class. Options: \"csi-clone\", \"snapshot\", \"host-assisted\"",
+		"filesystemOverhead": "FilesystemOverhead is the overhead of the storage class when filesystemOverhead doesn't list it",
+	}
+}
+
+func (FilesystemOverhead) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":                "FilesystemOverhead defines the fraction of filesystem PVCs the importer and upload server leave to filesystem\nmetadata when sizing the image",
+		"global":          "Global is the overhead of storage classes not listed in StorageClass, defaults to \"0.055\"",
+		"storageClass":    "StorageClass is the overhead of specific storage classes",
+		"inflateRequests": "InflateRequests makes DataVolumes request filesystem PVCs larger by the overhead, so the image is the requested size",
+	}
+}
+
+func (ScratchSpacePolicy) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":                          "ScratchSpacePolicy defines the size of the scratch space: the size of the source plus an overhead when the importer or\nupload server reported it, capped by the size of the target, the size of the target otherwise",
+		"overheadPercent":           "OverheadPercent is the percentage of the source size added for the expansion of uncompressed sources, defaults to 100\n+kubebuilder:validation:Minimum=0",
+		"compressedOverheadPercent": "CompressedOverheadPercent is the percentage of the source size added for gz and xz compressed sources, which are\ndecompressed into scratch space, defaults to 400\n+kubebuilder:validation:Minimum=0",
+		"emptyDirLimit":             "EmptyDirLimit is the largest scratch space backed by an emptyDir of the pod instead of a PVC, scratch space is always a PVC if not set",
+	}
+}
+
+func (WorkloadSecurityPolicy) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":          "WorkloadSecurityPolicy defines the user and group of the worker pods on filesystem volumes, pods on block volumes run as root to access the device.\nThe pods running as root are rejected in namespaces enforcing the restricted Pod Security Standard",
+		"runAsUser": "RunAsUser is the non root UID of the worker pods, defaults to 107 (qemu)\n+kubebuilder:validation:Minimum=1",
+		"fsGroup":   "FSGroup is the GID owning the volumes of the worker pods, defaults to 107 (qemu)\n+kubebuilder:validation:Minimum=0",
+	}
+}
+
+func (ImportCacheSpec) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":          "ImportCacheSpec defines the import cache, its PVCs are evicted when they are older than MaxAge, or when their total size exceeds MaxSize",
+		"namespace": "Namespace holding the cache PVCs",
+		"maxAge":    "MaxAge is the time a cache PVC is kept after it was created, unlimited if not set",
+		"maxSize":   "MaxSize is the total requested storage of the cache PVCs, the least recently used PVCs are evicted when it is exceeded, unlimited if not set",
+	}
+}
+
+func (StorageClassCloneStrategies) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":             "StorageClassCloneStrategies defines the order clone strategies are tried in for a storage class",
+		"storageClass": "StorageClass is the name of the storage class of the target PVC",
+		"strategies":   "Strategies are tried in order, the first possible strategy is used, \"host-assisted\" if none is possible. Options: \"csi-clone\", \"snapshot\", \"host-assisted\"",
+	}
+}
+
+func (UploadProxyLimits) SwaggerDoc() map[string]string {
+	return map[string]string{
+		"":                                 "UploadProxyLimits defines the per namespace limits enforced by the upload proxy, an unset limit is not enforced",
+		"maxConcurrentUploadsPerNamespace": "MaxConcurrentUploadsPerNamespace is the maximum number of uploads that can be in progress in a namespace at the same time\n+kubebuilder:validation:Minimum=1",
+		"bandwidthPerNamespace":            "BandwidthPerNamespace is the maximum rate, in bytes per second, shared by all uploads in a namespace",
 	}
 }
 
@@ -23,6 +23,7 @@ package v1beta1
 import (
 	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -138,6 +139,55 @@ func (in *CDIConfigSpec) DeepCopyInto(out *CDIConfigSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.UploadProxyLimits != nil {
+		in, out := &in.UploadProxyLimits, &out.UploadProxyLimits
+		*out = new(UploadProxyLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloneStrategies != nil {
+		in, out := &in.CloneStrategies, &out.CloneStrategies
+		*out = make([]StorageClassCloneStrategies, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ImportCache != nil {
+		in, out := &in.ImportCache, &out.ImportCache
+		*out = new(ImportCacheSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DataVolumeDeadline != nil {
+		in, out := &in.DataVolumeDeadline, &out.DataVolumeDeadline
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = new(NodePlacement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WorkloadSecurity != nil {
+		in, out := &in.WorkloadSecurity, &out.WorkloadSecurity
+		*out = new(WorkloadSecurityPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScratchSpace != nil {
+		in, out := &in.ScratchSpace, &out.ScratchSpace
+		*out = new(ScratchSpacePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FilesystemOverhead != nil {
+		in, out := &in.FilesystemOverhead, &out.FilesystemOverhead
+		*out = new(FilesystemOverhead)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StorageProfiles != nil {
+		in, out := &in.StorageProfiles, &out.StorageProfiles
+		*out = make([]StorageProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -254,6 +304,164 @@ func (in *CDIStatus) DeepCopy() *CDIStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataImportCron) DeepCopyInto(out *DataImportCron) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataImportCron.
+func (in *DataImportCron) DeepCopy() *DataImportCron {
+	if in == nil {
+		return nil
+	}
+	out := new(DataImportCron)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataImportCron) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataImportCronCondition) DeepCopyInto(out *DataImportCronCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.LastHeartbeatTime.DeepCopyInto(&out.LastHeartbeatTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataImportCronCondition.
+func (in *DataImportCronCondition) DeepCopy() *DataImportCronCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(DataImportCronCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataImportCronImport) DeepCopyInto(out *DataImportCronImport) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataImportCronImport.
+func (in *DataImportCronImport) DeepCopy() *DataImportCronImport {
+	if in == nil {
+		return nil
+	}
+	out := new(DataImportCronImport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataImportCronList) DeepCopyInto(out *DataImportCronList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DataImportCron, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataImportCronList.
+func (in *DataImportCronList) DeepCopy() *DataImportCronList {
+	if in == nil {
+		return nil
+	}
+	out := new(DataImportCronList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataImportCronList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataImportCronSpec) DeepCopyInto(out *DataImportCronSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.ImportsToKeep != nil {
+		in, out := &in.ImportsToKeep, &out.ImportsToKeep
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataImportCronSpec.
+func (in *DataImportCronSpec) DeepCopy() *DataImportCronSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DataImportCronSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataImportCronStatus) DeepCopyInto(out *DataImportCronStatus) {
+	*out = *in
+	if in.LastImportedPVC != nil {
+		in, out := &in.LastImportedPVC, &out.LastImportedPVC
+		*out = new(DataVolumeSourcePVC)
+		**out = **in
+	}
+	if in.LastImportTimestamp != nil {
+		in, out := &in.LastImportTimestamp, &out.LastImportTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastExecutionTimestamp != nil {
+		in, out := &in.LastExecutionTimestamp, &out.LastExecutionTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.CurrentImports != nil {
+		in, out := &in.CurrentImports, &out.CurrentImports
+		*out = make([]DataImportCronImport, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]DataImportCronCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataImportCronStatus.
+func (in *DataImportCronStatus) DeepCopy() *DataImportCronStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataImportCronStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataVolume) DeepCopyInto(out *DataVolume) {
 	*out = *in
@@ -316,6 +524,22 @@ func (in *DataVolumeCondition) DeepCopy() *DataVolumeCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataVolumeImportCacheStatus) DeepCopyInto(out *DataVolumeImportCacheStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataVolumeImportCacheStatus.
+func (in *DataVolumeImportCacheStatus) DeepCopy() *DataVolumeImportCacheStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataVolumeImportCacheStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataVolumeList) DeepCopyInto(out *DataVolumeList) {
 	*out = *in
@@ -349,6 +573,32 @@ func (in *DataVolumeList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataVolumeRetryPolicy) DeepCopyInto(out *DataVolumeRetryPolicy) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataVolumeRetryPolicy.
+func (in *DataVolumeRetryPolicy) DeepCopy() *DataVolumeRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DataVolumeRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataVolumeSource) DeepCopyInto(out *DataVolumeSource) {
 	*out = *in
@@ -505,6 +755,26 @@ func (in *DataVolumeSpec) DeepCopyInto(out *DataVolumeSpec) {
 		*out = new(v1.PersistentVolumeClaimSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(DataVolumeRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Deadline != nil {
+		in, out := &in.Deadline, &out.Deadline
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(NodePlacement)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -528,6 +798,16 @@ func (in *DataVolumeStatus) DeepCopyInto(out *DataVolumeStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ImportCache != nil {
+		in, out := &in.ImportCache, &out.ImportCache
+		*out = new(DataVolumeImportCacheStatus)
+		**out = **in
+	}
+	if in.Transfer != nil {
+		in, out := &in.Transfer, &out.Transfer
+		*out = new(DataVolumeTransferStatus)
+		**out = **in
+	}
 	return
 }
 
@@ -540,3 +820,275 @@ func (in *DataVolumeStatus) DeepCopy() *DataVolumeStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataVolumeTransferStatus) DeepCopyInto(out *DataVolumeTransferStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataVolumeTransferStatus.
+func (in *DataVolumeTransferStatus) DeepCopy() *DataVolumeTransferStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataVolumeTransferStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilesystemOverhead) DeepCopyInto(out *FilesystemOverhead) {
+	*out = *in
+	if in.StorageClass != nil {
+		in, out := &in.StorageClass, &out.StorageClass
+		*out = make(map[string]Percent, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilesystemOverhead.
+func (in *FilesystemOverhead) DeepCopy() *FilesystemOverhead {
+	if in == nil {
+		return nil
+	}
+	out := new(FilesystemOverhead)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImportCacheSpec) DeepCopyInto(out *ImportCacheSpec) {
+	*out = *in
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportCacheSpec.
+func (in *ImportCacheSpec) DeepCopy() *ImportCacheSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImportCacheSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePlacement) DeepCopyInto(out *NodePlacement) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePlacement.
+func (in *NodePlacement) DeepCopy() *NodePlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScratchSpacePolicy) DeepCopyInto(out *ScratchSpacePolicy) {
+	*out = *in
+	if in.OverheadPercent != nil {
+		in, out := &in.OverheadPercent, &out.OverheadPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CompressedOverheadPercent != nil {
+		in, out := &in.CompressedOverheadPercent, &out.CompressedOverheadPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.EmptyDirLimit != nil {
+		in, out := &in.EmptyDirLimit, &out.EmptyDirLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScratchSpacePolicy.
+func (in *ScratchSpacePolicy) DeepCopy() *ScratchSpacePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ScratchSpacePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageClassCloneStrategies) DeepCopyInto(out *StorageClassCloneStrategies) {
+	*out = *in
+	if in.Strategies != nil {
+		in, out := &in.Strategies, &out.Strategies
+		*out = make([]CloneStrategy, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClassCloneStrategies.
+func (in *StorageClassCloneStrategies) DeepCopy() *StorageClassCloneStrategies {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageClassCloneStrategies)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageProfile) DeepCopyInto(out *StorageProfile) {
+	*out = *in
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeMode != nil {
+		in, out := &in.VolumeMode, &out.VolumeMode
+		*out = new(v1.PersistentVolumeMode)
+		**out = **in
+	}
+	if in.CloneStrategy != nil {
+		in, out := &in.CloneStrategy, &out.CloneStrategy
+		*out = new(CloneStrategy)
+		**out = **in
+	}
+	if in.FilesystemOverhead != nil {
+		in, out := &in.FilesystemOverhead, &out.FilesystemOverhead
+		*out = new(Percent)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageProfile.
+func (in *StorageProfile) DeepCopy() *StorageProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
+	*out = *in
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.VolumeMode != nil {
+		in, out := &in.VolumeMode, &out.VolumeMode
+		*out = new(v1.PersistentVolumeMode)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageSpec.
+func (in *StorageSpec) DeepCopy() *StorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UploadProxyLimits) DeepCopyInto(out *UploadProxyLimits) {
+	*out = *in
+	if in.MaxConcurrentUploadsPerNamespace != nil {
+		in, out := &in.MaxConcurrentUploadsPerNamespace, &out.MaxConcurrentUploadsPerNamespace
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BandwidthPerNamespace != nil {
+		in, out := &in.BandwidthPerNamespace, &out.BandwidthPerNamespace
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UploadProxyLimits.
+func (in *UploadProxyLimits) DeepCopy() *UploadProxyLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(UploadProxyLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadSecurityPolicy) DeepCopyInto(out *WorkloadSecurityPolicy) {
+	*out = *in
+	if in.RunAsUser != nil {
+		in, out := &in.RunAsUser, &out.RunAsUser
+		*out = new(int64)
+		**out = **in
+	}
+	if in.FSGroup != nil {
+		in, out := &in.FSGroup, &out.FSGroup
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadSecurityPolicy.
+func (in *WorkloadSecurityPolicy) DeepCopy() *WorkloadSecurityPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadSecurityPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -62,6 +62,8 @@ const (
 
 	cdiValidatePath = "/cdi-validate"
 
+	cdiConfigValidatePath = "/cdiconfig-validate"
+
 	healthzPath = "/healthz"
 )
 
@@ -168,6 +170,11 @@ func NewCdiAPIServer(bindAddress string,
 		return nil, errors.Errorf("failed to create CDI validating webhook: %s", err)
 	}
 
+	err = app.createCDIConfigValidatingWebhook()
+	if err != nil {
+		return nil, errors.Errorf("failed to create CDIConfig validating webhook: %s", err)
+	}
+
 	return app, nil
 }
 
@@ -447,12 +454,12 @@ func (app *cdiAPIApp) healthzHandler(req *restful.Request, resp *restful.Respons
 }
 
 func (app *cdiAPIApp) createDataVolumeValidatingWebhook() error {
-	app.container.ServeMux.Handle(dvValidatePath, webhooks.NewDataVolumeValidatingWebhook(app.client))
+	app.container.ServeMux.Handle(dvValidatePath, webhooks.NewDataVolumeValidatingWebhook(app.client, app.cdiClient))
 	return nil
 }
 
 func (app *cdiAPIApp) createDataVolumeMutatingWebhook() error {
-	app.container.ServeMux.Handle(dvMutatePath, webhooks.NewDataVolumeMutatingWebhook(app.client, app.privateSigningKey))
+	app.container.ServeMux.Handle(dvMutatePath, webhooks.NewDataVolumeMutatingWebhook(app.client, app.cdiClient, app.privateSigningKey))
 	return nil
 }
 
@@ -460,3 +467,8 @@ func (app *cdiAPIApp) createCDIValidatingWebhook() error {
 	app.container.ServeMux.Handle(cdiValidatePath, webhooks.NewCDIValidatingWebhook(app.cdiClient))
 	return nil
 }
+
+func (app *cdiAPIApp) createCDIConfigValidatingWebhook() error {
+	app.container.ServeMux.Handle(cdiConfigValidatePath, webhooks.NewCDIConfigValidatingWebhook())
+	return nil
+}
@@ -0,0 +1,216 @@
+/*
+ * This file is part of the CDI project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2020 Red Hat, Inc.
+ *
+ */
+
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfield "k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+type cdiConfigValidatingWebhook struct{}
+
+// percentRegexp matches a fraction in [0, 1) with up to three decimals
+var percentRegexp = regexp.MustCompile(`^0(\.[0-9]{1,3})?$`)
+
+func (wh *cdiConfigValidatingWebhook) Admit(ar admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+	klog.V(3).Infof("Got AdmissionReview %+v", ar)
+
+	if ar.Request.Resource.Group != cdiv1.SchemeGroupVersion.Group || ar.Request.Resource.Resource != "cdiconfigs" {
+		klog.V(3).Infof("Got unexpected resource type %s", ar.Request.Resource.Resource)
+		return toAdmissionResponseError(fmt.Errorf("unexpected resource: %s", ar.Request.Resource.Resource))
+	}
+
+	if ar.Request.Operation != admissionv1beta1.Create && ar.Request.Operation != admissionv1beta1.Update {
+		return allowedAdmissionResponse()
+	}
+
+	config := cdiv1.CDIConfig{}
+	if err := json.Unmarshal(ar.Request.Object.Raw, &config); err != nil {
+		return toAdmissionResponseError(err)
+	}
+
+	causes := validateNodePlacement(k8sfield.NewPath("spec", "workloads"), config.Spec.Workloads)
+	causes = append(causes, validateWorkloadSecurity(k8sfield.NewPath("spec", "workloadSecurity"), config.Spec.WorkloadSecurity)...)
+	causes = append(causes, validateScratchSpace(k8sfield.NewPath("spec", "scratchSpace"), config.Spec.ScratchSpace)...)
+	causes = append(causes, validateFilesystemOverhead(k8sfield.NewPath("spec", "filesystemOverhead"), config.Spec.FilesystemOverhead)...)
+	causes = append(causes, validateStorageProfiles(k8sfield.NewPath("spec", "storageProfiles"), config.Spec.StorageProfiles)...)
+	if len(causes) > 0 {
+		klog.Infof("rejected CDIConfig admission")
+		return toRejectedAdmissionResponse(causes)
+	}
+
+	return allowedAdmissionResponse()
+}
+
+// validateWorkloadSecurity rejects a root user for the worker pods on filesystem volumes
+func validateWorkloadSecurity(field *k8sfield.Path, policy *cdiv1.WorkloadSecurityPolicy) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if policy == nil {
+		return causes
+	}
+	if policy.RunAsUser != nil && *policy.RunAsUser < 1 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("RunAsUser must be a non root UID"),
+			Field:   field.Child("runAsUser").String(),
+		})
+	}
+	if policy.FSGroup != nil && *policy.FSGroup < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("FSGroup can't be less than zero"),
+			Field:   field.Child("fsGroup").String(),
+		})
+	}
+	return causes
+}
+
+// validateScratchSpace rejects a negative overhead or emptyDir limit for the scratch space
+func validateScratchSpace(field *k8sfield.Path, policy *cdiv1.ScratchSpacePolicy) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if policy == nil {
+		return causes
+	}
+	if policy.OverheadPercent != nil && *policy.OverheadPercent < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("OverheadPercent can't be less than zero"),
+			Field:   field.Child("overheadPercent").String(),
+		})
+	}
+	if policy.CompressedOverheadPercent != nil && *policy.CompressedOverheadPercent < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("CompressedOverheadPercent can't be less than zero"),
+			Field:   field.Child("compressedOverheadPercent").String(),
+		})
+	}
+	if policy.EmptyDirLimit != nil && policy.EmptyDirLimit.Sign() < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("EmptyDirLimit can't be less than zero"),
+			Field:   field.Child("emptyDirLimit").String(),
+		})
+	}
+	return causes
+}
+
+// validateFilesystemOverhead rejects overheads that aren't a fraction of the volume
+func validateFilesystemOverhead(field *k8sfield.Path, overhead *cdiv1.FilesystemOverhead) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if overhead == nil {
+		return causes
+	}
+	if overhead.Global != "" && !percentRegexp.MatchString(string(overhead.Global)) {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Global overhead %q must be a fraction in [0, 1) with up to three decimals", overhead.Global),
+			Field:   field.Child("global").String(),
+		})
+	}
+	for storageClass, value := range overhead.StorageClass {
+		if !percentRegexp.MatchString(string(value)) {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("Overhead %q of storage class %s must be a fraction in [0, 1) with up to three decimals", value, storageClass),
+				Field:   field.Child("storageClass").Key(storageClass).String(),
+			})
+		}
+	}
+	return causes
+}
+
+// validateStorageProfiles rejects profiles without storage class or with settings the PVCs and clones can't use
+func validateStorageProfiles(field *k8sfield.Path, profiles []cdiv1.StorageProfile) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	storageClasses := make(map[string]bool)
+	for i, profile := range profiles {
+		profileField := field.Index(i)
+		if profile.StorageClass == "" {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueRequired,
+				Message: fmt.Sprintf("StorageClass is required"),
+				Field:   profileField.Child("storageClass").String(),
+			})
+		} else if storageClasses[profile.StorageClass] {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueDuplicate,
+				Message: fmt.Sprintf("Storage class %s has more than one profile", profile.StorageClass),
+				Field:   profileField.Child("storageClass").String(),
+			})
+		}
+		storageClasses[profile.StorageClass] = true
+		if len(profile.AccessModes) > 1 {
+			// DataVolumes have a single access mode
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("Profile of storage class %s has multiple accessModes", profile.StorageClass),
+				Field:   profileField.Child("accessModes").String(),
+			})
+		}
+		for j, accessMode := range profile.AccessModes {
+			switch accessMode {
+			case corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany:
+			default:
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldValueNotSupported,
+					Message: fmt.Sprintf("Access mode %s is not supported", accessMode),
+					Field:   profileField.Child("accessModes").Index(j).String(),
+				})
+			}
+		}
+		if profile.VolumeMode != nil && *profile.VolumeMode != corev1.PersistentVolumeBlock && *profile.VolumeMode != corev1.PersistentVolumeFilesystem {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueNotSupported,
+				Message: fmt.Sprintf("Volume mode %s is not supported", *profile.VolumeMode),
+				Field:   profileField.Child("volumeMode").String(),
+			})
+		}
+		if profile.CloneStrategy != nil {
+			switch *profile.CloneStrategy {
+			case cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategySnapshot, cdiv1.CloneStrategyHostAssisted:
+			default:
+				causes = append(causes, metav1.StatusCause{
+					Type: metav1.CauseTypeFieldValueNotSupported,
+					Message: fmt.Sprintf("Clone strategy %s is not one of %s, %s, %s",
+						*profile.CloneStrategy, cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategySnapshot, cdiv1.CloneStrategyHostAssisted),
+					Field: profileField.Child("cloneStrategy").String(),
+				})
+			}
+		}
+		if profile.FilesystemOverhead != nil && !percentRegexp.MatchString(string(*profile.FilesystemOverhead)) {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("Overhead %q of storage class %s must be a fraction in [0, 1) with up to three decimals", *profile.FilesystemOverhead, profile.StorageClass),
+				Field:   profileField.Child("filesystemOverhead").String(),
+			})
+		}
+	}
+	return causes
+}
@@ -0,0 +1,233 @@
+/*
+ * This file is part of the CDI project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2020 Red Hat, Inc.
+ *
+ */
+
+package webhooks
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+var _ = Describe("CDIConfig Validating Webhook", func() {
+	DescribeTable("should validate the workloads placement", func(workloads *cdiv1.NodePlacement, allowed bool) {
+		config := &cdiv1.CDIConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "config",
+			},
+			Spec: cdiv1.CDIConfigSpec{
+				Workloads: workloads,
+			},
+		}
+		resp := validateCDIConfig(config, admissionv1beta1.Update)
+		Expect(resp.Allowed).To(Equal(allowed))
+	},
+		Entry("without placement", nil, true),
+		Entry("with a valid placement", &cdiv1.NodePlacement{
+			NodeSelector: map[string]string{"node-role.kubernetes.io/storage": ""},
+			Tolerations: []corev1.Toleration{
+				{Key: "storage", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			},
+			Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+							MatchExpressions: []corev1.NodeSelectorRequirement{
+								{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a", "b"}},
+							},
+						}},
+					},
+				},
+			},
+			PriorityClassName: "cdi-workloads",
+		}, true),
+		Entry("with an invalid node selector", &cdiv1.NodePlacement{
+			NodeSelector: map[string]string{"-storage": "true"},
+		}, false),
+		Entry("with an empty toleration key and operator Equal", &cdiv1.NodePlacement{
+			Tolerations: []corev1.Toleration{{Value: "true"}},
+		}, false),
+		Entry("with toleration seconds without effect NoExecute", &cdiv1.NodePlacement{
+			Tolerations: []corev1.Toleration{{Key: "storage", Operator: corev1.TolerationOpExists, TolerationSeconds: new(int64)}},
+		}, false),
+		Entry("with an invalid toleration effect", &cdiv1.NodePlacement{
+			Tolerations: []corev1.Toleration{{Key: "storage", Operator: corev1.TolerationOpExists, Effect: "Evict"}},
+		}, false),
+		Entry("with an invalid priority class name", &cdiv1.NodePlacement{
+			PriorityClassName: "CDI_Workloads",
+		}, false),
+		Entry("with an invalid node affinity operator", &cdiv1.NodePlacement{
+			Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+						Weight: 10,
+						Preference: corev1.NodeSelectorTerm{
+							MatchExpressions: []corev1.NodeSelectorRequirement{
+								{Key: "zone", Operator: "Matches", Values: []string{"a"}},
+							},
+						},
+					}},
+				},
+			},
+		}, false),
+	)
+
+	DescribeTable("should validate the workload security policy", func(runAsUser, fsGroup int64, allowed bool) {
+		config := &cdiv1.CDIConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "config",
+			},
+			Spec: cdiv1.CDIConfigSpec{
+				WorkloadSecurity: &cdiv1.WorkloadSecurityPolicy{
+					RunAsUser: &runAsUser,
+					FSGroup:   &fsGroup,
+				},
+			},
+		}
+		resp := validateCDIConfig(config, admissionv1beta1.Update)
+		Expect(resp.Allowed).To(Equal(allowed))
+	},
+		Entry("with a non root user", int64(1000), int64(1000), true),
+		Entry("with the root group", int64(1000), int64(0), true),
+		Entry("with the root user", int64(0), int64(1000), false),
+		Entry("with a negative group", int64(1000), int64(-1), false),
+	)
+
+	DescribeTable("should validate the scratch space policy", func(overhead, compressedOverhead int32, emptyDirLimit string, allowed bool) {
+		limit := resource.MustParse(emptyDirLimit)
+		config := &cdiv1.CDIConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "config",
+			},
+			Spec: cdiv1.CDIConfigSpec{
+				ScratchSpace: &cdiv1.ScratchSpacePolicy{
+					OverheadPercent:           &overhead,
+					CompressedOverheadPercent: &compressedOverhead,
+					EmptyDirLimit:             &limit,
+				},
+			},
+		}
+		resp := validateCDIConfig(config, admissionv1beta1.Update)
+		Expect(resp.Allowed).To(Equal(allowed))
+	},
+		Entry("with an overhead and emptyDir limit", int32(50), int32(300), "1Gi", true),
+		Entry("without overhead", int32(0), int32(0), "0", true),
+		Entry("with a negative overhead", int32(-1), int32(300), "1Gi", false),
+		Entry("with a negative compressed overhead", int32(50), int32(-1), "1Gi", false),
+		Entry("with a negative emptyDir limit", int32(50), int32(300), "-1Gi", false),
+	)
+
+	DescribeTable("should validate the filesystem overhead", func(global cdiv1.Percent, storageClass map[string]cdiv1.Percent, allowed bool) {
+		config := &cdiv1.CDIConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "config",
+			},
+			Spec: cdiv1.CDIConfigSpec{
+				FilesystemOverhead: &cdiv1.FilesystemOverhead{
+					Global:       global,
+					StorageClass: storageClass,
+				},
+			},
+		}
+		resp := validateCDIConfig(config, admissionv1beta1.Update)
+		Expect(resp.Allowed).To(Equal(allowed))
+	},
+		Entry("with valid overheads", cdiv1.Percent("0.055"), map[string]cdiv1.Percent{"local": "0"}, true),
+		Entry("without global overhead", cdiv1.Percent(""), map[string]cdiv1.Percent{"local": "0.1"}, true),
+		Entry("with the whole volume as global overhead", cdiv1.Percent("1"), nil, false),
+		Entry("with too many decimals", cdiv1.Percent("0.0555"), nil, false),
+		Entry("with an invalid storage class overhead", cdiv1.Percent("0.055"), map[string]cdiv1.Percent{"local": "5%"}, false),
+	)
+
+	DescribeTable("should validate the storage profiles", func(profiles []cdiv1.StorageProfile, allowed bool) {
+		config := &cdiv1.CDIConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "config",
+			},
+			Spec: cdiv1.CDIConfigSpec{
+				StorageProfiles: profiles,
+			},
+		}
+		resp := validateCDIConfig(config, admissionv1beta1.Update)
+		Expect(resp.Allowed).To(Equal(allowed))
+	},
+		Entry("with a valid profile", []cdiv1.StorageProfile{
+			{
+				StorageClass:       "local",
+				AccessModes:        []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				VolumeMode:         volumeModePtr(corev1.PersistentVolumeBlock),
+				CloneStrategy:      cloneStrategyPtr(cdiv1.CloneStrategyCsiClone),
+				FilesystemOverhead: percentPtr("0.1"),
+			},
+		}, true),
+		Entry("without storage class", []cdiv1.StorageProfile{{AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}}}, false),
+		Entry("with two profiles of a storage class", []cdiv1.StorageProfile{{StorageClass: "local"}, {StorageClass: "local"}}, false),
+		Entry("with an invalid access mode", []cdiv1.StorageProfile{{StorageClass: "local", AccessModes: []corev1.PersistentVolumeAccessMode{"ReadWriteSometimes"}}}, false),
+		Entry("with multiple access modes", []cdiv1.StorageProfile{{StorageClass: "local", AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce, corev1.ReadWriteMany}}}, false),
+		Entry("with an invalid volume mode", []cdiv1.StorageProfile{{StorageClass: "local", VolumeMode: volumeModePtr("Object")}}, false),
+		Entry("with an invalid clone strategy", []cdiv1.StorageProfile{{StorageClass: "local", CloneStrategy: cloneStrategyPtr("rsync")}}, false),
+		Entry("with an invalid filesystem overhead", []cdiv1.StorageProfile{{StorageClass: "local", FilesystemOverhead: percentPtr("1")}}, false),
+	)
+
+	It("should allow deleting the config", func() {
+		resp := validateCDIConfig(&cdiv1.CDIConfig{}, admissionv1beta1.Delete)
+		Expect(resp.Allowed).To(BeTrue())
+	})
+})
+
+func validateCDIConfig(config *cdiv1.CDIConfig, op admissionv1beta1.Operation) *admissionv1beta1.AdmissionResponse {
+	bytes, _ := json.Marshal(config)
+	ar := admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			Operation: op,
+			Resource: metav1.GroupVersionResource{
+				Group:    cdiv1.SchemeGroupVersion.Group,
+				Version:  cdiv1.SchemeGroupVersion.Version,
+				Resource: "cdiconfigs",
+			},
+			Object: runtime.RawExtension{
+				Raw: bytes,
+			},
+		},
+	}
+
+	wh := &cdiConfigValidatingWebhook{}
+	return wh.Admit(ar)
+}
+
+func volumeModePtr(volumeMode corev1.PersistentVolumeMode) *corev1.PersistentVolumeMode {
+	return &volumeMode
+}
+
+func cloneStrategyPtr(strategy cdiv1.CloneStrategy) *cdiv1.CloneStrategy {
+	return &strategy
+}
+
+func percentPtr(value cdiv1.Percent) *cdiv1.Percent {
+	return &value
+}
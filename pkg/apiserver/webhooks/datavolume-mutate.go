@@ -20,22 +20,28 @@
 package webhooks
 
 import (
+	"context"
 	"encoding/json"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sfield "k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 
 	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	cdiclient "kubevirt.io/containerized-data-importer/pkg/client/clientset/versioned"
 	"kubevirt.io/containerized-data-importer/pkg/clone"
+	"kubevirt.io/containerized-data-importer/pkg/common"
 	"kubevirt.io/containerized-data-importer/pkg/controller"
 	"kubevirt.io/containerized-data-importer/pkg/token"
 )
 
 type dataVolumeMutatingWebhook struct {
 	client         kubernetes.Interface
+	cdiClient      cdiclient.Interface
 	tokenGenerator token.Generator
 }
 
@@ -70,9 +76,19 @@ func (wh *dataVolumeMutatingWebhook) Admit(ar admissionv1beta1.AdmissionReview)
 		targetName = ar.Request.Name
 	}
 
+	modifiedDataVolume := dataVolume.DeepCopy()
+	if ar.Request.Operation == admissionv1beta1.Create {
+		if err := wh.applyStorageProfile(modifiedDataVolume); err != nil {
+			return toAdmissionResponseError(err)
+		}
+	}
+
 	if pvcSource == nil {
 		klog.V(3).Infof("DataVolume %s/%s not cloning", targetNamespace, targetName)
-		return allowedAdmissionResponse()
+		if apiequality.Semantic.DeepEqual(dataVolume.Spec, modifiedDataVolume.Spec) {
+			return allowedAdmissionResponse()
+		}
+		return toPatchResponse(dataVolume, modifiedDataVolume)
 	}
 
 	sourceNamespace, sourceName := pvcSource.Namespace, pvcSource.Name
@@ -124,7 +140,6 @@ func (wh *dataVolumeMutatingWebhook) Admit(ar admissionv1beta1.AdmissionReview)
 		return toAdmissionResponseError(err)
 	}
 
-	modifiedDataVolume := dataVolume.DeepCopy()
 	if modifiedDataVolume.Annotations == nil {
 		modifiedDataVolume.Annotations = make(map[string]string)
 	}
@@ -135,3 +150,29 @@ func (wh *dataVolumeMutatingWebhook) Admit(ar admissionv1beta1.AdmissionReview)
 
 	return toPatchResponse(dataVolume, modifiedDataVolume)
 }
+
+// applyStorageProfile fills in the PVC settings the DataVolume omits from the storage profile of its storage class
+func (wh *dataVolumeMutatingWebhook) applyStorageProfile(dataVolume *cdiv1.DataVolume) error {
+	if dataVolume.Spec.PVC == nil {
+		return nil
+	}
+	cdiconfig, err := wh.cdiClient.CdiV1beta1().CDIConfigs().Get(context.TODO(), common.ConfigName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(cdiconfig.Spec.StorageProfiles) == 0 {
+		return nil
+	}
+	storageClass, err := getStorageClass(wh.client, dataVolume.Spec.PVC.StorageClassName)
+	if err != nil || storageClass == nil {
+		return err
+	}
+	profile := controller.GetStorageProfile(cdiconfig.Spec.StorageProfiles, storageClass.Name)
+	if controller.ApplyStorageProfile(dataVolume.Spec.PVC, profile) {
+		klog.V(3).Infof("Applied the storage profile of %s to DataVolume %s/%s", storageClass.Name, dataVolume.Namespace, dataVolume.Name)
+	}
+	return nil
+}
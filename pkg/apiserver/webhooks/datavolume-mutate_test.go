@@ -32,12 +32,15 @@ import (
 	"github.com/appscode/jsonpatch"
 	"k8s.io/api/admission/v1beta1"
 	authorization "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	fakeclient "k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 
 	cdicorev1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	cdiclientfake "kubevirt.io/containerized-data-importer/pkg/client/clientset/versioned/fake"
+	"kubevirt.io/containerized-data-importer/pkg/common"
 	"kubevirt.io/containerized-data-importer/pkg/controller"
 )
 
@@ -129,6 +132,74 @@ var _ = Describe("Mutating DataVolume Webhook", func() {
 			Expect(resp.Patch).To(BeNil())
 		})
 
+		Context("with storage profiles", func() {
+			config := &cdicorev1.CDIConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: common.ConfigName},
+				Spec: cdicorev1.CDIConfigSpec{
+					StorageProfiles: []cdicorev1.StorageProfile{{
+						StorageClass: "local",
+						AccessModes:  []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+						VolumeMode:   volumeModePtr(corev1.PersistentVolumeBlock),
+					}},
+				},
+			}
+
+			createDataVolume := func(dataVolume *cdicorev1.DataVolume) *v1beta1.AdmissionReview {
+				dvBytes, _ := json.Marshal(dataVolume)
+				return &v1beta1.AdmissionReview{
+					Request: &v1beta1.AdmissionRequest{
+						Operation: v1beta1.Create,
+						Resource: metav1.GroupVersionResource{
+							Group:    cdicorev1.SchemeGroupVersion.Group,
+							Version:  cdicorev1.SchemeGroupVersion.Version,
+							Resource: "datavolumes",
+						},
+						Object: runtime.RawExtension{
+							Raw: dvBytes,
+						},
+					},
+				}
+			}
+
+			It("should fill in the settings the DataVolume omits from the profile of the default storage class", func() {
+				dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+				dataVolume.Spec.PVC.AccessModes = nil
+
+				resp := mutateDVsWithConfig(key, createDataVolume(dataVolume), true, config, newStorageClass("local", "local-provisioner", true))
+				Expect(resp.Allowed).To(BeTrue())
+				Expect(resp.Patch).ToNot(BeNil())
+
+				var patchObjs []jsonpatch.Operation
+				err := json.Unmarshal(resp.Patch, &patchObjs)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(patchObjs).Should(ConsistOf(
+					jsonpatch.Operation{Operation: "add", Path: "/spec/pvc/accessModes", Value: []interface{}{string(corev1.ReadWriteMany)}},
+					jsonpatch.Operation{Operation: "add", Path: "/spec/pvc/volumeMode", Value: string(corev1.PersistentVolumeBlock)},
+				))
+			})
+
+			It("should keep the settings the DataVolume sets", func() {
+				dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+				volumeMode := corev1.PersistentVolumeFilesystem
+				dataVolume.Spec.PVC.VolumeMode = &volumeMode
+				storageClassName := "local"
+				dataVolume.Spec.PVC.StorageClassName = &storageClassName
+
+				resp := mutateDVsWithConfig(key, createDataVolume(dataVolume), true, config, newStorageClass("local", "local-provisioner", false))
+				Expect(resp.Allowed).To(BeTrue())
+				Expect(resp.Patch).To(BeNil())
+			})
+
+			It("should leave DataVolumes of storage classes without profile alone", func() {
+				dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+				dataVolume.Spec.PVC.AccessModes = nil
+
+				resp := mutateDVsWithConfig(key, createDataVolume(dataVolume), true, config, newStorageClass("ceph", "ceph-provisioner", true))
+				Expect(resp.Allowed).To(BeTrue())
+				Expect(resp.Patch).To(BeNil())
+			})
+		})
+
 		DescribeTable("should", func(srcNamespace string) {
 			dataVolume := newPVCDataVolume("testDV", srcNamespace, "test")
 			dvBytes, _ := json.Marshal(&dataVolume)
@@ -166,7 +237,11 @@ var _ = Describe("Mutating DataVolume Webhook", func() {
 })
 
 func mutateDVs(key *rsa.PrivateKey, ar *v1beta1.AdmissionReview, isAuthorized bool) *v1beta1.AdmissionResponse {
-	client := fakeclient.NewSimpleClientset()
+	return mutateDVsWithConfig(key, ar, isAuthorized, nil)
+}
+
+func mutateDVsWithConfig(key *rsa.PrivateKey, ar *v1beta1.AdmissionReview, isAuthorized bool, config *cdicorev1.CDIConfig, objects ...runtime.Object) *v1beta1.AdmissionResponse {
+	client := fakeclient.NewSimpleClientset(objects...)
 	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
 		if action.GetResource().Resource != "subjectaccessreviews" {
 			return false, nil, nil
@@ -180,6 +255,10 @@ func mutateDVs(key *rsa.PrivateKey, ar *v1beta1.AdmissionReview, isAuthorized bo
 		}
 		return true, sar, nil
 	})
-	wh := NewDataVolumeMutatingWebhook(client, key)
+	cdiClient := cdiclientfake.NewSimpleClientset()
+	if config != nil {
+		cdiClient = cdiclientfake.NewSimpleClientset(config)
+	}
+	wh := NewDataVolumeMutatingWebhook(client, cdiClient, key)
 	return serve(ar, wh)
 }
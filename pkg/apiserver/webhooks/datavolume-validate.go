@@ -28,6 +28,7 @@ import (
 
 	"k8s.io/api/admission/v1beta1"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kvalidation "k8s.io/apimachinery/pkg/util/validation"
@@ -36,11 +37,14 @@ import (
 	"k8s.io/klog"
 
 	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	cdiclient "kubevirt.io/containerized-data-importer/pkg/client/clientset/versioned"
+	"kubevirt.io/containerized-data-importer/pkg/common"
 	"kubevirt.io/containerized-data-importer/pkg/controller"
 )
 
 type dataVolumeValidatingWebhook struct {
-	client kubernetes.Interface
+	client    kubernetes.Interface
+	cdiClient cdiclient.Interface
 }
 
 func validateSourceURL(sourceURL string) string {
@@ -151,6 +155,47 @@ func (wh *dataVolumeValidatingWebhook) validateDataVolumeSpec(request *v1beta1.A
 		return causes
 	}
 
+	if spec.RetryPolicy != nil {
+		if spec.RetryPolicy.MaxRetries != nil && *spec.RetryPolicy.MaxRetries < 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("MaxRetries can't be less than zero"),
+				Field:   field.Child("retryPolicy", "maxRetries").String(),
+			})
+			return causes
+		}
+		if spec.RetryPolicy.Backoff != nil && spec.RetryPolicy.Backoff.Duration <= 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("Backoff must be greater than zero"),
+				Field:   field.Child("retryPolicy", "backoff").String(),
+			})
+			return causes
+		}
+	}
+
+	if spec.Deadline != nil && spec.Deadline.Duration <= 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Deadline must be greater than zero"),
+			Field:   field.Child("deadline").String(),
+		})
+		return causes
+	}
+
+	if spec.Action != "" && spec.Action != cdiv1.DataVolumeCancel {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Action %q is not supported, the only action is %q", spec.Action, cdiv1.DataVolumeCancel),
+			Field:   field.Child("action").String(),
+		})
+		return causes
+	}
+
+	if causes = validateNodePlacement(field.Child("placement"), spec.Placement); len(causes) > 0 {
+		return causes
+	}
+
 	if spec.Source.Imageio != nil {
 		if spec.Source.Imageio.SecretRef == "" || spec.Source.Imageio.CertConfigMap == "" || spec.Source.Imageio.DiskID == "" {
 			causes = append(causes, metav1.StatusCause{
@@ -184,22 +229,51 @@ func (wh *dataVolumeValidatingWebhook) validateDataVolumeSpec(request *v1beta1.A
 					return causes
 				}
 			}
-			err = controller.ValidateCanCloneSourceAndTargetSpec(&sourcePVC.Spec, spec.PVC)
-			if err != nil {
-				causes = append(causes, metav1.StatusCause{
-					Type:    metav1.CauseTypeFieldValueInvalid,
-					Message: err.Error(),
-					Field:   field.Child("PVC").String(),
-				})
-				return causes
+			targetSpec, targetField := pvcCloneTargetSpec(&sourcePVC.Spec, spec.PVC), field.Child("PVC")
+			if spec.Storage != nil {
+				targetSpec, targetField = storageCloneTargetSpec(&sourcePVC.Spec, spec.Storage), field.Child("storage")
+			}
+			// an omitted size is inferred by the controller with the filesystem overheads
+			if targetSpec != nil && hasCloneTargetSize(spec) {
+				err = wh.validateCloneTargetSize(&sourcePVC.Spec, targetSpec)
+				if err != nil {
+					causes = append(causes, metav1.StatusCause{
+						Type:    metav1.CauseTypeFieldValueInvalid,
+						Message: err.Error(),
+						Field:   targetField.String(),
+					})
+					return causes
+				}
+			}
+			if targetSpec != nil {
+				// the host-assisted clone converts the image of a kubevirt source between volume modes
+				if spec.ContentType == cdiv1.DataVolumeArchive && controller.ValidateSameVolumeMode(&sourcePVC.Spec, targetSpec) != nil {
+					causes = append(causes, metav1.StatusCause{
+						Type:    metav1.CauseTypeFieldValueInvalid,
+						Message: "Archive content can't be cloned between volume modes",
+						Field:   targetField.String(),
+					})
+					return causes
+				}
 			}
 		}
 	}
 
+	if spec.PVC != nil && spec.Storage != nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Data volume PVC and storage can't both be set"),
+			Field:   field.Child("storage").String(),
+		})
+		return causes
+	}
+	if spec.Storage != nil {
+		return validateStorageSpec(field.Child("storage"), spec.Storage, spec.Source.PVC != nil)
+	}
 	if spec.PVC == nil {
 		causes = append(causes, metav1.StatusCause{
 			Type:    metav1.CauseTypeFieldValueInvalid,
-			Message: fmt.Sprintf("Missing Data volume PVC"),
+			Message: fmt.Sprintf("Missing Data volume PVC or storage"),
 			Field:   field.Child("PVC").String(),
 		})
 		return causes
@@ -213,7 +287,8 @@ func (wh *dataVolumeValidatingWebhook) validateDataVolumeSpec(request *v1beta1.A
 			})
 			return causes
 		}
-	} else {
+	} else if spec.Source.PVC == nil {
+		// clones are at least the size of the source PVC
 		causes = append(causes, metav1.StatusCause{
 			Type:    metav1.CauseTypeFieldValueInvalid,
 			Message: fmt.Sprintf("PVC size is missing"),
@@ -251,6 +326,250 @@ func (wh *dataVolumeValidatingWebhook) validateDataVolumeSpec(request *v1beta1.A
 	return causes
 }
 
+// validateStorageSpec rejects a storage spec without size unless it is cloned, or with access modes or a volume mode
+// a DataVolume can't use
+func validateStorageSpec(field *k8sfield.Path, storage *cdiv1.StorageSpec, isClone bool) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if size, ok := storage.Resources.Requests[v1.ResourceStorage]; ok {
+		if size.IsZero() || size.Value() < 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("Storage size can't be equal or less than zero"),
+				Field:   field.Child("resources", "requests", "size").String(),
+			})
+			return causes
+		}
+	} else if !isClone {
+		// clones have the size of the source PVC
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Storage size is missing"),
+			Field:   field.Child("resources", "requests", "size").String(),
+		})
+		return causes
+	}
+	if len(storage.AccessModes) > 1 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Storage multiple accessModes"),
+			Field:   field.Child("accessModes").String(),
+		})
+		return causes
+	}
+	if len(storage.AccessModes) == 1 {
+		if accessMode := storage.AccessModes[0]; accessMode != v1.ReadWriteOnce && accessMode != v1.ReadOnlyMany && accessMode != v1.ReadWriteMany {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("Unsupported value: \"%s\": supported values: \"ReadOnlyMany\", \"ReadWriteMany\", \"ReadWriteOnce\"", string(accessMode)),
+				Field:   field.Child("accessModes").String(),
+			})
+			return causes
+		}
+	}
+	if storage.VolumeMode != nil && *storage.VolumeMode != v1.PersistentVolumeBlock && *storage.VolumeMode != v1.PersistentVolumeFilesystem {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Unsupported value: \"%s\": supported values: \"Block\", \"Filesystem\"", string(*storage.VolumeMode)),
+			Field:   field.Child("volumeMode").String(),
+		})
+	}
+	return causes
+}
+
+// pvcCloneTargetSpec returns the target PVC spec of a clone as far as the webhook can tell, the size it omits is the
+// one of the source PVC
+func pvcCloneTargetSpec(sourceSpec, pvcSpec *v1.PersistentVolumeClaimSpec) *v1.PersistentVolumeClaimSpec {
+	if pvcSpec == nil {
+		return nil
+	}
+	if _, ok := pvcSpec.Resources.Requests[v1.ResourceStorage]; ok {
+		return pvcSpec
+	}
+	targetSpec := pvcSpec.DeepCopy()
+	targetSpec.Resources.Requests = v1.ResourceList{v1.ResourceStorage: sourceSpec.Resources.Requests[v1.ResourceStorage]}
+	return targetSpec
+}
+
+// storageCloneTargetSpec returns the target PVC spec of a clone with a storage spec as far as the webhook can tell,
+// the size and volume mode it omits are the ones of the source PVC
+func storageCloneTargetSpec(sourceSpec *v1.PersistentVolumeClaimSpec, storage *cdiv1.StorageSpec) *v1.PersistentVolumeClaimSpec {
+	targetSpec := &v1.PersistentVolumeClaimSpec{
+		Resources:        *sourceSpec.Resources.DeepCopy(),
+		VolumeMode:       sourceSpec.VolumeMode,
+		StorageClassName: storage.StorageClassName,
+	}
+	if size, ok := storage.Resources.Requests[v1.ResourceStorage]; ok {
+		targetSpec.Resources.Requests = v1.ResourceList{v1.ResourceStorage: size}
+	}
+	if storage.VolumeMode != nil {
+		targetSpec.VolumeMode = storage.VolumeMode
+	}
+	return targetSpec
+}
+
+// hasCloneTargetSize returns true if the DataVolume gives the size of the target of its clone
+func hasCloneTargetSize(spec *cdiv1.DataVolumeSpec) bool {
+	var requests v1.ResourceList
+	switch {
+	case spec.Storage != nil:
+		requests = spec.Storage.Resources.Requests
+	case spec.PVC != nil:
+		requests = spec.PVC.Resources.Requests
+	}
+	_, ok := requests[v1.ResourceStorage]
+	return ok
+}
+
+// validateCloneTargetSize validates the data of the source fits the target with the filesystem overheads of the cdi
+// config, like the clone controllers validate it
+func (wh *dataVolumeValidatingWebhook) validateCloneTargetSize(sourceSpec, targetSpec *v1.PersistentVolumeClaimSpec) error {
+	sourceOverhead, err := wh.filesystemOverhead(sourceSpec)
+	if err != nil {
+		return err
+	}
+	targetOverhead, err := wh.filesystemOverhead(targetSpec)
+	if err != nil {
+		return err
+	}
+	return controller.ValidateCanCloneSourceAndTargetSpec(sourceSpec, targetSpec, sourceOverhead, targetOverhead)
+}
+
+// filesystemOverhead returns the filesystem overhead of a PVC spec from the cdi config, the default overhead without
+// a cdi config
+func (wh *dataVolumeValidatingWebhook) filesystemOverhead(pvcSpec *v1.PersistentVolumeClaimSpec) (cdiv1.Percent, error) {
+	if pvcSpec.VolumeMode != nil && *pvcSpec.VolumeMode == v1.PersistentVolumeBlock {
+		return "0", nil
+	}
+	configSpec := &cdiv1.CDIConfigSpec{}
+	cdiconfig, err := wh.cdiClient.CdiV1beta1().CDIConfigs().Get(context.TODO(), common.ConfigName, metav1.GetOptions{})
+	if err == nil {
+		configSpec = &cdiconfig.Spec
+	} else if !k8serrors.IsNotFound(err) {
+		return "", err
+	}
+	storageClass, err := getStorageClass(wh.client, pvcSpec.StorageClassName)
+	if err != nil {
+		return "", err
+	}
+	storageClassName := ""
+	if storageClass != nil {
+		storageClassName = storageClass.Name
+	} else if pvcSpec.StorageClassName != nil {
+		storageClassName = *pvcSpec.StorageClassName
+	}
+	return controller.FilesystemOverheadForStorageClass(configSpec, storageClassName), nil
+}
+
+// validateCloneStrategy rejects a clone strategy requested by the DataVolume that cannot be used
+func (wh *dataVolumeValidatingWebhook) validateCloneStrategy(request *v1beta1.AdmissionRequest, field *k8sfield.Path, dv *cdiv1.DataVolume) ([]metav1.StatusCause, error) {
+	var causes []metav1.StatusCause
+	requested, ok := dv.Annotations[controller.AnnCloneStrategy]
+	if !ok || requested == controller.CloneStrategyAuto {
+		return causes, nil
+	}
+	strategy := cdiv1.CloneStrategy(requested)
+	switch strategy {
+	case cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategySnapshot, cdiv1.CloneStrategyHostAssisted:
+	default:
+		causes = append(causes, metav1.StatusCause{
+			Type: metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Unsupported value: \"%s\": supported values: \"%s\", \"%s\", \"%s\", \"%s\"", requested,
+				controller.CloneStrategyAuto, cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategySnapshot, cdiv1.CloneStrategyHostAssisted),
+			Field: field.String(),
+		})
+		return causes, nil
+	}
+	if dv.Spec.Source.PVC == nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Clone strategy %s requires a PVC source", strategy),
+			Field:   field.String(),
+		})
+		return causes, nil
+	}
+	if strategy == cdiv1.CloneStrategyHostAssisted || request.Operation != v1beta1.Create {
+		return causes, nil
+	}
+	if strategy == cdiv1.CloneStrategyCsiClone && dv.Spec.Source.PVC.Namespace != dv.Namespace {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Clone strategy %s requires the source PVC in the DataVolume namespace", strategy),
+			Field:   field.String(),
+		})
+		return causes, nil
+	}
+
+	sourcePVC, err := wh.client.CoreV1().PersistentVolumeClaims(dv.Spec.Source.PVC.Namespace).Get(context.TODO(), dv.Spec.Source.PVC.Name, metav1.GetOptions{})
+	if err != nil {
+		return causes, err
+	}
+	var sourceStorageClass *storagev1.StorageClass
+	if sourcePVC.Spec.StorageClassName != nil {
+		if sourceStorageClass, err = getStorageClass(wh.client, sourcePVC.Spec.StorageClassName); err != nil {
+			return causes, err
+		}
+	}
+	targetStorageClass, err := getStorageClass(wh.client, controller.GetDataVolumeStorageClassName(dv))
+	if err != nil {
+		return causes, err
+	}
+	if sourceStorageClass == nil || targetStorageClass == nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Clone strategy %s requires a storage class for the source and target PVCs", strategy),
+			Field:   field.String(),
+		})
+		return causes, nil
+	}
+	if strategy == cdiv1.CloneStrategyCsiClone && sourceStorageClass.Name != targetStorageClass.Name {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Clone strategy %s requires the source and target PVCs in the same storage class", strategy),
+			Field:   field.String(),
+		})
+	}
+	if strategy == cdiv1.CloneStrategySnapshot && sourceStorageClass.Provisioner != targetStorageClass.Provisioner {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Clone strategy %s requires source and target storage classes with the same provisioner", strategy),
+			Field:   field.String(),
+		})
+	}
+	targetSpec := pvcCloneTargetSpec(&sourcePVC.Spec, dv.Spec.PVC)
+	if dv.Spec.Storage != nil {
+		targetSpec = storageCloneTargetSpec(&sourcePVC.Spec, dv.Spec.Storage)
+	}
+	if targetSpec != nil && controller.ValidateSameVolumeMode(&sourcePVC.Spec, targetSpec) != nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: fmt.Sprintf("Clone strategy %s requires source and target PVCs with the same volume mode", strategy),
+			Field:   field.String(),
+		})
+	}
+	return causes, nil
+}
+
+// getStorageClass returns the storage class by name or the default storage class if name is nil, nil if not found
+func getStorageClass(client kubernetes.Interface, name *string) (*storagev1.StorageClass, error) {
+	if name != nil {
+		storageClass, err := client.StorageV1().StorageClasses().Get(context.TODO(), *name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return storageClass, err
+	}
+	storageClasses, err := client.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range storageClasses.Items {
+		if storageClasses.Items[i].Annotations[controller.AnnDefaultStorageClass] == "true" {
+			return &storageClasses.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
 func (wh *dataVolumeValidatingWebhook) Admit(ar v1beta1.AdmissionReview) *v1beta1.AdmissionResponse {
 	if err := validateDataVolumeResource(ar); err != nil {
 		return toAdmissionResponseError(err)
@@ -271,7 +590,21 @@ func (wh *dataVolumeValidatingWebhook) Admit(ar v1beta1.AdmissionReview) *v1beta
 			return toAdmissionResponseError(err)
 		}
 
-		if !reflect.DeepEqual(dv.Spec, oldDV.Spec) {
+		if oldDV.Spec.Action == cdiv1.DataVolumeCancel && dv.Spec.Action != cdiv1.DataVolumeCancel {
+			klog.Errorf("Cannot resume cancelled DataVolume %s/%s", dv.GetNamespace(), dv.GetName())
+			var causes []metav1.StatusCause
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: "Cannot resume a cancelled DataVolume",
+				Field:   k8sfield.NewPath("spec", "action").String(),
+			})
+			return toRejectedAdmissionResponse(causes)
+		}
+
+		// the action is the only field of the spec that may change, cancelling the DataVolume
+		oldSpec := oldDV.Spec.DeepCopy()
+		oldSpec.Action = dv.Spec.Action
+		if !reflect.DeepEqual(dv.Spec, *oldSpec) {
 			klog.Errorf("Cannot update spec for DataVolume %s/%s", dv.GetNamespace(), dv.GetName())
 			var causes []metav1.StatusCause
 			causes = append(causes, metav1.StatusCause{
@@ -318,6 +651,15 @@ func (wh *dataVolumeValidatingWebhook) Admit(ar v1beta1.AdmissionReview) *v1beta
 		return toRejectedAdmissionResponse(causes)
 	}
 
+	causes, err = wh.validateCloneStrategy(ar.Request, k8sfield.NewPath("metadata", "annotations").Key(controller.AnnCloneStrategy), &dv)
+	if err != nil {
+		return toAdmissionResponseError(err)
+	}
+	if len(causes) > 0 {
+		klog.Infof("rejected DataVolume admission")
+		return toRejectedAdmissionResponse(causes)
+	}
+
 	reviewResponse := v1beta1.AdmissionResponse{}
 	reviewResponse.Allowed = true
 	return &reviewResponse
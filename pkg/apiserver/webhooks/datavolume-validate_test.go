@@ -25,19 +25,25 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
 	"k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	k8sv1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	fakeclient "k8s.io/client-go/kubernetes/fake"
 
 	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	cdiclientfake "kubevirt.io/containerized-data-importer/pkg/client/clientset/versioned/fake"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/controller"
 )
 
 var _ = Describe("Validating Webhook", func() {
@@ -149,6 +155,63 @@ var _ = Describe("Validating Webhook", func() {
 			Expect(resp.Allowed).To(Equal(false))
 		})
 
+		DescribeTable("should validate DataVolume with a storage spec", func(size string, accessModes []corev1.PersistentVolumeAccessMode, volumeMode corev1.PersistentVolumeMode, allowed bool) {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Spec.PVC = nil
+			dataVolume.Spec.Storage = &cdiv1.StorageSpec{AccessModes: accessModes}
+			if size != "" {
+				dataVolume.Spec.Storage.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}
+			}
+			if volumeMode != "" {
+				dataVolume.Spec.Storage.VolumeMode = &volumeMode
+			}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(allowed))
+		},
+			Entry("with only the size", "1Gi", nil, corev1.PersistentVolumeMode(""), true),
+			Entry("with an access mode and volume mode", "1Gi", []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, corev1.PersistentVolumeBlock, true),
+			Entry("without size", "", nil, corev1.PersistentVolumeMode(""), false),
+			Entry("with size 0", "0", nil, corev1.PersistentVolumeMode(""), false),
+			Entry("with multiple access modes", "1Gi", []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce, corev1.ReadWriteMany}, corev1.PersistentVolumeMode(""), false),
+			Entry("with an invalid access mode", "1Gi", []corev1.PersistentVolumeAccessMode{"ReadWriteSometimes"}, corev1.PersistentVolumeMode(""), false),
+			Entry("with an invalid volume mode", "1Gi", nil, corev1.PersistentVolumeMode("Object"), false),
+		)
+
+		It("should reject DataVolume with both a PVC and a storage spec", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Spec.Storage = &cdiv1.StorageSpec{}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should accept a clone DataVolume with a storage spec without size", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			pvc := newCloneSourcePVC(dataVolume, nil)
+			dataVolume.Spec.PVC = nil
+			dataVolume.Spec.Storage = &cdiv1.StorageSpec{}
+			resp := validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(true))
+
+			By("Rejecting a size smaller than the source")
+			dataVolume.Spec.Storage.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1")}
+			resp = validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should accept a clone DataVolume with a PVC spec without size", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			pvc := newCloneSourcePVC(dataVolume, nil)
+			dataVolume.Spec.PVC.Resources.Requests = nil
+			resp := validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(true))
+
+			By("Rejecting a PVC spec without size unless cloning")
+			dataVolume = newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Spec.PVC.Resources.Requests = nil
+			resp = validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
 		It("should accept DataVolume with Blank source and no content type", func() {
 			dataVolume := newBlankDataVolume("blank")
 			resp := validateDataVolumeCreate(dataVolume)
@@ -188,6 +251,214 @@ var _ = Describe("Validating Webhook", func() {
 
 		})
 
+		It("should accept DataVolume with a retry policy", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			maxRetries := int32(3)
+			dataVolume.Spec.RetryPolicy = &cdiv1.DataVolumeRetryPolicy{MaxRetries: &maxRetries, Backoff: &metav1.Duration{Duration: time.Minute}}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
+		It("should reject DataVolume with a negative retry limit", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			maxRetries := int32(-1)
+			dataVolume.Spec.RetryPolicy = &cdiv1.DataVolumeRetryPolicy{MaxRetries: &maxRetries}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should reject DataVolume with a zero retry backoff", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Spec.RetryPolicy = &cdiv1.DataVolumeRetryPolicy{Backoff: &metav1.Duration{}}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should accept DataVolume with a deadline", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Spec.Deadline = &metav1.Duration{Duration: time.Hour}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
+		It("should reject DataVolume with a negative deadline", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Spec.Deadline = &metav1.Duration{Duration: -time.Hour}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should accept DataVolume with a placement", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Spec.Placement = &cdiv1.NodePlacement{
+				NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+				Tolerations: []corev1.Toleration{
+					{Key: "storage", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				},
+				PriorityClassName: "cdi-workloads",
+			}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
+		It("should reject DataVolume with an invalid placement toleration", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Spec.Placement = &cdiv1.NodePlacement{
+				Tolerations: []corev1.Toleration{
+					{Key: "storage", Operator: corev1.TolerationOpExists, Value: "fast"},
+				},
+			}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+			Expect(resp.Result.Details.Causes[0].Field).To(Equal("spec.placement.tolerations[0]"))
+		})
+
+		It("should reject DataVolume with an unknown clone strategy", func() {
+			dataVolume := newPVCDataVolume("testDV", k8sv1.NamespaceDefault, "test")
+			dataVolume.Annotations = map[string]string{controller.AnnCloneStrategy: "fastest"}
+			resp := validateDataVolumeCreate(dataVolume, newCloneSourcePVC(dataVolume, nil))
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should reject DataVolume with a clone strategy and no PVC source", func() {
+			dataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			dataVolume.Annotations = map[string]string{controller.AnnCloneStrategy: string(cdiv1.CloneStrategySnapshot)}
+			resp := validateDataVolumeCreate(dataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should accept DataVolume with the host-assisted clone strategy", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			dataVolume.Annotations = map[string]string{controller.AnnCloneStrategy: string(cdiv1.CloneStrategyHostAssisted)}
+			resp := validateDataVolumeCreate(dataVolume, newCloneSourcePVC(dataVolume, nil))
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
+		It("should reject DataVolume with the csi-clone strategy across namespaces", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			dataVolume.Annotations = map[string]string{controller.AnnCloneStrategy: string(cdiv1.CloneStrategyCsiClone)}
+			resp := validateDataVolumeCreate(dataVolume, newCloneSourcePVC(dataVolume, nil))
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should reject DataVolume with the csi-clone strategy and different storage classes", func() {
+			dataVolume := newPVCDataVolume("testDV", k8sv1.NamespaceDefault, "test")
+			dataVolume.Annotations = map[string]string{controller.AnnCloneStrategy: string(cdiv1.CloneStrategyCsiClone)}
+			sourceSc := newStorageClass("source", "csi-plugin", false)
+			targetSc := newStorageClass("target", "csi-plugin", true)
+			resp := validateDataVolumeCreate(dataVolume, newCloneSourcePVC(dataVolume, &sourceSc.Name), sourceSc, targetSc)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should reject DataVolume with the snapshot strategy and different provisioners", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			dataVolume.Annotations = map[string]string{controller.AnnCloneStrategy: string(cdiv1.CloneStrategySnapshot)}
+			sourceSc := newStorageClass("source", "csi-plugin", false)
+			targetSc := newStorageClass("target", "other-plugin", false)
+			dataVolume.Spec.PVC.StorageClassName = &targetSc.Name
+			resp := validateDataVolumeCreate(dataVolume, newCloneSourcePVC(dataVolume, &sourceSc.Name), sourceSc, targetSc)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should accept DataVolume with the snapshot strategy and the same provisioner", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			dataVolume.Annotations = map[string]string{controller.AnnCloneStrategy: string(cdiv1.CloneStrategySnapshot)}
+			sourceSc := newStorageClass("source", "csi-plugin", false)
+			targetSc := newStorageClass("target", "csi-plugin", true)
+			resp := validateDataVolumeCreate(dataVolume, newCloneSourcePVC(dataVolume, &sourceSc.Name), sourceSc, targetSc)
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
+		It("should accept a clone DataVolume between volume modes", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			pvc := newCloneSourcePVC(dataVolume, nil)
+			blockMode := corev1.PersistentVolumeBlock
+			dataVolume.Spec.PVC.VolumeMode = &blockMode
+			resp := validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(true))
+
+			By("Rejecting archive content")
+			dataVolume.Spec.ContentType = cdiv1.DataVolumeArchive
+			resp = validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should reject a clone DataVolume into a filesystem target smaller than the source with the overhead", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			dataVolume.Spec.PVC = newPVCSpec(10*1024*1024*1024, resource.BinarySI)
+			pvc := newCloneSourcePVC(dataVolume, nil)
+			blockMode := corev1.PersistentVolumeBlock
+			pvc.Spec.VolumeMode = &blockMode
+			resp := validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(false))
+			Expect(resp.Result.Details.Causes[0].Message).To(ContainSubstring("filesystem overhead"))
+
+			By("Accepting a target the data fits in with the default overhead")
+			dataVolume.Spec.PVC.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("10.6Gi")
+			resp = validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(true))
+
+			By("Accepting an omitted size, the controller infers it")
+			dataVolume.Spec.PVC.Resources.Requests = nil
+			resp = validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
+		DescribeTable("should validate the size of a clone DataVolume between volume modes", func(sourceMode, targetMode corev1.PersistentVolumeMode, targetSize string, allowed bool) {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			dataVolume.Spec.PVC = newPVCSpec(10*1024*1024*1024, resource.BinarySI)
+			pvc := newCloneSourcePVC(dataVolume, nil)
+			pvc.Spec.VolumeMode = &sourceMode
+			dataVolume.Spec.PVC.VolumeMode = &targetMode
+			dataVolume.Spec.PVC.Resources.Requests[corev1.ResourceStorage] = resource.MustParse(targetSize)
+			resp := validateDataVolumeCreate(dataVolume, pvc)
+			Expect(resp.Allowed).To(Equal(allowed))
+		},
+			Entry("reject block to filesystem of the size of the source", corev1.PersistentVolumeBlock, corev1.PersistentVolumeFilesystem, "10Gi", false),
+			Entry("accept block to filesystem with room for the overhead", corev1.PersistentVolumeBlock, corev1.PersistentVolumeFilesystem, "10.6Gi", true),
+			Entry("reject filesystem to block smaller than the source", corev1.PersistentVolumeFilesystem, corev1.PersistentVolumeBlock, "9.5Gi", false),
+			Entry("accept filesystem to block of the size of the source", corev1.PersistentVolumeFilesystem, corev1.PersistentVolumeBlock, "10Gi", true),
+		)
+
+		It("should reject a filesystem clone DataVolume into a storage class with a larger overhead", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			sourceSc := newStorageClass("source", "csi-plugin", false)
+			targetSc := newStorageClass("target", "csi-plugin", true)
+			pvc := newCloneSourcePVC(dataVolume, &sourceSc.Name)
+			config := &cdiv1.CDIConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: common.ConfigName},
+				Spec: cdiv1.CDIConfigSpec{
+					FilesystemOverhead: &cdiv1.FilesystemOverhead{
+						StorageClass: map[string]cdiv1.Percent{"source": "0.05", "target": "0.2"},
+					},
+				},
+			}
+			resp := validateDataVolumeCreate(dataVolume, pvc, sourceSc, targetSc, config)
+			Expect(resp.Allowed).To(Equal(false))
+
+			By("Accepting the same overhead")
+			config.Spec.FilesystemOverhead.StorageClass["target"] = "0.05"
+			resp = validateDataVolumeCreate(dataVolume, pvc, sourceSc, targetSc, config)
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
+		It("should reject DataVolume with the snapshot strategy between volume modes", func() {
+			dataVolume := newPVCDataVolume("testDV", "testNamespace", "test")
+			dataVolume.Annotations = map[string]string{controller.AnnCloneStrategy: string(cdiv1.CloneStrategySnapshot)}
+			sourceSc := newStorageClass("source", "csi-plugin", false)
+			targetSc := newStorageClass("target", "csi-plugin", true)
+			pvc := newCloneSourcePVC(dataVolume, &sourceSc.Name)
+			blockMode := corev1.PersistentVolumeBlock
+			dataVolume.Spec.PVC.VolumeMode = &blockMode
+			resp := validateDataVolumeCreate(dataVolume, pvc, sourceSc, targetSc)
+			Expect(resp.Allowed).To(Equal(false))
+
+			By("Accepting the host-assisted strategy")
+			dataVolume.Annotations[controller.AnnCloneStrategy] = string(cdiv1.CloneStrategyHostAssisted)
+			resp = validateDataVolumeCreate(dataVolume, pvc, sourceSc, targetSc)
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
 		It("should reject invalid DataVolume spec update", func() {
 			newDataVolume := newPVCDataVolume("testDV", "newNamespace", "testName")
 			newBytes, _ := json.Marshal(&newDataVolume)
@@ -245,9 +516,68 @@ var _ = Describe("Validating Webhook", func() {
 			resp := validateAdmissionReview(ar)
 			Expect(resp.Allowed).To(Equal(true))
 		})
+
+		It("should accept cancelling a DataVolume", func() {
+			oldDataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			newDataVolume := oldDataVolume.DeepCopy()
+			newDataVolume.Spec.Action = cdiv1.DataVolumeCancel
+			resp := validateDataVolumeUpdate(newDataVolume, oldDataVolume)
+			Expect(resp.Allowed).To(Equal(true))
+		})
+
+		It("should reject pausing a DataVolume", func() {
+			oldDataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			newDataVolume := oldDataVolume.DeepCopy()
+			newDataVolume.Spec.Action = "Pause"
+			resp := validateDataVolumeUpdate(newDataVolume, oldDataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should reject resuming a cancelled DataVolume", func() {
+			oldDataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			oldDataVolume.Spec.Action = cdiv1.DataVolumeCancel
+			newDataVolume := oldDataVolume.DeepCopy()
+			newDataVolume.Spec.Action = ""
+			resp := validateDataVolumeUpdate(newDataVolume, oldDataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
+
+		It("should reject a spec update with the action", func() {
+			oldDataVolume := newHTTPDataVolume("testDV", "http://www.example.com")
+			newDataVolume := oldDataVolume.DeepCopy()
+			newDataVolume.Spec.Action = cdiv1.DataVolumeCancel
+			newDataVolume.Spec.Source.HTTP.URL = "http://www.example.com/other"
+			resp := validateDataVolumeUpdate(newDataVolume, oldDataVolume)
+			Expect(resp.Allowed).To(Equal(false))
+		})
 	})
 })
 
+func newCloneSourcePVC(dv *cdiv1.DataVolume, storageClassName *string) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dv.Spec.Source.PVC.Name,
+			Namespace: dv.Spec.Source.PVC.Namespace,
+		},
+		Spec: *dv.Spec.PVC.DeepCopy(),
+	}
+	pvc.Spec.StorageClassName = storageClassName
+	return pvc
+}
+
+func newStorageClass(name, provisioner string, isDefault bool) *storagev1.StorageClass {
+	sc := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Provisioner: provisioner,
+	}
+	if isDefault {
+		sc.Annotations = map[string]string{controller.AnnDefaultStorageClass: "true"}
+	}
+	return sc
+}
+
 func newHTTPDataVolume(name, url string) *cdiv1.DataVolume {
 	httpSource := cdiv1.DataVolumeSource{
 		HTTP: &cdiv1.DataVolumeSourceHTTP{URL: url},
@@ -351,8 +681,7 @@ func newPVCSpec(sizeValue int64, sizeFormat resource.Format) *corev1.PersistentV
 }
 
 func validateDataVolumeCreate(dv *cdiv1.DataVolume, objects ...runtime.Object) *v1beta1.AdmissionResponse {
-	client := fakeclient.NewSimpleClientset(objects...)
-	wh := NewDataVolumeValidatingWebhook(client)
+	wh := newDataVolumeValidatingWebhook(objects...)
 
 	dvBytes, _ := json.Marshal(dv)
 	ar := &v1beta1.AdmissionReview{
@@ -372,12 +701,47 @@ func validateDataVolumeCreate(dv *cdiv1.DataVolume, objects ...runtime.Object) *
 	return serve(ar, wh)
 }
 
+func validateDataVolumeUpdate(dv, oldDV *cdiv1.DataVolume) *v1beta1.AdmissionResponse {
+	newBytes, _ := json.Marshal(dv)
+	oldBytes, _ := json.Marshal(oldDV)
+	ar := &v1beta1.AdmissionReview{
+		Request: &v1beta1.AdmissionRequest{
+			Operation: v1beta1.Update,
+			Resource: metav1.GroupVersionResource{
+				Group:    cdiv1.SchemeGroupVersion.Group,
+				Version:  cdiv1.SchemeGroupVersion.Version,
+				Resource: "datavolumes",
+			},
+			Object: runtime.RawExtension{
+				Raw: newBytes,
+			},
+			OldObject: runtime.RawExtension{
+				Raw: oldBytes,
+			},
+		},
+	}
+	return validateAdmissionReview(ar)
+}
+
 func validateAdmissionReview(ar *v1beta1.AdmissionReview, objects ...runtime.Object) *v1beta1.AdmissionResponse {
-	client := fakeclient.NewSimpleClientset(objects...)
-	wh := NewDataVolumeValidatingWebhook(client)
+	wh := newDataVolumeValidatingWebhook(objects...)
 	return serve(ar, wh)
 }
 
+// newDataVolumeValidatingWebhook creates the webhook with fake clients, the CDIConfig objects are served by the cdi
+// client
+func newDataVolumeValidatingWebhook(objects ...runtime.Object) http.Handler {
+	var objs, cdiObjs []runtime.Object
+	for _, obj := range objects {
+		if _, ok := obj.(*cdiv1.CDIConfig); ok {
+			cdiObjs = append(cdiObjs, obj)
+		} else {
+			objs = append(objs, obj)
+		}
+	}
+	return NewDataVolumeValidatingWebhook(fakeclient.NewSimpleClientset(objs...), cdiclientfake.NewSimpleClientset(cdiObjs...))
+}
+
 func serve(ar *v1beta1.AdmissionReview, handler http.Handler) *v1beta1.AdmissionResponse {
 	reqBytes, _ := json.Marshal(ar)
 	req, err := http.NewRequest("POST", "/foobar", bytes.NewReader(reqBytes))
@@ -52,14 +52,14 @@ type admissionHandler struct {
 }
 
 // NewDataVolumeValidatingWebhook creates a new DataVolumeValidation webhook
-func NewDataVolumeValidatingWebhook(client kubernetes.Interface) http.Handler {
-	return newAdmissionHandler(&dataVolumeValidatingWebhook{client: client})
+func NewDataVolumeValidatingWebhook(client kubernetes.Interface, cdiClient cdiclient.Interface) http.Handler {
+	return newAdmissionHandler(&dataVolumeValidatingWebhook{client: client, cdiClient: cdiClient})
 }
 
 // NewDataVolumeMutatingWebhook creates a new DataVolumeMutation webhook
-func NewDataVolumeMutatingWebhook(client kubernetes.Interface, key *rsa.PrivateKey) http.Handler {
+func NewDataVolumeMutatingWebhook(client kubernetes.Interface, cdiClient cdiclient.Interface, key *rsa.PrivateKey) http.Handler {
 	generator := newCloneTokenGenerator(key)
-	return newAdmissionHandler(&dataVolumeMutatingWebhook{client: client, tokenGenerator: generator})
+	return newAdmissionHandler(&dataVolumeMutatingWebhook{client: client, cdiClient: cdiClient, tokenGenerator: generator})
 }
 
 // NewCDIValidatingWebhook creates a new CDI validating webhook
@@ -67,6 +67,11 @@ func NewCDIValidatingWebhook(client cdiclient.Interface) http.Handler {
 	return newAdmissionHandler(&cdiValidatingWebhook{client: client})
 }
 
+// NewCDIConfigValidatingWebhook creates a new CDIConfig validating webhook
+func NewCDIConfigValidatingWebhook() http.Handler {
+	return newAdmissionHandler(&cdiConfigValidatingWebhook{})
+}
+
 func newCloneTokenGenerator(key *rsa.PrivateKey) token.Generator {
 	return token.NewGenerator(common.CloneTokenIssuer, key, 5*time.Minute)
 }
@@ -0,0 +1,141 @@
+/*
+ * This file is part of the CDI project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2020 Red Hat, Inc.
+ *
+ */
+
+package webhooks
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kvalidation "k8s.io/apimachinery/pkg/util/validation"
+	k8sfield "k8s.io/apimachinery/pkg/util/validation/field"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+// validateNodePlacement validates the placement of the worker pods, of a DataVolume or of the cdi config workloads
+func validateNodePlacement(field *k8sfield.Path, placement *cdiv1.NodePlacement) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if placement == nil {
+		return causes
+	}
+	invalid := func(field *k8sfield.Path, msgs []string) {
+		if len(msgs) > 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fmt.Sprintf("%s is invalid: %s", field.String(), strings.Join(msgs, "; ")),
+				Field:   field.String(),
+			})
+		}
+	}
+
+	for key, value := range placement.NodeSelector {
+		invalid(field.Child("nodeSelector").Key(key), kvalidation.IsQualifiedName(key))
+		invalid(field.Child("nodeSelector").Key(key), kvalidation.IsValidLabelValue(value))
+	}
+
+	for i, toleration := range placement.Tolerations {
+		invalid(field.Child("tolerations").Index(i), validateToleration(&toleration))
+	}
+
+	if placement.PriorityClassName != "" {
+		invalid(field.Child("priorityClassName"), kvalidation.IsDNS1123Subdomain(placement.PriorityClassName))
+	}
+
+	if placement.Affinity != nil && placement.Affinity.NodeAffinity != nil {
+		nodeAffinity := placement.Affinity.NodeAffinity
+		affinityField := field.Child("affinity", "nodeAffinity")
+		if required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			requiredField := affinityField.Child("requiredDuringSchedulingIgnoredDuringExecution")
+			if len(required.NodeSelectorTerms) == 0 {
+				invalid(requiredField.Child("nodeSelectorTerms"), []string{"must have at least one node selector term"})
+			}
+			for i, term := range required.NodeSelectorTerms {
+				termField := requiredField.Child("nodeSelectorTerms").Index(i)
+				for j, requirement := range term.MatchExpressions {
+					invalid(termField.Child("matchExpressions").Index(j), validateNodeSelectorRequirement(&requirement))
+				}
+			}
+		}
+		for i, term := range nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			termField := affinityField.Child("preferredDuringSchedulingIgnoredDuringExecution").Index(i)
+			if term.Weight < 1 || term.Weight > 100 {
+				invalid(termField.Child("weight"), []string{kvalidation.InclusiveRangeError(1, 100)})
+			}
+			for j, requirement := range term.Preference.MatchExpressions {
+				invalid(termField.Child("preference", "matchExpressions").Index(j), validateNodeSelectorRequirement(&requirement))
+			}
+		}
+	}
+	return causes
+}
+
+func validateToleration(toleration *v1.Toleration) []string {
+	var msgs []string
+	if toleration.Key != "" {
+		msgs = append(msgs, kvalidation.IsQualifiedName(toleration.Key)...)
+	}
+	switch toleration.Operator {
+	case v1.TolerationOpEqual, "":
+		if toleration.Key == "" {
+			msgs = append(msgs, "operator must be Exists when key is empty")
+		}
+		if toleration.Value != "" {
+			msgs = append(msgs, kvalidation.IsValidLabelValue(toleration.Value)...)
+		}
+	case v1.TolerationOpExists:
+		if toleration.Value != "" {
+			msgs = append(msgs, "value must be empty when operator is Exists")
+		}
+	default:
+		msgs = append(msgs, fmt.Sprintf("unsupported operator \"%s\": supported values: \"%s\", \"%s\"", toleration.Operator, v1.TolerationOpEqual, v1.TolerationOpExists))
+	}
+	switch toleration.Effect {
+	case v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute, "":
+	default:
+		msgs = append(msgs, fmt.Sprintf("unsupported effect \"%s\": supported values: \"%s\", \"%s\", \"%s\"", toleration.Effect, v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute))
+	}
+	if toleration.TolerationSeconds != nil && toleration.Effect != v1.TaintEffectNoExecute {
+		msgs = append(msgs, "tolerationSeconds requires effect NoExecute")
+	}
+	return msgs
+}
+
+func validateNodeSelectorRequirement(requirement *v1.NodeSelectorRequirement) []string {
+	msgs := kvalidation.IsQualifiedName(requirement.Key)
+	switch requirement.Operator {
+	case v1.NodeSelectorOpIn, v1.NodeSelectorOpNotIn:
+		if len(requirement.Values) == 0 {
+			msgs = append(msgs, fmt.Sprintf("values must be non-empty when operator is %s", requirement.Operator))
+		}
+	case v1.NodeSelectorOpExists, v1.NodeSelectorOpDoesNotExist:
+		if len(requirement.Values) > 0 {
+			msgs = append(msgs, fmt.Sprintf("values must be empty when operator is %s", requirement.Operator))
+		}
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if len(requirement.Values) != 1 {
+			msgs = append(msgs, fmt.Sprintf("values must have a single element when operator is %s", requirement.Operator))
+		}
+	default:
+		msgs = append(msgs, fmt.Sprintf("unsupported operator \"%s\"", requirement.Operator))
+	}
+	return msgs
+}
@@ -28,6 +28,7 @@ type CdiV1beta1Interface interface {
 	RESTClient() rest.Interface
 	CDIsGetter
 	CDIConfigsGetter
+	DataImportCronsGetter
 	DataVolumesGetter
 }
 
@@ -44,6 +45,10 @@ func (c *CdiV1beta1Client) CDIConfigs() CDIConfigInterface {
 	return newCDIConfigs(c)
 }
 
+func (c *CdiV1beta1Client) DataImportCrons(namespace string) DataImportCronInterface {
+	return newDataImportCrons(c, namespace)
+}
+
 func (c *CdiV1beta1Client) DataVolumes(namespace string) DataVolumeInterface {
 	return newDataVolumes(c, namespace)
 }
@@ -22,4 +22,6 @@ type CDIExpansion interface{}
 
 type CDIConfigExpansion interface{}
 
+type DataImportCronExpansion interface{}
+
 type DataVolumeExpansion interface{}
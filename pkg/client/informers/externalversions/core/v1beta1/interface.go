@@ -28,6 +28,8 @@ type Interface interface {
 	CDIs() CDIInformer
 	// CDIConfigs returns a CDIConfigInformer.
 	CDIConfigs() CDIConfigInformer
+	// DataImportCrons returns a DataImportCronInformer.
+	DataImportCrons() DataImportCronInformer
 	// DataVolumes returns a DataVolumeInformer.
 	DataVolumes() DataVolumeInformer
 }
@@ -53,6 +55,11 @@ func (v *version) CDIConfigs() CDIConfigInformer {
 	return &cDIConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// DataImportCrons returns a DataImportCronInformer.
+func (v *version) DataImportCrons() DataImportCronInformer {
+	return &dataImportCronInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // DataVolumes returns a DataVolumeInformer.
 func (v *version) DataVolumes() DataVolumeInformer {
 	return &dataVolumeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
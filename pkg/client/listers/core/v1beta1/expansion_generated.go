@@ -26,6 +26,14 @@ type CDIListerExpansion interface{}
 // CDIConfigLister.
 type CDIConfigListerExpansion interface{}
 
+// DataImportCronListerExpansion allows custom methods to be added to
+// DataImportCronLister.
+type DataImportCronListerExpansion interface{}
+
+// DataImportCronNamespaceListerExpansion allows custom methods to be added to
+// DataImportCronNamespaceLister.
+type DataImportCronNamespaceListerExpansion interface{}
+
 // DataVolumeListerExpansion allows custom methods to be added to
 // DataVolumeLister.
 type DataVolumeListerExpansion interface{}
@@ -24,6 +24,16 @@ const (
 	PrometheusLabel = "prometheus.cdi.kubevirt.io"
 	// PrometheusServiceName is the name of the prometheus service created by the operator.
 	PrometheusServiceName = "cdi-prometheus-metrics"
+	// ProgressLabel provides the label to indicate the pod receives the progress reports of the worker pods.
+	ProgressLabel = "progress.cdi.kubevirt.io"
+	// ProgressServiceName is the name of the service receiving the progress reports of the worker pods, created by the operator.
+	ProgressServiceName = "cdi-progress"
+	// ProgressPath is the path the worker pods post their progress reports to.
+	ProgressPath = "/v1beta1/progress"
+	// ProgressPort is the port of the controller receiving the progress reports of the worker pods.
+	ProgressPort = 8444
+	// ProgressURL provides a constant to capture our env variable "PROGRESS_URL", where the worker pods post their progress reports
+	ProgressURL = "PROGRESS_URL"
 
 	// ImporterVolumePath provides a constant for the directory where the PV is mounted.
 	ImporterVolumePath = "/data"
@@ -68,6 +78,10 @@ const (
 	InsecureTLSVar = "INSECURE_TLS"
 	// ImporterDiskID provides a constant to capture our env variable "IMPORTER_DISK_ID"
 	ImporterDiskID = "IMPORTER_DISK_ID"
+	// ImporterChecksum provides a constant to capture our env variable "IMPORTER_CHECKSUM"
+	ImporterChecksum = "IMPORTER_CHECKSUM"
+	// ImporterPollDigest provides a constant to capture our env variable "IMPORTER_POLL_DIGEST", the importer only polls the digest of the source
+	ImporterPollDigest = "IMPORTER_POLL_DIGEST"
 
 	// CloningLabelValue provides a constant to use as a label value for pod affinity (controller pkg only)
 	CloningLabelValue = "host-assisted-cloning"
@@ -79,6 +93,10 @@ const (
 	ClonerMountPath = "/var/run/cdi/clone/source"
 	// ClonerSourcePodNameSuffix (controller pkg only)
 	ClonerSourcePodNameSuffix = "-source-pod"
+	// ClonerCompression provides a constant to capture our env variable "CLONER_COMPRESSION"
+	ClonerCompression = "CLONER_COMPRESSION"
+	// ClonerTargetVolumeMode provides a constant to capture our env variable "TARGET_VOLUME_MODE"
+	ClonerTargetVolumeMode = "TARGET_VOLUME_MODE"
 
 	// KubeVirtAnnKey is part of a kubevirt.io key.
 	KubeVirtAnnKey = "kubevirt.io/"
@@ -87,6 +105,12 @@ const (
 
 	// SmartClonerCDILabel is the label applied to resources created by the smart-clone controller
 	SmartClonerCDILabel = "cdi-smart-clone"
+	// ImportCacheCDILabel is the label applied to the import cache PVCs
+	ImportCacheCDILabel = "cdi-import-cache"
+	// SmartCloneExpanderPodName is the name of the pod container resizing the image of an expanded smart clone
+	SmartCloneExpanderPodName = "cdi-smart-clone-expander"
+	// SourceDigestPollerPodName is the name of the pod container polling the source digest of a DataImportCron
+	SourceDigestPollerPodName = "cdi-source-digest-poller"
 
 	// UploadServerCDILabel is the label applied to upload server resources
 	UploadServerCDILabel = "cdi-upload-server"
@@ -98,6 +122,14 @@ const (
 	UploadServerServiceLabel = "service"
 	// UploadImageSize provides a constant to capture our env variable "UPLOAD_IMAGE_SIZE"
 	UploadImageSize = "UPLOAD_IMAGE_SIZE"
+	// UploadCloneTarget provides a constant to capture our env variable "UPLOAD_CLONE_TARGET", set when the upload server is a clone target
+	UploadCloneTarget = "UPLOAD_CLONE_TARGET"
+	// UploadCloneCheckpoint provides a constant to capture our env variable "UPLOAD_CLONE_CHECKPOINT", the offset up to which a block clone target is known to be complete
+	UploadCloneCheckpoint = "UPLOAD_CLONE_CHECKPOINT"
+	// FilesystemOverheadVar provides a constant to capture our env variable "FILESYSTEM_OVERHEAD", the fraction of a filesystem volume reserved for filesystem metadata
+	FilesystemOverheadVar = "FILESYSTEM_OVERHEAD"
+	// CloneCheckpointDir is the directory where the upload server of a clone target keeps its checkpoint across restarts
+	CloneCheckpointDir = "/var/run/cdi/clone-checkpoint"
 
 	// ConfigName is the name of default CDI Config
 	ConfigName = "config"
@@ -129,6 +161,8 @@ const (
 
 	// QemuSubGid is the gid used as the qemu group in fsGroup
 	QemuSubGid = int64(107)
+	// QemuUID is the uid of the qemu user, the default user of the worker pods on filesystem volumes
+	QemuUID = int64(107)
 
 	// ControllerServiceAccountName is the name of the CDI controller service account
 	ControllerServiceAccountName = "cdi-sa"
@@ -26,6 +26,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
 	"kubevirt.io/containerized-data-importer/pkg/common"
 	"kubevirt.io/containerized-data-importer/pkg/token"
 	"kubevirt.io/containerized-data-importer/pkg/uploadserver"
@@ -47,6 +48,8 @@ const (
 	CloneUniqueID = "cdi.kubevirt.io/storage.clone.cloneUniqeId"
 	// AnnCloneSourcePod name of the source clone pod
 	AnnCloneSourcePod = "cdi.kubevirt.io/storage.sourceClonePodName"
+	// AnnCloneCheckpoint is the offset up to which the target of a block clone is known to be complete
+	AnnCloneCheckpoint = "cdi.kubevirt.io/storage.clone.checkpoint"
 
 	// ErrIncompatiblePVC provides a const to indicate a clone is not possible due to an incompatible PVC
 	ErrIncompatiblePVC = "ErrIncompatiblePVC"
@@ -155,11 +158,19 @@ func (r *CloneReconciler) Reconcile(req reconcile.Request) (reconcile.Result, er
 	}
 	log := r.log.WithValues("PVC", req.NamespacedName)
 	log.V(1).Info("reconciling Clone PVCs")
-	if pvc.DeletionTimestamp != nil || !r.shouldReconcile(pvc, log) {
+	if pvc.DeletionTimestamp == nil && r.shouldReconcile(pvc, log) && failPVCIfDeadlineExceeded(pvc, r.recorder) {
+		log.V(1).Info("Deadline exceeded, cleaning up", "deadline", pvc.Annotations[AnnDeadline])
+		if err := r.updatePVC(pvc); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if pvc.DeletionTimestamp != nil || !r.shouldReconcile(pvc, log) || isPVCFailed(pvc) || isPopulationCancelled(pvc) {
 		log.V(1).Info("Should not reconcile this PVC",
 			"checkPVC(AnnCloneRequest)", checkPVC(pvc, AnnCloneRequest, log),
 			"NOT has annotation(AnnCloneOf)", !metav1.HasAnnotation(pvc.ObjectMeta, AnnCloneOf),
 			"isBound", isBound(pvc, log),
+			"isPVCFailed", isPVCFailed(pvc),
+			"isPopulationCancelled", isPopulationCancelled(pvc),
 			"has finalizer?", r.hasFinalizer(pvc, cloneSourcePodFinalizer))
 		if r.hasFinalizer(pvc, cloneSourcePodFinalizer) {
 			// Clone completed, remove source pod and finalizer.
@@ -194,6 +205,13 @@ func (r *CloneReconciler) Reconcile(req reconcile.Request) (reconcile.Result, er
 		return reconcile.Result{Requeue: true}, nil
 	}
 
+	if sourcePod == nil {
+		if delay := retryDelay(pvc); delay > 0 {
+			log.V(1).Info("Waiting for the retry backoff to elapse", "delay", delay)
+			return reconcile.Result{RequeueAfter: delay}, nil
+		}
+	}
+
 	if requeue, err := r.reconcileSourcePod(sourcePod, pvc, log); requeue || err != nil {
 		return reconcile.Result{Requeue: requeue}, err
 	}
@@ -201,9 +219,33 @@ func (r *CloneReconciler) Reconcile(req reconcile.Request) (reconcile.Result, er
 	if err := r.updatePvcFromPod(sourcePod, pvc, log); err != nil {
 		return reconcile.Result{}, err
 	}
+
+	if sourcePod != nil && hasRetryPolicy(pvc) {
+		if failure := getPodFailure(sourcePod); failure != nil {
+			return r.retryFailedSourcePod(pvc, sourcePod, failure, log)
+		}
+	}
 	return reconcile.Result{}, nil
 }
 
+// retryFailedSourcePod deletes the failed source pod so it is recreated once the backoff elapsed, or fails the PVC if
+// the retry policy allows no more retries, the source pod is then cleaned up on the next reconcile
+func (r *CloneReconciler) retryFailedSourcePod(pvc *corev1.PersistentVolumeClaim, sourcePod *corev1.Pod, failure *podFailure, log logr.Logger) (reconcile.Result, error) {
+	retry := retryFailedPod(pvc, failure, r.recorder)
+	if err := r.updatePVC(pvc); err != nil {
+		return reconcile.Result{}, err
+	}
+	if !retry {
+		log.V(1).Info("Clone source pod failed, not retrying", "pod.Name", sourcePod.Name, "reason", failure.reason)
+		return reconcile.Result{}, nil
+	}
+	log.V(1).Info("Clone source pod failed, deleting pod to retry", "pod.Name", sourcePod.Name, "reason", failure.reason, "retries", pvc.Annotations[AnnPodRestarts])
+	if err := r.client.Delete(context.TODO(), sourcePod); IgnoreNotFound(err) != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: retryDelay(pvc)}, nil
+}
+
 func (r *CloneReconciler) reconcileSourcePod(sourcePod *corev1.Pod, targetPvc *corev1.PersistentVolumeClaim, log logr.Logger) (bool, error) {
 	if sourcePod == nil {
 		sourcePvc, err := r.getCloneRequestSourcePVC(targetPvc)
@@ -237,7 +279,7 @@ func (r *CloneReconciler) reconcileSourcePod(sourcePod *corev1.Pod, targetPvc *c
 			return true, nil
 		}
 
-		sourcePod, err := r.CreateCloneSourcePod(r.image, r.pullPolicy, clientName, targetPvc, log)
+		sourcePod, err := r.CreateCloneSourcePod(r.image, r.pullPolicy, clientName, targetPvc, getVolumeMode(sourcePvc), log)
 		if err != nil {
 			return false, err
 		}
@@ -264,10 +306,24 @@ func (r *CloneReconciler) updatePvcFromPod(sourcePod *corev1.Pod, pvc *corev1.Pe
 		// see the same in upload-controller
 		annPodRestarts, _ := strconv.Atoi(pvc.Annotations[AnnPodRestarts])
 		podRestarts := int(sourcePod.Status.ContainerStatuses[0].RestartCount)
+		failure := podTerminationFailure(sourcePod)
 		if podRestarts > annPodRestarts {
 			pvc.Annotations[AnnPodRestarts] = strconv.Itoa(podRestarts)
+			if failure != nil {
+				r.recorder.Event(pvc, corev1.EventTypeWarning, failure.Reason, failure.Message)
+			}
 		}
 		setConditionFromPodWithPrefix(pvc.Annotations, AnnSourceRunningCondition, sourcePod)
+		if failure != nil {
+			// the cloner reports why it failed, more useful than the waiting reason of the restarting pod
+			pvc.Annotations[AnnSourceRunningConditionMessage] = failure.Message
+			pvc.Annotations[AnnSourceRunningConditionReason] = failure.Reason
+			// remember how far the target got so a new target pod resumes instead of starting over
+			checkpoint, _ := strconv.ParseInt(pvc.Annotations[AnnCloneCheckpoint], 10, 64)
+			if failure.Checkpoint > checkpoint {
+				pvc.Annotations[AnnCloneCheckpoint] = strconv.FormatInt(failure.Checkpoint, 10)
+			}
+		}
 	}
 
 	if !reflect.DeepEqual(currentPvcCopy, pvc) {
@@ -340,11 +396,13 @@ func (r *CloneReconciler) findCloneSourcePod(pvc *corev1.PersistentVolumeClaim)
 }
 
 func (r *CloneReconciler) validateSourceAndTarget(sourcePvc, targetPvc *corev1.PersistentVolumeClaim) error {
-	if err := validateCloneToken(r.tokenValidator, sourcePvc, targetPvc); err != nil {
-		return err
+	if !isImportCacheClone(sourcePvc, targetPvc.Annotations[AnnImportCache]) {
+		if err := validateCloneToken(r.tokenValidator, sourcePvc, targetPvc); err != nil {
+			return err
+		}
 	}
 
-	err := ValidateCanCloneSourceAndTargetSpec(&sourcePvc.Spec, &targetPvc.Spec)
+	err := validateCanClone(r.client, &sourcePvc.Spec, &targetPvc.Spec)
 	if err == nil {
 		// Validation complete, put source PVC bound status in annotation
 		setBoundConditionFromPVC(targetPvc.GetAnnotations(), AnnBoundCondition, sourcePvc)
@@ -425,7 +483,7 @@ func (r *CloneReconciler) cleanup(pvc *corev1.PersistentVolumeClaim, log logr.Lo
 }
 
 // CreateCloneSourcePod creates our cloning src pod which will be used for out of band cloning to read the contents of the src PVC
-func (r *CloneReconciler) CreateCloneSourcePod(image, pullPolicy, clientName string, pvc *corev1.PersistentVolumeClaim, log logr.Logger) (*corev1.Pod, error) {
+func (r *CloneReconciler) CreateCloneSourcePod(image, pullPolicy, clientName string, pvc *corev1.PersistentVolumeClaim, sourceVolumeMode corev1.PersistentVolumeMode, log logr.Logger) (*corev1.Pod, error) {
 	exists, sourcePvcNamespace, sourcePvcName := ParseCloneRequestAnnotation(pvc)
 	if !exists {
 		return nil, errors.Errorf("bad CloneRequest Annotation")
@@ -451,7 +509,16 @@ func (r *CloneReconciler) CreateCloneSourcePod(image, pullPolicy, clientName str
 		return nil, err
 	}
 
-	pod := MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace, ownerKey, clientKey, clientCert, serverCABundle, pvc, podResourceRequirements)
+	compression := GetCloneCompression(r.client)
+
+	workloadPlacement, err := GetWorkloadPlacement(r.client, pvc)
+	if err != nil {
+		return nil, err
+	}
+
+	pod := MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace, ownerKey, clientKey, clientCert, serverCABundle, pvc, podResourceRequirements, compression, sourceVolumeMode)
+	applyNodePlacement(&pod.Spec, workloadPlacement)
+	applyCloneSourceSecurityContext(pod, sourceVolumeMode)
 
 	if err := r.client.Create(context.TODO(), pod); err != nil {
 		return nil, errors.Wrap(err, "source pod API create errored")
@@ -466,9 +533,11 @@ func createCloneSourcePodName(targetPvc *corev1.PersistentVolumeClaim) string {
 	return string(targetPvc.GetUID()) + common.ClonerSourcePodNameSuffix
 }
 
-// MakeCloneSourcePodSpec creates and returns the clone source pod spec based on the target pvc.
+// MakeCloneSourcePodSpec creates and returns the clone source pod spec based on the target pvc. The source volume is
+// attached according to its volume mode, which may differ from the one of the target.
 func MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace, ownerRefAnno string,
-	clientKey, clientCert, serverCACert []byte, targetPvc *corev1.PersistentVolumeClaim, resourceRequirements *corev1.ResourceRequirements) *corev1.Pod {
+	clientKey, clientCert, serverCACert []byte, targetPvc *corev1.PersistentVolumeClaim, resourceRequirements *corev1.ResourceRequirements,
+	compression cdiv1.CloneCompression, sourceVolumeMode corev1.PersistentVolumeMode) *corev1.Pod {
 
 	var ownerID string
 	cloneSourcePodName, _ := targetPvc.Annotations[AnnCloneSourcePod]
@@ -495,9 +564,6 @@ func MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace
 			},
 		},
 		Spec: corev1.PodSpec{
-			SecurityContext: &corev1.PodSecurityContext{
-				RunAsUser: &[]int64{0}[0],
-			},
 			Containers: []corev1.Container{
 				{
 					Name:            common.ClonerSourcePodName,
@@ -524,10 +590,22 @@ func MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace
 							Name:  "UPLOAD_URL",
 							Value: url,
 						},
+						{
+							Name:  "CHECKPOINT_URL",
+							Value: GetUploadServerURL(targetPvc.Namespace, targetPvc.Name, uploadserver.UploadPathCloneCheckpoint),
+						},
 						{
 							Name:  common.OwnerUID,
 							Value: ownerID,
 						},
+						{
+							Name:  common.ClonerCompression,
+							Value: string(compression),
+						},
+						{
+							Name:  common.ProgressURL,
+							Value: GetProgressURL(),
+						},
 					},
 					Ports: []corev1.ContainerPort{
 						{
@@ -538,7 +616,7 @@ func MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace
 					},
 				},
 			},
-			RestartPolicy: corev1.RestartPolicyOnFailure,
+			RestartPolicy: podRestartPolicy(targetPvc),
 			Volumes: []corev1.Volume{
 				{
 					Name: DataVolName,
@@ -559,16 +637,9 @@ func MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace
 		pod.Spec.Containers[0].Resources = *resourceRequirements
 	}
 
-	var volumeMode corev1.PersistentVolumeMode
 	var addVars []corev1.EnvVar
 
-	if targetPvc.Spec.VolumeMode != nil {
-		volumeMode = *targetPvc.Spec.VolumeMode
-	} else {
-		volumeMode = corev1.PersistentVolumeFilesystem
-	}
-
-	if volumeMode == corev1.PersistentVolumeBlock {
+	if sourceVolumeMode == corev1.PersistentVolumeBlock {
 		pod.Spec.Containers[0].VolumeDevices = addVolumeDevices()
 		addVars = []corev1.EnvVar{
 			{
@@ -585,6 +656,7 @@ func MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace
 			{
 				Name:      DataVolName,
 				MountPath: common.ClonerMountPath,
+				ReadOnly:  true,
 			},
 		}
 		addVars = []corev1.EnvVar{
@@ -599,6 +671,15 @@ func MakeCloneSourcePodSpec(image, pullPolicy, sourcePvcName, sourcePvcNamespace
 		}
 	}
 
+	targetVolumeMode := "filesystem"
+	if getVolumeMode(targetPvc) == corev1.PersistentVolumeBlock {
+		targetVolumeMode = "block"
+	}
+	addVars = append(addVars, corev1.EnvVar{
+		Name:  common.ClonerTargetVolumeMode,
+		Value: targetVolumeMode,
+	})
+
 	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, addVars...)
 
 	return pod
@@ -645,15 +726,47 @@ func ParseCloneRequestAnnotation(pvc *corev1.PersistentVolumeClaim) (exists bool
 	return
 }
 
-// ValidateCanCloneSourceAndTargetSpec validates the specs passed in are compatible for cloning.
-func ValidateCanCloneSourceAndTargetSpec(sourceSpec, targetSpec *corev1.PersistentVolumeClaimSpec) error {
+// ValidateCanCloneSourceAndTargetSpec validates the specs passed in are compatible for cloning, the data of the source
+// fits the target with the filesystem overheads of the source and target. The volume modes may differ, the
+// host-assisted clone converts between them.
+func ValidateCanCloneSourceAndTargetSpec(sourceSpec, targetSpec *corev1.PersistentVolumeClaimSpec, sourceOverhead, targetOverhead cdiv1.Percent) error {
 	sourceRequest := sourceSpec.Resources.Requests[corev1.ResourceStorage]
 	targetRequest := targetSpec.Resources.Requests[corev1.ResourceStorage]
 	// Verify that the target PVC size is equal or larger than the source.
 	if sourceRequest.Value() > targetRequest.Value() {
 		return errors.New("target resources requests storage size is smaller than the source")
 	}
-	// Verify that the source and target volume modes are the same.
+	if minSize := MinCloneTargetSize(sourceRequest, sourceOverhead, targetOverhead); minSize.Value() > targetRequest.Value() {
+		return errors.Errorf("target resources requests storage size is smaller than %s, the size of the source with the filesystem overhead of the target", minSize.String())
+	}
+	// Can clone.
+	return nil
+}
+
+// validateCanClone validates the specs are compatible for cloning with the filesystem overheads of the cdi config
+func validateCanClone(c client.Client, sourceSpec, targetSpec *corev1.PersistentVolumeClaimSpec) error {
+	sourceOverhead, targetOverhead, err := getCloneFilesystemOverheads(c, sourceSpec, targetSpec)
+	if err != nil {
+		return err
+	}
+	return ValidateCanCloneSourceAndTargetSpec(sourceSpec, targetSpec, sourceOverhead, targetOverhead)
+}
+
+// getCloneFilesystemOverheads returns the filesystem overheads of the source and target of a clone
+func getCloneFilesystemOverheads(c client.Client, sourceSpec, targetSpec *corev1.PersistentVolumeClaimSpec) (cdiv1.Percent, cdiv1.Percent, error) {
+	sourceOverhead, err := GetFilesystemOverhead(c, &corev1.PersistentVolumeClaim{Spec: *sourceSpec})
+	if err != nil {
+		return "", "", err
+	}
+	targetOverhead, err := GetFilesystemOverhead(c, &corev1.PersistentVolumeClaim{Spec: *targetSpec})
+	if err != nil {
+		return "", "", err
+	}
+	return sourceOverhead, targetOverhead, nil
+}
+
+// ValidateSameVolumeMode validates the source and target volume modes are the same, as clones by the storage require
+func ValidateSameVolumeMode(sourceSpec, targetSpec *corev1.PersistentVolumeClaimSpec) error {
 	sourceVolumeMode := corev1.PersistentVolumeFilesystem
 	if sourceSpec.VolumeMode != nil && *sourceSpec.VolumeMode == corev1.PersistentVolumeBlock {
 		sourceVolumeMode = corev1.PersistentVolumeBlock
@@ -666,6 +779,5 @@ func ValidateCanCloneSourceAndTargetSpec(sourceSpec, targetSpec *corev1.Persiste
 		return fmt.Errorf("source volumeMode (%s) and target volumeMode (%s) do not match",
 			sourceVolumeMode, targetVolumeMode)
 	}
-	// Can clone.
 	return nil
 }
@@ -123,6 +123,38 @@ var _ = Describe("Clone controller reconcile loop", func() {
 		Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("error parsing %s annotation", AnnPodReady)))
 	})
 
+	It("Should clone an import cache PVC without a clone token", func() {
+		testPvc := createPvc("testPvc1", "default", map[string]string{
+			AnnCloneRequest:     "cache-ns/cdi-cache-key",
+			AnnPodReady:         "true",
+			AnnImportCache:      "key",
+			AnnUploadClientName: "uploadclient"}, nil)
+		cachePvc := createPvc("cdi-cache-key", "cache-ns", map[string]string{AnnImportCache: "key"}, map[string]string{common.CDIComponentLabel: common.ImportCacheCDILabel})
+		reconciler = createCloneReconciler(testPvc, cachePvc)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		By("Verifying the source pod is created")
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		sourcePod, err := reconciler.findCloneSourcePod(testPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sourcePod).ToNot(BeNil())
+	})
+
+	It("Should not clone an import cache PVC of another key without a clone token", func() {
+		testPvc := createPvc("testPvc1", "default", map[string]string{
+			AnnCloneRequest:     "cache-ns/cdi-cache-key",
+			AnnPodReady:         "true",
+			AnnImportCache:      "other",
+			AnnUploadClientName: "uploadclient"}, nil)
+		cachePvc := createPvc("cdi-cache-key", "cache-ns", map[string]string{AnnImportCache: "key"}, map[string]string{common.CDIComponentLabel: common.ImportCacheCDILabel})
+		reconciler = createCloneReconciler(testPvc, cachePvc)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).To(HaveOccurred())
+	})
+
 	It("Should create source pod name", func() {
 		testPvc := createPvc("testPvc1", "default", map[string]string{
 			AnnCloneRequest:     "default/source",
@@ -221,6 +253,9 @@ var _ = Describe("Clone controller reconcile loop", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(sourcePod).ToNot(BeNil())
 		Expect(sourcePod.GetLabels()[CloneUniqueID]).To(Equal("default-testPvc1-source-pod"))
+		By("Verifying the source pod doesn't change the source PVC")
+		Expect(sourcePod.Spec.SecurityContext.FSGroup).To(BeNil())
+		Expect(sourcePod.Spec.Containers[0].VolumeMounts[0].ReadOnly).To(BeTrue())
 		By("Verifying the PVC now has a finalizer")
 		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, testPvc)
 		Expect(err).ToNot(HaveOccurred())
@@ -282,6 +317,129 @@ var _ = Describe("Clone controller reconcile loop", func() {
 		Expect(reconciler.hasFinalizer(testPvc, cloneSourcePodFinalizer)).To(BeTrue())
 	})
 
+	Context("with a retry policy", func() {
+		newRetryClonePvc := func(maxRetries string) *corev1.PersistentVolumeClaim {
+			return createPvc("testPvc1", "default", map[string]string{
+				AnnCloneRequest: "default/source", AnnPodReady: "true", AnnCloneToken: "foobaz", AnnUploadClientName: "uploadclient",
+				AnnCloneSourcePod: "default-testPvc1-source-pod", AnnPodRestarts: "0", AnnRetryBackoff: "10s", AnnRetryMaxRetries: maxRetries}, nil)
+		}
+
+		newFailedSourcePod := func(pvc *corev1.PersistentVolumeClaim) *corev1.Pod {
+			pod := createSourcePod(pvc, string(pvc.GetUID()))
+			pod.Name = "source-pod"
+			pod.Namespace = "default"
+			pod.Labels[CloneUniqueID] = pvc.Annotations[AnnCloneSourcePod]
+			pod.Status = corev1.PodStatus{
+				Phase: corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{
+								ExitCode: 1,
+								Message:  `{"reason":"CloneSourceReadFailed","message":"read failed"}`,
+							},
+						},
+					},
+				},
+			}
+			return pod
+		}
+
+		It("Should delete the failed source pod and schedule a retry", func() {
+			testPvc := newRetryClonePvc("1")
+			reconciler = createCloneReconciler(testPvc, createPvc("source", "default", map[string]string{}, nil), newFailedSourcePod(testPvc))
+			result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			sourcePod, err := reconciler.findCloneSourcePod(testPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sourcePod).To(BeNil())
+			actualPvc := &corev1.PersistentVolumeClaim{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, actualPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualPvc.Annotations[AnnPodRestarts]).To(Equal("1"))
+			Expect(actualPvc.Annotations[AnnRetryAfter]).ToNot(BeEmpty())
+
+			By("Not recreating the source pod before the backoff elapsed")
+			result, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			sourcePod, err = reconciler.findCloneSourcePod(testPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sourcePod).To(BeNil())
+		})
+
+		It("Should fail the PVC and clean up the source pod once the retry limit is reached", func() {
+			testPvc := newRetryClonePvc("0")
+			reconciler = createCloneReconciler(testPvc, createPvc("source", "default", map[string]string{}, nil), newFailedSourcePod(testPvc))
+			_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+			actualPvc := &corev1.PersistentVolumeClaim{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, actualPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualPvc.Annotations[AnnPodPhase]).To(Equal(string(corev1.PodFailed)))
+			Expect(actualPvc.Annotations[AnnRunningConditionReason]).To(Equal("CloneSourceReadFailed"))
+			Expect(actualPvc.Annotations[AnnRunningConditionMessage]).To(Equal("read failed"))
+			Expect(actualPvc.Annotations).ToNot(HaveKey(AnnSourceRunningCondition))
+
+			By("Cleaning up the source pod")
+			_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+			sourcePod, err := reconciler.findCloneSourcePod(testPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sourcePod).To(BeNil())
+			actualPvc = &corev1.PersistentVolumeClaim{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, actualPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reconciler.hasFinalizer(actualPvc, cloneSourcePodFinalizer)).To(BeFalse())
+		})
+	})
+
+	It("Should fail the PVC and clean up the source pod once its deadline passed", func() {
+		testPvc := createPvc("testPvc1", "default", map[string]string{
+			AnnCloneRequest: "default/source", AnnPodReady: "true", AnnCloneToken: "foobaz", AnnUploadClientName: "uploadclient",
+			AnnCloneSourcePod: "default-testPvc1-source-pod", AnnPodPhase: string(corev1.PodRunning), AnnDeadline: "1h0m0s"}, nil)
+		testPvc.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		testPvc.Finalizers = []string{cloneSourcePodFinalizer}
+		sourcePod := createSourcePod(testPvc, string(testPvc.GetUID()))
+		sourcePod.Name = "source-pod"
+		sourcePod.Namespace = "default"
+		sourcePod.Labels[CloneUniqueID] = testPvc.Annotations[AnnCloneSourcePod]
+		reconciler = createCloneReconciler(testPvc, createPvc("source", "default", map[string]string{}, nil), sourcePod)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		actualPvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, actualPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualPvc.Annotations[AnnPodPhase]).To(Equal(string(corev1.PodFailed)))
+		Expect(actualPvc.Annotations[AnnRunningConditionReason]).To(Equal(DeadlineExceeded))
+		Expect(reconciler.hasFinalizer(actualPvc, cloneSourcePodFinalizer)).To(BeFalse())
+		pod, err := reconciler.findCloneSourcePod(testPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod).To(BeNil())
+	})
+
+	It("Should remove the source pod of a cancelled clone", func() {
+		testPvc := createPvc("testPvc1", "default", map[string]string{
+			AnnCloneRequest: "default/source", AnnPodReady: "true", AnnCloneToken: "foobaz", AnnUploadClientName: "uploadclient",
+			AnnCloneSourcePod: "default-testPvc1-source-pod", AnnPodPhase: string(corev1.PodRunning), AnnPopulationAction: string(cdiv1.DataVolumeCancel)}, nil)
+		testPvc.Finalizers = []string{cloneSourcePodFinalizer}
+		sourcePod := createSourcePod(testPvc, string(testPvc.GetUID()))
+		sourcePod.Name = "source-pod"
+		sourcePod.Namespace = "default"
+		sourcePod.Labels[CloneUniqueID] = testPvc.Annotations[AnnCloneSourcePod]
+		reconciler = createCloneReconciler(testPvc, createPvc("source", "default", map[string]string{}, nil), sourcePod)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		actualPvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, actualPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reconciler.hasFinalizer(actualPvc, cloneSourcePodFinalizer)).To(BeFalse())
+		pod, err := reconciler.findCloneSourcePod(testPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod).To(BeNil())
+	})
+
 	It("Should update the cloneof when complete", func() {
 		testPvc := createPvc("testPvc1", "default", map[string]string{
 			AnnCloneRequest: "default/source", AnnPodReady: "true", AnnCloneToken: "foobaz", AnnUploadClientName: "uploadclient"}, nil)
@@ -386,41 +544,39 @@ var _ = Describe("Clone controller reconcile loop", func() {
 		Expect(sourcePod).To(BeNil())
 	})
 
-	It("Should error when source and target volume modes do not match (fs->block)", func() {
-		testPvc := createBlockPvc("testPvc1", "default", map[string]string{
+	DescribeTable("Should attach the source volume by its own volume mode", func(createTarget, createSource func(string, string, map[string]string, map[string]string) *corev1.PersistentVolumeClaim, sourceVolumeMode, targetVolumeMode string) {
+		testPvc := createTarget("testPvc1", "default", map[string]string{
 			AnnCloneRequest: "default/source", AnnPodReady: "true", AnnCloneToken: "foobaz", AnnUploadClientName: "uploadclient", AnnCloneSourcePod: "default-testPvc1-source-pod"}, nil)
-		reconciler = createCloneReconciler(testPvc, createPvc("source", "default", map[string]string{}, nil))
-		By("Setting up the match token")
-		reconciler.tokenValidator.(*FakeValidator).match = "foobaz"
-		reconciler.tokenValidator.(*FakeValidator).Name = "source"
-		reconciler.tokenValidator.(*FakeValidator).Namespace = "default"
-		reconciler.tokenValidator.(*FakeValidator).Params["targetNamespace"] = "default"
-		reconciler.tokenValidator.(*FakeValidator).Params["targetName"] = "testPvc1"
-		By("Verifying no source pod exists")
-		sourcePod, err := reconciler.findCloneSourcePod(testPvc)
-		Expect(sourcePod).To(BeNil())
-		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
-		Expect(err).To(HaveOccurred())
-		Expect(err.Error()).To(ContainSubstring("source volumeMode (Filesystem) and target volumeMode (Block) do not match"))
-	})
-
-	It("Should error when source and target volume modes do not match (fs->block)", func() {
-		testPvc := createPvc("testPvc1", "default", map[string]string{
-			AnnCloneRequest: "default/source", AnnPodReady: "true", AnnCloneToken: "foobaz", AnnUploadClientName: "uploadclient", AnnCloneSourcePod: "default-testPvc1-source-pod"}, nil)
-		reconciler = createCloneReconciler(testPvc, createBlockPvc("source", "default", map[string]string{}, nil))
+		if targetVolumeMode == "filesystem" {
+			// leave room for the filesystem overhead of the target
+			testPvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2G")
+		}
+		reconciler = createCloneReconciler(testPvc, createSource("source", "default", map[string]string{}, nil))
 		By("Setting up the match token")
 		reconciler.tokenValidator.(*FakeValidator).match = "foobaz"
 		reconciler.tokenValidator.(*FakeValidator).Name = "source"
 		reconciler.tokenValidator.(*FakeValidator).Namespace = "default"
 		reconciler.tokenValidator.(*FakeValidator).Params["targetNamespace"] = "default"
 		reconciler.tokenValidator.(*FakeValidator).Params["targetName"] = "testPvc1"
-		By("Verifying no source pod exists")
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
 		sourcePod, err := reconciler.findCloneSourcePod(testPvc)
-		Expect(sourcePod).To(BeNil())
-		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
-		Expect(err).To(HaveOccurred())
-		Expect(err.Error()).To(ContainSubstring("source volumeMode (Block) and target volumeMode (Filesystem) do not match"))
-	})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sourcePod).ToNot(BeNil())
+		container := sourcePod.Spec.Containers[0]
+		if sourceVolumeMode == "block" {
+			Expect(container.VolumeDevices).To(HaveLen(1))
+			Expect(container.VolumeMounts).To(BeEmpty())
+		} else {
+			Expect(container.VolumeDevices).To(BeEmpty())
+			Expect(container.VolumeMounts).To(HaveLen(1))
+		}
+		Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "VOLUME_MODE", Value: sourceVolumeMode}))
+		Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: common.ClonerTargetVolumeMode, Value: targetVolumeMode}))
+	},
+		Entry("fs->block", createBlockPvc, createPvc, "filesystem", "block"),
+		Entry("block->fs", createPvc, createBlockPvc, "block", "filesystem"),
+	)
 })
 
 var _ = Describe("ParseCloneRequestAnnotation", func() {
@@ -546,6 +702,78 @@ var _ = Describe("Update PVC", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(actualPvc.Annotations[AnnPodRestarts]).To(Equal("3"))
 	})
+
+	It("Should surface the failure reported by the cloner", func() {
+		testPvc := createPvc("testPvc1", "default", map[string]string{AnnCloneRequest: "default/test"}, nil)
+		pod := createSourcePod(testPvc, string(testPvc.GetUID()))
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					RestartCount: 1,
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{
+							Reason:  "CrashLoopBackOff",
+							Message: "back-off restarting failed container",
+						},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: 1,
+							Message:  `{"reason":"CloneTargetFailed","message":"Upload server returned status 500","checkpoint":1073741824}`,
+						},
+					},
+				},
+			},
+		}
+		reconciler = createCloneReconciler(testPvc, createPvc("source", "default", map[string]string{}, nil))
+
+		err := reconciler.updatePvcFromPod(pod, testPvc, reconciler.log)
+		Expect(err).ToNot(HaveOccurred())
+
+		actualPvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, actualPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualPvc.Annotations[AnnSourceRunningConditionReason]).To(Equal("CloneTargetFailed"))
+		Expect(actualPvc.Annotations[AnnSourceRunningConditionMessage]).To(Equal("Upload server returned status 500"))
+		Expect(actualPvc.Annotations[AnnCloneCheckpoint]).To(Equal("1073741824"))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring("CloneTargetFailed"))
+	})
+
+	It("Should keep the waiting reason if the termination message isn't structured", func() {
+		testPvc := createPvc("testPvc1", "default", map[string]string{AnnCloneRequest: "default/test"}, nil)
+		pod := createSourcePod(testPvc, string(testPvc.GetUID()))
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					RestartCount: 1,
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{
+							Reason:  "CrashLoopBackOff",
+							Message: "back-off restarting failed container",
+						},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: 1,
+							Message:  "I went poof",
+						},
+					},
+				},
+			},
+		}
+		reconciler = createCloneReconciler(testPvc, createPvc("source", "default", map[string]string{}, nil))
+
+		err := reconciler.updatePvcFromPod(pod, testPvc, reconciler.log)
+		Expect(err).ToNot(HaveOccurred())
+
+		actualPvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, actualPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualPvc.Annotations[AnnSourceRunningConditionReason]).To(Equal("CrashLoopBackOff"))
+	})
 })
 
 var _ = Describe("TokenValidation", func() {
@@ -793,10 +1021,22 @@ func createSourcePod(pvc *corev1.PersistentVolumeClaim, pvcUID string) *corev1.P
 							Name:  "UPLOAD_URL",
 							Value: GetUploadServerURL(pvc.Namespace, pvc.Name, uploadserver.UploadPathSync),
 						},
+						{
+							Name:  "CHECKPOINT_URL",
+							Value: GetUploadServerURL(pvc.Namespace, pvc.Name, uploadserver.UploadPathCloneCheckpoint),
+						},
 						{
 							Name:  common.OwnerUID,
 							Value: "",
 						},
+						{
+							Name:  common.ClonerCompression,
+							Value: string(cdiv1.CloneCompressionGzip),
+						},
+						{
+							Name:  common.ProgressURL,
+							Value: GetProgressURL(),
+						},
 					},
 					Ports: []corev1.ContainerPort{
 						{
@@ -851,6 +1091,7 @@ func createSourcePod(pvc *corev1.PersistentVolumeClaim, pvcUID string) *corev1.P
 			{
 				Name:      DataVolName,
 				MountPath: common.ClonerMountPath,
+				ReadOnly:  true,
 			},
 		}
 		addVars = []corev1.EnvVar{
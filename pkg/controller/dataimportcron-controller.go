@@ -0,0 +1,478 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/util/cron"
+	"kubevirt.io/containerized-data-importer/pkg/util/naming"
+)
+
+const (
+	// LabelDataImportCron provides a const for the label of the DataVolumes created by a DataImportCron
+	LabelDataImportCron = AnnAPIGroup + "/dataImportCron"
+	// AnnSourceDigest provides a const for the source digest imported by a DataImportCron DataVolume
+	AnnSourceDigest = AnnAPIGroup + "/storage.import.sourceDigest"
+	// AnnImportCronTimestamp provides a const for the time the source digest of a DataImportCron DataVolume was last polled
+	AnnImportCronTimestamp = AnnAPIGroup + "/storage.import.cronTimestamp"
+
+	// ImportCronPollFailed provides a const to indicate polling the source digest failed
+	ImportCronPollFailed = "PollFailed"
+	// ImportCronInvalidSpec provides a const to indicate the DataImportCron schedule or template is invalid
+	ImportCronInvalidSpec = "InvalidSpec"
+	// ImportCronImportStarted provides a const to indicate a new source digest is being imported
+	ImportCronImportStarted = "ImportStarted"
+	// ImportCronLastImportUpdated provides a const to indicate the last imported PVC was updated
+	ImportCronLastImportUpdated = "LastImportUpdated"
+
+	// MessageImportCronPollFailed provides a const to form the poll failed message
+	MessageImportCronPollFailed = "Polling the source digest failed: %v"
+	// MessageImportCronImportStarted provides a const to form the import started message
+	MessageImportCronImportStarted = "Importing digest %s to DataVolume %s"
+	// MessageImportCronLastImportUpdated provides a const to form the last import updated message
+	MessageImportCronLastImportUpdated = "Last imported PVC is %s, digest %s"
+
+	importCronUpToDate         = "UpToDate"
+	importCronImportInProgress = "ImportInProgress"
+	importCronImportFailed     = "ImportFailed"
+	importCronNoImport         = "NoImport"
+
+	defaultImportsToKeep = 3
+
+	// imported PVCs in use by pods are garbage collected again after this period
+	importCronGarbageCollectRetryPeriod = 5 * time.Minute
+)
+
+// DataImportCronReconciler members
+type DataImportCronReconciler struct {
+	client         client.Client
+	uncachedClient client.Client
+	recorder       record.EventRecorder
+	log            logr.Logger
+	image          string
+	verbose        string
+	pullPolicy     string
+}
+
+// NewDataImportCronController creates a new instance of the DataImportCron controller.
+func NewDataImportCronController(mgr manager.Manager, log logr.Logger, importerImage, pullPolicy, verbose string) (controller.Controller, error) {
+	uncachedClient, err := client.New(mgr.GetConfig(), client.Options{
+		Scheme: mgr.GetScheme(),
+		Mapper: mgr.GetRESTMapper(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	reconciler := &DataImportCronReconciler{
+		client:         mgr.GetClient(),
+		uncachedClient: uncachedClient,
+		recorder:       mgr.GetEventRecorderFor("dataimportcron-controller"),
+		log:            log.WithName("dataimportcron-controller"),
+		image:          importerImage,
+		verbose:        verbose,
+		pullPolicy:     pullPolicy,
+	}
+	dataImportCronController, err := controller.New("dataimportcron-controller", mgr, controller.Options{
+		Reconciler: reconciler,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := dataImportCronController.Watch(&source.Kind{Type: &cdiv1.DataImportCron{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return nil, err
+	}
+	if err := dataImportCronController.Watch(&source.Kind{Type: &cdiv1.DataVolume{}}, &handler.EnqueueRequestForOwner{
+		OwnerType:    &cdiv1.DataImportCron{},
+		IsController: true,
+	}); err != nil {
+		return nil, err
+	}
+	if err := dataImportCronController.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
+		OwnerType:    &cdiv1.DataImportCron{},
+		IsController: true,
+	}); err != nil {
+		return nil, err
+	}
+	return dataImportCronController, nil
+}
+
+// Reconcile polls the source digest on the schedule from a poller pod and imports new digests, points the status to
+// the newest imported PVC and garbage collects the older imports.
+func (r *DataImportCronReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("DataImportCron", req.NamespacedName)
+
+	dataImportCron := &cdiv1.DataImportCron{}
+	if err := r.client.Get(context.TODO(), req.NamespacedName, dataImportCron); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if dataImportCron.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	dataImportCronCopy := dataImportCron.DeepCopy()
+
+	schedule, err := validateDataImportCron(dataImportCron)
+	if err != nil {
+		r.recorder.Event(dataImportCron, corev1.EventTypeWarning, ImportCronInvalidSpec, err.Error())
+		dataImportCron.Status.Conditions = updateImportCronCondition(dataImportCron.Status.Conditions, corev1.ConditionFalse, err.Error(), ImportCronInvalidSpec)
+		return reconcile.Result{}, r.updateDataImportCronStatus(dataImportCron, dataImportCronCopy)
+	}
+
+	imports, err := r.listImports(dataImportCron)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// the schedule is in UTC
+	now := time.Now().UTC()
+	lastExecution := dataImportCron.Status.LastExecutionTimestamp
+	polling := false
+	if lastExecution == nil || !now.Before(schedule.Next(lastExecution.Time.UTC())) {
+		log.V(1).Info("Polling the source digest")
+		digest, polled, err := r.pollSourceDigest(log, dataImportCron)
+		if err != nil {
+			// retried with backoff, the schedule may be far away
+			message := fmt.Sprintf(MessageImportCronPollFailed, err)
+			r.recorder.Event(dataImportCron, corev1.EventTypeWarning, ImportCronPollFailed, message)
+			dataImportCron.Status.Conditions = updateImportCronCondition(dataImportCron.Status.Conditions, corev1.ConditionFalse, message, ImportCronPollFailed)
+			if err := r.updateDataImportCronStatus(dataImportCron, dataImportCronCopy); err != nil {
+				return reconcile.Result{}, err
+			}
+			return reconcile.Result{}, err
+		}
+		if polled {
+			if digest == "" {
+				// the source has no digest, every execution imports it
+				digest = fmt.Sprintf("unknown:%d", now.Unix())
+			}
+			if imports, err = r.ensureImport(dataImportCron, imports, digest, now); err != nil {
+				return reconcile.Result{}, err
+			}
+			dataImportCron.Status.LastExecutionTimestamp = &metav1.Time{Time: now}
+		} else {
+			polling = true
+		}
+	}
+
+	r.updateImports(dataImportCron, imports)
+	collected, err := r.garbageCollect(log, dataImportCron, imports)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.updateDataImportCronStatus(dataImportCron, dataImportCronCopy); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	var requeueAfter time.Duration
+	if polling {
+		// reconciled again once the poller pod completes
+		if !collected {
+			requeueAfter = importCronGarbageCollectRetryPeriod
+		}
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
+	if next := schedule.Next(dataImportCron.Status.LastExecutionTimestamp.Time.UTC()); !next.IsZero() {
+		requeueAfter = next.Sub(now)
+	}
+	if !collected && (requeueAfter == 0 || requeueAfter > importCronGarbageCollectRetryPeriod) {
+		requeueAfter = importCronGarbageCollectRetryPeriod
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// validateDataImportCron parses the schedule and checks the template imports a source with a digest
+func validateDataImportCron(dataImportCron *cdiv1.DataImportCron) (*cron.Schedule, error) {
+	schedule, err := cron.Parse(dataImportCron.Spec.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	source := dataImportCron.Spec.Template.Spec.Source
+	switch {
+	case source.Registry != nil:
+		if _, err := parseRegistryURL(source.Registry.URL); err != nil {
+			return nil, err
+		}
+	case source.HTTP == nil:
+		return nil, fmt.Errorf("the template source must be a registry or http source")
+	}
+	if dataImportCron.Spec.Template.Spec.PVC == nil && dataImportCron.Spec.Template.Spec.Storage == nil {
+		return nil, fmt.Errorf("the template has no PVC or storage")
+	}
+	return schedule, nil
+}
+
+// listImports returns the DataVolumes created by the DataImportCron, the most recently polled digest first
+func (r *DataImportCronReconciler) listImports(dataImportCron *cdiv1.DataImportCron) ([]*cdiv1.DataVolume, error) {
+	selector, err := labels.Parse(fmt.Sprintf("%s=%s", LabelDataImportCron, naming.GetLabelNameFromResourceName(dataImportCron.Name)))
+	if err != nil {
+		return nil, err
+	}
+	dataVolumes := &cdiv1.DataVolumeList{}
+	if err := r.client.List(context.TODO(), dataVolumes, &client.ListOptions{Namespace: dataImportCron.Namespace, LabelSelector: selector}); err != nil {
+		return nil, err
+	}
+	var imports []*cdiv1.DataVolume
+	for i := range dataVolumes.Items {
+		dataVolume := &dataVolumes.Items[i]
+		if dataVolume.DeletionTimestamp == nil && metav1.IsControlledBy(dataVolume, dataImportCron) {
+			imports = append(imports, dataVolume)
+		}
+	}
+	sortImports(imports)
+	return imports, nil
+}
+
+func sortImports(imports []*cdiv1.DataVolume) {
+	sort.SliceStable(imports, func(i, j int) bool {
+		return importCronTimestamp(imports[j]).Before(importCronTimestamp(imports[i]))
+	})
+}
+
+// importCronTimestamp returns when the digest of the DataVolume was last polled, or its creation time
+func importCronTimestamp(dataVolume *cdiv1.DataVolume) time.Time {
+	if timestamp, err := time.Parse(time.RFC3339Nano, dataVolume.Annotations[AnnImportCronTimestamp]); err == nil {
+		return timestamp
+	}
+	return dataVolume.CreationTimestamp.Time
+}
+
+// ensureImport creates the DataVolume importing the digest, unless it was already imported. If the digest was
+// imported before, e.g. the tag was moved back, its DataVolume becomes the most recent import again.
+func (r *DataImportCronReconciler) ensureImport(dataImportCron *cdiv1.DataImportCron, imports []*cdiv1.DataVolume, digest string, now time.Time) ([]*cdiv1.DataVolume, error) {
+	name := dataImportCronDataVolumeName(dataImportCron, digest)
+	for i, dataVolume := range imports {
+		if dataVolume.Name != name {
+			continue
+		}
+		if i > 0 {
+			dataVolume.Annotations[AnnImportCronTimestamp] = now.UTC().Format(time.RFC3339Nano)
+			if err := r.client.Update(context.TODO(), dataVolume); err != nil {
+				return nil, err
+			}
+			sortImports(imports)
+		}
+		return imports, nil
+	}
+
+	dataVolume, err := newDataImportCronDataVolume(dataImportCron, name, digest, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.client.Create(context.TODO(), dataVolume); err != nil {
+		if !k8serrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		// not controlled by the DataImportCron, or not in the cache yet
+		return imports, nil
+	}
+	r.recorder.Eventf(dataImportCron, corev1.EventTypeNormal, ImportCronImportStarted, MessageImportCronImportStarted, digest, name)
+	return append([]*cdiv1.DataVolume{dataVolume}, imports...), nil
+}
+
+// updateImports points the status to the newest successful import and lists the imports in progress
+func (r *DataImportCronReconciler) updateImports(dataImportCron *cdiv1.DataImportCron, imports []*cdiv1.DataVolume) {
+	status := &dataImportCron.Status
+	status.CurrentImports = nil
+	var lastImport *cdiv1.DataVolume
+	for _, dataVolume := range imports {
+		switch dataVolume.Status.Phase {
+		case cdiv1.Succeeded:
+			if lastImport == nil {
+				lastImport = dataVolume
+			}
+		case cdiv1.Failed:
+		default:
+			if lastImport == nil {
+				status.CurrentImports = append(status.CurrentImports, cdiv1.DataImportCronImport{
+					DataVolumeName: dataVolume.Name,
+					Digest:         dataVolume.Annotations[AnnSourceDigest],
+				})
+			}
+		}
+	}
+
+	if lastImport == nil {
+		status.LastImportedPVC = nil
+		status.LastImportedDigest = ""
+		status.LastImportTimestamp = nil
+	} else if status.LastImportedPVC == nil || status.LastImportedPVC.Name != lastImport.Name {
+		status.LastImportedPVC = &cdiv1.DataVolumeSourcePVC{Namespace: lastImport.Namespace, Name: lastImport.Name}
+		status.LastImportedDigest = lastImport.Annotations[AnnSourceDigest]
+		status.LastImportTimestamp = &metav1.Time{Time: importCronTimestamp(lastImport)}
+		r.recorder.Eventf(dataImportCron, corev1.EventTypeNormal, ImportCronLastImportUpdated, MessageImportCronLastImportUpdated, lastImport.Name, status.LastImportedDigest)
+	}
+
+	switch {
+	case len(imports) == 0:
+		status.Conditions = updateImportCronCondition(status.Conditions, corev1.ConditionFalse, "No import was started", importCronNoImport)
+	case imports[0] == lastImport:
+		status.Conditions = updateImportCronCondition(status.Conditions, corev1.ConditionTrue, "The last imported PVC holds the latest digest", importCronUpToDate)
+	case imports[0].Status.Phase == cdiv1.Failed:
+		status.Conditions = updateImportCronCondition(status.Conditions, corev1.ConditionFalse, fmt.Sprintf("Import of the latest digest to DataVolume %s failed", imports[0].Name), importCronImportFailed)
+	default:
+		status.Conditions = updateImportCronCondition(status.Conditions, corev1.ConditionFalse, fmt.Sprintf("Importing the latest digest to DataVolume %s", imports[0].Name), importCronImportInProgress)
+	}
+}
+
+// garbageCollect deletes the successful imports older than the imports to keep and the failed imports older than the
+// last import, their PVCs are deleted with them. It returns false if an import could not be collected because its PVC
+// is in use by a pod.
+func (r *DataImportCronReconciler) garbageCollect(log logr.Logger, dataImportCron *cdiv1.DataImportCron, imports []*cdiv1.DataVolume) (bool, error) {
+	importsToKeep := defaultImportsToKeep
+	if dataImportCron.Spec.ImportsToKeep != nil {
+		importsToKeep = int(*dataImportCron.Spec.ImportsToKeep)
+	}
+	collected := true
+	succeeded := 0
+	for _, dataVolume := range imports {
+		switch dataVolume.Status.Phase {
+		case cdiv1.Succeeded:
+			succeeded++
+			if succeeded <= importsToKeep {
+				continue
+			}
+		case cdiv1.Failed:
+			if succeeded == 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		if last := dataImportCron.Status.LastImportedPVC; last != nil && last.Name == dataVolume.Name {
+			continue
+		}
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: dataVolume.Name, Namespace: dataVolume.Namespace}}
+		inUse, err := isPvcUsedByAnyPod(r.client, pvc, log)
+		if err != nil {
+			return false, err
+		}
+		if inUse {
+			log.V(1).Info("Imported PVC in use by a pod, not garbage collected", "pvc.Name", pvc.Name)
+			collected = false
+			continue
+		}
+		log.V(1).Info("Garbage collecting import", "DataVolume.Name", dataVolume.Name)
+		if err := r.client.Delete(context.TODO(), dataVolume); err != nil && !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	return collected, nil
+}
+
+func (r *DataImportCronReconciler) updateDataImportCronStatus(dataImportCron, dataImportCronCopy *cdiv1.DataImportCron) error {
+	if !reflect.DeepEqual(dataImportCron.Status, dataImportCronCopy.Status) {
+		return r.client.Update(context.TODO(), dataImportCron)
+	}
+	return nil
+}
+
+func updateImportCronCondition(conditions []cdiv1.DataImportCronCondition, status corev1.ConditionStatus, message, reason string) []cdiv1.DataImportCronCondition {
+	var condition *cdiv1.DataImportCronCondition
+	for i := range conditions {
+		if conditions[i].Type == cdiv1.DataImportCronUpToDate {
+			condition = &conditions[i]
+		}
+	}
+	if condition == nil {
+		conditions = append(conditions, cdiv1.DataImportCronCondition{Type: cdiv1.DataImportCronUpToDate})
+		condition = &conditions[len(conditions)-1]
+	}
+	if condition.Status != status {
+		condition.LastTransitionTime = metav1.Now()
+		condition.Message = message
+		condition.Reason = reason
+		condition.LastHeartbeatTime = condition.LastTransitionTime
+	} else if condition.Message != message || condition.Reason != reason {
+		condition.Message = message
+		condition.Reason = reason
+		condition.LastHeartbeatTime = metav1.Now()
+	}
+	condition.Status = status
+	return conditions
+}
+
+// dataImportCronDataVolumeName returns the name of the DataVolume importing a digest, the same digest is imported
+// once
+func dataImportCronDataVolumeName(dataImportCron *cdiv1.DataImportCron, digest string) string {
+	hash := sha256.Sum256([]byte(digest))
+	return naming.GetResourceName(dataImportCron.Name, hex.EncodeToString(hash[:])[:12])
+}
+
+// newDataImportCronDataVolume creates the DataVolume importing a digest from the template, registry sources are
+// pinned to the digest so the import is the polled image even if the tag moves meanwhile, http sources are keyed by
+// the digest in the import cache
+func newDataImportCronDataVolume(dataImportCron *cdiv1.DataImportCron, name, digest string, now time.Time) (*cdiv1.DataVolume, error) {
+	template := dataImportCron.Spec.Template.DeepCopy()
+	dataVolume := &cdiv1.DataVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   dataImportCron.Namespace,
+			Labels:      template.Labels,
+			Annotations: template.Annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(dataImportCron, cdiv1.SchemeGroupVersion.WithKind("DataImportCron")),
+			},
+		},
+		Spec: template.Spec,
+	}
+	if dataVolume.Labels == nil {
+		dataVolume.Labels = map[string]string{}
+	}
+	dataVolume.Labels[LabelDataImportCron] = naming.GetLabelNameFromResourceName(dataImportCron.Name)
+	if dataVolume.Annotations == nil {
+		dataVolume.Annotations = map[string]string{}
+	}
+	dataVolume.Annotations[AnnSourceDigest] = digest
+	dataVolume.Annotations[AnnImportCronTimestamp] = now.UTC().Format(time.RFC3339Nano)
+
+	if registry := dataVolume.Spec.Source.Registry; registry != nil && strings.HasPrefix(digest, "sha256:") {
+		ref, err := parseRegistryURL(registry.URL)
+		if err != nil {
+			return nil, err
+		}
+		registry.URL = ref.withDigest(digest)
+	}
+	// the http url doesn't change with the digest, the import cache must not serve a previous digest
+	if dataVolume.Spec.Source.HTTP != nil {
+		dataVolume.Annotations[AnnImportChecksum] = digest
+	}
+	return dataVolume, nil
+}
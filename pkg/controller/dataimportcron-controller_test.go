@@ -0,0 +1,430 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/util"
+)
+
+var (
+	dicLog = logf.Log.WithName("dataimportcron-controller-test")
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+var _ = Describe("DataImportCron controller", func() {
+	var (
+		digest string
+	)
+
+	BeforeEach(func() {
+		digest = testDigest
+	})
+
+	cronKey := types.NamespacedName{Name: "test-cron", Namespace: metav1.NamespaceDefault}
+	pollerKey := types.NamespacedName{Name: "cdi-poll-test-cron", Namespace: metav1.NamespaceDefault}
+
+	getCron := func(reconciler *DataImportCronReconciler) *cdiv1.DataImportCron {
+		dataImportCron := &cdiv1.DataImportCron{}
+		Expect(reconciler.client.Get(context.TODO(), cronKey, dataImportCron)).To(Succeed())
+		return dataImportCron
+	}
+
+	getPoller := func(reconciler *DataImportCronReconciler) *corev1.Pod {
+		pod := &corev1.Pod{}
+		err := reconciler.client.Get(context.TODO(), pollerKey, pod)
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return pod
+	}
+
+	// completePoller terminates the poller pod like the importer polling the digest
+	completePoller := func(reconciler *DataImportCronReconciler, phase corev1.PodPhase, exitCode int32, msg *util.TerminationMessage) {
+		pod := getPoller(reconciler)
+		Expect(pod).ToNot(BeNil())
+		message, err := json.Marshal(msg)
+		Expect(err).ToNot(HaveOccurred())
+		pod.Status.Phase = phase
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Message: string(message)},
+				},
+			},
+		}
+		Expect(reconciler.client.Update(context.TODO(), pod)).To(Succeed())
+	}
+
+	// reconcileCron reconciles the DataImportCron, a poller pod it creates completes with the digest
+	reconcileCron := func(reconciler *DataImportCronReconciler) reconcile.Result {
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: cronKey})
+		Expect(err).ToNot(HaveOccurred())
+		if getPoller(reconciler) != nil {
+			completePoller(reconciler, corev1.PodSucceeded, 0, &util.TerminationMessage{Reason: "DigestPolled", Digest: digest})
+			result, err = reconciler.Reconcile(reconcile.Request{NamespacedName: cronKey})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getPoller(reconciler)).To(BeNil())
+		}
+		return result
+	}
+
+	getImport := func(reconciler *DataImportCronReconciler, name string) *cdiv1.DataVolume {
+		dataVolume := &cdiv1.DataVolume{}
+		err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: metav1.NamespaceDefault}, dataVolume)
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return dataVolume
+	}
+
+	// pollAgain moves the last execution back so the schedule is due
+	pollAgain := func(reconciler *DataImportCronReconciler) {
+		dataImportCron := getCron(reconciler)
+		dataImportCron.Status.LastExecutionTimestamp = &metav1.Time{Time: time.Now().Add(-25 * time.Hour)}
+		Expect(reconciler.client.Update(context.TODO(), dataImportCron)).To(Succeed())
+	}
+
+	setPhase := func(reconciler *DataImportCronReconciler, name string, phase cdiv1.DataVolumePhase) {
+		dataVolume := getImport(reconciler, name)
+		dataVolume.Status.Phase = phase
+		Expect(reconciler.client.Update(context.TODO(), dataVolume)).To(Succeed())
+	}
+
+	It("Should poll the source digest from a pod in the namespace of the DataImportCron", func() {
+		dataImportCron := newDataImportCron("@daily")
+		dataImportCron.Spec.Template.Spec.Source.Registry.SecretRef = "creds"
+		dataImportCron.Spec.Template.Spec.Source.Registry.CertConfigMap = "certs"
+		reconciler := createDataImportCronReconciler(dataImportCron)
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: cronKey})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(getCron(reconciler).Status.LastExecutionTimestamp).To(BeNil())
+
+		pod := getPoller(reconciler)
+		Expect(pod).ToNot(BeNil())
+		Expect(metav1.IsControlledBy(pod, dataImportCron)).To(BeTrue())
+		Expect(pod.Labels[common.CDIComponentLabel]).To(Equal(common.SourceDigestPollerPodName))
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(pod.Spec.ActiveDeadlineSeconds).ToNot(BeNil())
+		Expect(pod.Spec.Containers[0].Image).To(Equal("test-importer"))
+		env := map[string]corev1.EnvVar{}
+		for _, envVar := range pod.Spec.Containers[0].Env {
+			env[envVar.Name] = envVar
+		}
+		Expect(env[common.ImporterPollDigest].Value).To(Equal("true"))
+		Expect(env[common.ImporterSource].Value).To(Equal(SourceRegistry))
+		Expect(env[common.ImporterEndpoint].Value).To(Equal("docker://quay.io/kubevirt/fedora:latest"))
+		Expect(env[common.ImporterAccessKeyID].ValueFrom.SecretKeyRef.Name).To(Equal("creds"))
+		Expect(env[common.ImporterCertDirVar].Value).To(Equal(common.ImporterCertDir))
+		Expect(pod.Spec.Volumes[0].ConfigMap.Name).To(Equal("certs"))
+
+		// the pod is watched, nothing happens until it completes
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: cronKey})
+		Expect(err).ToNot(HaveOccurred())
+		dataVolumes := &cdiv1.DataVolumeList{}
+		Expect(reconciler.client.List(context.TODO(), dataVolumes)).To(Succeed())
+		Expect(dataVolumes.Items).To(BeEmpty())
+	})
+
+	It("Should not poll a registry source pinned to a digest", func() {
+		dataImportCron := newDataImportCron("@daily")
+		dataImportCron.Spec.Template.Spec.Source.Registry.URL = "docker://quay.io/kubevirt/fedora@" + testDigest
+		reconciler := createDataImportCronReconciler(dataImportCron)
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: cronKey})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(getPoller(reconciler)).To(BeNil())
+		Expect(getImport(reconciler, dataImportCronDataVolumeName(dataImportCron, testDigest))).ToNot(BeNil())
+	})
+
+	It("Should import the source pinned to the digest and requeue at the next execution", func() {
+		reconciler := createDataImportCronReconciler(newDataImportCron("@daily"))
+		result := reconcileCron(reconciler)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(result.RequeueAfter).To(BeNumerically("<=", 24*time.Hour))
+
+		dataImportCron := getCron(reconciler)
+		name := dataImportCronDataVolumeName(dataImportCron, testDigest)
+		dataVolume := getImport(reconciler, name)
+		Expect(dataVolume).ToNot(BeNil())
+		Expect(dataVolume.Spec.Source.Registry.URL).To(Equal("docker://quay.io/kubevirt/fedora@" + testDigest))
+		Expect(dataVolume.Annotations[AnnSourceDigest]).To(Equal(testDigest))
+		Expect(dataVolume.Labels[LabelDataImportCron]).To(Equal("test-cron"))
+		Expect(dataVolume.Labels["app"]).To(Equal("fedora"))
+		Expect(metav1.IsControlledBy(dataVolume, dataImportCron)).To(BeTrue())
+		Expect(dataImportCron.Status.LastExecutionTimestamp).ToNot(BeNil())
+		Expect(dataImportCron.Status.CurrentImports).To(Equal([]cdiv1.DataImportCronImport{{DataVolumeName: name, Digest: testDigest}}))
+		Expect(dataImportCron.Status.LastImportedPVC).To(BeNil())
+		Expect(dataImportCron.Status.Conditions[0].Reason).To(Equal(importCronImportInProgress))
+		Expect(reconciler.recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring(ImportCronImportStarted)))
+	})
+
+	It("Should not poll the source before the schedule is due", func() {
+		reconciler := createDataImportCronReconciler(newDataImportCron("@daily"))
+		reconcileCron(reconciler)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: cronKey})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getPoller(reconciler)).To(BeNil())
+	})
+
+	It("Should point to the imported PVC once the import succeeds", func() {
+		reconciler := createDataImportCronReconciler(newDataImportCron("@daily"))
+		reconcileCron(reconciler)
+		name := dataImportCronDataVolumeName(getCron(reconciler), testDigest)
+		setPhase(reconciler, name, cdiv1.Succeeded)
+		reconcileCron(reconciler)
+
+		dataImportCron := getCron(reconciler)
+		Expect(dataImportCron.Status.LastImportedPVC).To(Equal(&cdiv1.DataVolumeSourcePVC{Namespace: metav1.NamespaceDefault, Name: name}))
+		Expect(dataImportCron.Status.LastImportedDigest).To(Equal(testDigest))
+		Expect(dataImportCron.Status.LastImportTimestamp).ToNot(BeNil())
+		Expect(dataImportCron.Status.CurrentImports).To(BeEmpty())
+		Expect(dataImportCron.Status.Conditions[0].Status).To(Equal(corev1.ConditionTrue))
+		Expect(dataImportCron.Status.Conditions[0].Reason).To(Equal(importCronUpToDate))
+	})
+
+	It("Should not import an unchanged digest again", func() {
+		reconciler := createDataImportCronReconciler(newDataImportCron("@daily"))
+		reconcileCron(reconciler)
+		pollAgain(reconciler)
+		reconcileCron(reconciler)
+		dataVolumes := &cdiv1.DataVolumeList{}
+		Expect(reconciler.client.List(context.TODO(), dataVolumes)).To(Succeed())
+		Expect(dataVolumes.Items).To(HaveLen(1))
+	})
+
+	It("Should keep pointing to the previous import while a new digest is imported", func() {
+		reconciler := createDataImportCronReconciler(newDataImportCron("@daily"))
+		reconcileCron(reconciler)
+		first := dataImportCronDataVolumeName(getCron(reconciler), testDigest)
+		setPhase(reconciler, first, cdiv1.Succeeded)
+
+		digest = "sha256:new"
+		pollAgain(reconciler)
+		reconcileCron(reconciler)
+		second := dataImportCronDataVolumeName(getCron(reconciler), "sha256:new")
+		Expect(getImport(reconciler, second)).ToNot(BeNil())
+		dataImportCron := getCron(reconciler)
+		Expect(dataImportCron.Status.LastImportedPVC.Name).To(Equal(first))
+		Expect(dataImportCron.Status.CurrentImports).To(Equal([]cdiv1.DataImportCronImport{{DataVolumeName: second, Digest: "sha256:new"}}))
+		Expect(dataImportCron.Status.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+
+		setPhase(reconciler, second, cdiv1.Succeeded)
+		reconcileCron(reconciler)
+		dataImportCron = getCron(reconciler)
+		Expect(dataImportCron.Status.LastImportedPVC.Name).To(Equal(second))
+		Expect(dataImportCron.Status.LastImportedDigest).To(Equal("sha256:new"))
+	})
+
+	It("Should point back to a previous import if the digest is moved back", func() {
+		reconciler := createDataImportCronReconciler(newDataImportCron("@daily"))
+		reconcileCron(reconciler)
+		first := dataImportCronDataVolumeName(getCron(reconciler), testDigest)
+		setPhase(reconciler, first, cdiv1.Succeeded)
+		digest = "sha256:new"
+		pollAgain(reconciler)
+		reconcileCron(reconciler)
+		second := dataImportCronDataVolumeName(getCron(reconciler), "sha256:new")
+		setPhase(reconciler, second, cdiv1.Succeeded)
+		reconcileCron(reconciler)
+		Expect(getCron(reconciler).Status.LastImportedPVC.Name).To(Equal(second))
+
+		digest = testDigest
+		pollAgain(reconciler)
+		reconcileCron(reconciler)
+		Expect(getCron(reconciler).Status.LastImportedPVC.Name).To(Equal(first))
+	})
+
+	It("Should garbage collect the imports older than the imports to keep unless used by a pod", func() {
+		dataImportCron := newDataImportCron("@daily")
+		keep := int32(1)
+		dataImportCron.Spec.ImportsToKeep = &keep
+		reconciler := createDataImportCronReconciler(dataImportCron)
+		dataImportCron = getCron(reconciler)
+
+		var names []string
+		for i := 0; i < 3; i++ {
+			digest := fmt.Sprintf("sha256:%d", i)
+			name := dataImportCronDataVolumeName(dataImportCron, digest)
+			dataVolume, err := newDataImportCronDataVolume(dataImportCron, name, digest, time.Now().Add(time.Duration(i-10)*time.Minute))
+			Expect(err).ToNot(HaveOccurred())
+			dataVolume.Status.Phase = cdiv1.Succeeded
+			Expect(reconciler.client.Create(context.TODO(), dataVolume)).To(Succeed())
+			names = append(names, name)
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "vm-pod", Namespace: metav1.NamespaceDefault},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{
+						Name: "disk",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: names[0]},
+						},
+					},
+				},
+			},
+		}
+		Expect(reconciler.client.Create(context.TODO(), pod)).To(Succeed())
+
+		// polls testDigest, which is imported and newer than the others
+		result := reconcileCron(reconciler)
+		newest := dataImportCronDataVolumeName(dataImportCron, testDigest)
+		Expect(getImport(reconciler, newest)).ToNot(BeNil())
+		Expect(getImport(reconciler, names[0])).ToNot(BeNil())
+		Expect(getImport(reconciler, names[1])).To(BeNil())
+		Expect(getImport(reconciler, names[2])).ToNot(BeNil())
+		Expect(getCron(reconciler).Status.LastImportedPVC.Name).To(Equal(names[2]))
+		Expect(result.RequeueAfter).To(Equal(importCronGarbageCollectRetryPeriod))
+
+		setPhase(reconciler, newest, cdiv1.Succeeded)
+		Expect(reconciler.client.Delete(context.TODO(), pod)).To(Succeed())
+		reconcileCron(reconciler)
+		Expect(getImport(reconciler, names[0])).To(BeNil())
+		Expect(getImport(reconciler, names[2])).To(BeNil())
+		Expect(getImport(reconciler, newest)).ToNot(BeNil())
+		Expect(getCron(reconciler).Status.LastImportedPVC.Name).To(Equal(newest))
+	})
+
+	It("Should key http imports by the digest in the import cache", func() {
+		digest = "etag:1234"
+		dataImportCron := newDataImportCron("@daily")
+		dataImportCron.Spec.Template.Spec.Source = cdiv1.DataVolumeSource{HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "http://example.com/fedora-latest.img"}}
+		reconciler := createDataImportCronReconciler(dataImportCron)
+		reconcileCron(reconciler)
+		dataVolume := getImport(reconciler, dataImportCronDataVolumeName(dataImportCron, "etag:1234"))
+		Expect(dataVolume.Spec.Source.HTTP.URL).To(Equal("http://example.com/fedora-latest.img"))
+		Expect(dataVolume.Annotations[AnnImportChecksum]).To(Equal("etag:1234"))
+	})
+
+	It("Should import every execution if the source has no digest", func() {
+		digest = ""
+		reconciler := createDataImportCronReconciler(newDataImportCron("@daily"))
+		reconcileCron(reconciler)
+		time.Sleep(time.Second)
+		pollAgain(reconciler)
+		reconcileCron(reconciler)
+		dataVolumes := &cdiv1.DataVolumeList{}
+		Expect(reconciler.client.List(context.TODO(), dataVolumes)).To(Succeed())
+		Expect(dataVolumes.Items).To(HaveLen(2))
+	})
+
+	It("Should retry polling the source when the poller pod fails", func() {
+		reconciler := createDataImportCronReconciler(newDataImportCron("@daily"))
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: cronKey})
+		Expect(err).ToNot(HaveOccurred())
+		completePoller(reconciler, corev1.PodFailed, 1, &util.TerminationMessage{Reason: "Unauthorized", Message: "registry unavailable"})
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: cronKey})
+		Expect(err).To(HaveOccurred())
+		Expect(getPoller(reconciler)).To(BeNil())
+		dataImportCron := getCron(reconciler)
+		Expect(dataImportCron.Status.LastExecutionTimestamp).To(BeNil())
+		Expect(dataImportCron.Status.Conditions[0].Reason).To(Equal(ImportCronPollFailed))
+		Expect(dataImportCron.Status.Conditions[0].Message).To(ContainSubstring("registry unavailable"))
+
+		reconcileCron(reconciler)
+		Expect(getImport(reconciler, dataImportCronDataVolumeName(dataImportCron, testDigest))).ToNot(BeNil())
+	})
+
+	It("Should report an invalid schedule", func() {
+		reconciler := createDataImportCronReconciler(newDataImportCron("every day"))
+		result := reconcileCron(reconciler)
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(getPoller(reconciler)).To(BeNil())
+		dataImportCron := getCron(reconciler)
+		Expect(dataImportCron.Status.Conditions[0].Reason).To(Equal(ImportCronInvalidSpec))
+	})
+
+	It("Should report a template without a registry or http source", func() {
+		dataImportCron := newDataImportCron("@daily")
+		dataImportCron.Spec.Template.Spec.Source = cdiv1.DataVolumeSource{Blank: &cdiv1.DataVolumeBlankImage{}}
+		reconciler := createDataImportCronReconciler(dataImportCron)
+		reconcileCron(reconciler)
+		Expect(getPoller(reconciler)).To(BeNil())
+		Expect(getCron(reconciler).Status.Conditions[0].Reason).To(Equal(ImportCronInvalidSpec))
+	})
+})
+
+func newDataImportCron(schedule string) *cdiv1.DataImportCron {
+	return &cdiv1.DataImportCron{
+		TypeMeta: metav1.TypeMeta{APIVersion: cdiv1.SchemeGroupVersion.String(), Kind: "DataImportCron"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cron",
+			Namespace: metav1.NamespaceDefault,
+			UID:       types.UID(metav1.NamespaceDefault + "-test-cron"),
+		},
+		Spec: cdiv1.DataImportCronSpec{
+			Schedule: schedule,
+			Template: cdiv1.DataVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "fedora"},
+				},
+				Spec: cdiv1.DataVolumeSpec{
+					Source: cdiv1.DataVolumeSource{
+						Registry: &cdiv1.DataVolumeSourceRegistry{
+							URL: "docker://quay.io/kubevirt/fedora:latest",
+						},
+					},
+					PVC: &corev1.PersistentVolumeClaimSpec{},
+				},
+			},
+		},
+	}
+}
+
+func createDataImportCronReconciler(objects ...runtime.Object) *DataImportCronReconciler {
+	objs := []runtime.Object{}
+	objs = append(objs, objects...)
+	objs = append(objs, MakeEmptyCDIConfigSpec(common.ConfigName))
+
+	s := scheme.Scheme
+	cdiv1.AddToScheme(s)
+
+	client := fake.NewFakeClientWithScheme(s, objs...)
+	return &DataImportCronReconciler{
+		client:         client,
+		uncachedClient: client,
+		recorder:       record.NewFakeRecorder(10),
+		log:            dicLog,
+		image:          "test-importer",
+		verbose:        "5",
+		pullPolicy:     string(corev1.PullIfNotPresent),
+	}
+}
@@ -32,6 +32,10 @@ const (
 	pvcPending      = "Pending"
 	claimLost       = "ClaimLost"
 	notFound        = "NotFound"
+
+	cloneStrategyChosen      = "StrategyChosen"
+	cloneStrategyFallback    = "FallbackStrategy"
+	cloneStrategyNotPossible = "StrategyNotPossible"
 )
 
 func findConditionByType(conditionType cdiv1.DataVolumeConditionType, conditions []cdiv1.DataVolumeCondition) *cdiv1.DataVolumeCondition {
@@ -18,12 +18,9 @@ package controller
 
 import (
 	"context"
-	"crypto/tls"
+	"crypto/rsa"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -54,6 +51,8 @@ import (
 
 	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
 	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/token"
+	progressutil "kubevirt.io/containerized-data-importer/pkg/util/progress"
 )
 
 const controllerAgentName = "datavolume-controller"
@@ -87,8 +86,22 @@ const (
 	SnapshotForSmartCloneCreated = "SnapshotForSmartCloneCreated"
 	// SmartClonePVCInProgress provides a const to indicate snapshot creation for smart-clone is in progress
 	SmartClonePVCInProgress = "SmartClonePVCInProgress"
+	// SmartCloneExpansionInProgress provides a const to indicate the PVC of a smart-clone is expanded to the requested size
+	SmartCloneExpansionInProgress = "SmartCloneExpansionInProgress"
+	// SmartCloneExpansionNotPossible provides a const to indicate the PVC of a smart-clone cannot be expanded to the requested size
+	SmartCloneExpansionNotPossible = "SmartCloneExpansionNotPossible"
+	// SmartCloneExpansionFailed provides a const to indicate the image on the expanded PVC of a smart-clone could not be resized
+	SmartCloneExpansionFailed = "SmartCloneExpansionFailed"
 	// SmartCloneSourceInUse provides a const to indicate a smart clone is being delayed becasuse the source is in use
 	SmartCloneSourceInUse = "SmartCloneSourceInUse"
+	// CloneStrategyChosen provides a const to indicate the clone strategy has been chosen
+	CloneStrategyChosen = "CloneStrategyChosen"
+	// CloneStrategyNotPossible provides a const to indicate the requested clone strategy is not possible
+	CloneStrategyNotPossible = "CloneStrategyNotPossible"
+	// ImportCacheHit provides a const to indicate the DataVolume is cloned from a populated import cache PVC
+	ImportCacheHit = "ImportCacheHit"
+	// ImportCacheMiss provides a const to indicate the DataVolume populates the import cache PVC it is cloned from
+	ImportCacheMiss = "ImportCacheMiss"
 	// CloneFailed provides a const to indicate clone has failed
 	CloneFailed = "CloneFailed"
 	// CloneSucceeded provides a const to indicate clone has succeeded
@@ -129,6 +142,20 @@ const (
 	MessageSmartCloneInProgress = "Creating snapshot for smart-clone is in progress (for pvc %s/%s)"
 	// MessageSmartClonePVCInProgress provides a const to form snapshot for smart-clone is in progress message
 	MessageSmartClonePVCInProgress = "Creating PVC for smart-clone is in progress (for pvc %s/%s)"
+	// MessageSmartCloneExpansionInProgress provides a const to form smart-clone expansion in progress message
+	MessageSmartCloneExpansionInProgress = "Expanding PVC %s restored from a snapshot to %s"
+	// MessageSmartCloneExpansionNotPossible provides a const to form smart-clone expansion not possible message
+	MessageSmartCloneExpansionNotPossible = "Storage class %s does not allow volume expansion, PVC %s keeps the size of the snapshot"
+	// MessageSmartCloneExpansionFailed provides a const to form smart-clone expansion failed message
+	MessageSmartCloneExpansionFailed = "Resizing the image on PVC %s failed: %s"
+	// MessageCloneStrategyChosen provides a const to form clone strategy chosen message
+	MessageCloneStrategyChosen = "Cloning with the %s strategy: %s"
+	// MessageCloneStrategyNotPossible provides a const to form requested clone strategy not possible message
+	MessageCloneStrategyNotPossible = "Cannot clone with the requested %s strategy: %s"
+	// MessageImportCacheHit provides a const to form import cache hit message
+	MessageImportCacheHit = "Cloning from import cache PVC %s/%s"
+	// MessageImportCacheMiss provides a const to form import cache miss message
+	MessageImportCacheMiss = "Populating import cache PVC %s/%s"
 	// MessageUploadScheduled provides a const to form upload is scheduled message
 	MessageUploadScheduled = "Upload into %s scheduled"
 	// MessageUploadReady provides a const to form upload is ready message
@@ -139,8 +166,6 @@ const (
 	MessageUploadSucceeded = "Successfully uploaded into %s"
 )
 
-var httpClient *http.Client
-
 // DataVolumeEvent reoresents event
 type DataVolumeEvent struct {
 	eventType string
@@ -150,12 +175,14 @@ type DataVolumeEvent struct {
 
 // DatavolumeReconciler members
 type DatavolumeReconciler struct {
-	client       client.Client
-	extClientSet extclientset.Interface
-	recorder     record.EventRecorder
-	scheme       *runtime.Scheme
-	log          logr.Logger
-	featureGates featuregates.FeatureGates
+	client         client.Client
+	extClientSet   extclientset.Interface
+	recorder       record.EventRecorder
+	scheme         *runtime.Scheme
+	log            logr.Logger
+	featureGates   featuregates.FeatureGates
+	tokenValidator token.Validator
+	progressStore  *progressutil.Store
 }
 
 func pvcIsPopulated(pvc *corev1.PersistentVolumeClaim, dv *cdiv1.DataVolume) bool {
@@ -163,16 +190,30 @@ func pvcIsPopulated(pvc *corev1.PersistentVolumeClaim, dv *cdiv1.DataVolume) boo
 	return ok && dvName == dv.Name
 }
 
+// getCloneSourcePVC returns the PVC the DataVolume is cloned from, the import cache PVC if the import is served by the
+// cache, nil if the DataVolume is not a clone
+func getCloneSourcePVC(dataVolume *cdiv1.DataVolume) *cdiv1.DataVolumeSourcePVC {
+	if dataVolume.Spec.Source.PVC != nil {
+		return dataVolume.Spec.Source.PVC
+	}
+	if importCache := dataVolume.Status.ImportCache; importCache != nil {
+		return &cdiv1.DataVolumeSourcePVC{Namespace: importCache.Namespace, Name: importCache.Name}
+	}
+	return nil
+}
+
 // NewDatavolumeController creates a new instance of the datavolume controller.
-func NewDatavolumeController(mgr manager.Manager, extClientSet extclientset.Interface, log logr.Logger) (controller.Controller, error) {
+func NewDatavolumeController(mgr manager.Manager, extClientSet extclientset.Interface, log logr.Logger, apiServerKey *rsa.PublicKey, progressStore *progressutil.Store) (controller.Controller, error) {
 	client := mgr.GetClient()
 	reconciler := &DatavolumeReconciler{
-		client:       client,
-		scheme:       mgr.GetScheme(),
-		extClientSet: extClientSet,
-		log:          log.WithName("datavolume-controller"),
-		recorder:     mgr.GetEventRecorderFor("datavolume-controller"),
-		featureGates: featuregates.NewFeatureGates(client),
+		client:         client,
+		scheme:         mgr.GetScheme(),
+		extClientSet:   extClientSet,
+		log:            log.WithName("datavolume-controller"),
+		recorder:       mgr.GetEventRecorderFor("datavolume-controller"),
+		featureGates:   featuregates.NewFeatureGates(client),
+		tokenValidator: newCloneTokenValidator(apiServerKey),
+		progressStore:  progressStore,
 	}
 	datavolumeController, err := controller.New("datavolume-controller", mgr, controller.Options{
 		Reconciler: reconciler,
@@ -255,29 +296,80 @@ func (r *DatavolumeReconciler) Reconcile(req reconcile.Request) (reconcile.Resul
 				return reconcile.Result{}, errors.Errorf(msg)
 			}
 		}
+		if !isPVCComplete(pvc) && syncPopulationAction(pvc, datavolume.Spec.Action) {
+			log.Info("Updating the population action of the PVC", "action", datavolume.Spec.Action)
+			if err := r.client.Update(context.TODO(), pvc); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
 	}
 
 	if !pvcExists {
-		snapshotClassName, err := r.getSnapshotClassForSmartClone(datavolume)
-		if err == nil {
-			r.log.V(3).Info("Smart-Clone via Snapshot is available with Volume Snapshot Class", "snapshotClassName", snapshotClassName)
-			if requeue, err := r.sourceInUse(datavolume); requeue || err != nil {
-				return reconcile.Result{Requeue: requeue}, err
+		cachePvc, err := r.reconcileImportCache(datavolume)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if cachePvc != nil && !isPVCComplete(cachePvc) {
+			return r.updateImportCacheStatusPhase(datavolume, cachePvc)
+		}
+
+		// the PVC spec of a DataVolume with a storage spec is rendered on a copy, the DataVolume spec can't change
+		pvcSpec, err := renderPvcSpec(r.client, datavolume)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		rendered := datavolume.DeepCopy()
+		rendered.Spec.PVC = pvcSpec
+
+		var newPvc *corev1.PersistentVolumeClaim
+		if getCloneSourcePVC(datavolume) != nil {
+			strategy, snapshotClassName, reason, fallback := r.selectCloneStrategy(rendered)
+			if strategy == "" {
+				// The requested strategy may become possible, e.g. when a snapshot class is created
+				return reconcile.Result{Requeue: true}, r.updateCloneStrategyNotPossible(datavolume, reason)
 			}
-			newSnapshot := newSnapshot(datavolume, snapshotClassName)
-			if err := r.client.Create(context.TODO(), newSnapshot); err != nil {
-				if k8serrors.IsAlreadyExists(err) {
-					return reconcile.Result{}, nil
-				}
+			if err := r.updateCloneStrategy(datavolume, strategy, reason, fallback); err != nil {
 				return reconcile.Result{}, err
 			}
-			return reconcile.Result{}, r.updateSmartCloneStatusPhase(cdiv1.SnapshotForSmartCloneInProgress, datavolume)
+			switch strategy {
+			case cdiv1.CloneStrategySnapshot:
+				r.log.V(3).Info("Smart-Clone via Snapshot is available with Volume Snapshot Class", "snapshotClassName", snapshotClassName)
+				if requeue, err := r.sourceInUse(datavolume); requeue || err != nil {
+					return reconcile.Result{Requeue: requeue}, err
+				}
+				newSnapshot := newSnapshot(datavolume, snapshotClassName)
+				if err := r.client.Create(context.TODO(), newSnapshot); err != nil {
+					if k8serrors.IsAlreadyExists(err) {
+						return reconcile.Result{}, nil
+					}
+					return reconcile.Result{}, err
+				}
+				return reconcile.Result{}, r.updateSmartCloneStatusPhase(cdiv1.SnapshotForSmartCloneInProgress, datavolume)
+			case cdiv1.CloneStrategyCsiClone:
+				log.Info("Creating PVC for datavolume with CSI volume cloning")
+				newPvc, err = newCsiClonePvc(rendered)
+			}
+		}
+		if newPvc == nil && err == nil {
+			log.Info("Creating PVC for datavolume")
+			newPvc, err = newPersistentVolumeClaim(rendered)
 		}
-		log.Info("Creating PVC for datavolume")
-		newPvc, err := newPersistentVolumeClaim(datavolume)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
+		if datavolume.Spec.Source.PVC == nil {
+			// clones copy the image of the source, only imported, uploaded and blank images are sized to the request
+			if err := inflateFilesystemRequest(r.client, newPvc); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if deadline := r.getDeadline(datavolume); deadline != nil && deadline.Duration > 0 {
+			newPvc.Annotations[AnnDeadline] = deadline.Duration.String()
+		}
+		syncPopulationAction(newPvc, datavolume.Spec.Action)
+		if err := setPodPlacementAnnotation(newPvc, datavolume.Spec.Placement); err != nil {
+			return reconcile.Result{}, err
+		}
 		if err := r.client.Create(context.TODO(), newPvc); err != nil {
 			return reconcile.Result{}, err
 		}
@@ -289,8 +381,222 @@ func (r *DatavolumeReconciler) Reconcile(req reconcile.Request) (reconcile.Resul
 	return r.reconcileDataVolumeStatus(datavolume, pvc)
 }
 
+// getDeadline returns the deadline of populating the DataVolume, the default of the CDIConfig if the DataVolume doesn't
+// set one
+func (r *DatavolumeReconciler) getDeadline(dataVolume *cdiv1.DataVolume) *metav1.Duration {
+	if dataVolume.Spec.Deadline != nil {
+		return dataVolume.Spec.Deadline
+	}
+	return GetDataVolumeDeadline(r.client)
+}
+
+// reconcileImportCache returns the import cache PVC of a DataVolume importing a cached source, nil if the source is not
+// cached. The cache PVC is created if it doesn't exist and recorded in the DataVolume status, with whether it was
+// already populated.
+func (r *DatavolumeReconciler) reconcileImportCache(dataVolume *cdiv1.DataVolume) (*corev1.PersistentVolumeClaim, error) {
+	importCache := GetImportCache(r.client)
+	key := importCacheKey(dataVolume)
+	if importCache == nil || key == "" {
+		if dataVolume.Status.ImportCache != nil {
+			// the cache was disabled before the PVC was created, import the source
+			dataVolume.Status.ImportCache = nil
+			return nil, r.client.Update(context.TODO(), dataVolume)
+		}
+		return nil, nil
+	}
+
+	cacheKey := types.NamespacedName{Namespace: importCache.Namespace, Name: importCachePvcName(key)}
+	cachePvc := &corev1.PersistentVolumeClaim{}
+	if err := r.client.Get(context.TODO(), cacheKey, cachePvc); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+		if cachePvc, err = newImportCachePvc(dataVolume, cacheKey.Namespace, key); err != nil {
+			return nil, err
+		}
+		r.log.V(1).Info("Creating import cache PVC", "pvc.Namespace", cachePvc.Namespace, "pvc.Name", cachePvc.Name)
+		if err := r.client.Create(context.TODO(), cachePvc); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return nil, err
+		}
+	}
+
+	populated := isPVCComplete(cachePvc)
+	if status := dataVolume.Status.ImportCache; status == nil || status.Namespace != cacheKey.Namespace || status.Name != cacheKey.Name {
+		dataVolume.Status.ImportCache = &cdiv1.DataVolumeImportCacheStatus{
+			Namespace: cacheKey.Namespace,
+			Name:      cacheKey.Name,
+			Hit:       populated,
+		}
+		if err := r.client.Update(context.TODO(), dataVolume); err != nil {
+			return nil, err
+		}
+		if populated {
+			r.recorder.Eventf(dataVolume, corev1.EventTypeNormal, ImportCacheHit, MessageImportCacheHit, cacheKey.Namespace, cacheKey.Name)
+		} else {
+			r.recorder.Eventf(dataVolume, corev1.EventTypeNormal, ImportCacheMiss, MessageImportCacheMiss, cacheKey.Namespace, cacheKey.Name)
+		}
+	}
+	if populated {
+		// the least recently used cache PVCs are evicted first
+		cachePvc.Annotations[AnnImportCacheLastUsed] = time.Now().UTC().Format(time.RFC3339)
+		if err := r.client.Update(context.TODO(), cachePvc); err != nil {
+			return nil, err
+		}
+	}
+	return cachePvc, nil
+}
+
+// updateImportCacheStatusPhase reports the import into the cache PVC in the status of a DataVolume waiting for it
+func (r *DatavolumeReconciler) updateImportCacheStatusPhase(dataVolume *cdiv1.DataVolume, cachePvc *corev1.PersistentVolumeClaim) (reconcile.Result, error) {
+	dataVolumeCopy := dataVolume.DeepCopy()
+	var event DataVolumeEvent
+
+	curPhase := dataVolumeCopy.Status.Phase
+	dataVolumeCopy.Status.Phase = cdiv1.ImportScheduled
+	r.updateImportStatusPhase(cachePvc, dataVolumeCopy, &event)
+
+	currentCond := make([]cdiv1.DataVolumeCondition, len(dataVolumeCopy.Status.Conditions))
+	copy(currentCond, dataVolumeCopy.Status.Conditions)
+	r.updateConditions(dataVolumeCopy, nil)
+	dataVolumeCopy.Status.Conditions = updateRunningCondition(dataVolumeCopy.Status.Conditions, cachePvc.Annotations)
+	if err := r.emitEvent(dataVolume, dataVolumeCopy, curPhase, currentCond, &event); err != nil {
+		return reconcile.Result{}, err
+	}
+	if isPVCFailed(cachePvc) {
+		// the failed cache PVC is evicted, the DataVolume stays failed like after failing to import the source itself
+		return reconcile.Result{}, nil
+	}
+	// the cache PVC isn't owned by the DataVolume, poll the import
+	return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
+}
+
+// selectCloneStrategy returns the first possible clone strategy in the order configured for the target storage class,
+// the volume snapshot class to use with the snapshot strategy, the reason the strategy was chosen and whether a
+// preferred strategy was skipped, in which case the reason lists the failed checks. Host-assisted clone is used if no
+// other strategy is possible. If the DataVolume requests a strategy only that strategy is checked, the strategy is
+// empty if it is not possible.
+func (r *DatavolumeReconciler) selectCloneStrategy(dataVolume *cdiv1.DataVolume) (cdiv1.CloneStrategy, string, string, bool) {
+	var storageClassName string
+	targetStorageClass, err := GetStorageClassByName(r.client, dataVolume.Spec.PVC.StorageClassName)
+	if err == nil && targetStorageClass != nil {
+		storageClassName = targetStorageClass.Name
+	}
+
+	strategies := GetCloneStrategies(r.client, storageClassName)
+	requested, ok := dataVolume.Annotations[AnnCloneStrategy]
+	if ok && requested != CloneStrategyAuto {
+		strategies = []cdiv1.CloneStrategy{cdiv1.CloneStrategy(requested)}
+	}
+
+	var reasons []string
+	for _, strategy := range strategies {
+		switch strategy {
+		case cdiv1.CloneStrategyCsiClone:
+			if err := r.validateCsiClone(dataVolume, targetStorageClass); err != nil {
+				reasons = append(reasons, fmt.Sprintf("%s: %v", strategy, err))
+				continue
+			}
+			if len(reasons) > 0 {
+				return strategy, "", strings.Join(reasons, ", "), true
+			}
+			return strategy, "", fmt.Sprintf("storage class %s supports CSI volume cloning", storageClassName), false
+		case cdiv1.CloneStrategySnapshot:
+			snapshotClassName, err := r.getSnapshotClassForSmartClone(dataVolume)
+			if err != nil {
+				reasons = append(reasons, fmt.Sprintf("%s: %v", strategy, err))
+				continue
+			}
+			if len(reasons) > 0 {
+				return strategy, snapshotClassName, strings.Join(reasons, ", "), true
+			}
+			return strategy, snapshotClassName, fmt.Sprintf("volume snapshot class %s matches the source storage class", snapshotClassName), false
+		case cdiv1.CloneStrategyHostAssisted:
+			if len(reasons) > 0 {
+				return strategy, "", strings.Join(reasons, ", "), true
+			}
+			if len(strategies) == 1 {
+				return strategy, "", "host-assisted clone is requested", false
+			}
+			return strategy, "", fmt.Sprintf("storage class %s is configured for host-assisted clone", storageClassName), false
+		default:
+			reasons = append(reasons, fmt.Sprintf("%s: unknown clone strategy", strategy))
+		}
+	}
+	if ok && requested != CloneStrategyAuto {
+		return "", "", strings.Join(reasons, ", "), false
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "no clone strategy configured")
+	}
+	return cdiv1.CloneStrategyHostAssisted, "", strings.Join(reasons, ", "), true
+}
+
+// validateCsiClone checks if the target PVC can be cloned from the source PVC by the CSI driver
+func (r *DatavolumeReconciler) validateCsiClone(dataVolume *cdiv1.DataVolume, targetStorageClass *storagev1.StorageClass) error {
+	if isCrossNamespaceClone(dataVolume) {
+		return errors.New("the source PVC is in another namespace")
+	}
+	if targetStorageClass == nil {
+		return errors.New("target PVC storage class not found")
+	}
+	sourcePvc := &corev1.PersistentVolumeClaim{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: dataVolume.Namespace, Name: getCloneSourcePVC(dataVolume).Name}, sourcePvc); err != nil {
+		return errors.New("source PVC not found")
+	}
+	if sourcePvc.Spec.StorageClassName == nil || *sourcePvc.Spec.StorageClassName != targetStorageClass.Name {
+		return errors.New("source PVC and target PVC belong to different storage classes")
+	}
+	if sourcePvc.Status.Phase != corev1.ClaimBound {
+		return errors.New("source PVC is not bound")
+	}
+	if err := ValidateSameVolumeMode(&sourcePvc.Spec, dataVolume.Spec.PVC); err != nil {
+		return err
+	}
+	return validateCanClone(r.client, &sourcePvc.Spec, dataVolume.Spec.PVC)
+}
+
+// updateCloneStrategy records the clone strategy and the reason it was chosen in the DataVolume status
+func (r *DatavolumeReconciler) updateCloneStrategy(dv *cdiv1.DataVolume, strategy cdiv1.CloneStrategy, reason string, fallback bool) error {
+	conditionReason := cloneStrategyChosen
+	if fallback {
+		conditionReason = cloneStrategyFallback
+	}
+	message := fmt.Sprintf(MessageCloneStrategyChosen, strategy, reason)
+	condition := findConditionByType(cdiv1.DataVolumeCloneStrategySelected, dv.Status.Conditions)
+	if dv.Status.CloneStrategy == strategy && dv.Status.CloneStrategyReason == reason &&
+		condition != nil && condition.Status == corev1.ConditionTrue && condition.Reason == conditionReason {
+		return nil
+	}
+	dv.Status.CloneStrategy = strategy
+	dv.Status.CloneStrategyReason = reason
+	dv.Status.Conditions = updateCondition(dv.Status.Conditions, cdiv1.DataVolumeCloneStrategySelected, corev1.ConditionTrue, message, conditionReason)
+	if err := r.client.Update(context.TODO(), dv); err != nil {
+		return err
+	}
+	r.recorder.Event(dv, corev1.EventTypeNormal, CloneStrategyChosen, message)
+	return nil
+}
+
+// updateCloneStrategyNotPossible records why the clone strategy requested by the DataVolume is not possible
+func (r *DatavolumeReconciler) updateCloneStrategyNotPossible(dv *cdiv1.DataVolume, reason string) error {
+	message := fmt.Sprintf(MessageCloneStrategyNotPossible, dv.Annotations[AnnCloneStrategy], reason)
+	condition := findConditionByType(cdiv1.DataVolumeCloneStrategySelected, dv.Status.Conditions)
+	if condition != nil && condition.Status == corev1.ConditionFalse && condition.Message == message {
+		return nil
+	}
+	dv.Status.CloneStrategy = ""
+	dv.Status.CloneStrategyReason = reason
+	dv.Status.Conditions = updateCondition(dv.Status.Conditions, cdiv1.DataVolumeCloneStrategySelected, corev1.ConditionFalse, message, cloneStrategyNotPossible)
+	if err := r.client.Update(context.TODO(), dv); err != nil {
+		return err
+	}
+	r.recorder.Event(dv, corev1.EventTypeWarning, CloneStrategyNotPossible, message)
+	return nil
+}
+
 func (r *DatavolumeReconciler) sourceInUse(dv *cdiv1.DataVolume) (bool, error) {
-	pods, err := getPodsUsingPVCs(r.client, dv.Spec.Source.PVC.Namespace, sets.NewString(dv.Spec.Source.PVC.Name), false)
+	sourcePVC := getCloneSourcePVC(dv)
+	pods, err := getPodsUsingPVCs(r.client, sourcePVC.Namespace, sets.NewString(sourcePVC.Name), false)
 	if err != nil {
 		return false, err
 	}
@@ -299,7 +605,7 @@ func (r *DatavolumeReconciler) sourceInUse(dv *cdiv1.DataVolume) (bool, error) {
 		r.log.V(1).Info("Cannot snapshot",
 			"namespace", dv.Namespace, "name", dv.Name, "pod namespace", pod.Namespace, "pod name", pod.Name)
 		r.recorder.Eventf(dv, corev1.EventTypeWarning, SmartCloneSourceInUse,
-			"pod %s/%s using PersistentVolumeClaim %s", pod.Namespace, pod.Name, dv.Spec.Source.PVC.Name)
+			"pod %s/%s using PersistentVolumeClaim %s", pod.Namespace, pod.Name, sourcePVC.Name)
 	}
 
 	return len(pods) > 0, nil
@@ -307,7 +613,7 @@ func (r *DatavolumeReconciler) sourceInUse(dv *cdiv1.DataVolume) (bool, error) {
 
 func (r *DatavolumeReconciler) getStorageClassBindingMode(dataVolume *cdiv1.DataVolume) (*storagev1.VolumeBindingMode, error) {
 	// Handle unspecified storage class name, fallback to default storage class
-	storageClass, err := r.getStorageClassByName(dataVolume.Spec.PVC.StorageClassName)
+	storageClass, err := GetStorageClassByName(r.client, GetDataVolumeStorageClassName(dataVolume))
 	if err != nil {
 		return nil, err
 	}
@@ -327,8 +633,8 @@ func (r *DatavolumeReconciler) reconcileProgressUpdate(datavolume *cdiv1.DataVol
 		datavolume.Status.Progress = "N/A"
 	}
 
-	if datavolume.Spec.Source.PVC != nil {
-		podNamespace = datavolume.Spec.Source.PVC.Namespace
+	if sourcePVC := getCloneSourcePVC(datavolume); sourcePVC != nil {
+		podNamespace = sourcePVC.Namespace
 	} else {
 		podNamespace = datavolume.Namespace
 	}
@@ -336,13 +642,16 @@ func (r *DatavolumeReconciler) reconcileProgressUpdate(datavolume *cdiv1.DataVol
 	if datavolume.Status.Phase == cdiv1.Succeeded || datavolume.Status.Phase == cdiv1.Failed {
 		// Data volume completed progress, or failed, either way stop queueing the data volume.
 		r.log.Info("Datavolume finished, no longer updating progress", "Namespace", datavolume.Namespace, "Name", datavolume.Name, "Phase", datavolume.Status.Phase)
+		r.progressStore.Delete(string(datavolume.UID))
 		return reconcile.Result{}, nil
 	}
 	pod, err := r.getPodFromPvc(podNamespace, pvcUID)
 	if err == nil {
-		if err := updateProgressUsingPod(datavolume, pod); err != nil {
-			return reconcile.Result{}, err
+		// only the pod populating the DataVolume may report its progress
+		if !r.progressStore.Track(string(datavolume.UID), pod.Status.PodIP) {
+			r.log.V(1).Info("Too many DataVolumes in progress, not tracking the progress reports", "Namespace", datavolume.Namespace, "Name", datavolume.Name)
 		}
+		updateProgressFromReport(datavolume, r.progressStore)
 	}
 	// We are not done yet, force a re-reconcile in 2 seconds to get an update.
 	return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
@@ -351,7 +660,8 @@ func (r *DatavolumeReconciler) reconcileProgressUpdate(datavolume *cdiv1.DataVol
 func (r *DatavolumeReconciler) getSnapshotClassForSmartClone(dataVolume *cdiv1.DataVolume) (string, error) {
 	// TODO: Figure out if this belongs somewhere else, seems like something for the smart clone controller.
 	// Check if clone is requested
-	if dataVolume.Spec.Source.PVC == nil {
+	sourcePVC := getCloneSourcePVC(dataVolume)
+	if sourcePVC == nil {
 		return "", errors.New("no source PVC provided")
 	}
 
@@ -362,21 +672,21 @@ func (r *DatavolumeReconciler) getSnapshotClassForSmartClone(dataVolume *cdiv1.D
 	}
 
 	// Find source PVC
-	sourcePvcNs := dataVolume.Spec.Source.PVC.Namespace
+	sourcePvcNs := sourcePVC.Namespace
 	if sourcePvcNs == "" {
 		sourcePvcNs = dataVolume.Namespace
 	}
 
 	pvc := &corev1.PersistentVolumeClaim{}
-	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: sourcePvcNs, Name: dataVolume.Spec.Source.PVC.Name}, pvc); err != nil {
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: sourcePvcNs, Name: sourcePVC.Name}, pvc); err != nil {
 		if k8serrors.IsNotFound(err) {
-			r.log.V(3).Info("Source PVC is missing", "source namespace", dataVolume.Spec.Source.PVC.Namespace, "source name", dataVolume.Spec.Source.PVC.Name)
+			r.log.V(3).Info("Source PVC is missing", "source namespace", sourcePVC.Namespace, "source name", sourcePVC.Name)
 		}
 		return "", errors.New("source PVC not found")
 	}
 
 	targetPvcStorageClassName := dataVolume.Spec.PVC.StorageClassName
-	targetStorageClass, err := r.getStorageClassByName(targetPvcStorageClassName)
+	targetStorageClass, err := GetStorageClassByName(r.client, targetPvcStorageClassName)
 	if err != nil {
 		return "", err
 	}
@@ -384,21 +694,10 @@ func (r *DatavolumeReconciler) getSnapshotClassForSmartClone(dataVolume *cdiv1.D
 		r.log.V(3).Info("Target PVC's Storage Class not found")
 		return "", errors.New("Target PVC storage class not found")
 	}
-	targetPvcStorageClassName = &targetStorageClass.Name
 	sourcePvcStorageClassName := pvc.Spec.StorageClassName
-
-	// Compare source and target storage classess
-	if *sourcePvcStorageClassName != *targetPvcStorageClassName {
-		r.log.V(3).Info("Source PVC and target PVC belong to different storage classes", "source storage class",
-			*sourcePvcStorageClassName, "target storage class", *targetPvcStorageClassName)
-		return "", errors.New("source PVC and target PVC belong to different storage classes")
-	}
-
-	// Compare source and target namespaces
-	if pvc.Namespace != dataVolume.Namespace {
-		r.log.V(3).Info("Source PVC and target PVC belong to different namespaces", "source namespace",
-			pvc.Namespace, "target namespace", dataVolume.Namespace)
-		return "", errors.New("source PVC and target PVC belong to different namespaces")
+	if sourcePvcStorageClassName == nil {
+		r.log.V(3).Info("Source PVC has no storage class")
+		return "", errors.New("source PVC has no storage class")
 	}
 
 	// Fetch the source storage class
@@ -408,6 +707,37 @@ func (r *DatavolumeReconciler) getSnapshotClassForSmartClone(dataVolume *cdiv1.D
 		return "", errors.New("unable to retrieve storage class, falling back to host assisted clone")
 	}
 
+	// A snapshot can be restored to any storage class of the same provisioner
+	if srcStorageClass.Provisioner != targetStorageClass.Provisioner {
+		r.log.V(3).Info("Source PVC and target PVC storage classes have different provisioners", "source storage class",
+			srcStorageClass.Name, "target storage class", targetStorageClass.Name)
+		return "", errors.New("source PVC and target PVC storage classes have different provisioners")
+	}
+
+	targetVolumeMode := corev1.PersistentVolumeFilesystem
+	if dataVolume.Spec.PVC.VolumeMode != nil {
+		targetVolumeMode = *dataVolume.Spec.PVC.VolumeMode
+	}
+	if getVolumeMode(pvc) != targetVolumeMode {
+		r.log.V(3).Info("Source PVC and target PVC have different volume modes")
+		return "", errors.New("source PVC and target PVC have different volume modes")
+	}
+
+	// Cloning across namespaces creates the snapshot in the source namespace on behalf of the user
+	if pvc.Namespace != dataVolume.Namespace && !isImportCacheClone(pvc, importCacheKey(dataVolume)) {
+		target := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        dataVolume.Name,
+				Namespace:   dataVolume.Namespace,
+				Annotations: dataVolume.Annotations,
+			},
+		}
+		if err := validateCloneToken(r.tokenValidator, pvc, target); err != nil {
+			r.log.V(3).Info("Clone token is not valid for cross namespace smart clone", "error", err)
+			return "", errors.Wrap(err, "cannot clone across namespaces with a snapshot")
+		}
+	}
+
 	// List the snapshot classes
 	scs := &snapshotv1.VolumeSnapshotClassList{}
 	if err := r.client.List(context.TODO(), scs); err != nil {
@@ -427,32 +757,6 @@ func (r *DatavolumeReconciler) getSnapshotClassForSmartClone(dataVolume *cdiv1.D
 	return "", errors.New("could not match snapshotter with storage class, falling back to host assisted clone")
 }
 
-// getStorageClassByName looks up the storage class based on the name. If no storage class is found returns nil
-func (r *DatavolumeReconciler) getStorageClassByName(name *string) (*storagev1.StorageClass, error) {
-	// look up storage class by name
-	if name == nil {
-		storageClasses := &storagev1.StorageClassList{}
-		if err := r.client.List(context.TODO(), storageClasses); err != nil {
-			r.log.V(3).Info("Unable to retrieve available storage classes")
-			return nil, errors.New("unable to retrieve storage classes")
-		}
-		for _, storageClass := range storageClasses.Items {
-			if storageClass.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" {
-				return &storageClass, nil
-			}
-		}
-	} else {
-		storageClass := &storagev1.StorageClass{}
-		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: *name}, storageClass); err != nil {
-			r.log.V(3).Info("Unable to retrieve storage class", "storage class name", *name)
-			return nil, errors.New("unable to retrieve storage class")
-		}
-		return storageClass, nil
-	}
-	// No storage class found, just return nil for storage class and let caller deal with it.
-	return nil, nil
-}
-
 func newSnapshot(dataVolume *cdiv1.DataVolume, snapshotClassName string) *snapshotv1.VolumeSnapshot {
 	annotations := make(map[string]string)
 	annotations[AnnSmartCloneRequest] = "true"
@@ -461,27 +765,33 @@ func newSnapshot(dataVolume *cdiv1.DataVolume, snapshotClassName string) *snapsh
 		common.CDILabelKey:       common.CDILabelValue,
 		common.CDIComponentLabel: common.SmartClonerCDILabel,
 	}
+	key := smartCloneSourceKey(dataVolume)
 	snapshot := &snapshotv1.VolumeSnapshot{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        dataVolume.Name,
-			Namespace:   dataVolume.Namespace,
+			Name:        key.Name,
+			Namespace:   key.Namespace,
 			Labels:      labels,
 			Annotations: annotations,
-			OwnerReferences: []metav1.OwnerReference{
-				*metav1.NewControllerRef(dataVolume, schema.GroupVersionKind{
-					Group:   cdiv1.SchemeGroupVersion.Group,
-					Version: cdiv1.SchemeGroupVersion.Version,
-					Kind:    "DataVolume",
-				}),
-			},
 		},
 		Spec: snapshotv1.VolumeSnapshotSpec{
 			Source: snapshotv1.VolumeSnapshotSource{
-				PersistentVolumeClaimName: &dataVolume.Spec.Source.PVC.Name,
+				PersistentVolumeClaimName: &getCloneSourcePVC(dataVolume).Name,
 			},
 			VolumeSnapshotClassName: &className,
 		},
 	}
+	// owner references can't cross namespaces
+	if isCrossNamespaceClone(dataVolume) {
+		annotations[AnnSmartCloneTarget] = dataVolume.Namespace + "/" + dataVolume.Name
+	} else {
+		snapshot.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(dataVolume, schema.GroupVersionKind{
+				Group:   cdiv1.SchemeGroupVersion.Group,
+				Version: cdiv1.SchemeGroupVersion.Version,
+				Kind:    "DataVolume",
+			}),
+		}
+	}
 	return snapshot
 }
 
@@ -527,7 +837,7 @@ func (r *DatavolumeReconciler) updateSmartCloneStatusPhase(phase cdiv1.DataVolum
 		dataVolumeCopy.Status.Phase = cdiv1.SnapshotForSmartCloneInProgress
 		event.eventType = corev1.EventTypeNormal
 		event.reason = SnapshotForSmartCloneInProgress
-		event.message = fmt.Sprintf(MessageSmartCloneInProgress, dataVolumeCopy.Spec.Source.PVC.Namespace, dataVolumeCopy.Spec.Source.PVC.Name)
+		event.message = fmt.Sprintf(MessageSmartCloneInProgress, getCloneSourcePVC(dataVolumeCopy).Namespace, getCloneSourcePVC(dataVolumeCopy).Name)
 	}
 
 	return r.emitEvent(dataVolume, dataVolumeCopy, curPhase, dataVolume.Status.Conditions, &event)
@@ -542,29 +852,38 @@ func (r *DatavolumeReconciler) updateCloneStatusPhase(pvc *corev1.PersistentVolu
 			dataVolumeCopy.Status.Phase = cdiv1.CloneScheduled
 			event.eventType = corev1.EventTypeNormal
 			event.reason = CloneScheduled
-			event.message = fmt.Sprintf(MessageCloneScheduled, dataVolumeCopy.Spec.Source.PVC.Namespace, dataVolumeCopy.Spec.Source.PVC.Name, pvc.Namespace, pvc.Name)
+			event.message = fmt.Sprintf(MessageCloneScheduled, getCloneSourcePVC(dataVolumeCopy).Namespace, getCloneSourcePVC(dataVolumeCopy).Name, pvc.Namespace, pvc.Name)
 		case string(corev1.PodRunning):
 			// TODO: Use a more generic In Progess, like maybe TransferInProgress.
 			dataVolumeCopy.Status.Phase = cdiv1.CloneInProgress
 			event.eventType = corev1.EventTypeNormal
 			event.reason = CloneInProgress
-			event.message = fmt.Sprintf(MessageCloneInProgress, dataVolumeCopy.Spec.Source.PVC.Namespace, dataVolumeCopy.Spec.Source.PVC.Name, pvc.Namespace, pvc.Name)
+			event.message = fmt.Sprintf(MessageCloneInProgress, getCloneSourcePVC(dataVolumeCopy).Namespace, getCloneSourcePVC(dataVolumeCopy).Name, pvc.Namespace, pvc.Name)
 		case string(corev1.PodFailed):
 			dataVolumeCopy.Status.Phase = cdiv1.Failed
 			event.eventType = corev1.EventTypeWarning
 			event.reason = CloneFailed
-			event.message = fmt.Sprintf(MessageCloneFailed, dataVolumeCopy.Spec.Source.PVC.Namespace, dataVolumeCopy.Spec.Source.PVC.Name, pvc.Namespace, pvc.Name)
+			event.message = fmt.Sprintf(MessageCloneFailed, getCloneSourcePVC(dataVolumeCopy).Namespace, getCloneSourcePVC(dataVolumeCopy).Name, pvc.Namespace, pvc.Name)
 		case string(corev1.PodSucceeded):
 			dataVolumeCopy.Status.Phase = cdiv1.Succeeded
 			dataVolumeCopy.Status.Progress = cdiv1.DataVolumeProgress("100.0%")
 			event.eventType = corev1.EventTypeNormal
 			event.reason = CloneSucceeded
-			event.message = fmt.Sprintf(MessageCloneSucceeded, dataVolumeCopy.Spec.Source.PVC.Namespace, dataVolumeCopy.Spec.Source.PVC.Name, pvc.Namespace, pvc.Name)
+			event.message = fmt.Sprintf(MessageCloneSucceeded, getCloneSourcePVC(dataVolumeCopy).Namespace, getCloneSourcePVC(dataVolumeCopy).Name, pvc.Namespace, pvc.Name)
 		}
 
 	}
 }
 
+// updateCSICloneStatusPhase completes a CSI volume clone, the CSI driver populated the PVC once it is bound
+func (r *DatavolumeReconciler) updateCSICloneStatusPhase(pvc *corev1.PersistentVolumeClaim, dataVolumeCopy *cdiv1.DataVolume, event *DataVolumeEvent) {
+	dataVolumeCopy.Status.Phase = cdiv1.Succeeded
+	dataVolumeCopy.Status.Progress = cdiv1.DataVolumeProgress("100.0%")
+	event.eventType = corev1.EventTypeNormal
+	event.reason = CloneSucceeded
+	event.message = fmt.Sprintf(MessageCloneSucceeded, pvc.Namespace, getCloneSourcePVC(dataVolumeCopy).Name, pvc.Namespace, pvc.Name)
+}
+
 func (r *DatavolumeReconciler) updateUploadStatusPhase(pvc *corev1.PersistentVolumeClaim, dataVolumeCopy *cdiv1.DataVolume, event *DataVolumeEvent) {
 	phase, ok := pvc.Annotations[AnnPodPhase]
 	if ok {
@@ -598,6 +917,14 @@ func (r *DatavolumeReconciler) updateUploadStatusPhase(pvc *corev1.PersistentVol
 	}
 }
 
+// updateCancelledStatusPhase reflects the cancel action of the DataVolume, the pods populating its PVC are removed
+func (r *DatavolumeReconciler) updateCancelledStatusPhase(pvc *corev1.PersistentVolumeClaim, dataVolumeCopy *cdiv1.DataVolume, event *DataVolumeEvent) {
+	dataVolumeCopy.Status.Phase = cdiv1.Cancelled
+	event.eventType = corev1.EventTypeNormal
+	event.reason = PopulationCancelled
+	event.message = fmt.Sprintf(MessagePopulationCancelled, pvc.Name)
+}
+
 func (r *DatavolumeReconciler) reconcileDataVolumeStatus(dataVolume *cdiv1.DataVolume, pvc *corev1.PersistentVolumeClaim) (reconcile.Result, error) {
 	dataVolumeCopy := dataVolume.DeepCopy()
 	var event DataVolumeEvent
@@ -643,6 +970,8 @@ func (r *DatavolumeReconciler) reconcileDataVolumeStatus(dataVolume *cdiv1.DataV
 				if honorWaitForFirstConsumerEnabled &&
 					*storageClassBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
 					dataVolumeCopy.Status.Phase = cdiv1.WaitForFirstConsumer
+				} else if _, ok := pvc.Annotations[AnnCSICloneRequest]; ok {
+					dataVolumeCopy.Status.Phase = cdiv1.CSICloneInProgress
 				} else {
 					dataVolumeCopy.Status.Phase = cdiv1.Pending
 				}
@@ -656,6 +985,11 @@ func (r *DatavolumeReconciler) reconcileDataVolumeStatus(dataVolume *cdiv1.DataV
 					dataVolumeCopy.Status.Phase = cdiv1.PVCBound
 				}
 
+				if _, ok := pvc.Annotations[AnnCSICloneRequest]; ok {
+					r.updateCSICloneStatusPhase(pvc, dataVolumeCopy, &event)
+					break
+				}
+
 				if pvcIsPopulated(pvc, dataVolumeCopy) {
 					if dataVolumeCopy.Annotations == nil {
 						dataVolumeCopy.Annotations = make(map[string]string)
@@ -691,6 +1025,9 @@ func (r *DatavolumeReconciler) reconcileDataVolumeStatus(dataVolume *cdiv1.DataV
 				}
 			}
 		}
+		if isPopulatedByPods(pvc) && isPopulationCancelled(pvc) {
+			r.updateCancelledStatusPhase(pvc, dataVolumeCopy, &event)
+		}
 		if i, err := strconv.Atoi(pvc.Annotations[AnnPodRestarts]); err == nil && i >= 0 {
 			dataVolumeCopy.Status.RestartCount = int32(i)
 		}
@@ -703,7 +1040,13 @@ func (r *DatavolumeReconciler) reconcileDataVolumeStatus(dataVolume *cdiv1.DataV
 	currentCond := make([]cdiv1.DataVolumeCondition, len(dataVolumeCopy.Status.Conditions))
 	copy(currentCond, dataVolumeCopy.Status.Conditions)
 	r.updateConditions(dataVolumeCopy, pvc)
-	return result, r.emitEvent(dataVolume, dataVolumeCopy, curPhase, currentCond, &event)
+	if err := r.emitEvent(dataVolume, dataVolumeCopy, curPhase, currentCond, &event); err != nil {
+		return result, err
+	}
+	if curPhase != dataVolumeCopy.Status.Phase {
+		observeDataVolumePhase(dataVolumeCopy, pvc)
+	}
+	return result, nil
 }
 
 func (r *DatavolumeReconciler) updateConditions(dataVolume *cdiv1.DataVolume, pvc *corev1.PersistentVolumeClaim) {
@@ -732,6 +1075,10 @@ func (r *DatavolumeReconciler) updateConditions(dataVolume *cdiv1.DataVolume, pv
 	dataVolume.Status.Conditions = updateBoundCondition(dataVolume.Status.Conditions, pvc)
 	dataVolume.Status.Conditions = updateReadyCondition(dataVolume.Status.Conditions, readyStatus, "", "")
 	dataVolume.Status.Conditions = updateRunningCondition(dataVolume.Status.Conditions, anno)
+	switch dataVolume.Status.Phase {
+	case cdiv1.Cancelled:
+		dataVolume.Status.Conditions = updateCondition(dataVolume.Status.Conditions, cdiv1.DataVolumeRunning, corev1.ConditionFalse, fmt.Sprintf(MessagePopulationCancelled, dataVolume.Name), PopulationCancelled)
+	}
 }
 
 func (r *DatavolumeReconciler) emitConditionEvent(dataVolume *cdiv1.DataVolume, originalCond []cdiv1.DataVolumeCondition) {
@@ -814,76 +1161,43 @@ func (r *DatavolumeReconciler) addOwnerRef(pvc *corev1.PersistentVolumeClaim, dv
 	return r.client.Update(context.TODO(), pvc)
 }
 
-func updateProgressUsingPod(dataVolumeCopy *cdiv1.DataVolume, pod *corev1.Pod) error {
-	httpClient := buildHTTPClient()
-	// Example value: import_progress{ownerUID="b856691e-1038-11e9-a5ab-525500d15501"} 13.45
-	var importRegExp = regexp.MustCompile("progress\\{ownerUID\\=\"" + string(dataVolumeCopy.UID) + "\"\\} (\\d{1,3}\\.?\\d*)")
-
-	port, err := getPodMetricsPort(pod)
-	if err == nil && pod.Status.PodIP != "" {
-		url := fmt.Sprintf("https://%s:%d/metrics", pod.Status.PodIP, port)
-		resp, err := httpClient.Get(url)
-		if err != nil {
-			if errConnectionRefused(err) {
-				return nil
-			}
-			return err
-		}
-		defer resp.Body.Close()
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-
-		match := importRegExp.FindStringSubmatch(string(body))
-		if match == nil {
-			// No match
-			return nil
-		}
-		if f, err := strconv.ParseFloat(match[1], 64); err == nil {
-			dataVolumeCopy.Status.Progress = cdiv1.DataVolumeProgress(fmt.Sprintf("%.2f%%", f))
-		}
-		return nil
+// updateProgressFromReport sets the transfer and progress of the DataVolume from the last progress report of the pod
+// populating it, the store only accepts the reports of the pod tracked for the DataVolume
+func updateProgressFromReport(dataVolumeCopy *cdiv1.DataVolume, store *progressutil.Store) {
+	report, ok := store.Get(string(dataVolumeCopy.UID))
+	if !ok {
+		return
 	}
-	return err
-}
-
-func errConnectionRefused(err error) bool {
-	return strings.Contains(err.Error(), "connection refused")
-}
-
-func getPodMetricsPort(pod *corev1.Pod) (int, error) {
-	for _, container := range pod.Spec.Containers {
-		for _, port := range container.Ports {
-			if port.Name == "metrics" {
-				return int(port.ContainerPort), nil
-			}
-		}
+	transfer := dataVolumeCopy.Status.Transfer
+	if transfer == nil {
+		transfer = &cdiv1.DataVolumeTransferStatus{}
+		dataVolumeCopy.Status.Transfer = transfer
+	}
+	if report.Phase != "" {
+		transfer.Phase = report.Phase
 	}
-	return 0, errors.New("Metrics port not found in pod")
+	transfer.Bytes = int64(report.Bytes)
+	transfer.TotalBytes = int64(report.Total)
+	// the percentage is meaningless without the total
+	if report.Total == 0 {
+		return
+	}
+	dataVolumeCopy.Status.Progress = cdiv1.DataVolumeProgress(fmt.Sprintf("%.2f%%", report.Progress))
 }
 
-// buildHTTPClient generates an http client that accepts any certificate, since we are using
-// it to get prometheus data it doesn't matter if someone can intercept the data. Once we have
-// a mechanism to properly sign the server, we can update this method to get a proper client.
-func buildHTTPClient() *http.Client {
-	if httpClient == nil {
-		defaultTransport := http.DefaultTransport.(*http.Transport)
-		// Create new Transport that ignores self-signed SSL
-		tr := &http.Transport{
-			Proxy:                 defaultTransport.Proxy,
-			DialContext:           defaultTransport.DialContext,
-			MaxIdleConns:          defaultTransport.MaxIdleConns,
-			IdleConnTimeout:       defaultTransport.IdleConnTimeout,
-			ExpectContinueTimeout: defaultTransport.ExpectContinueTimeout,
-			TLSHandshakeTimeout:   defaultTransport.TLSHandshakeTimeout,
-			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
-		}
-		httpClient = &http.Client{
-			Transport: tr,
-		}
+// newCsiClonePvc creates the PVC of a DataVolume cloned by the CSI driver from the source PVC
+func newCsiClonePvc(dataVolume *cdiv1.DataVolume) (*corev1.PersistentVolumeClaim, error) {
+	pvc, err := newPersistentVolumeClaim(dataVolume)
+	if err != nil {
+		return nil, err
 	}
-	return httpClient
+	delete(pvc.Annotations, AnnCloneRequest)
+	pvc.Annotations[AnnCSICloneRequest] = "true"
+	pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+		Kind: "PersistentVolumeClaim",
+		Name: getCloneSourcePVC(dataVolume).Name,
+	}
+	return pvc, nil
 }
 
 // newPersistentVolumeClaim creates a new PVC the DataVolume resource.
@@ -896,8 +1210,7 @@ func newPersistentVolumeClaim(dataVolume *cdiv1.DataVolume) (*corev1.PersistentV
 	}
 
 	if dataVolume.Spec.PVC == nil {
-		// TODO remove this requirement and dynamically generate
-		// PVC spec if not present on DataVolume
+		// the PVC spec of a storage spec is rendered by renderPvcSpec
 		return nil, errors.Errorf("datavolume.pvc field is required")
 	}
 
@@ -908,7 +1221,13 @@ func newPersistentVolumeClaim(dataVolume *cdiv1.DataVolume) (*corev1.PersistentV
 	}
 
 	annotations[AnnPodRestarts] = "0"
-	if dataVolume.Spec.Source.HTTP != nil {
+	if retryPolicy := dataVolume.Spec.RetryPolicy; retryPolicy != nil {
+		setRetryPolicyAnnotations(annotations, retryPolicy)
+	}
+	if importCache := dataVolume.Status.ImportCache; importCache != nil {
+		annotations[AnnImportCache] = importCacheKey(dataVolume)
+		annotations[AnnCloneRequest] = importCache.Namespace + "/" + importCache.Name
+	} else if dataVolume.Spec.Source.HTTP != nil {
 		annotations[AnnEndpoint] = dataVolume.Spec.Source.HTTP.URL
 		annotations[AnnSource] = SourceHTTP
 		if dataVolume.Spec.ContentType == cdiv1.DataVolumeArchive {
@@ -17,12 +17,12 @@ limitations under the License.
 package controller
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
@@ -38,6 +38,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	extfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -49,6 +50,8 @@ import (
 
 	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
 	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/importer"
+	progressutil "kubevirt.io/containerized-data-importer/pkg/util/progress"
 )
 
 var (
@@ -90,6 +93,30 @@ var _ = Describe("Datavolume controller reconcile loop", func() {
 		Expect(pvc.Name).To(Equal("test-dv"))
 	})
 
+	It("Should create a PVC with the rendered spec of a DV with a storage spec", func() {
+		dv := newImportDataVolume("test-dv")
+		dv.Spec.PVC = nil
+		dv.Spec.Storage = &cdiv1.StorageSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		}
+		reconciler = createDatavolumeReconciler(dv)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Spec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}))
+		size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("1Gi"))).To(Equal(0))
+		By("Leaving the DataVolume spec unchanged")
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Spec.PVC).To(BeNil())
+	})
+
 	It("Should pass annotation from DV to created a PVC on a DV", func() {
 		dv := newImportDataVolume("test-dv")
 		dv.SetAnnotations(make(map[string]string))
@@ -182,6 +209,88 @@ var _ = Describe("Datavolume controller reconcile loop", func() {
 		Expect(dv.Status.RestartCount).To(Equal(int32(2)))
 	})
 
+	It("Should pass the retry policy of the DV to the PVC and fail once the PVC failed", func() {
+		dv := newImportDataVolume("test-dv")
+		maxRetries := int32(3)
+		dv.Spec.RetryPolicy = &cdiv1.DataVolumeRetryPolicy{MaxRetries: &maxRetries}
+		reconciler = createDatavolumeReconciler(dv)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnRetryMaxRetries]).To(Equal("3"))
+		Expect(pvc.Annotations[AnnRetryBackoff]).To(Equal(defaultRetryBackoff.String()))
+
+		pvc.Status.Phase = corev1.ClaimBound
+		pvc.Annotations[AnnPodPhase] = string(corev1.PodFailed)
+		pvc.Annotations[AnnImportPod] = "importer-test-dv"
+		pvc.Annotations[AnnPodRestarts] = "3"
+		pvc.Annotations[AnnRunningCondition] = "false"
+		pvc.Annotations[AnnRunningConditionReason] = "Error"
+		pvc.Annotations[AnnRunningConditionMessage] = "Unable to process data: connection reset by peer"
+		err = reconciler.client.Update(context.TODO(), pvc)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.Phase).To(Equal(cdiv1.Failed))
+		Expect(dv.Status.RestartCount).To(Equal(int32(3)))
+		running := findConditionByType(cdiv1.DataVolumeRunning, dv.Status.Conditions)
+		Expect(running).ToNot(BeNil())
+		Expect(running.Status).To(Equal(corev1.ConditionFalse))
+		Expect(running.Reason).To(Equal("Error"))
+		Expect(running.Message).To(Equal("Unable to process data: connection reset by peer"))
+	})
+
+	DescribeTable("Should pass the deadline to the PVC", func(deadline, defaultDeadline *metav1.Duration, expected string) {
+		dv := newImportDataVolume("test-dv")
+		dv.Spec.Deadline = deadline
+		reconciler = createDatavolumeReconciler(dv)
+		setDataVolumeDeadline(reconciler, defaultDeadline)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnDeadline]).To(Equal(expected))
+	},
+		Entry("without a deadline", nil, nil, ""),
+		Entry("of the DataVolume", &metav1.Duration{Duration: time.Hour}, nil, "1h0m0s"),
+		Entry("of the DataVolume over the default", &metav1.Duration{Duration: time.Hour}, &metav1.Duration{Duration: 2 * time.Hour}, "1h0m0s"),
+		Entry("from the CDIConfig default", nil, &metav1.Duration{Duration: 2 * time.Hour}, "2h0m0s"),
+	)
+
+	It("Should stop populating the PVC of a cancelled DataVolume", func() {
+		dv := newImportDataVolume("test-dv")
+		dv.Spec.Action = cdiv1.DataVolumeCancel
+		reconciler = createDatavolumeReconciler(dv)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnPopulationAction]).To(Equal(string(cdiv1.DataVolumeCancel)))
+
+		pvc.Status.Phase = corev1.ClaimBound
+		pvc.Annotations[AnnPodPhase] = string(corev1.PodRunning)
+		err = reconciler.client.Update(context.TODO(), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.Phase).To(Equal(cdiv1.Cancelled))
+		running := findConditionByType(cdiv1.DataVolumeRunning, dv.Status.Conditions)
+		Expect(running).ToNot(BeNil())
+		Expect(running.Status).To(Equal(corev1.ConditionFalse))
+		Expect(running.Reason).To(Equal(PopulationCancelled))
+	})
+
 	It("Should error if a PVC with same name already exists that is not owned by us", func() {
 		reconciler = createDatavolumeReconciler(createPvc("test-dv", metav1.NamespaceDefault, map[string]string{}, nil), newImportDataVolume("test-dv"))
 		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
@@ -232,6 +341,200 @@ var _ = Describe("Datavolume controller reconcile loop", func() {
 		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(dv.Status.Phase).To(Equal(cdiv1.SnapshotForSmartCloneInProgress))
+		Expect(dv.Status.CloneStrategy).To(Equal(cdiv1.CloneStrategySnapshot))
+		Expect(dv.Status.CloneStrategyReason).To(ContainSubstring(expectedSnapshotClass))
+	})
+
+	It("Should create a snapshot in the source namespace if cloning across namespaces with a valid token", func() {
+		dv := newCloneDataVolumeWithPVCNS("test-dv", "source-ns")
+		dv.UID = "dv-uid"
+		scName := "testsc"
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &scName
+		pvc := createPvcInStorageClass("test", "source-ns", &scName, nil, nil, corev1.ClaimBound)
+		snapClass := createSnapshotClass("snap-class", nil, "csi-plugin")
+		reconciler := createDatavolumeReconciler(sc, dv, pvc, snapClass)
+		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
+		setValidCloneToken(reconciler, dv)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		By("Verifying the snapshot is in the source namespace")
+		snapshot := &snapshotv1.VolumeSnapshot{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "cdi-tmp-dv-uid", Namespace: "source-ns"}, snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshot.OwnerReferences).To(BeEmpty())
+		Expect(snapshot.Annotations[AnnSmartCloneTarget]).To(Equal("default/test-dv"))
+		Expect(*snapshot.Spec.Source.PersistentVolumeClaimName).To(Equal("test"))
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.Phase).To(Equal(cdiv1.SnapshotForSmartCloneInProgress))
+		Expect(dv.Status.CloneStrategy).To(Equal(cdiv1.CloneStrategySnapshot))
+	})
+
+	It("Should record the host-assisted strategy and reason if no snapshot class matches", func() {
+		dv := newCloneDataVolume("test-dv")
+		scName := "testsc"
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &scName
+		pvc := createPvcInStorageClass("test", metav1.NamespaceDefault, &scName, nil, nil, corev1.ClaimBound)
+		reconciler := createDatavolumeReconciler(sc, dv, pvc)
+		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.CloneStrategy).To(Equal(cdiv1.CloneStrategyHostAssisted))
+		Expect(dv.Status.CloneStrategyReason).To(ContainSubstring("could not match snapshotter with storage class"))
+		condition := findConditionByType(cdiv1.DataVolumeCloneStrategySelected, dv.Status.Conditions)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(cloneStrategyFallback))
+		Expect(condition.Message).To(ContainSubstring("could not match snapshotter with storage class"))
+		pvc = &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnCloneRequest]).To(Equal("default/test"))
+	})
+
+	It("Should use host-assisted clone if requested by the DataVolume even if a snapshot is possible", func() {
+		dv := newCloneDataVolume("test-dv")
+		dv.Annotations[AnnCloneStrategy] = string(cdiv1.CloneStrategyHostAssisted)
+		scName := "testsc"
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &scName
+		pvc := createPvcInStorageClass("test", metav1.NamespaceDefault, &scName, nil, nil, corev1.ClaimBound)
+		snapClass := createSnapshotClass("snap-class", nil, "csi-plugin")
+		reconciler := createDatavolumeReconciler(sc, dv, pvc, snapClass)
+		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.CloneStrategy).To(Equal(cdiv1.CloneStrategyHostAssisted))
+		condition := findConditionByType(cdiv1.DataVolumeCloneStrategySelected, dv.Status.Conditions)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(cloneStrategyChosen))
+		pvc = &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnCloneRequest]).To(Equal("default/test"))
+	})
+
+	It("Should not fall back to host-assisted clone if the snapshot strategy is requested and not possible", func() {
+		dv := newCloneDataVolume("test-dv")
+		dv.Annotations[AnnCloneStrategy] = string(cdiv1.CloneStrategySnapshot)
+		scName := "testsc"
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &scName
+		pvc := createPvcInStorageClass("test", metav1.NamespaceDefault, &scName, nil, nil, corev1.ClaimBound)
+		reconciler := createDatavolumeReconciler(sc, dv, pvc)
+		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Requeue).To(BeTrue())
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(dv.Status.CloneStrategy)).To(BeEmpty())
+		condition := findConditionByType(cdiv1.DataVolumeCloneStrategySelected, dv.Status.Conditions)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(cloneStrategyNotPossible))
+		Expect(condition.Message).To(ContainSubstring("could not match snapshotter with storage class"))
+		pvc = &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("Should create a PVC with the source PVC as data source if the storage class is configured for CSI volume cloning", func() {
+		dv := newCloneDataVolume("test-dv")
+		scName := "testsc"
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &scName
+		dv.Spec.PVC.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1G")}
+		pvc := createPvcInStorageClass("test", metav1.NamespaceDefault, &scName, nil, nil, corev1.ClaimBound)
+		snapClass := createSnapshotClass("snap-class", nil, "csi-plugin")
+		reconciler := createDatavolumeReconciler(sc, dv, pvc, snapClass)
+		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
+		setCloneStrategies(reconciler, scName, cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategySnapshot)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		pvc = &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Spec.DataSource).ToNot(BeNil())
+		Expect(pvc.Spec.DataSource.Kind).To(Equal("PersistentVolumeClaim"))
+		Expect(pvc.Spec.DataSource.Name).To(Equal("test"))
+		Expect(pvc.Annotations).To(HaveKey(AnnCSICloneRequest))
+		Expect(pvc.Annotations).ToNot(HaveKey(AnnCloneRequest))
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.CloneStrategy).To(Equal(cdiv1.CloneStrategyCsiClone))
+
+		By("Waiting for the CSI driver the DataVolume is in progress")
+		pvc.Status.Phase = corev1.ClaimPending
+		err = reconciler.client.Update(context.TODO(), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.Phase).To(Equal(cdiv1.CSICloneInProgress))
+
+		By("Binding the PVC the DataVolume succeeds")
+		pvc.Status.Phase = corev1.ClaimBound
+		err = reconciler.client.Update(context.TODO(), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.Phase).To(Equal(cdiv1.Succeeded))
+		Expect(string(dv.Status.Progress)).To(Equal("100.0%"))
+	})
+
+	It("Should fall back to the next strategy if the source and target storage classes differ with CSI volume cloning", func() {
+		dv := newCloneDataVolume("test-dv")
+		scName := "testsc"
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
+		sourceScName := "sourcesc"
+		sourceSc := createStorageClassWithProvisioner(sourceScName, nil, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &scName
+		dv.Spec.PVC.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1G")}
+		pvc := createPvcInStorageClass("test", metav1.NamespaceDefault, &sourceScName, nil, nil, corev1.ClaimBound)
+		reconciler := createDatavolumeReconciler(sc, sourceSc, dv, pvc)
+		setCloneStrategies(reconciler, scName, cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategyHostAssisted)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}})
+		Expect(err).ToNot(HaveOccurred())
+		dv = &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dv.Status.CloneStrategy).To(Equal(cdiv1.CloneStrategyHostAssisted))
+		Expect(dv.Status.CloneStrategyReason).To(ContainSubstring("csi-clone: source PVC and target PVC belong to different storage classes"))
+		pvc = &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Spec.DataSource).To(BeNil())
+		Expect(pvc.Annotations[AnnCloneRequest]).To(Equal("default/test"))
 	})
 
 	DescribeTable("Should NOT create a snapshot if source PVC mounted", func(podFunc func(*cdiv1.DataVolume) *corev1.Pod) {
@@ -268,6 +571,144 @@ var _ = Describe("Datavolume controller reconcile loop", func() {
 	)
 })
 
+var _ = Describe("Import cache", func() {
+	var (
+		reconciler *DatavolumeReconciler
+		dvKey      = types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}
+	)
+	AfterEach(func() {
+		if reconciler != nil {
+			close(reconciler.recorder.(*record.FakeRecorder).Events)
+			reconciler = nil
+		}
+	})
+
+	getDataVolume := func() *cdiv1.DataVolume {
+		dv := &cdiv1.DataVolume{}
+		err := reconciler.client.Get(context.TODO(), dvKey, dv)
+		Expect(err).ToNot(HaveOccurred())
+		return dv
+	}
+
+	getCachePvc := func(dv *cdiv1.DataVolume) *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: importCachePvcName(importCacheKey(dv)), Namespace: "cache-ns"}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		return pvc
+	}
+
+	setCachePodPhase := func(cachePvc *corev1.PersistentVolumeClaim, phase corev1.PodPhase) {
+		cachePvc.Annotations[AnnPodPhase] = string(phase)
+		err := reconciler.client.Update(context.TODO(), cachePvc)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("Should populate the cache PVC then clone the DataVolume from it", func() {
+		dv := newCachedImportDataVolume("test-dv")
+		reconciler = createDatavolumeReconciler(dv)
+		setImportCache(reconciler, "cache-ns")
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).ToNot(BeZero())
+
+		By("Checking the cache PVC imports the source")
+		cachePvc := getCachePvc(dv)
+		Expect(cachePvc.OwnerReferences).To(BeEmpty())
+		Expect(cachePvc.Labels[common.CDIComponentLabel]).To(Equal(common.ImportCacheCDILabel))
+		Expect(cachePvc.Annotations[AnnEndpoint]).To(Equal("http://example.com/data"))
+		Expect(cachePvc.Annotations[AnnImportCache]).To(Equal(importCacheKey(dv)))
+		err = reconciler.client.Get(context.TODO(), dvKey, &corev1.PersistentVolumeClaim{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		Expect(getDataVolume().Status.ImportCache).To(Equal(&cdiv1.DataVolumeImportCacheStatus{Namespace: "cache-ns", Name: cachePvc.Name, Hit: false}))
+		Expect(getDataVolume().Status.Phase).To(Equal(cdiv1.ImportScheduled))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(ImportCacheMiss))
+
+		By("Checking the DataVolume reports the import into the cache PVC")
+		setCachePodPhase(cachePvc, corev1.PodRunning)
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getDataVolume().Status.Phase).To(Equal(cdiv1.ImportInProgress))
+
+		By("Checking the DataVolume is cloned from the populated cache PVC")
+		setCachePodPhase(getCachePvc(dv), corev1.PodSucceeded)
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), dvKey, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnCloneRequest]).To(Equal("cache-ns/" + cachePvc.Name))
+		Expect(pvc.Annotations[AnnImportCache]).To(Equal(importCacheKey(dv)))
+		Expect(pvc.Annotations).ToNot(HaveKey(AnnEndpoint))
+		Expect(getDataVolume().Status.ImportCache.Hit).To(BeFalse())
+		Expect(getDataVolume().Status.CloneStrategy).To(Equal(cdiv1.CloneStrategyHostAssisted))
+		Expect(getCachePvc(dv).Annotations).To(HaveKey(AnnImportCacheLastUsed))
+	})
+
+	It("Should clone the DataVolume from a populated cache PVC", func() {
+		dv := newCachedImportDataVolume("test-dv")
+		cachePvc, err := newImportCachePvc(dv, "cache-ns", importCacheKey(dv))
+		Expect(err).ToNot(HaveOccurred())
+		cachePvc.Annotations[AnnPodPhase] = string(corev1.PodSucceeded)
+		reconciler = createDatavolumeReconciler(dv, cachePvc)
+		setImportCache(reconciler, "cache-ns")
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getDataVolume().Status.ImportCache).To(Equal(&cdiv1.DataVolumeImportCacheStatus{Namespace: "cache-ns", Name: cachePvc.Name, Hit: true}))
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), dvKey, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnCloneRequest]).To(Equal("cache-ns/" + cachePvc.Name))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(ImportCacheHit))
+	})
+
+	It("Should not cache a source requiring a secret", func() {
+		dv := newCachedImportDataVolume("test-dv")
+		dv.Spec.Source.HTTP.SecretRef = "secret"
+		reconciler = createDatavolumeReconciler(dv)
+		setImportCache(reconciler, "cache-ns")
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), dvKey, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnEndpoint]).To(Equal("http://example.com/data"))
+		Expect(getDataVolume().Status.ImportCache).To(BeNil())
+	})
+
+	It("Should not cache a source without a verified checksum", func() {
+		dv := newImportDataVolume("test-dv")
+		reconciler = createDatavolumeReconciler(dv)
+		setImportCache(reconciler, "cache-ns")
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), dvKey, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Annotations[AnnEndpoint]).To(Equal("http://example.com/data"))
+		Expect(getDataVolume().Status.ImportCache).To(BeNil())
+	})
+
+	It("Should fail the DataVolume if populating the cache PVC failed", func() {
+		dv := newCachedImportDataVolume("test-dv")
+		reconciler = createDatavolumeReconciler(dv)
+		setImportCache(reconciler, "cache-ns")
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		cachePvc := getCachePvc(dv)
+		markPVCFailed(cachePvc, importer.ReasonChecksumMismatch, "checksum mismatch")
+		err = reconciler.client.Update(context.TODO(), cachePvc)
+		Expect(err).ToNot(HaveOccurred())
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(getDataVolume().Status.Phase).To(Equal(cdiv1.Failed))
+		err = reconciler.client.Get(context.TODO(), dvKey, &corev1.PersistentVolumeClaim{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
 var _ = Describe("Reconcile Datavolume status", func() {
 	var (
 		reconciler *DatavolumeReconciler
@@ -511,7 +952,12 @@ var _ = Describe("Reconcile Datavolume status", func() {
 		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}, dv)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(dv.Status.Phase).To(Equal(expected))
-		Expect(len(dv.Status.Conditions)).To(Equal(3))
+		if dv.Spec.Source.PVC != nil {
+			Expect(len(dv.Status.Conditions)).To(Equal(4))
+			Expect(findConditionByType(cdiv1.DataVolumeCloneStrategySelected, dv.Status.Conditions)).ToNot(BeNil())
+		} else {
+			Expect(len(dv.Status.Conditions)).To(Equal(3))
+		}
 		boundCondition := findConditionByType(cdiv1.DataVolumeBound, dv.Status.Conditions)
 		Expect(boundCondition.Status).To(Equal(boundStatusByPVCPhase(pvcPhase)))
 		Expect(boundCondition.Message).To(Equal(boundMessageByPVCPhase(pvcPhase, "test-dv")))
@@ -659,43 +1105,99 @@ var _ = Describe("Smart clone", func() {
 		Expect(snapclass).To(BeEmpty())
 	})
 
-	It("Should not return storage class, if source SC and target SC do not match", func() {
+	It("Should not return storage class, if source SC and target SC have different provisioners", func() {
 		dv := newCloneDataVolume("test-dv")
 		targetSc := "testsc"
-		tsc := createStorageClass(targetSc, map[string]string{
+		tsc := createStorageClassWithProvisioner(targetSc, map[string]string{
 			AnnDefaultStorageClass: "true",
-		})
+		}, "csi-plugin")
 		dv.Spec.PVC.StorageClassName = &targetSc
 		sourceSc := "testsc2"
-		ssc := createStorageClass(sourceSc, map[string]string{
+		ssc := createStorageClassWithProvisioner(sourceSc, map[string]string{
 			AnnDefaultStorageClass: "true",
-		})
+		}, "csi-plugin2")
 		pvc := createPvcInStorageClass("test", metav1.NamespaceDefault, &sourceSc, nil, nil, corev1.ClaimBound)
 		reconciler := createDatavolumeReconciler(ssc, tsc, dv, pvc)
 		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
 		snapclass, err := reconciler.getSnapshotClassForSmartClone(dv)
 		Expect(err).To(HaveOccurred())
-		Expect(err.Error()).To(ContainSubstring("source PVC and target PVC belong to different storage classes"))
+		Expect(err.Error()).To(ContainSubstring("source PVC and target PVC storage classes have different provisioners"))
 		Expect(snapclass).To(BeEmpty())
 	})
 
-	It("Should not return storage class, if source NS and target NS do not match", func() {
+	It("Should return snapshot class, if source SC and target SC differ but have the same provisioner", func() {
+		dv := newCloneDataVolume("test-dv")
+		targetSc := "testsc"
+		tsc := createStorageClassWithProvisioner(targetSc, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &targetSc
+		sourceSc := "testsc2"
+		ssc := createStorageClassWithProvisioner(sourceSc, nil, "csi-plugin")
+		pvc := createPvcInStorageClass("test", metav1.NamespaceDefault, &sourceSc, nil, nil, corev1.ClaimBound)
+		snapClass := createSnapshotClass("snap-class", nil, "csi-plugin")
+		reconciler := createDatavolumeReconciler(ssc, tsc, dv, pvc, snapClass)
+		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
+		snapclass, err := reconciler.getSnapshotClassForSmartClone(dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapclass).To(Equal("snap-class"))
+	})
+
+	It("Should not return storage class, if source and target volume modes differ", func() {
 		dv := newCloneDataVolume("test-dv")
 		scName := "testsc"
-		sc := createStorageClass(scName, map[string]string{
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
 			AnnDefaultStorageClass: "true",
-		})
+		}, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &scName
+		blockMode := corev1.PersistentVolumeBlock
+		dv.Spec.PVC.VolumeMode = &blockMode
+		pvc := createPvcInStorageClass("test", metav1.NamespaceDefault, &scName, nil, nil, corev1.ClaimBound)
+		snapClass := createSnapshotClass("snap-class", nil, "csi-plugin")
+		reconciler := createDatavolumeReconciler(sc, dv, pvc, snapClass)
+		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
+		snapclass, err := reconciler.getSnapshotClassForSmartClone(dv)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("source PVC and target PVC have different volume modes"))
+		Expect(snapclass).To(BeEmpty())
+	})
+
+	It("Should not return storage class, if source NS and target NS do not match and the token is invalid", func() {
+		dv := newCloneDataVolume("test-dv")
+		scName := "testsc"
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
 		dv.Spec.PVC.StorageClassName = &scName
 		dv.Spec.Source.PVC.Namespace = "other-ns"
 		pvc := createPvcInStorageClass("test", "other-ns", &scName, nil, nil, corev1.ClaimBound)
-		reconciler := createDatavolumeReconciler(sc, dv, pvc)
+		snapClass := createSnapshotClass("snap-class", nil, "csi-plugin")
+		reconciler := createDatavolumeReconciler(sc, dv, pvc, snapClass)
 		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
 		snapclass, err := reconciler.getSnapshotClassForSmartClone(dv)
 		Expect(err).To(HaveOccurred())
-		Expect(err.Error()).To(ContainSubstring("source PVC and target PVC belong to different namespaces"))
+		Expect(err.Error()).To(ContainSubstring("cannot clone across namespaces with a snapshot"))
 		Expect(snapclass).To(BeEmpty())
 	})
 
+	It("Should return snapshot class, if source NS and target NS do not match and the token is valid", func() {
+		dv := newCloneDataVolume("test-dv")
+		scName := "testsc"
+		sc := createStorageClassWithProvisioner(scName, map[string]string{
+			AnnDefaultStorageClass: "true",
+		}, "csi-plugin")
+		dv.Spec.PVC.StorageClassName = &scName
+		dv.Spec.Source.PVC.Namespace = "other-ns"
+		pvc := createPvcInStorageClass("test", "other-ns", &scName, nil, nil, corev1.ClaimBound)
+		snapClass := createSnapshotClass("snap-class", nil, "csi-plugin")
+		reconciler := createDatavolumeReconciler(sc, dv, pvc, snapClass)
+		reconciler.extClientSet = extfake.NewSimpleClientset(createVolumeSnapshotContentCrd(), createVolumeSnapshotClassCrd(), createVolumeSnapshotCrd())
+		setValidCloneToken(reconciler, dv)
+		snapclass, err := reconciler.getSnapshotClassForSmartClone(dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapclass).To(Equal("snap-class"))
+	})
+
 	It("Should not return storage class, if storage class does not exist", func() {
 		dv := newCloneDataVolume("test-dv")
 		scName := "testsc"
@@ -803,69 +1305,114 @@ var _ = Describe("Get Pod from PVC", func() {
 
 var _ = Describe("Update Progress from pod", func() {
 	var (
-		pvc *corev1.PersistentVolumeClaim
-		pod *corev1.Pod
-		dv  *cdiv1.DataVolume
+		pvc   *corev1.PersistentVolumeClaim
+		pod   *corev1.Pod
+		dv    *cdiv1.DataVolume
+		store *progressutil.Store
 	)
 
 	BeforeEach(func() {
 		pvc = createPvc("test", metav1.NamespaceDefault, nil, nil)
 		pod = createImporterTestPod(pvc, "test", nil)
+		pod.Status.PodIP = "192.0.2.10"
 		dv = newImportDataVolume("test")
+		dv.SetUID("b856691e-1038-11e9-a5ab-525500d15501")
+		dv.Status.Progress = cdiv1.DataVolumeProgress("2.3%")
+		store = progressutil.NewStore()
 	})
 
-	It("Should return error, if no metrics port in pod", func() {
-		pod.Spec.Containers[0].Ports = nil
-		err := updateProgressUsingPod(dv, pod)
-		Expect(err).To(HaveOccurred())
-		Expect(err.Error()).To(ContainSubstring("Metrics port not found in pod"))
+	postReport := func(report progressutil.Report, remoteAddr string, expectedCode int) {
+		body, err := json.Marshal(report)
+		Expect(err).ToNot(HaveOccurred())
+		req := httptest.NewRequest(http.MethodPost, common.ProgressPath, bytes.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		store.ServeHTTP(rr, req)
+		Expect(rr.Code).To(Equal(expectedCode))
+	}
+
+	It("Should properly update progress from the report of the pod", func() {
+		Expect(store.Track(string(dv.UID), pod.Status.PodIP)).To(BeTrue())
+		postReport(progressutil.Report{OwnerUID: string(dv.UID), Phase: "TransferDataFile", Progress: 13.45, Bytes: 1345, Total: 10000}, "192.0.2.10:43210", http.StatusOK)
+		updateProgressFromReport(dv, store)
+		Expect(dv.Status.Progress).To(BeEquivalentTo("13.45%"))
+		Expect(dv.Status.Transfer).To(Equal(&cdiv1.DataVolumeTransferStatus{Phase: "TransferDataFile", Bytes: 1345, TotalBytes: 10000}))
 	})
 
-	It("Should not error, if no endpoint exists", func() {
-		pod.Spec.Containers[0].Ports[0].ContainerPort = 12345
-		pod.Status.PodIP = "127.0.0.1"
-		err := updateProgressUsingPod(dv, pod)
-		Expect(err).ToNot(HaveOccurred())
+	It("Should not update progress without a report", func() {
+		Expect(store.Track(string(dv.UID), pod.Status.PodIP)).To(BeTrue())
+		postReport(progressutil.Report{OwnerUID: "b856691e-1038-11e9-a5ab-55500d15501", Progress: 13.45, Bytes: 1345, Total: 10000}, "192.0.2.10:43210", http.StatusNotFound)
+		updateProgressFromReport(dv, store)
+		Expect(dv.Status.Progress).To(BeEquivalentTo("2.3%"))
+		Expect(dv.Status.Transfer).To(BeNil())
 	})
 
-	It("Should properly update progress if http endpoint returns matching data", func() {
-		dv.SetUID("b856691e-1038-11e9-a5ab-525500d15501")
-		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte(fmt.Sprintf("import_progress{ownerUID=\"%v\"} 13.45", dv.GetUID())))
-			w.WriteHeader(200)
-		}))
-		defer ts.Close()
-		ep, err := url.Parse(ts.URL)
-		Expect(err).ToNot(HaveOccurred())
-		port, err := strconv.Atoi(ep.Port())
-		Expect(err).ToNot(HaveOccurred())
-		pod.Spec.Containers[0].Ports[0].ContainerPort = int32(port)
-		pod.Status.PodIP = ep.Hostname()
-		err = updateProgressUsingPod(dv, pod)
-		Expect(err).ToNot(HaveOccurred())
-		Expect(dv.Status.Progress).To(BeEquivalentTo("13.45%"))
+	It("Should reject the report of another pod", func() {
+		Expect(store.Track(string(dv.UID), pod.Status.PodIP)).To(BeTrue())
+		postReport(progressutil.Report{OwnerUID: string(dv.UID), Progress: 13.45, Bytes: 1345, Total: 10000}, "192.0.2.20:43210", http.StatusForbidden)
+		updateProgressFromReport(dv, store)
+		Expect(dv.Status.Progress).To(BeEquivalentTo("2.3%"))
 	})
 
-	It("Should not change update progress if http endpoint returns no matching data", func() {
-		dv.SetUID("b856691e-1038-11e9-a5ab-525500d15501")
-		dv.Status.Progress = cdiv1.DataVolumeProgress("2.3%")
-		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte(fmt.Sprintf("import_progress{ownerUID=\"%v\"} 13.45", "b856691e-1038-11e9-a5ab-55500d15501")))
-			w.WriteHeader(200)
-		}))
-		defer ts.Close()
-		ep, err := url.Parse(ts.URL)
+	It("Should only update the phase and bytes from a report without a total", func() {
+		Expect(store.Track(string(dv.UID), pod.Status.PodIP)).To(BeTrue())
+		postReport(progressutil.Report{OwnerUID: string(dv.UID), Phase: "Convert", Bytes: 1345}, "192.0.2.10:43210", http.StatusOK)
+		updateProgressFromReport(dv, store)
+		Expect(dv.Status.Progress).To(BeEquivalentTo("2.3%"))
+		Expect(dv.Status.Transfer).To(Equal(&cdiv1.DataVolumeTransferStatus{Phase: "Convert", Bytes: 1345}))
+	})
+
+	It("Should track the pod populating the DataVolume and drop the report once the DataVolume finished", func() {
+		reconciler := createDatavolumeReconciler(dv, pvc, pod)
+		reconciler.progressStore = store
+		postReport(progressutil.Report{OwnerUID: string(dv.UID), Progress: 50, Bytes: 5000, Total: 10000}, "192.0.2.10:43210", http.StatusNotFound)
+		_, err := reconciler.reconcileProgressUpdate(dv, pvc.UID)
 		Expect(err).ToNot(HaveOccurred())
-		port, err := strconv.Atoi(ep.Port())
+		postReport(progressutil.Report{OwnerUID: string(dv.UID), Progress: 100, Bytes: 10000, Total: 10000}, "192.0.2.10:43210", http.StatusOK)
+		_, err = reconciler.reconcileProgressUpdate(dv, pvc.UID)
 		Expect(err).ToNot(HaveOccurred())
-		pod.Spec.Containers[0].Ports[0].ContainerPort = int32(port)
-		pod.Status.PodIP = ep.Hostname()
-		err = updateProgressUsingPod(dv, pod)
+		Expect(dv.Status.Progress).To(BeEquivalentTo("100.00%"))
+
+		dv.Status.Phase = cdiv1.Succeeded
+		_, err = reconciler.reconcileProgressUpdate(dv, pvc.UID)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(dv.Status.Progress).To(BeEquivalentTo("2.3%"))
+		_, ok := store.Get(string(dv.UID))
+		Expect(ok).To(BeFalse())
 	})
 })
 
+func setCloneStrategies(reconciler *DatavolumeReconciler, storageClassName string, strategies ...cdiv1.CloneStrategy) {
+	cdiConfig := &cdiv1.CDIConfig{}
+	err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiConfig)
+	Expect(err).ToNot(HaveOccurred())
+	cdiConfig.Spec.CloneStrategies = []cdiv1.StorageClassCloneStrategies{
+		{
+			StorageClass: storageClassName,
+			Strategies:   strategies,
+		},
+	}
+	err = reconciler.client.Update(context.TODO(), cdiConfig)
+	Expect(err).ToNot(HaveOccurred())
+}
+
+func setDataVolumeDeadline(reconciler *DatavolumeReconciler, deadline *metav1.Duration) {
+	cdiConfig := &cdiv1.CDIConfig{}
+	err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiConfig)
+	Expect(err).ToNot(HaveOccurred())
+	cdiConfig.Spec.DataVolumeDeadline = deadline
+	err = reconciler.client.Update(context.TODO(), cdiConfig)
+	Expect(err).ToNot(HaveOccurred())
+}
+
+func setImportCache(reconciler *DatavolumeReconciler, namespace string) {
+	cdiConfig := &cdiv1.CDIConfig{}
+	err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiConfig)
+	Expect(err).ToNot(HaveOccurred())
+	cdiConfig.Spec.ImportCache = &cdiv1.ImportCacheSpec{Namespace: namespace}
+	err = reconciler.client.Update(context.TODO(), cdiConfig)
+	Expect(err).ToNot(HaveOccurred())
+}
+
 func createDatavolumeReconciler(objects ...runtime.Object) *DatavolumeReconciler {
 	objs := []runtime.Object{}
 	objs = append(objs, objects...)
@@ -895,10 +1442,23 @@ func createDatavolumeReconciler(objects ...runtime.Object) *DatavolumeReconciler
 		recorder:     rec,
 		extClientSet: extfakeclientset,
 		featureGates: featuregates.NewFeatureGates(cl),
+		tokenValidator: &FakeValidator{
+			Params: make(map[string]string, 0),
+		},
+		progressStore: progressutil.NewStore(),
 	}
 	return r
 }
 
+func setValidCloneToken(r *DatavolumeReconciler, dv *cdiv1.DataVolume) {
+	validator := r.tokenValidator.(*FakeValidator)
+	validator.match = dv.Annotations[AnnCloneToken]
+	validator.Name = dv.Spec.Source.PVC.Name
+	validator.Namespace = dv.Spec.Source.PVC.Namespace
+	validator.Params["targetNamespace"] = dv.Namespace
+	validator.Params["targetName"] = dv.Name
+}
+
 func newImportDataVolume(name string) *cdiv1.DataVolume {
 	return &cdiv1.DataVolume{
 		TypeMeta: metav1.TypeMeta{APIVersion: cdiv1.SchemeGroupVersion.String()},
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// AnnDeadline is a PVC annotation with the maximum time populating the PVC may take, counted from its creation
+	AnnDeadline = AnnAPIGroup + "/storage.deadline"
+
+	// DeadlineExceeded provides a const to indicate populating a PVC didn't complete before its deadline
+	DeadlineExceeded = "DeadlineExceeded"
+	// MessageDeadlineExceeded provides a const to form the deadline exceeded event and condition message
+	MessageDeadlineExceeded = "Populating PVC %s did not complete within %s"
+)
+
+// deadlineRemaining returns the time left to populate the PVC, false if it has no deadline
+func deadlineRemaining(pvc *corev1.PersistentVolumeClaim) (time.Duration, bool) {
+	deadline, err := time.ParseDuration(pvc.Annotations[AnnDeadline])
+	if err != nil || deadline <= 0 {
+		return 0, false
+	}
+	return time.Until(pvc.CreationTimestamp.Add(deadline)), true
+}
+
+// isDeadlineExceeded returns true if the deadline of populating the PVC passed
+func isDeadlineExceeded(pvc *corev1.PersistentVolumeClaim) bool {
+	remaining, ok := deadlineRemaining(pvc)
+	return ok && remaining <= 0
+}
+
+// failPVCIfDeadlineExceeded marks the PVC failed if its deadline passed before it was populated or cancelled, it
+// returns true if the PVC has to be updated and the pods populating it terminated
+func failPVCIfDeadlineExceeded(pvc *corev1.PersistentVolumeClaim, recorder record.EventRecorder) bool {
+	if !isDeadlineExceeded(pvc) || isPVCComplete(pvc) || podPhaseFromPVC(pvc) == corev1.PodFailed || isPopulationCancelled(pvc) {
+		return false
+	}
+	message := fmt.Sprintf(MessageDeadlineExceeded, pvc.Name, pvc.Annotations[AnnDeadline])
+	markPVCFailed(pvc, DeadlineExceeded, message)
+	recorder.Event(pvc, corev1.EventTypeWarning, DeadlineExceeded, message)
+	return true
+}
+
+// requeueAtDeadline makes sure the PVC is reconciled again when its deadline passes
+func requeueAtDeadline(pvc *corev1.PersistentVolumeClaim, result reconcile.Result) reconcile.Result {
+	remaining, ok := deadlineRemaining(pvc)
+	if !ok || remaining <= 0 || isPVCComplete(pvc) || isPVCFailed(pvc) || (result.Requeue && result.RequeueAfter == 0) {
+		return result
+	}
+	// the deadline is checked once it passed, a moment later so it surely did
+	remaining += time.Second
+	if result.RequeueAfter == 0 || result.RequeueAfter > remaining {
+		result.RequeueAfter = remaining
+	}
+	return result
+}
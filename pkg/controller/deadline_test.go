@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Deadline", func() {
+	newPvc := func(deadline string, age time.Duration, phase corev1.PodPhase) *corev1.PersistentVolumeClaim {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnDeadline: deadline, AnnPodPhase: string(phase)}, nil)
+		pvc.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+		return pvc
+	}
+
+	table.DescribeTable("Should requeue", func(pvc *corev1.PersistentVolumeClaim, result reconcile.Result, expected time.Duration) {
+		Expect(requeueAtDeadline(pvc, result).RequeueAfter).To(BeNumerically("~", expected, 2*time.Second))
+	},
+		table.Entry("at the deadline", newPvc("1h", 50*time.Minute, corev1.PodRunning), reconcile.Result{}, 10*time.Minute),
+		table.Entry("before the deadline if requested", newPvc("1h", 50*time.Minute, corev1.PodRunning), reconcile.Result{RequeueAfter: time.Minute}, time.Minute),
+		table.Entry("as requested without a deadline", newPvc("", 50*time.Minute, corev1.PodRunning), reconcile.Result{RequeueAfter: time.Minute}, time.Minute),
+		table.Entry("not at the deadline of a populated PVC", newPvc("1h", 50*time.Minute, corev1.PodSucceeded), reconcile.Result{}, time.Duration(0)),
+	)
+
+	It("Should fail a PVC once its deadline passed", func() {
+		recorder := record.NewFakeRecorder(10)
+		pvc := newPvc("1h", 50*time.Minute, corev1.PodRunning)
+		Expect(failPVCIfDeadlineExceeded(pvc, recorder)).To(BeFalse())
+		Expect(isPVCFailed(pvc)).To(BeFalse())
+		pvc = newPvc("1h", 2*time.Hour, corev1.PodRunning)
+		Expect(failPVCIfDeadlineExceeded(pvc, recorder)).To(BeTrue())
+		Expect(isPVCFailed(pvc)).To(BeTrue())
+		Expect(pvc.Annotations[AnnRunningConditionReason]).To(Equal(DeadlineExceeded))
+		By("Not failing it twice")
+		Expect(failPVCIfDeadlineExceeded(pvc, recorder)).To(BeFalse())
+	})
+
+	It("Should not fail a populated PVC", func() {
+		pvc := newPvc("1h", 2*time.Hour, corev1.PodSucceeded)
+		Expect(failPVCIfDeadlineExceeded(pvc, record.NewFakeRecorder(10))).To(BeFalse())
+	})
+})
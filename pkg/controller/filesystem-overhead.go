@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/util"
+)
+
+// defaultFilesystemOverhead is the overhead of storage classes not configured in the cdi config, it fits the metadata
+// of ext4 and xfs
+const defaultFilesystemOverhead = cdiv1.Percent("0.055")
+
+// GetFilesystemOverhead gets the fraction of the PVC reserved for filesystem metadata from the cdi config spec, the
+// overhead of the storage class of the PVC if listed, then the overhead of its storage profile, the global overhead
+// otherwise. Block PVCs have no overhead.
+func GetFilesystemOverhead(c client.Client, pvc *corev1.PersistentVolumeClaim) (cdiv1.Percent, error) {
+	if getVolumeMode(pvc) == corev1.PersistentVolumeBlock {
+		return "0", nil
+	}
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		return "", err
+	}
+	storageClassName := ""
+	if pvc.Spec.StorageClassName != nil {
+		storageClassName = *pvc.Spec.StorageClassName
+	} else if hasStorageClassOverhead(&cdiconfig.Spec) {
+		if storageClass, err := GetStorageClassByName(c, nil); err == nil && storageClass != nil {
+			// the PVC of a DataVolume gets the default storage class once it is created
+			storageClassName = storageClass.Name
+		}
+	}
+	return FilesystemOverheadForStorageClass(&cdiconfig.Spec, storageClassName), nil
+}
+
+// FilesystemOverheadForStorageClass returns the filesystem overhead of the filesystem PVCs of a storage class from the
+// cdi config spec
+func FilesystemOverheadForStorageClass(spec *cdiv1.CDIConfigSpec, storageClassName string) cdiv1.Percent {
+	overhead := spec.FilesystemOverhead
+	if hasStorageClassOverhead(spec) {
+		if overhead != nil {
+			if value, ok := overhead.StorageClass[storageClassName]; ok {
+				return value
+			}
+		}
+		if profile := GetStorageProfile(spec.StorageProfiles, storageClassName); profile != nil && profile.FilesystemOverhead != nil {
+			return *profile.FilesystemOverhead
+		}
+	}
+	if overhead != nil && overhead.Global != "" {
+		return overhead.Global
+	}
+	return defaultFilesystemOverhead
+}
+
+// hasStorageClassOverhead returns true if the overhead depends on the storage class
+func hasStorageClassOverhead(spec *cdiv1.CDIConfigSpec) bool {
+	return (spec.FilesystemOverhead != nil && len(spec.FilesystemOverhead.StorageClass) > 0) || len(spec.StorageProfiles) > 0
+}
+
+// MinCloneTargetSize returns the smallest size of a clone target the data of the source fits in: the size of the
+// source, larger if the filesystem overhead of the target exceeds the one of the source. The data of a filesystem
+// source is what its overhead leaves, and a filesystem target needs its own overhead on top of the data.
+func MinCloneTargetSize(sourceSize resource.Quantity, sourceOverhead, targetOverhead cdiv1.Percent) resource.Quantity {
+	source, target := util.ParseFilesystemOverhead(string(sourceOverhead)), util.ParseFilesystemOverhead(string(targetOverhead))
+	if target <= source {
+		return sourceSize.DeepCopy()
+	}
+	dataSize := float64(sourceSize.Value()) * (1 - source)
+	size := int64(math.Ceil(dataSize / (1 - target)))
+	return *resource.NewQuantity(size, sourceSize.Format)
+}
+
+// inflateFilesystemRequest makes the storage request of a filesystem PVC larger by its filesystem overhead when the
+// cdi config asks for it, so the image fits the original request
+func inflateFilesystemRequest(c client.Client, pvc *corev1.PersistentVolumeClaim) error {
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		return err
+	}
+	if cdiconfig.Spec.FilesystemOverhead == nil || !cdiconfig.Spec.FilesystemOverhead.InflateRequests {
+		return nil
+	}
+	requestedSize, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if !ok || requestedSize.IsZero() {
+		return nil
+	}
+	value, err := GetFilesystemOverhead(c, pvc)
+	if err != nil {
+		return err
+	}
+	overhead := util.ParseFilesystemOverhead(string(value))
+	if overhead == 0 {
+		return nil
+	}
+	size := int64(math.Ceil(float64(requestedSize.Value()) / (1 - overhead)))
+	// the requests may be shared with the DataVolume
+	pvc.Spec.Resources = *pvc.Spec.Resources.DeepCopy()
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = *resource.NewQuantity(size, requestedSize.Format)
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+var _ = Describe("Filesystem overhead", func() {
+	newConfig := func(overhead *cdiv1.FilesystemOverhead) *cdiv1.CDIConfig {
+		config := createCDIConfig(common.ConfigName)
+		config.Spec.FilesystemOverhead = overhead
+		return config
+	}
+
+	overhead := &cdiv1.FilesystemOverhead{
+		Global:       "0.1",
+		StorageClass: map[string]cdiv1.Percent{"local": "0.2"},
+	}
+
+	table.DescribeTable("Should get the overhead of the PVC", func(overhead *cdiv1.FilesystemOverhead, storageClassName string, volumeMode corev1.PersistentVolumeMode, expected cdiv1.Percent) {
+		pvc := createPvcInStorageClass("testPvc1", "default", &storageClassName, nil, nil, corev1.ClaimBound)
+		pvc.Spec.VolumeMode = &volumeMode
+		value, err := GetFilesystemOverhead(createClient(newConfig(overhead)), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal(expected))
+	},
+		table.Entry("default", nil, "local", corev1.PersistentVolumeFilesystem, defaultFilesystemOverhead),
+		table.Entry("global", overhead, "ceph", corev1.PersistentVolumeFilesystem, cdiv1.Percent("0.1")),
+		table.Entry("of the storage class", overhead, "local", corev1.PersistentVolumeFilesystem, cdiv1.Percent("0.2")),
+		table.Entry("of a block volume", overhead, "local", corev1.PersistentVolumeBlock, cdiv1.Percent("0")),
+	)
+
+	It("Should use the overhead of the default storage class if the PVC doesn't set one", func() {
+		pvc := createPvc("testPvc1", "default", nil, nil)
+		storageClass := createStorageClass("local", map[string]string{AnnDefaultStorageClass: "true"})
+		value, err := GetFilesystemOverhead(createClient(newConfig(overhead), storageClass), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal(cdiv1.Percent("0.2")))
+	})
+
+	It("Should use the overhead of the storage profile if the storage class isn't listed", func() {
+		pvc := createPvc("testPvc1", "default", nil, nil)
+		storageClassName := "ceph"
+		pvc.Spec.StorageClassName = &storageClassName
+		profileOverhead := cdiv1.Percent("0.3")
+		config := newConfig(overhead)
+		config.Spec.StorageProfiles = []cdiv1.StorageProfile{
+			{StorageClass: "local", FilesystemOverhead: &profileOverhead},
+			{StorageClass: "ceph", FilesystemOverhead: &profileOverhead},
+		}
+		value, err := GetFilesystemOverhead(createClient(config), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal(profileOverhead))
+
+		By("Preferring the overhead of the storage class")
+		storageClassName = "local"
+		value, err = GetFilesystemOverhead(createClient(config), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal(cdiv1.Percent("0.2")))
+	})
+
+	It("Should inflate the request of a filesystem PVC when asked to", func() {
+		pvc := createPvc("testPvc1", "default", nil, nil)
+		requests := pvc.Spec.Resources.Requests
+		Expect(inflateFilesystemRequest(createClient(newConfig(overhead)), pvc)).To(Succeed())
+		size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+
+		inflate := overhead.DeepCopy()
+		inflate.InflateRequests = true
+		Expect(inflateFilesystemRequest(createClient(newConfig(inflate)), pvc)).To(Succeed())
+		size = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Value()).To(Equal(int64(1111111112)))
+		By("Keeping the original requests")
+		size = requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+
+		By("Leaving block PVCs alone")
+		pvc = createPvc("testPvc1", "default", nil, nil)
+		volumeMode := corev1.PersistentVolumeBlock
+		pvc.Spec.VolumeMode = &volumeMode
+		Expect(inflateFilesystemRequest(createClient(newConfig(inflate)), pvc)).To(Succeed())
+		size = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+	})
+})
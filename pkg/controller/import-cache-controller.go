@@ -0,0 +1,328 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/importer"
+)
+
+const (
+	importCachePvcPrefix = "cdi-cache-"
+
+	// cache PVCs a DataVolume was cloned from recently are not evicted, the clone may not have started yet
+	importCacheInUseGracePeriod = 5 * time.Minute
+	// times a failed pod populating a cache PVC is recreated, unless the DataVolume has a retry policy
+	importCacheMaxRetries = 3
+)
+
+// ImportCacheReconciler members
+type ImportCacheReconciler struct {
+	client client.Client
+	log    logr.Logger
+}
+
+// NewImportCacheController creates a new instance of the import cache controller, evicting the import cache PVCs.
+func NewImportCacheController(mgr manager.Manager, log logr.Logger) (controller.Controller, error) {
+	reconciler := &ImportCacheReconciler{
+		client: mgr.GetClient(),
+		log:    log.WithName("import-cache-controller"),
+	}
+	importCacheController, err := controller.New("import-cache-controller", mgr, controller.Options{
+		Reconciler: reconciler,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := importCacheController.Watch(&source.Kind{Type: &corev1.PersistentVolumeClaim{}}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isImportCachePvc(e.Meta)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isImportCachePvc(e.MetaNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return isImportCachePvc(e.Meta)
+		},
+	}); err != nil {
+		return nil, err
+	}
+	return importCacheController, nil
+}
+
+func isImportCachePvc(obj metav1.Object) bool {
+	return obj.GetLabels()[common.CDIComponentLabel] == common.ImportCacheCDILabel
+}
+
+// Reconcile evicts the failed import cache PVCs and the PVCs older than the maximum age, then the least recently used
+// PVCs until the cache fits the maximum size.
+func (r *ImportCacheReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("PVC", req.NamespacedName)
+
+	importCache := GetImportCache(r.client)
+	if importCache == nil || importCache.Namespace != req.Namespace {
+		return reconcile.Result{}, nil
+	}
+
+	selector, err := labels.Parse(fmt.Sprintf("%s=%s", common.CDIComponentLabel, common.ImportCacheCDILabel))
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.client.List(context.TODO(), pvcs, &client.ListOptions{Namespace: importCache.Namespace, LabelSelector: selector}); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	now := time.Now()
+	var requeueAfter time.Duration
+	requeue := func(d time.Duration) {
+		if requeueAfter == 0 || d < requeueAfter {
+			requeueAfter = d
+		}
+	}
+
+	var cached []*corev1.PersistentVolumeClaim
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		if isPVCFailed(pvc) {
+			evicted, err := r.evict(log, pvc, now)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if evicted {
+				continue
+			}
+			requeue(importCacheInUseGracePeriod)
+		}
+		if importCache.MaxAge != nil {
+			expiry := pvc.CreationTimestamp.Add(importCache.MaxAge.Duration)
+			if !now.Before(expiry) {
+				evicted, err := r.evict(log, pvc, now)
+				if err != nil {
+					return reconcile.Result{}, err
+				}
+				if evicted {
+					continue
+				}
+				requeue(importCacheInUseGracePeriod)
+			} else {
+				requeue(expiry.Sub(now))
+			}
+		}
+		cached = append(cached, pvc)
+	}
+
+	if importCache.MaxSize != nil {
+		total := resource.Quantity{}
+		for _, pvc := range cached {
+			total.Add(pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+		}
+		sort.SliceStable(cached, func(i, j int) bool {
+			return importCacheLastUsed(cached[i]).Before(importCacheLastUsed(cached[j]))
+		})
+		for _, pvc := range cached {
+			if total.Cmp(*importCache.MaxSize) <= 0 {
+				break
+			}
+			evicted, err := r.evict(log, pvc, now)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if evicted {
+				total.Sub(pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+			}
+		}
+		if total.Cmp(*importCache.MaxSize) > 0 {
+			requeue(importCacheInUseGracePeriod)
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// evict deletes a cache PVC, unless it is being populated or a DataVolume is being cloned from it. A failed cache PVC
+// is deleted once its pods terminated.
+func (r *ImportCacheReconciler) evict(log logr.Logger, pvc *corev1.PersistentVolumeClaim, now time.Time) (bool, error) {
+	if !isPVCFailed(pvc) && (!isPVCComplete(pvc) || now.Sub(importCacheLastUsed(pvc)) < importCacheInUseGracePeriod) {
+		return false, nil
+	}
+	inUse, err := r.isUsedByRunningPod(pvc)
+	if err != nil || inUse {
+		return false, err
+	}
+	log.V(1).Info("Evicting import cache PVC", "pvc.Namespace", pvc.Namespace, "pvc.Name", pvc.Name)
+	if err := r.client.Delete(context.TODO(), pvc); err != nil && !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// isUsedByRunningPod returns true if a pod which didn't terminate uses the cache PVC, like the source pod of a clone
+func (r *ImportCacheReconciler) isUsedByRunningPod(pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	pods := &corev1.PodList{}
+	if err := r.client.List(context.TODO(), pods, &client.ListOptions{Namespace: pvc.Namespace}); err != nil {
+		return false, err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed && isPvcUsedByPod(pod, pvc.Name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// importCacheLastUsed returns when a DataVolume was last cloned from the cache PVC, or its creation time
+func importCacheLastUsed(pvc *corev1.PersistentVolumeClaim) time.Time {
+	if lastUsed, err := time.Parse(time.RFC3339, pvc.Annotations[AnnImportCacheLastUsed]); err == nil {
+		return lastUsed
+	}
+	return pvc.CreationTimestamp.Time
+}
+
+// importCacheSource returns the source type and url of the content a DataVolume imports into the import cache, and the
+// checksum the importer verifies it against. The cache PVC is shared by DataVolumes in all namespaces, so only content
+// identified by a digest the importer verifies is cached: http sources need a sha256 checksum, registry sources an
+// image digest, either in their url or as a sha256 checksum the url of the cache PVC is pinned to. Sources requiring a
+// secret or a certificate config map are not cached. The url is empty if the source is not cached.
+func importCacheSource(dataVolume *cdiv1.DataVolume) (string, string, string) {
+	source := dataVolume.Spec.Source
+	checksum := dataVolume.Annotations[AnnImportChecksum]
+	_, checksumErr := importer.ParseSha256Checksum(checksum)
+	switch {
+	case source.HTTP != nil:
+		if source.HTTP.SecretRef != "" || source.HTTP.CertConfigMap != "" || checksumErr != nil {
+			return "", "", ""
+		}
+		return SourceHTTP, source.HTTP.URL, checksum
+	case source.Registry != nil:
+		if source.Registry.SecretRef != "" || source.Registry.CertConfigMap != "" {
+			return "", "", ""
+		}
+		ref, err := parseRegistryURL(source.Registry.URL)
+		if err != nil {
+			return "", "", ""
+		}
+		// the image is pulled by its digest, which verifies it
+		if ref.digest != "" {
+			if _, err := importer.ParseSha256Checksum(ref.digest); err != nil {
+				return "", "", ""
+			}
+			return SourceRegistry, source.Registry.URL, ""
+		}
+		if checksumErr != nil {
+			return "", "", ""
+		}
+		return SourceRegistry, ref.withDigest(checksum), ""
+	}
+	return "", "", ""
+}
+
+// importCacheKey returns the import cache key of a DataVolume, empty if its source is not cached. DataVolumes
+// importing the same URL and checksum with the same content type, size and volume mode share a cache PVC.
+func importCacheKey(dataVolume *cdiv1.DataVolume) string {
+	sourceType, url, checksum := importCacheSource(dataVolume)
+	if url == "" || dataVolume.Spec.PVC == nil {
+		return ""
+	}
+	contentType := dataVolume.Spec.ContentType
+	if contentType == "" {
+		contentType = cdiv1.DataVolumeKubeVirt
+	}
+	volumeMode := corev1.PersistentVolumeFilesystem
+	if dataVolume.Spec.PVC.VolumeMode != nil {
+		volumeMode = *dataVolume.Spec.PVC.VolumeMode
+	}
+	size := dataVolume.Spec.PVC.Resources.Requests[corev1.ResourceStorage]
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s\n%s\n%s", sourceType, url, checksum, contentType, volumeMode, size.String())
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
+
+func importCachePvcName(key string) string {
+	return importCachePvcPrefix + key
+}
+
+// isImportCacheClone returns true if the source PVC is the import cache PVC of the key. DataVolumes importing a
+// cached source are cloned from the cache PVC without a clone token: they could import the source themselves, and the
+// cache PVC holds the content of the verified digest their key includes.
+func isImportCacheClone(source *corev1.PersistentVolumeClaim, key string) bool {
+	return key != "" && source.Labels[common.CDIComponentLabel] == common.ImportCacheCDILabel && source.Annotations[AnnImportCache] == key
+}
+
+// newImportCachePvc creates the cache PVC populated with the source of the DataVolume, it is not owned by the
+// DataVolume. Its pods are retried a few times unless the DataVolume has a retry policy, a failed cache PVC is evicted
+// and recreated by the next DataVolume importing the source.
+func newImportCachePvc(dataVolume *cdiv1.DataVolume, namespace, key string) (*corev1.PersistentVolumeClaim, error) {
+	cacheDataVolume := &cdiv1.DataVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        importCachePvcName(key),
+			Namespace:   namespace,
+			Annotations: map[string]string{},
+		},
+		Spec: *dataVolume.Spec.DeepCopy(),
+	}
+	_, url, checksum := importCacheSource(dataVolume)
+	if checksum != "" {
+		cacheDataVolume.Annotations[AnnImportChecksum] = checksum
+	}
+	if cacheDataVolume.Spec.Source.Registry != nil {
+		cacheDataVolume.Spec.Source.Registry.URL = url
+	}
+	if cacheDataVolume.Spec.RetryPolicy == nil {
+		maxRetries := int32(importCacheMaxRetries)
+		cacheDataVolume.Spec.RetryPolicy = &cdiv1.DataVolumeRetryPolicy{MaxRetries: &maxRetries}
+	}
+	pvc, err := newPersistentVolumeClaim(cacheDataVolume)
+	if err != nil {
+		return nil, err
+	}
+	pvc.OwnerReferences = nil
+	pvc.Labels[common.CDILabelKey] = common.CDILabelValue
+	pvc.Labels[common.CDIComponentLabel] = common.ImportCacheCDILabel
+	pvc.Annotations[AnnImportCache] = key
+	return pvc, nil
+}
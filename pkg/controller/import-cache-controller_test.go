@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/importer"
+)
+
+var (
+	icLog = logf.Log.WithName("import-cache-controller-test")
+)
+
+var _ = Describe("Import cache key", func() {
+	It("Should be the same for DataVolumes importing the same source", func() {
+		Expect(importCacheKey(newCachedImportDataVolume("dv1"))).To(Equal(importCacheKey(newCachedImportDataVolume("dv2"))))
+	})
+
+	It("Should differ by URL, checksum, size and volume mode", func() {
+		key := importCacheKey(newCachedImportDataVolume("test-dv"))
+		dv := newCachedImportDataVolume("test-dv")
+		dv.Spec.Source.HTTP.URL = "http://example.com/other"
+		Expect(importCacheKey(dv)).ToNot(Equal(key))
+		dv = newCachedImportDataVolume("test-dv")
+		dv.Annotations[AnnImportChecksum] = "sha256:" + strings.Repeat("cd", 32)
+		Expect(importCacheKey(dv)).ToNot(Equal(key))
+		dv = newCachedImportDataVolume("test-dv")
+		dv.Spec.PVC.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1G")}
+		Expect(importCacheKey(dv)).ToNot(Equal(key))
+		dv = newCachedImportDataVolume("test-dv")
+		blockMode := corev1.PersistentVolumeBlock
+		dv.Spec.PVC.VolumeMode = &blockMode
+		Expect(importCacheKey(dv)).ToNot(Equal(key))
+	})
+
+	It("Should be empty for sources that are not cached", func() {
+		dv := newCachedImportDataVolume("test-dv")
+		dv.Spec.Source.HTTP.CertConfigMap = "certs"
+		Expect(importCacheKey(dv)).To(BeEmpty())
+		Expect(importCacheKey(newS3ImportDataVolume("test-dv"))).To(BeEmpty())
+		Expect(importCacheKey(newCloneDataVolume("test-dv"))).To(BeEmpty())
+	})
+
+	table.DescribeTable("Should only cache content identified by a verified digest", func(source cdiv1.DataVolumeSource, checksum string, cached bool) {
+		dv := newImportDataVolume("test-dv")
+		dv.Spec.Source = source
+		if checksum != "" {
+			dv.Annotations = map[string]string{AnnImportChecksum: checksum}
+		}
+		Expect(importCacheKey(dv) != "").To(Equal(cached))
+	},
+		table.Entry("http with a sha256 checksum", cdiv1.DataVolumeSource{HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "http://example.com/data"}}, testImportChecksum, true),
+		table.Entry("http without a checksum", cdiv1.DataVolumeSource{HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "http://example.com/data"}}, "", false),
+		table.Entry("http with an etag", cdiv1.DataVolumeSource{HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "http://example.com/data"}}, "etag:\"1234\"", false),
+		table.Entry("http with an invalid sha256 checksum", cdiv1.DataVolumeSource{HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "http://example.com/data"}}, "sha256:1234", false),
+		table.Entry("registry pinned to a digest", cdiv1.DataVolumeSource{Registry: &cdiv1.DataVolumeSourceRegistry{URL: "docker://quay.io/image@" + testImportChecksum}}, "", true),
+		table.Entry("registry with a sha256 checksum", cdiv1.DataVolumeSource{Registry: &cdiv1.DataVolumeSourceRegistry{URL: "docker://quay.io/image:latest"}}, testImportChecksum, true),
+		table.Entry("registry with a tag", cdiv1.DataVolumeSource{Registry: &cdiv1.DataVolumeSourceRegistry{URL: "docker://quay.io/image:latest"}}, "", false),
+	)
+
+	It("Should verify the content of the cache PVC", func() {
+		dv := newCachedImportDataVolume("test-dv")
+		cachePvc, err := newImportCachePvc(dv, "cache-ns", importCacheKey(dv))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cachePvc.Annotations[AnnImportChecksum]).To(Equal(testImportChecksum))
+		Expect(cachePvc.Annotations[AnnRetryMaxRetries]).To(Equal(strconv.Itoa(importCacheMaxRetries)))
+
+		dv = newImportDataVolume("test-dv")
+		dv.Annotations = map[string]string{AnnImportChecksum: testImportChecksum}
+		dv.Spec.Source = cdiv1.DataVolumeSource{Registry: &cdiv1.DataVolumeSourceRegistry{URL: "docker://quay.io/image:latest"}}
+		cachePvc, err = newImportCachePvc(dv, "cache-ns", importCacheKey(dv))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cachePvc.Annotations[AnnEndpoint]).To(Equal("docker://quay.io/image@" + testImportChecksum))
+	})
+
+	It("Should allow cloning the cache PVC of the key only", func() {
+		dv := newCachedImportDataVolume("test-dv")
+		cachePvc, err := newImportCachePvc(dv, "cache-ns", importCacheKey(dv))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isImportCacheClone(cachePvc, importCacheKey(dv))).To(BeTrue())
+		Expect(isImportCacheClone(cachePvc, "other")).To(BeFalse())
+		Expect(isImportCacheClone(cachePvc, "")).To(BeFalse())
+		Expect(isImportCacheClone(createPvc("test", "cache-ns", map[string]string{AnnImportCache: importCacheKey(dv)}, nil), importCacheKey(dv))).To(BeFalse())
+	})
+})
+
+var _ = Describe("Import cache eviction", func() {
+	var (
+		now = time.Now()
+	)
+
+	newCachePvc := func(name string, created, lastUsed time.Time, populated bool) *corev1.PersistentVolumeClaim {
+		pvc := createPvc(name, "cache-ns", map[string]string{}, map[string]string{common.CDIComponentLabel: common.ImportCacheCDILabel})
+		pvc.CreationTimestamp = metav1.NewTime(created)
+		if !lastUsed.IsZero() {
+			pvc.Annotations[AnnImportCacheLastUsed] = lastUsed.UTC().Format(time.RFC3339)
+		}
+		if populated {
+			pvc.Annotations[AnnPodPhase] = string(corev1.PodSucceeded)
+		}
+		return pvc
+	}
+
+	newFailedCachePvc := func(name string, created time.Time) *corev1.PersistentVolumeClaim {
+		pvc := newCachePvc(name, created, time.Time{}, false)
+		pvc.Annotations[AnnRetryBackoff] = defaultRetryBackoff.String()
+		markPVCFailed(pvc, importer.ReasonChecksumMismatch, "checksum mismatch")
+		return pvc
+	}
+
+	newPodUsing := func(name, claimName string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cache-ns"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{
+						Name: DataVolName,
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	exists := func(reconciler *ImportCacheReconciler, name string) bool {
+		err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "cache-ns"}, &corev1.PersistentVolumeClaim{})
+		if k8serrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return true
+	}
+
+	reconcileCache := func(reconciler *ImportCacheReconciler) reconcile.Result {
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "cache", Namespace: "cache-ns"}})
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	It("Should evict the cache PVCs older than the maximum age", func() {
+		reconciler := createImportCacheReconciler(&cdiv1.ImportCacheSpec{Namespace: "cache-ns", MaxAge: &metav1.Duration{Duration: time.Hour}},
+			newCachePvc("expired", now.Add(-2*time.Hour), time.Time{}, true),
+			newCachePvc("recent", now.Add(-30*time.Minute), time.Time{}, true),
+			newCachePvc("populating", now.Add(-2*time.Hour), time.Time{}, false),
+			newCachePvc("just-used", now.Add(-2*time.Hour), now.Add(-time.Minute), true),
+		)
+		result := reconcileCache(reconciler)
+		Expect(exists(reconciler, "expired")).To(BeFalse())
+		Expect(exists(reconciler, "recent")).To(BeTrue())
+		Expect(exists(reconciler, "populating")).To(BeTrue())
+		Expect(exists(reconciler, "just-used")).To(BeTrue())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(result.RequeueAfter).To(BeNumerically("<=", 30*time.Minute))
+	})
+
+	It("Should evict failed cache PVCs", func() {
+		maxSize := resource.MustParse("2G")
+		reconciler := createImportCacheReconciler(&cdiv1.ImportCacheSpec{Namespace: "cache-ns", MaxSize: &maxSize},
+			newFailedCachePvc("failed", now),
+			newCachePvc("populating", now, time.Time{}, false),
+		)
+		reconcileCache(reconciler)
+		Expect(exists(reconciler, "failed")).To(BeFalse())
+		Expect(exists(reconciler, "populating")).To(BeTrue())
+	})
+
+	It("Should evict a cache PVC used by terminated pods only", func() {
+		reconciler := createImportCacheReconciler(&cdiv1.ImportCacheSpec{Namespace: "cache-ns"},
+			newFailedCachePvc("failed", now),
+			newPodUsing("importer-failed", "failed", corev1.PodFailed),
+			newFailedCachePvc("still-running", now),
+			newPodUsing("importer-still-running", "still-running", corev1.PodRunning),
+		)
+		result := reconcileCache(reconciler)
+		Expect(exists(reconciler, "failed")).To(BeFalse())
+		Expect(exists(reconciler, "still-running")).To(BeTrue())
+		Expect(result.RequeueAfter).To(Equal(importCacheInUseGracePeriod))
+	})
+
+	It("Should not evict a cache PVC used by a pod", func() {
+		pod := newPodUsing("source-pod", "expired", corev1.PodRunning)
+		reconciler := createImportCacheReconciler(&cdiv1.ImportCacheSpec{Namespace: "cache-ns", MaxAge: &metav1.Duration{Duration: time.Hour}},
+			newCachePvc("expired", now.Add(-2*time.Hour), time.Time{}, true), pod)
+		result := reconcileCache(reconciler)
+		Expect(exists(reconciler, "expired")).To(BeTrue())
+		Expect(result.RequeueAfter).To(Equal(importCacheInUseGracePeriod))
+	})
+
+	It("Should evict the least recently used cache PVCs above the maximum size", func() {
+		maxSize := resource.MustParse("2G")
+		reconciler := createImportCacheReconciler(&cdiv1.ImportCacheSpec{Namespace: "cache-ns", MaxSize: &maxSize},
+			newCachePvc("old", now.Add(-3*time.Hour), now.Add(-2*time.Hour), true),
+			newCachePvc("older", now.Add(-3*time.Hour), now.Add(-3*time.Hour), true),
+			newCachePvc("new", now.Add(-3*time.Hour), now.Add(-time.Hour), true),
+		)
+		reconcileCache(reconciler)
+		Expect(exists(reconciler, "older")).To(BeFalse())
+		Expect(exists(reconciler, "old")).To(BeTrue())
+		Expect(exists(reconciler, "new")).To(BeTrue())
+	})
+
+	It("Should not evict anything if the cache is not enabled", func() {
+		reconciler := createImportCacheReconciler(nil, newCachePvc("expired", now.Add(-2*time.Hour), time.Time{}, true))
+		reconcileCache(reconciler)
+		Expect(exists(reconciler, "expired")).To(BeTrue())
+	})
+})
+
+// testImportChecksum is a valid sha256 checksum, DataVolumes without a checksum the importer verifies are not cached
+var testImportChecksum = "sha256:" + strings.Repeat("ab", 32)
+
+func newCachedImportDataVolume(name string) *cdiv1.DataVolume {
+	dv := newImportDataVolume(name)
+	dv.Annotations = map[string]string{AnnImportChecksum: testImportChecksum}
+	return dv
+}
+
+func createImportCacheReconciler(importCache *cdiv1.ImportCacheSpec, objects ...runtime.Object) *ImportCacheReconciler {
+	objs := []runtime.Object{}
+	objs = append(objs, objects...)
+
+	s := scheme.Scheme
+	cdiv1.AddToScheme(s)
+
+	cdiConfig := MakeEmptyCDIConfigSpec(common.ConfigName)
+	cdiConfig.Spec.ImportCache = importCache
+	objs = append(objs, cdiConfig)
+
+	return &ImportCacheReconciler{
+		client: fake.NewFakeClientWithScheme(s, objs...),
+		log:    icLog,
+	}
+}
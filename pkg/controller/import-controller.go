@@ -40,6 +40,8 @@ const (
 	SourceGlance = "glance"
 	// SourceNone means there is no source.
 	SourceNone = "none"
+	// SourceResize means the image already on the PVC is resized to the requested size.
+	SourceResize = "resize"
 	// SourceRegistry is the source type of Registry
 	SourceRegistry = "registry"
 	// SourceImageio is the source type ovirt-imageio
@@ -90,8 +92,8 @@ type ImportReconciler struct {
 }
 
 type importPodEnvVar struct {
-	ep, secretName, source, contentType, imageSize, certConfigMap, diskID string
-	insecureTLS                                                           bool
+	ep, secretName, source, contentType, imageSize, certConfigMap, diskID, filesystemOverhead, checksum string
+	insecureTLS                                                                                         bool
 }
 
 // NewImportController creates a new instance of the import controller.
@@ -198,7 +200,33 @@ func (r *ImportReconciler) Reconcile(req reconcile.Request) (reconcile.Result, e
 		}
 		return reconcile.Result{}, nil
 	}
-	return r.reconcilePvc(pvc, log)
+	if failPVCIfDeadlineExceeded(pvc, r.recorder) {
+		log.V(1).Info("Import deadline exceeded, deleting pod", "deadline", pvc.Annotations[AnnDeadline])
+		return reconcile.Result{}, r.terminateImport(pvc, log)
+	}
+	if isPopulationCancelled(pvc) {
+		log.V(1).Info("Import cancelled, deleting pod")
+		return reconcile.Result{}, r.deleteImporterPod(pvc, log)
+	}
+	result, err := r.reconcilePvc(pvc, log)
+	return requeueAtDeadline(pvc, result), err
+}
+
+// terminateImport updates the PVC failed by its deadline and deletes the importer pod populating it
+func (r *ImportReconciler) terminateImport(pvc *corev1.PersistentVolumeClaim, log logr.Logger) error {
+	if err := r.updatePVC(pvc, log); err != nil {
+		return err
+	}
+	return r.deleteImporterPod(pvc, log)
+}
+
+// deleteImporterPod deletes the importer pod of the PVC, the scratch PVC owned by the pod goes with it
+func (r *ImportReconciler) deleteImporterPod(pvc *corev1.PersistentVolumeClaim, log logr.Logger) error {
+	pod, err := r.findImporterPod(pvc, log)
+	if err != nil || pod == nil || pod.DeletionTimestamp != nil {
+		return err
+	}
+	return IgnoreNotFound(r.client.Delete(context.TODO(), pod))
 }
 
 func (r *ImportReconciler) findImporterPod(pvc *corev1.PersistentVolumeClaim, log logr.Logger) (*corev1.Pod, error) {
@@ -229,8 +257,14 @@ func (r *ImportReconciler) reconcilePvc(pvc *corev1.PersistentVolumeClaim, log l
 		if isPVCComplete(pvc) {
 			// Don't create the POD if the PVC is completed already
 			log.V(1).Info("PVC is already complete")
+		} else if isPVCFailed(pvc) {
+			log.V(1).Info("PVC import failed, not retrying")
 		} else if pvc.DeletionTimestamp == nil {
 			if _, ok := pvc.Annotations[AnnImportPod]; ok {
+				if delay := retryDelay(pvc); delay > 0 {
+					log.V(1).Info("Waiting for the retry backoff to elapse", "delay", delay)
+					return reconcile.Result{RequeueAfter: delay}, nil
+				}
 				// Create importer pod, make sure the PVC owns it.
 				if err := r.createImporterPod(pvc); err != nil {
 					return reconcile.Result{}, err
@@ -251,6 +285,16 @@ func (r *ImportReconciler) reconcilePvc(pvc *corev1.PersistentVolumeClaim, log l
 			return reconcile.Result{}, nil
 		}
 
+		if isPVCFailed(pvc) {
+			// Keep the failed pod around for its logs
+			return reconcile.Result{}, nil
+		}
+		if hasRetryPolicy(pvc) {
+			if failure := getPodFailure(pod); failure != nil {
+				return r.retryFailedPod(pvc, pod, failure, log)
+			}
+		}
+
 		// Pod exists, we need to update the PVC status.
 		if err := r.updatePvcFromPod(pvc, pod, log); err != nil {
 			return reconcile.Result{}, err
@@ -259,6 +303,24 @@ func (r *ImportReconciler) reconcilePvc(pvc *corev1.PersistentVolumeClaim, log l
 	return reconcile.Result{}, nil
 }
 
+// retryFailedPod deletes the failed importer pod so it is recreated once the backoff elapsed, or fails the PVC if the
+// retry policy allows no more retries
+func (r *ImportReconciler) retryFailedPod(pvc *corev1.PersistentVolumeClaim, pod *corev1.Pod, failure *podFailure, log logr.Logger) (reconcile.Result, error) {
+	retry := retryFailedPod(pvc, failure, r.recorder)
+	if err := r.updatePVC(pvc, log); err != nil {
+		return reconcile.Result{}, err
+	}
+	if !retry {
+		log.V(1).Info("Import failed, not retrying", "pod.Name", pod.Name, "reason", failure.reason)
+		return reconcile.Result{}, nil
+	}
+	log.V(1).Info("Import failed, deleting pod to retry", "pod.Name", pod.Name, "reason", failure.reason, "retries", pvc.Annotations[AnnPodRestarts])
+	if err := r.client.Delete(context.TODO(), pod); IgnoreNotFound(err) != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: retryDelay(pvc)}, nil
+}
+
 func (r *ImportReconciler) initPvcPodName(pvc *corev1.PersistentVolumeClaim, log logr.Logger) error {
 	currentPvcCopy := pvc.DeepCopyObject()
 
@@ -288,18 +350,32 @@ func (r *ImportReconciler) updatePvcFromPod(pvc *corev1.PersistentVolumeClaim, p
 	log.V(1).Info("Updating PVC from pod")
 	anno := pvc.GetAnnotations()
 	setConditionFromPodWithPrefix(anno, AnnRunningCondition, pod)
+	failure := podTerminationFailure(pod)
+	if failure != nil {
+		// the importer reports why it failed, more useful than the waiting reason of the restarting pod
+		anno[AnnRunningConditionMessage] = failure.Message
+		anno[AnnRunningConditionReason] = failure.Reason
+	}
 
 	scratchExitCode := false
-	if pod.Status.ContainerStatuses != nil &&
-		pod.Status.ContainerStatuses[0].LastTerminationState.Terminated != nil &&
-		pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.ExitCode > 0 {
-		log.Info("Pod termination code", "pod.Name", pod.Name, "ExitCode", pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.ExitCode)
-		if pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.ExitCode == common.ScratchSpaceNeededExitCode {
-			log.V(1).Info("Pod requires scratch space, terminating pod, and restarting with scratch space", "pod.Name", pod.Name)
-			scratchExitCode = true
-			anno[AnnRequiresScratch] = "true"
-		} else {
-			r.recorder.Event(pvc, corev1.EventTypeWarning, ErrImportFailedPVC, pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.Message)
+	if pod.Status.ContainerStatuses != nil {
+		terminated := pod.Status.ContainerStatuses[0].LastTerminationState.Terminated
+		if terminated == nil {
+			// pods of a PVC with a retry policy aren't restarted, they stay terminated
+			terminated = pod.Status.ContainerStatuses[0].State.Terminated
+		}
+		if terminated != nil && terminated.ExitCode > 0 {
+			log.Info("Pod termination code", "pod.Name", pod.Name, "ExitCode", terminated.ExitCode)
+			if terminated.ExitCode == common.ScratchSpaceNeededExitCode {
+				log.V(1).Info("Pod requires scratch space, terminating pod, and restarting with scratch space", "pod.Name", pod.Name)
+				scratchExitCode = true
+				anno[AnnRequiresScratch] = "true"
+				setScratchSizeFromPod(pvc, pod)
+			} else if failure != nil {
+				r.recorder.Event(pvc, corev1.EventTypeWarning, failure.Reason, failure.Message)
+			} else {
+				r.recorder.Event(pvc, corev1.EventTypeWarning, ErrImportFailedPVC, terminated.Message)
+			}
 		}
 	}
 
@@ -417,12 +493,21 @@ func (r *ImportReconciler) createImportEnvVar(pvc *corev1.PersistentVolumeClaim)
 			return nil, err
 		}
 		podEnvVar.diskID = getDiskID(pvc)
+		if isImportCachePvc(pvc) {
+			// DataVolumes in all namespaces clone the import cache PVCs, their content is verified
+			podEnvVar.checksum = pvc.Annotations[AnnImportChecksum]
+		}
 	}
 	//get the requested image size.
 	podEnvVar.imageSize, err = getRequestedImageSize(pvc)
 	if err != nil {
 		return nil, err
 	}
+	filesystemOverhead, err := GetFilesystemOverhead(r.client, pvc)
+	if err != nil {
+		return nil, err
+	}
+	podEnvVar.filesystemOverhead = string(filesystemOverhead)
 	return podEnvVar, nil
 }
 
@@ -532,6 +617,10 @@ func (r *ImportReconciler) createScratchPvcForPod(pvc *corev1.PersistentVolumeCl
 	scratchPvc := &corev1.PersistentVolumeClaim{}
 	scratchPVCName, exists := getScratchNameFromPod(pod)
 	if !exists {
+		if hasScratchVolume(pod) {
+			// emptyDir scratch space
+			return nil
+		}
 		return errors.New("Scratch Volume not configured for pod")
 	}
 	anno := pvc.GetAnnotations()
@@ -633,7 +722,26 @@ func createImporterPod(log logr.Logger, client client.Client, image, verbose, pu
 		return nil, err
 	}
 
+	workloadPlacement, err := GetWorkloadPlacement(client, pvc)
+	if err != nil {
+		return nil, err
+	}
+
+	securityPolicy, err := GetWorkloadSecurityPolicy(client)
+	if err != nil {
+		return nil, err
+	}
+
 	pod := makeImporterPodSpec(pvc.Namespace, image, verbose, pullPolicy, podEnvVar, pvc, scratchPvcName, podResourceRequirements)
+	applyNodePlacement(&pod.Spec, workloadPlacement)
+	applySecurityContext(pod, securityPolicy, getVolumeMode(pvc))
+	if scratchPvcName != nil {
+		scratchPolicy, err := GetScratchSpacePolicy(client)
+		if err != nil {
+			return nil, err
+		}
+		applyScratchSpacePolicy(pod, pvc, scratchPolicy)
+	}
 
 	if err := client.Create(context.TODO(), pod); err != nil {
 		return nil, err
@@ -717,7 +825,7 @@ func makeImporterPodSpec(namespace, image, verbose, pullPolicy string, podEnvVar
 					},
 				},
 			},
-			RestartPolicy: corev1.RestartPolicyOnFailure,
+			RestartPolicy: podRestartPolicy(pvc),
 			Volumes:       volumes,
 		},
 	}
@@ -733,9 +841,6 @@ func makeImporterPodSpec(namespace, image, verbose, pullPolicy string, podEnvVar
 
 	if getVolumeMode(pvc) == corev1.PersistentVolumeBlock {
 		pod.Spec.Containers[0].VolumeDevices = addVolumeDevices()
-		pod.Spec.SecurityContext = &corev1.PodSecurityContext{
-			RunAsUser: &[]int64{0}[0],
-		}
 	} else {
 		pod.Spec.Containers[0].VolumeMounts = addImportVolumeMounts()
 	}
@@ -769,15 +874,6 @@ func makeImporterPodSpec(namespace, image, verbose, pullPolicy string, podEnvVar
 		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, vm)
 		pod.Spec.Volumes = append(pod.Spec.Volumes, vol)
 	}
-
-	if podEnvVar.contentType == string(cdiv1.DataVolumeKubeVirt) {
-		// Set the fsGroup on the security context to the QemuSubGid
-		if pod.Spec.SecurityContext == nil {
-			pod.Spec.SecurityContext = &corev1.PodSecurityContext{}
-		}
-		fsGroup := common.QemuSubGid
-		pod.Spec.SecurityContext.FSGroup = &fsGroup
-	}
 	return pod
 }
 
@@ -823,6 +919,10 @@ func makeImportEnv(podEnvVar *importPodEnvVar, uid types.UID) []corev1.EnvVar {
 			Name:  common.ImporterDiskID,
 			Value: podEnvVar.diskID,
 		},
+		{
+			Name:  common.ProgressURL,
+			Value: GetProgressURL(),
+		},
 	}
 	if podEnvVar.secretName != "" {
 		env = append(env, corev1.EnvVar{
@@ -854,5 +954,17 @@ func makeImportEnv(podEnvVar *importPodEnvVar, uid types.UID) []corev1.EnvVar {
 			Value: common.ImporterCertDir,
 		})
 	}
+	if podEnvVar.filesystemOverhead != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  common.FilesystemOverheadVar,
+			Value: podEnvVar.filesystemOverhead,
+		})
+	}
+	if podEnvVar.checksum != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  common.ImporterChecksum,
+			Value: podEnvVar.checksum,
+		})
+	}
 	return env
 }
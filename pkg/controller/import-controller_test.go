@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	featuregates "kubevirt.io/containerized-data-importer/pkg/feature-gates"
 
@@ -34,6 +36,7 @@ import (
 
 	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
 	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/importer"
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
@@ -178,7 +181,7 @@ var _ = Describe("ImportConfig Controller reconcile loop", func() {
 		Expect(*pod.Spec.SecurityContext.FSGroup).To(Equal(int64(107)))
 	})
 
-	It("Should create a POD if a bound PVC with all needed annotations is passed, but not set fsgroup if not kubevirt contenttype", func() {
+	It("Should create a non root POD with the qemu fsGroup if a bound PVC with archive contenttype is passed", func() {
 		pvc := createPvc("testPvc1", "default", map[string]string{AnnEndpoint: testEndPoint, AnnImportPod: "importer-testPvc1", AnnContentType: string(cdiv1.DataVolumeArchive)}, nil)
 		pvc.Status.Phase = v1.ClaimBound
 		reconciler = createImportReconciler(pvc)
@@ -195,8 +198,9 @@ var _ = Describe("ImportConfig Controller reconcile loop", func() {
 			}
 		}
 		Expect(foundEndPoint).To(BeTrue())
-		By("Verifying the fsGroupis not set")
-		Expect(pod.Spec.SecurityContext).To(BeNil())
+		By("Verifying the pod runs as the qemu user")
+		Expect(*pod.Spec.SecurityContext.RunAsUser).To(Equal(common.QemuUID))
+		Expect(*pod.Spec.SecurityContext.FSGroup).To(Equal(common.QemuSubGid))
 	})
 
 	It("Should error if a POD with the same name exists, but is not owned by the PVC, if a PVC with all needed annotations is passed", func() {
@@ -304,6 +308,43 @@ var _ = Describe("Update PVC from POD", func() {
 		Expect(resPvc.GetAnnotations()[AnnRunningConditionReason]).To(Equal("Pod is running"))
 	})
 
+	It("Should use the reason the importer reported for the running condition and event", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnEndpoint: testEndPoint, AnnPodPhase: string(corev1.PodRunning)}, nil)
+		pod := createImporterTestPod(pvc, "testPvc1", nil)
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					RestartCount: 1,
+					State: v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{
+							Reason: "CrashLoopBackOff",
+						},
+					},
+					LastTerminationState: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{
+							ExitCode: 1,
+							Reason:   "Error",
+							Message:  `{"reason":"NotFound","message":"Unable to connect to http data source: expected status code 200, got 404. Status: 404 Not Found"}`,
+						},
+					},
+				},
+			},
+		}
+		reconciler = createImportReconciler(pvc, pod)
+		err := reconciler.updatePvcFromPod(pvc, pod, reconciler.log)
+		Expect(err).ToNot(HaveOccurred())
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(importer.ReasonNotFound))
+		Expect(event).ToNot(ContainSubstring(ErrImportFailedPVC))
+		resPvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, resPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resPvc.GetAnnotations()[AnnRunningCondition]).To(Equal("false"))
+		Expect(resPvc.GetAnnotations()[AnnRunningConditionReason]).To(Equal(importer.ReasonNotFound))
+		Expect(resPvc.GetAnnotations()[AnnRunningConditionMessage]).To(Equal("Unable to connect to http data source: expected status code 200, got 404. Status: 404 Not Found"))
+	})
+
 	It("Should create scratch PVC, if pod is pending and PVC is marked with scratch", func() {
 		scratchPvcName := &corev1.PersistentVolumeClaim{}
 		scratchPvcName.Name = "testPvc1-scratch"
@@ -434,6 +475,272 @@ var _ = Describe("Update PVC from POD", func() {
 	})
 })
 
+var _ = Describe("Import retry policy", func() {
+	var (
+		reconciler *ImportReconciler
+	)
+	AfterEach(func() {
+		if reconciler != nil {
+			close(reconciler.recorder.(*record.FakeRecorder).Events)
+			reconciler = nil
+		}
+	})
+
+	newRetryPvc := func(annotations map[string]string) *corev1.PersistentVolumeClaim {
+		anno := map[string]string{AnnEndpoint: testEndPoint, AnnImportPod: "importer-testPvc1", AnnPodRestarts: "0", AnnRetryBackoff: "10s", AnnRetryMaxRetries: "2"}
+		for k, v := range annotations {
+			anno[k] = v
+		}
+		pvc := createPvc("testPvc1", "default", anno, nil)
+		pvc.Status.Phase = v1.ClaimBound
+		return pvc
+	}
+
+	newFailedPod := func(pvc *corev1.PersistentVolumeClaim, message string) *corev1.Pod {
+		pod := createImporterTestPod(pvc, "testPvc1", nil)
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodFailed,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: 1,
+							Message:  message,
+							Reason:   "Error",
+						},
+					},
+				},
+			},
+		}
+		return pod
+	}
+
+	reconcilePvc := func() reconcile.Result {
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	getPvc := func() *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		return pvc
+	}
+
+	getPod := func() (*corev1.Pod, error) {
+		pod := &corev1.Pod{}
+		err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "importer-testPvc1", Namespace: "default"}, pod)
+		return pod, err
+	}
+
+	It("Should create a pod which isn't restarted by the kubelet", func() {
+		reconciler = createImportReconciler(newRetryPvc(nil))
+		reconcilePvc()
+		pod, err := getPod()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+	})
+
+	It("Should delete a failed pod and schedule a retry", func() {
+		pvc := newRetryPvc(nil)
+		reconciler = createImportReconciler(pvc, newFailedPod(pvc, "Unable to connect to http data source: connection refused"))
+		result := reconcilePvc()
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(result.RequeueAfter).To(BeNumerically("<=", 10*time.Second))
+		_, err := getPod()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		resPvc := getPvc()
+		Expect(resPvc.Annotations[AnnPodRestarts]).To(Equal("1"))
+		Expect(resPvc.Annotations[AnnRetryAfter]).ToNot(BeEmpty())
+		Expect(resPvc.Annotations[AnnPodPhase]).ToNot(Equal(string(corev1.PodFailed)))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(RetryScheduled))
+		Expect(event).To(ContainSubstring("connection refused"))
+
+		By("Not recreating the pod before the backoff elapsed")
+		result = reconcilePvc()
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		_, err = getPod()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("Should recreate the pod once the backoff elapsed", func() {
+		pvc := newRetryPvc(map[string]string{AnnPodRestarts: "1", AnnRetryAfter: time.Now().Add(-time.Second).UTC().Format(time.RFC3339)})
+		reconciler = createImportReconciler(pvc)
+		reconcilePvc()
+		_, err := getPod()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Should fail the PVC once the retry limit is reached", func() {
+		pvc := newRetryPvc(map[string]string{AnnPodRestarts: "2"})
+		reconciler = createImportReconciler(pvc, newFailedPod(pvc, "Unable to process data: connection reset by peer"))
+		reconcilePvc()
+		By("Keeping the failed pod for its logs")
+		_, err := getPod()
+		Expect(err).ToNot(HaveOccurred())
+		resPvc := getPvc()
+		Expect(resPvc.Annotations[AnnPodPhase]).To(Equal(string(corev1.PodFailed)))
+		Expect(resPvc.Annotations[AnnPodRestarts]).To(Equal("2"))
+		Expect(resPvc.Annotations[AnnRunningCondition]).To(Equal("false"))
+		Expect(resPvc.Annotations[AnnRunningConditionReason]).To(Equal("Error"))
+		Expect(resPvc.Annotations[AnnRunningConditionMessage]).To(Equal("Unable to process data: connection reset by peer"))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(RetryLimitReached))
+	})
+
+	It("Should fail the PVC on a permanent error without retrying", func() {
+		pvc := newRetryPvc(nil)
+		reconciler = createImportReconciler(pvc, newFailedPod(pvc, "Unable to connect to http data source: expected status code 200, got 404. Status: 404 Not Found"))
+		reconcilePvc()
+		resPvc := getPvc()
+		Expect(resPvc.Annotations[AnnPodPhase]).To(Equal(string(corev1.PodFailed)))
+		Expect(resPvc.Annotations[AnnPodRestarts]).To(Equal("0"))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(PermanentFailure))
+	})
+
+	It("Should fail the PVC on a permanent reason reported by the importer", func() {
+		pvc := newRetryPvc(nil)
+		reconciler = createImportReconciler(pvc, newFailedPod(pvc, `{"reason":"InvalidImage","message":"Unable to process data: Invalid format iso for image /data/disk.img"}`))
+		reconcilePvc()
+		resPvc := getPvc()
+		Expect(resPvc.Annotations[AnnPodPhase]).To(Equal(string(corev1.PodFailed)))
+		Expect(resPvc.Annotations[AnnRunningConditionReason]).To(Equal(importer.ReasonInvalidImage))
+		Expect(resPvc.Annotations[AnnRunningConditionMessage]).To(Equal("Unable to process data: Invalid format iso for image /data/disk.img"))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(PermanentFailure))
+		Expect(event).To(ContainSubstring(importer.ReasonInvalidImage))
+	})
+
+	It("Should not recreate the pod of a failed PVC", func() {
+		reconciler = createImportReconciler(newRetryPvc(map[string]string{AnnPodPhase: string(corev1.PodFailed)}))
+		reconcilePvc()
+		_, err := getPod()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("Should recreate a pod which exited for scratch space without counting a retry", func() {
+		pvc := newRetryPvc(nil)
+		pod := newFailedPod(pvc, "scratch space required")
+		pod.Status.ContainerStatuses[0].State.Terminated.ExitCode = common.ScratchSpaceNeededExitCode
+		reconciler = createImportReconciler(pvc, pod)
+		reconcilePvc()
+		_, err := getPod()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		resPvc := getPvc()
+		Expect(resPvc.Annotations[AnnRequiresScratch]).To(Equal("true"))
+		Expect(resPvc.Annotations[AnnPodRestarts]).To(Equal("0"))
+		Expect(resPvc.Annotations[AnnRetryAfter]).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Import deadline", func() {
+	var (
+		reconciler *ImportReconciler
+	)
+	AfterEach(func() {
+		if reconciler != nil {
+			close(reconciler.recorder.(*record.FakeRecorder).Events)
+			reconciler = nil
+		}
+	})
+
+	newDeadlinePvc := func(age time.Duration) *corev1.PersistentVolumeClaim {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnEndpoint: testEndPoint, AnnImportPod: "importer-testPvc1", AnnPodPhase: string(corev1.PodRunning), AnnDeadline: "1h0m0s"}, nil)
+		pvc.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+		return pvc
+	}
+
+	newRunningPod := func(pvc *corev1.PersistentVolumeClaim) *corev1.Pod {
+		pod := createImporterTestPod(pvc, "testPvc1", nil)
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{},
+					},
+				},
+			},
+		}
+		return pod
+	}
+
+	getPod := func() (*corev1.Pod, error) {
+		pod := &corev1.Pod{}
+		err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "importer-testPvc1", Namespace: "default"}, pod)
+		return pod, err
+	}
+
+	It("Should requeue the PVC when its deadline passes", func() {
+		pvc := newDeadlinePvc(59 * time.Minute)
+		reconciler = createImportReconciler(pvc, newRunningPod(pvc))
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Minute, 5*time.Second))
+		_, err = getPod()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Should delete the importer pod and fail the PVC once its deadline passed", func() {
+		pvc := newDeadlinePvc(2 * time.Hour)
+		reconciler = createImportReconciler(pvc, newRunningPod(pvc))
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		_, err = getPod()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		resPvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, resPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resPvc.Annotations[AnnPodPhase]).To(Equal(string(corev1.PodFailed)))
+		Expect(resPvc.Annotations[AnnRunningConditionReason]).To(Equal(DeadlineExceeded))
+		Expect(resPvc.Annotations[AnnRunningConditionMessage]).To(Equal("Populating PVC testPvc1 did not complete within 1h0m0s"))
+		Expect(isPVCFailed(resPvc)).To(BeTrue())
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(DeadlineExceeded))
+
+		By("Not recreating the pod")
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = getPod()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Import pause and cancel", func() {
+	var (
+		reconciler *ImportReconciler
+	)
+	AfterEach(func() {
+		if reconciler != nil {
+			close(reconciler.recorder.(*record.FakeRecorder).Events)
+			reconciler = nil
+		}
+	})
+
+	getPod := func() (*corev1.Pod, error) {
+		pod := &corev1.Pod{}
+		err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "importer-testPvc1", Namespace: "default"}, pod)
+		return pod, err
+	}
+
+	It("Should delete the importer pod of a cancelled import", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnEndpoint: testEndPoint, AnnImportPod: "importer-testPvc1", AnnPodPhase: string(corev1.PodRunning), AnnPopulationAction: string(cdiv1.DataVolumeCancel)}, nil)
+		reconciler = createImportReconciler(pvc, createImporterTestPod(pvc, "testPvc1", nil))
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = getPod()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		resPvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1", Namespace: "default"}, resPvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resPvc.Annotations[AnnPodPhase]).To(Equal(string(corev1.PodRunning)))
+	})
+})
+
 var _ = Describe("Create Importer Pod", func() {
 	var scratchPvcName = "scratchPvc"
 
@@ -491,9 +798,27 @@ var _ = Describe("Import test env", func() {
 	const mockUID = "1111-1111-1111-1111"
 
 	It("Should create import env", func() {
-		testEnvVar := &importPodEnvVar{"myendpoint", "mysecret", SourceHTTP, string(cdiv1.DataVolumeKubeVirt), "1G", "", "", false}
+		testEnvVar := &importPodEnvVar{"myendpoint", "mysecret", SourceHTTP, string(cdiv1.DataVolumeKubeVirt), "1G", "", "", "0.055", "", false}
 		Expect(reflect.DeepEqual(makeImportEnv(testEnvVar, mockUID), createImportTestEnv(testEnvVar, mockUID))).To(BeTrue())
 	})
+
+	It("Should pass the checksum of an import cache PVC to the importer", func() {
+		checksum := "sha256:" + strings.Repeat("ab", 32)
+		pvc := createPvc("cdi-cache-key", "cache-ns", map[string]string{AnnEndpoint: testEndPoint, AnnImportChecksum: checksum}, map[string]string{common.CDIComponentLabel: common.ImportCacheCDILabel})
+		reconciler := createImportReconciler(pvc)
+		podEnvVar, err := reconciler.createImportEnvVar(pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(podEnvVar.checksum).To(Equal(checksum))
+		Expect(makeImportEnv(podEnvVar, mockUID)).To(ContainElement(corev1.EnvVar{Name: common.ImporterChecksum, Value: checksum}))
+	})
+
+	It("Should not pass the checksum of other PVCs to the importer", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnEndpoint: testEndPoint, AnnImportChecksum: "etag:1234"}, nil)
+		reconciler := createImportReconciler(pvc)
+		podEnvVar, err := reconciler.createImportEnvVar(pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(podEnvVar.checksum).To(BeEmpty())
+	})
 })
 
 var _ = Describe("getSecretName", func() {
@@ -713,6 +1038,10 @@ func createImportTestEnv(podEnvVar *importPodEnvVar, uid string) []corev1.EnvVar
 			Name:  common.ImporterDiskID,
 			Value: podEnvVar.diskID,
 		},
+		{
+			Name:  common.ProgressURL,
+			Value: GetProgressURL(),
+		},
 	}
 
 	if podEnvVar.secretName != "" {
@@ -738,6 +1067,18 @@ func createImportTestEnv(podEnvVar *importPodEnvVar, uid string) []corev1.EnvVar
 			},
 		})
 	}
+	if podEnvVar.filesystemOverhead != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  common.FilesystemOverheadVar,
+			Value: podEnvVar.filesystemOverhead,
+		})
+	}
+	if podEnvVar.checksum != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  common.ImporterChecksum,
+			Value: podEnvVar.checksum,
+		})
+	}
 	return env
 }
 
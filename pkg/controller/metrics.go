@@ -0,0 +1,253 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+const (
+	operationImport = "import"
+	operationClone  = "clone"
+	operationUpload = "upload"
+
+	resultSucceeded = "succeeded"
+	resultFailed    = "failed"
+)
+
+var (
+	dataVolumeDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "cdi_datavolume_duration_seconds",
+			Help: "The time from the creation of a DataVolume until it succeeded or failed",
+			// 10s to about 11h
+			Buckets: prometheus.ExponentialBuckets(10, 2, 13),
+		},
+		[]string{"operation", "result"},
+	)
+	dataVolumeFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cdi_datavolume_failures_total",
+			Help: "The number of DataVolumes that failed, by the reason of their Running condition",
+		},
+		[]string{"operation", "reason"},
+	)
+	dataVolumePopulatedCapacity = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cdi_datavolume_populated_capacity_bytes_total",
+			Help: "The capacity of the PVCs of the DataVolumes that succeeded, not the bytes transferred to them",
+		},
+		[]string{"operation"},
+	)
+
+	dataVolumesDesc = prometheus.NewDesc(
+		"cdi_datavolumes",
+		"The number of DataVolumes by phase and source",
+		[]string{"phase", "source"}, nil,
+	)
+	dataVolumeRestartsDesc = prometheus.NewDesc(
+		"cdi_datavolume_restarts",
+		"A histogram of the number of times the pods populating a DataVolume restarted, per namespace, for DataVolumes with restarts",
+		[]string{"namespace"}, nil,
+	)
+	// the alert on DataVolumes restarting too often counts the DataVolumes above the 3 restarts bucket
+	dataVolumeRestartsBuckets = []float64{1, 3, 5, 10}
+
+	scratchPVCsDesc = prometheus.NewDesc(
+		"cdi_scratch_pvcs",
+		"The number of scratch PVCs in use by import and upload pods",
+		nil, nil,
+	)
+)
+
+func init() {
+	dataVolumeDuration = registerCollector(dataVolumeDuration).(*prometheus.HistogramVec)
+	dataVolumeFailures = registerCollector(dataVolumeFailures).(*prometheus.CounterVec)
+	dataVolumePopulatedCapacity = registerCollector(dataVolumePopulatedCapacity).(*prometheus.CounterVec)
+}
+
+func registerCollector(c prometheus.Collector) prometheus.Collector {
+	if err := metrics.Registry.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			// A collector for that metric has been registered before.
+			// Use the old collector from now on.
+			return are.ExistingCollector
+		}
+		klog.Errorf("Unable to register prometheus collector: %v", err)
+	}
+	return c
+}
+
+// dataVolumeCollector collects the metrics of the current DataVolumes and scratch PVCs from the cache of the
+// controller manager on every scrape
+type dataVolumeCollector struct {
+	client client.Client
+}
+
+// RegisterDataVolumeCollector registers the collector of the DataVolume and scratch PVC metrics with the metrics
+// registry served by the controller manager
+func RegisterDataVolumeCollector(client client.Client) {
+	registerCollector(&dataVolumeCollector{client: client})
+}
+
+func (c *dataVolumeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dataVolumesDesc
+	ch <- dataVolumeRestartsDesc
+	ch <- scratchPVCsDesc
+}
+
+func (c *dataVolumeCollector) Collect(ch chan<- prometheus.Metric) {
+	dataVolumes := &cdiv1.DataVolumeList{}
+	if err := c.client.List(context.TODO(), dataVolumes); err != nil {
+		klog.Errorf("Unable to list DataVolumes for metrics: %v", err)
+	} else {
+		collectDataVolumeMetrics(ch, dataVolumes.Items)
+	}
+
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := c.client.List(context.TODO(), pvcs, client.MatchingLabels{common.CDILabelKey: common.CDILabelValue}); err != nil {
+		klog.Errorf("Unable to list PVCs for metrics: %v", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(scratchPVCsDesc, prometheus.GaugeValue, float64(countScratchPVCs(pvcs.Items)))
+	}
+}
+
+func collectDataVolumeMetrics(ch chan<- prometheus.Metric, dataVolumes []cdiv1.DataVolume) {
+	type phaseSource struct {
+		phase  cdiv1.DataVolumePhase
+		source string
+	}
+	type restartHistogram struct {
+		count   uint64
+		sum     float64
+		buckets map[float64]uint64
+	}
+	counts := make(map[phaseSource]int)
+	restarts := make(map[string]*restartHistogram)
+	for i := range dataVolumes {
+		dv := &dataVolumes[i]
+		counts[phaseSource{dv.Status.Phase, dataVolumeSourceType(dv)}]++
+		if dv.Status.RestartCount <= 0 {
+			continue
+		}
+		histogram, ok := restarts[dv.Namespace]
+		if !ok {
+			histogram = &restartHistogram{buckets: make(map[float64]uint64)}
+			for _, bound := range dataVolumeRestartsBuckets {
+				histogram.buckets[bound] = 0
+			}
+			restarts[dv.Namespace] = histogram
+		}
+		histogram.count++
+		histogram.sum += float64(dv.Status.RestartCount)
+		for _, bound := range dataVolumeRestartsBuckets {
+			if float64(dv.Status.RestartCount) <= bound {
+				histogram.buckets[bound]++
+			}
+		}
+	}
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(dataVolumesDesc, prometheus.GaugeValue, float64(count), string(key.phase), key.source)
+	}
+	for namespace, histogram := range restarts {
+		ch <- prometheus.MustNewConstHistogram(dataVolumeRestartsDesc, histogram.count, histogram.sum, histogram.buckets, namespace)
+	}
+}
+
+// countScratchPVCs returns the number of scratch PVCs, the CDI PVCs owned by the pod using them
+func countScratchPVCs(pvcs []corev1.PersistentVolumeClaim) int {
+	count := 0
+	for _, pvc := range pvcs {
+		for _, ref := range pvc.OwnerReferences {
+			if ref.Kind == "Pod" {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// dataVolumeSourceType returns the type of the source of the DataVolume, the metrics label of the source
+func dataVolumeSourceType(dv *cdiv1.DataVolume) string {
+	source := dv.Spec.Source
+	switch {
+	case source.HTTP != nil:
+		return SourceHTTP
+	case source.S3 != nil:
+		return SourceS3
+	case source.Registry != nil:
+		return SourceRegistry
+	case source.Imageio != nil:
+		return SourceImageio
+	case source.PVC != nil:
+		return "pvc"
+	case source.Upload != nil:
+		return "upload"
+	case source.Blank != nil:
+		return "blank"
+	}
+	return ""
+}
+
+// dataVolumeOperation returns the operation populating the DataVolume
+func dataVolumeOperation(dv *cdiv1.DataVolume) string {
+	switch {
+	case dv.Spec.Source.PVC != nil:
+		return operationClone
+	case dv.Spec.Source.Upload != nil:
+		return operationUpload
+	}
+	return operationImport
+}
+
+// observeDataVolumePhase records the metrics of a DataVolume that moved to its current phase
+func observeDataVolumePhase(dv *cdiv1.DataVolume, pvc *corev1.PersistentVolumeClaim) {
+	if _, ok := dv.Annotations[AnnPrePopulated]; ok {
+		return
+	}
+	operation := dataVolumeOperation(dv)
+	duration := time.Since(dv.CreationTimestamp.Time).Seconds()
+	switch dv.Status.Phase {
+	case cdiv1.Succeeded:
+		dataVolumeDuration.WithLabelValues(operation, resultSucceeded).Observe(duration)
+		if pvc != nil {
+			size, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+			if !ok {
+				size = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			}
+			dataVolumePopulatedCapacity.WithLabelValues(operation).Add(float64(size.Value()))
+		}
+	case cdiv1.Failed:
+		dataVolumeDuration.WithLabelValues(operation, resultFailed).Observe(duration)
+		reason := ""
+		if running := findConditionByType(cdiv1.DataVolumeRunning, dv.Status.Conditions); running != nil {
+			reason = running.Reason
+		}
+		dataVolumeFailures.WithLabelValues(operation, reason).Inc()
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+var _ = Describe("DataVolume metrics", func() {
+	newDataVolumeWithPhase := func(name string, source cdiv1.DataVolumeSource, phase cdiv1.DataVolumePhase, restarts int32) *cdiv1.DataVolume {
+		dv := newImportDataVolume(name)
+		dv.Spec.Source = source
+		dv.Status.Phase = phase
+		dv.Status.RestartCount = restarts
+		return dv
+	}
+
+	gather := func(collector prometheus.Collector) map[string]*dto.MetricFamily {
+		registry := prometheus.NewRegistry()
+		Expect(registry.Register(collector)).To(Succeed())
+		families, err := registry.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		result := make(map[string]*dto.MetricFamily)
+		for _, family := range families {
+			result[family.GetName()] = family
+		}
+		return result
+	}
+
+	labelValues := func(metric *dto.Metric) map[string]string {
+		result := make(map[string]string)
+		for _, label := range metric.GetLabel() {
+			result[label.GetName()] = label.GetValue()
+		}
+		return result
+	}
+
+	It("Should collect the DataVolumes by phase and source, their restarts and the scratch PVCs", func() {
+		httpSource := cdiv1.DataVolumeSource{HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "http://example.com/data"}}
+		pvcSource := cdiv1.DataVolumeSource{PVC: &cdiv1.DataVolumeSourcePVC{Namespace: "default", Name: "source"}}
+		scratchPvc := createPvc("test-dv-3-scratch", metav1.NamespaceDefault, nil, map[string]string{"app": "containerized-data-importer"})
+		scratchPvc.OwnerReferences = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: "importer-test-dv-3", UID: "1234"}}
+		s := scheme.Scheme
+		cdiv1.AddToScheme(s)
+		cl := fake.NewFakeClientWithScheme(s,
+			newDataVolumeWithPhase("test-dv-1", httpSource, cdiv1.Succeeded, 0),
+			newDataVolumeWithPhase("test-dv-2", httpSource, cdiv1.Succeeded, 0),
+			newDataVolumeWithPhase("test-dv-3", pvcSource, cdiv1.CloneInProgress, 2),
+			newDataVolumeWithPhase("test-dv-4", httpSource, cdiv1.ImportInProgress, 4),
+			scratchPvc,
+			createPvc("test-dv-1", metav1.NamespaceDefault, nil, map[string]string{"app": "containerized-data-importer"}))
+
+		families := gather(&dataVolumeCollector{client: cl})
+
+		Expect(families).To(HaveKey("cdi_datavolumes"))
+		counts := make(map[string]float64)
+		for _, metric := range families["cdi_datavolumes"].GetMetric() {
+			labels := labelValues(metric)
+			counts[labels["phase"]+"/"+labels["source"]] = metric.GetGauge().GetValue()
+		}
+		Expect(counts).To(Equal(map[string]float64{"Succeeded/http": 2, "CloneInProgress/pvc": 1, "ImportInProgress/http": 1}))
+
+		Expect(families).To(HaveKey("cdi_datavolume_restarts"))
+		restarts := families["cdi_datavolume_restarts"].GetMetric()
+		Expect(restarts).To(HaveLen(1))
+		Expect(labelValues(restarts[0])).To(Equal(map[string]string{"namespace": metav1.NamespaceDefault}))
+		histogram := restarts[0].GetHistogram()
+		Expect(histogram.GetSampleCount()).To(BeNumerically("==", 2))
+		Expect(histogram.GetSampleSum()).To(BeNumerically("==", 6))
+		buckets := make(map[float64]uint64)
+		for _, bucket := range histogram.GetBucket() {
+			buckets[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+		}
+		Expect(buckets).To(Equal(map[float64]uint64{1: 0, 3: 1, 5: 2, 10: 2}))
+
+		Expect(families).To(HaveKey("cdi_scratch_pvcs"))
+		Expect(families["cdi_scratch_pvcs"].GetMetric()[0].GetGauge().GetValue()).To(BeNumerically("==", 1))
+	})
+
+	It("Should count the failures of DataVolumes by reason", func() {
+		dv := newImportDataVolume("test-dv")
+		dv.Status.Phase = cdiv1.Failed
+		dv.Status.Conditions = updateCondition(dv.Status.Conditions, cdiv1.DataVolumeRunning, corev1.ConditionFalse, "not found", "NotFound")
+		failures := dataVolumeFailures.WithLabelValues(operationImport, "NotFound")
+		metric := &dto.Metric{}
+		Expect(failures.Write(metric)).To(Succeed())
+		before := metric.GetCounter().GetValue()
+
+		observeDataVolumePhase(dv, nil)
+
+		Expect(failures.Write(metric)).To(Succeed())
+		Expect(metric.GetCounter().GetValue()).To(Equal(before + 1))
+	})
+
+	It("Should count the capacity of the succeeded DataVolumes", func() {
+		dv := newImportDataVolume("test-dv")
+		dv.Status.Phase = cdiv1.Succeeded
+		pvc := createPvc("test-dv", metav1.NamespaceDefault, nil, nil)
+		pvc.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+		populated := dataVolumePopulatedCapacity.WithLabelValues(operationImport)
+		metric := &dto.Metric{}
+		Expect(populated.Write(metric)).To(Succeed())
+		before := metric.GetCounter().GetValue()
+
+		observeDataVolumePhase(dv, pvc)
+
+		Expect(populated.Write(metric)).To(Succeed())
+		Expect(metric.GetCounter().GetValue()).To(Equal(before + 1024*1024*1024))
+	})
+})
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+// AnnPodPlacement is a PVC annotation with the placement of the pods populating the PVC, the placement of its DataVolume
+const AnnPodPlacement = AnnAPIGroup + "/storage.pod.placement"
+
+// setPodPlacementAnnotation sets the placement of the DataVolume on its PVC
+func setPodPlacementAnnotation(pvc *corev1.PersistentVolumeClaim, placement *cdiv1.NodePlacement) error {
+	if placement == nil {
+		return nil
+	}
+	value, err := json.Marshal(placement)
+	if err != nil {
+		return err
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = make(map[string]string)
+	}
+	pvc.Annotations[AnnPodPlacement] = string(value)
+	return nil
+}
+
+// GetWorkloadPlacement gets the placement of the pods populating the PVC, the workloads placement of the cdi config
+// with the fields set by the placement of the PVC replaced
+func GetWorkloadPlacement(c client.Client, pvc *corev1.PersistentVolumeClaim) (*cdiv1.NodePlacement, error) {
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		return nil, err
+	}
+	placement := &cdiv1.NodePlacement{}
+	if cdiconfig.Spec.Workloads != nil {
+		placement = cdiconfig.Spec.Workloads.DeepCopy()
+	}
+
+	value, ok := pvc.Annotations[AnnPodPlacement]
+	if !ok {
+		return placement, nil
+	}
+	override := &cdiv1.NodePlacement{}
+	if err := json.Unmarshal([]byte(value), override); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s annotation", AnnPodPlacement)
+	}
+	if override.NodeSelector != nil {
+		placement.NodeSelector = override.NodeSelector
+	}
+	if override.Affinity != nil {
+		placement.Affinity = override.Affinity
+	}
+	if override.Tolerations != nil {
+		placement.Tolerations = override.Tolerations
+	}
+	if override.PriorityClassName != "" {
+		placement.PriorityClassName = override.PriorityClassName
+	}
+	return placement, nil
+}
+
+// applyNodePlacement applies the placement to the spec of a worker pod
+func applyNodePlacement(podSpec *corev1.PodSpec, placement *cdiv1.NodePlacement) {
+	if placement == nil {
+		return
+	}
+	podSpec.NodeSelector = placement.NodeSelector
+	podSpec.Affinity = placement.Affinity
+	podSpec.Tolerations = placement.Tolerations
+	podSpec.PriorityClassName = placement.PriorityClassName
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+var _ = Describe("Placement", func() {
+	workloads := &cdiv1.NodePlacement{
+		NodeSelector: map[string]string{"node-role.kubernetes.io/storage": ""},
+		Tolerations: []corev1.Toleration{
+			{Key: "storage", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		},
+		PriorityClassName: "cdi-workloads",
+	}
+
+	newConfig := func() *cdiv1.CDIConfig {
+		config := createCDIConfig(common.ConfigName)
+		config.Spec.Workloads = workloads
+		return config
+	}
+
+	It("Should use the workloads placement of the cdi config", func() {
+		pvc := createPvc("testPvc1", "default", nil, nil)
+		placement, err := GetWorkloadPlacement(createClient(newConfig()), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(placement).To(Equal(workloads))
+	})
+
+	It("Should replace the fields set by the placement of the DataVolume", func() {
+		pvc := createPvc("testPvc1", "default", nil, nil)
+		Expect(setPodPlacementAnnotation(pvc, &cdiv1.NodePlacement{
+			NodeSelector: map[string]string{"zone": "a"},
+		})).To(Succeed())
+		placement, err := GetWorkloadPlacement(createClient(newConfig()), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(placement.NodeSelector).To(Equal(map[string]string{"zone": "a"}))
+		Expect(placement.Tolerations).To(Equal(workloads.Tolerations))
+		Expect(placement.PriorityClassName).To(Equal(workloads.PriorityClassName))
+	})
+
+	It("Should fail with an invalid placement annotation", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnPodPlacement: "zone=a"}, nil)
+		_, err := GetWorkloadPlacement(createClient(newConfig()), pvc)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should apply the placement to the pod spec", func() {
+		podSpec := &corev1.PodSpec{}
+		applyNodePlacement(podSpec, workloads)
+		Expect(podSpec.NodeSelector).To(Equal(workloads.NodeSelector))
+		Expect(podSpec.Tolerations).To(Equal(workloads.Tolerations))
+		Expect(podSpec.PriorityClassName).To(Equal(workloads.PriorityClassName))
+		Expect(podSpec.Affinity).To(BeNil())
+	})
+})
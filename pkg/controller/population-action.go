@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+const (
+	// AnnPopulationAction is a PVC annotation cancelling populating the PVC, the action of its DataVolume
+	AnnPopulationAction = AnnAPIGroup + "/storage.action"
+
+	// PopulationCancelled provides a const to indicate populating a PVC was cancelled
+	PopulationCancelled = "Cancelled"
+	// MessagePopulationCancelled provides a const to form the cancelled event and condition message
+	MessagePopulationCancelled = "Populating PVC %s was cancelled"
+)
+
+// populationAction returns the action on populating the PVC, empty if it should be populated
+func populationAction(pvc *corev1.PersistentVolumeClaim) cdiv1.DataVolumeAction {
+	return cdiv1.DataVolumeAction(pvc.Annotations[AnnPopulationAction])
+}
+
+// isPopulationCancelled returns true if populating the PVC was cancelled, the pods populating it are removed
+func isPopulationCancelled(pvc *corev1.PersistentVolumeClaim) bool {
+	return populationAction(pvc) == cdiv1.DataVolumeCancel && !isPVCComplete(pvc) && !isPVCFailed(pvc)
+}
+
+// isPopulatedByPods returns true if the PVC is populated by import, upload or clone pods, the populations that can be
+// cancelled
+func isPopulatedByPods(pvc *corev1.PersistentVolumeClaim) bool {
+	return metav1.HasAnnotation(pvc.ObjectMeta, AnnEndpoint) ||
+		metav1.HasAnnotation(pvc.ObjectMeta, AnnSource) ||
+		metav1.HasAnnotation(pvc.ObjectMeta, AnnUploadRequest) ||
+		metav1.HasAnnotation(pvc.ObjectMeta, AnnCloneRequest)
+}
+
+// syncPopulationAction sets the action of the DataVolume on its PVC, it returns true if the PVC has to be updated
+func syncPopulationAction(pvc *corev1.PersistentVolumeClaim, action cdiv1.DataVolumeAction) bool {
+	if populationAction(pvc) == action {
+		return false
+	}
+	if action == "" {
+		delete(pvc.Annotations, AnnPopulationAction)
+	} else {
+		if pvc.Annotations == nil {
+			pvc.Annotations = make(map[string]string)
+		}
+		pvc.Annotations[AnnPopulationAction] = string(action)
+	}
+	return true
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/importer"
+	"kubevirt.io/containerized-data-importer/pkg/util"
+)
+
+const (
+	// AnnRetryMaxRetries is a PVC annotation with the number of times a failed pod populating the PVC is recreated
+	AnnRetryMaxRetries = AnnAPIGroup + "/storage.retry.maxRetries"
+	// AnnRetryBackoff is a PVC annotation with the delay before the first retry of a failed pod populating the PVC,
+	// the pods are restarted by the kubelet if it isn't set
+	AnnRetryBackoff = AnnAPIGroup + "/storage.retry.backoff"
+	// AnnRetryAfter is a PVC annotation with the time the failed pod populating the PVC is recreated
+	AnnRetryAfter = AnnAPIGroup + "/storage.retry.after"
+
+	// RetryScheduled provides a const to indicate a failed pod will be recreated once the backoff elapsed
+	RetryScheduled = "RetryScheduled"
+	// RetryLimitReached provides a const to indicate a failed pod isn't recreated because the retry policy allows no more retries
+	RetryLimitReached = "RetryLimitReached"
+	// PermanentFailure provides a const to indicate a failed pod isn't recreated because retrying can't fix the error
+	PermanentFailure = "PermanentFailure"
+
+	// MessageRetryScheduled provides a const to form the retry scheduled event message
+	MessageRetryScheduled = "%s: %s, retrying in %s"
+	// MessageRetryLimitReached provides a const to form the retry limit reached event message
+	MessageRetryLimitReached = "%s: %s, giving up after %d retries"
+	// MessagePermanentFailure provides a const to form the permanent failure event message
+	MessagePermanentFailure = "%s: %s, not retrying a permanent error"
+
+	defaultRetryBackoff = 10 * time.Second
+	maxRetryBackoff     = 5 * time.Minute
+)
+
+// permanentFailureReasons are the structured termination message reasons of failures retrying can't fix
+var permanentFailureReasons = map[string]bool{
+	"CloneSourceInvalidConfiguration":   true,
+	importer.ReasonNotFound:             true,
+	importer.ReasonUnauthorized:         true,
+	importer.ReasonInvalidImage:         true,
+	importer.ReasonInsufficientSpace:    true,
+	importer.ReasonChecksumMismatch:     true,
+	importer.ReasonInvalidConfiguration: true,
+}
+
+// permanentFailureMessages match the free text termination messages of failures retrying can't fix, like a missing
+// source, invalid credentials or an invalid image
+var permanentFailureMessages = []string{
+	"got 401",
+	"got 403",
+	"got 404",
+	"got 410",
+	"unauthorized",
+	"authentication required",
+	"access to the resource is denied",
+	"manifest unknown",
+	"name unknown",
+	"error disk not found",
+	"invalid format",
+	"is invalid because it has backing file",
+	"failed to find vm disk image file",
+	"a larger pvc is required",
+	"unknown data source",
+	"cannot create empty disk with content type archive",
+}
+
+// podFailure is the failure of a pod populating a PVC
+type podFailure struct {
+	reason    string
+	message   string
+	permanent bool
+}
+
+// hasRetryPolicy returns true if the failed pods populating the PVC are recreated by CDI instead of being restarted by the kubelet
+func hasRetryPolicy(pvc *corev1.PersistentVolumeClaim) bool {
+	_, ok := pvc.Annotations[AnnRetryBackoff]
+	return ok
+}
+
+// setRetryPolicyAnnotations sets the annotations applying the retry policy of a DataVolume to the pods populating its PVC
+func setRetryPolicyAnnotations(annotations map[string]string, retryPolicy *cdiv1.DataVolumeRetryPolicy) {
+	backoff := defaultRetryBackoff
+	if retryPolicy.Backoff != nil && retryPolicy.Backoff.Duration > 0 {
+		backoff = retryPolicy.Backoff.Duration
+	}
+	annotations[AnnRetryBackoff] = backoff.String()
+	if retryPolicy.MaxRetries != nil {
+		annotations[AnnRetryMaxRetries] = strconv.Itoa(int(*retryPolicy.MaxRetries))
+	}
+}
+
+// podRestartPolicy returns the restart policy of the pods populating the PVC
+func podRestartPolicy(pvc *corev1.PersistentVolumeClaim) corev1.RestartPolicy {
+	if hasRetryPolicy(pvc) {
+		return corev1.RestartPolicyNever
+	}
+	return corev1.RestartPolicyOnFailure
+}
+
+// isPVCFailed returns true if the retry policy of the PVC gave up on populating it, or its deadline passed
+func isPVCFailed(pvc *corev1.PersistentVolumeClaim) bool {
+	return (hasRetryPolicy(pvc) || isDeadlineExceeded(pvc)) && podPhaseFromPVC(pvc) == corev1.PodFailed
+}
+
+// getPodFailure returns the failure of a pod which isn't restarted by the kubelet, nil if the pod didn't fail. A pod
+// exiting because it requires scratch space didn't fail.
+func getPodFailure(pod *corev1.Pod) *podFailure {
+	if pod.Status.Phase != corev1.PodFailed {
+		return nil
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		if terminated == nil || terminated.ExitCode == 0 {
+			continue
+		}
+		if terminated.ExitCode == common.ScratchSpaceNeededExitCode {
+			return nil
+		}
+		return containerFailure(terminated)
+	}
+	// the pod failed without a failing container, e.g. it was evicted
+	failure := &podFailure{reason: pod.Status.Reason, message: pod.Status.Message}
+	if failure.reason == "" {
+		failure.reason = string(corev1.PodFailed)
+	}
+	return failure
+}
+
+// containerFailure returns the failure of a container from its termination
+func containerFailure(terminated *corev1.ContainerStateTerminated) *podFailure {
+	if msg := util.ParseTerminationMessage(terminated.Message); msg != nil {
+		return &podFailure{reason: msg.Reason, message: msg.Message, permanent: permanentFailureReasons[msg.Reason]}
+	}
+	return &podFailure{reason: terminated.Reason, message: terminated.Message, permanent: isPermanentFailureMessage(terminated.Message)}
+}
+
+func isPermanentFailureMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, m := range permanentFailureMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryFailedPod applies the retry policy of the PVC to the failure of one of its pods. It returns true if the pod has
+// to be deleted and recreated once the backoff elapsed, otherwise the PVC is marked failed with the failure as the
+// reason of its running condition.
+func retryFailedPod(pvc *corev1.PersistentVolumeClaim, failure *podFailure, recorder record.EventRecorder) bool {
+	anno := pvc.Annotations
+	retries, _ := strconv.Atoi(anno[AnnPodRestarts])
+	maxRetries, err := strconv.Atoi(anno[AnnRetryMaxRetries])
+	limited := err == nil
+
+	switch {
+	case failure.permanent:
+		recorder.Eventf(pvc, corev1.EventTypeWarning, PermanentFailure, MessagePermanentFailure, failure.reason, failure.message)
+	case limited && retries >= maxRetries:
+		recorder.Eventf(pvc, corev1.EventTypeWarning, RetryLimitReached, MessageRetryLimitReached, failure.reason, failure.message, retries)
+	default:
+		backoff := retryBackoff(pvc, retries)
+		anno[AnnPodRestarts] = strconv.Itoa(retries + 1)
+		anno[AnnRetryAfter] = time.Now().Add(backoff).UTC().Format(time.RFC3339)
+		recorder.Eventf(pvc, corev1.EventTypeWarning, RetryScheduled, MessageRetryScheduled, failure.reason, failure.message, backoff)
+		return true
+	}
+
+	markPVCFailed(pvc, failure.reason, failure.message)
+	return false
+}
+
+// markPVCFailed marks the PVC failed with the reason and message of its running condition, the controllers stop
+// populating it
+func markPVCFailed(pvc *corev1.PersistentVolumeClaim, reason, message string) {
+	anno := pvc.Annotations
+	anno[AnnPodPhase] = string(corev1.PodFailed)
+	anno[AnnRunningCondition] = "false"
+	anno[AnnRunningConditionReason] = reason
+	anno[AnnRunningConditionMessage] = message
+	// the failure is the reason the DataVolume isn't running, no matter which of its pods failed
+	delete(anno, AnnSourceRunningCondition)
+	delete(anno, AnnSourceRunningConditionReason)
+	delete(anno, AnnSourceRunningConditionMessage)
+	delete(anno, AnnRetryAfter)
+}
+
+// retryBackoff returns the delay before a retry, the backoff of the retry policy doubled for every previous retry
+func retryBackoff(pvc *corev1.PersistentVolumeClaim, retries int) time.Duration {
+	backoff, err := time.ParseDuration(pvc.Annotations[AnnRetryBackoff])
+	if err != nil || backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	for i := 0; i < retries && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// retryDelay returns how long to wait before recreating the failed pod populating the PVC
+func retryDelay(pvc *corev1.PersistentVolumeClaim) time.Duration {
+	after, err := time.Parse(time.RFC3339, pvc.Annotations[AnnRetryAfter])
+	if err != nil {
+		return 0
+	}
+	if delay := time.Until(after); delay > 0 {
+		return delay
+	}
+	return 0
+}
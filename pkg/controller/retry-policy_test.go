@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+var _ = Describe("Retry policy", func() {
+	newPod := func(phase corev1.PodPhase, exitCode int32, message string) *corev1.Pod {
+		return &corev1.Pod{
+			Status: corev1.PodStatus{
+				Phase: phase,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{
+								ExitCode: exitCode,
+								Reason:   "Error",
+								Message:  message,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	table.DescribeTable("Should get the failure of a pod", func(pod *corev1.Pod, expected *podFailure) {
+		Expect(getPodFailure(pod)).To(Equal(expected))
+	},
+		table.Entry("not failed", newPod(corev1.PodRunning, 1, "oops"), nil),
+		table.Entry("exiting for scratch space", newPod(corev1.PodFailed, common.ScratchSpaceNeededExitCode, ""), nil),
+		table.Entry("with a transient error", newPod(corev1.PodFailed, 1, "Unable to process data: connection reset by peer"),
+			&podFailure{reason: "Error", message: "Unable to process data: connection reset by peer"}),
+		table.Entry("with a missing http source", newPod(corev1.PodFailed, 1, "Unable to connect to http data source: expected status code 200, got 404. Status: 404 Not Found"),
+			&podFailure{reason: "Error", message: "Unable to connect to http data source: expected status code 200, got 404. Status: 404 Not Found", permanent: true}),
+		table.Entry("with invalid registry credentials", newPod(corev1.PodFailed, 1, "Unable to process data: Failed to read registry image: unauthorized: authentication required"),
+			&podFailure{reason: "Error", message: "Unable to process data: Failed to read registry image: unauthorized: authentication required", permanent: true}),
+		table.Entry("with an invalid image", newPod(corev1.PodFailed, 1, "Unable to process data: Invalid format iso for image http://example.com/disk.iso"),
+			&podFailure{reason: "Error", message: "Unable to process data: Invalid format iso for image http://example.com/disk.iso", permanent: true}),
+		table.Entry("with a structured termination message", newPod(corev1.PodFailed, 1, `{"reason":"CloneSourceReadFailed","message":"read failed"}`),
+			&podFailure{reason: "CloneSourceReadFailed", message: "read failed"}),
+		table.Entry("with a permanent structured termination message", newPod(corev1.PodFailed, 1, `{"reason":"CloneSourceInvalidConfiguration","message":"Error getting env var"}`),
+			&podFailure{reason: "CloneSourceInvalidConfiguration", message: "Error getting env var", permanent: true}),
+		table.Entry("with a permanent reason reported by the importer", newPod(corev1.PodFailed, 1, `{"reason":"Unauthorized","message":"Unable to process data: unauthorized"}`),
+			&podFailure{reason: "Unauthorized", message: "Unable to process data: unauthorized", permanent: true}),
+		table.Entry("with a transient reason reported by the importer", newPod(corev1.PodFailed, 1, `{"reason":"Timeout","message":"Unable to process data: context canceled"}`),
+			&podFailure{reason: "Timeout", message: "Unable to process data: context canceled"}),
+		table.Entry("evicted", &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: ephemeral-storage"}},
+			&podFailure{reason: "Evicted", message: "The node was low on resource: ephemeral-storage"}),
+	)
+
+	table.DescribeTable("Should double the backoff with every retry", func(backoff string, retries int, expected time.Duration) {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnRetryBackoff: backoff}, nil)
+		Expect(retryBackoff(pvc, retries)).To(Equal(expected))
+	},
+		table.Entry("first retry", "10s", 0, 10*time.Second),
+		table.Entry("third retry", "10s", 2, 40*time.Second),
+		table.Entry("up to 5 minutes", "10s", 10, 5*time.Minute),
+		table.Entry("with an initial backoff above the maximum", "10m", 0, 5*time.Minute),
+		table.Entry("with an invalid backoff", "soon", 1, 20*time.Second),
+	)
+
+	It("Should use the kubelet restart policy without a retry policy", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{}, nil)
+		Expect(podRestartPolicy(pvc)).To(Equal(corev1.RestartPolicyOnFailure))
+		Expect(isPVCFailed(pvc)).To(BeFalse())
+		pvc.Annotations[AnnRetryBackoff] = "10s"
+		Expect(podRestartPolicy(pvc)).To(Equal(corev1.RestartPolicyNever))
+	})
+
+	It("Should return the delay before the retry", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnRetryAfter: time.Now().Add(time.Minute).UTC().Format(time.RFC3339)}, nil)
+		Expect(retryDelay(pvc)).To(BeNumerically("~", time.Minute, 2*time.Second))
+		pvc.Annotations[AnnRetryAfter] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		Expect(retryDelay(pvc)).To(BeZero())
+	})
+})
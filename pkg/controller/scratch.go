@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+const (
+	// AnnScratchSize is the size of the source reported by an importer or upload server requiring scratch space
+	AnnScratchSize = AnnAPIGroup + "/storage.import.scratchSize"
+	// AnnScratchCompressed is set if the source of AnnScratchSize is compressed
+	AnnScratchCompressed = AnnAPIGroup + "/storage.import.scratchCompressed"
+
+	defaultScratchOverheadPercent           = int32(100)
+	defaultCompressedScratchOverheadPercent = int32(400)
+)
+
+// GetScratchSpacePolicy gets the scratch space sizing policy from the cdi config spec, a 100% overhead, 400% for
+// compressed sources, and no emptyDir scratch space if not set
+func GetScratchSpacePolicy(c client.Client) (*cdiv1.ScratchSpacePolicy, error) {
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		return nil, err
+	}
+	policy := &cdiv1.ScratchSpacePolicy{}
+	if cdiconfig.Spec.ScratchSpace != nil {
+		policy = cdiconfig.Spec.ScratchSpace.DeepCopy()
+	}
+	if policy.OverheadPercent == nil {
+		overhead := defaultScratchOverheadPercent
+		policy.OverheadPercent = &overhead
+	}
+	if policy.CompressedOverheadPercent == nil {
+		overhead := defaultCompressedScratchOverheadPercent
+		policy.CompressedOverheadPercent = &overhead
+	}
+	return policy, nil
+}
+
+// getScratchSpaceSize returns the size of the scratch space of the PVC, the size of the source plus the overhead of
+// the policy when the pod reported it, capped by the size of the PVC, the size of the PVC otherwise. Compressed sources
+// have their own overhead, they are decompressed into scratch space.
+func getScratchSpaceSize(pvc *corev1.PersistentVolumeClaim, policy *cdiv1.ScratchSpacePolicy) resource.Quantity {
+	pvcSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	sourceSize, err := strconv.ParseInt(pvc.Annotations[AnnScratchSize], 10, 64)
+	if err != nil || sourceSize <= 0 {
+		return pvcSize
+	}
+	overhead := int64(defaultScratchOverheadPercent)
+	if policy.OverheadPercent != nil {
+		overhead = int64(*policy.OverheadPercent)
+	}
+	if pvc.Annotations[AnnScratchCompressed] == "true" {
+		overhead = int64(defaultCompressedScratchOverheadPercent)
+		if policy.CompressedOverheadPercent != nil {
+			overhead = int64(*policy.CompressedOverheadPercent)
+		}
+	}
+	size := resource.NewQuantity(sourceSize+sourceSize*overhead/100, resource.BinarySI)
+	if !pvcSize.IsZero() && size.Cmp(pvcSize) > 0 {
+		return pvcSize
+	}
+	return *size
+}
+
+// applyScratchSpacePolicy replaces the scratch PVC of the pod with an emptyDir when the scratch space is within the
+// emptyDir limit of the policy, and returns true if it did
+func applyScratchSpacePolicy(pod *corev1.Pod, pvc *corev1.PersistentVolumeClaim, policy *cdiv1.ScratchSpacePolicy) bool {
+	if policy.EmptyDirLimit == nil {
+		return false
+	}
+	size := getScratchSpaceSize(pvc, policy)
+	if size.IsZero() || size.Cmp(*policy.EmptyDirLimit) > 0 {
+		return false
+	}
+	for i, vol := range pod.Spec.Volumes {
+		if vol.Name == ScratchVolName {
+			pod.Spec.Volumes[i].VolumeSource = corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					SizeLimit: &size,
+				},
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// setScratchSizeFromPod records the size of the source reported by a pod requiring scratch space on the PVC, and if
+// the source is compressed
+func setScratchSizeFromPod(pvc *corev1.PersistentVolumeClaim, pod *corev1.Pod) {
+	failure := podTerminationFailure(pod)
+	delete(pvc.Annotations, AnnScratchCompressed)
+	if failure == nil || failure.ScratchSize <= 0 {
+		delete(pvc.Annotations, AnnScratchSize)
+		return
+	}
+	pvc.Annotations[AnnScratchSize] = strconv.FormatInt(failure.ScratchSize, 10)
+	if failure.ScratchCompressed {
+		pvc.Annotations[AnnScratchCompressed] = "true"
+	}
+}
+
+// hasScratchVolume returns true if the pod has scratch space, a PVC or an emptyDir
+func hasScratchVolume(pod *corev1.Pod) bool {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Name == ScratchVolName {
+			return true
+		}
+	}
+	return false
+}
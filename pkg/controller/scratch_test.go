@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+var _ = Describe("Scratch space", func() {
+	scratchPvc := &corev1.PersistentVolumeClaim{}
+	scratchPvc.Name = "testPvc1-scratch"
+
+	It("Should default to a 100% overhead, 400% for compressed sources, without emptyDir", func() {
+		policy, err := GetScratchSpacePolicy(createClient(createCDIConfig(common.ConfigName)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*policy.OverheadPercent).To(Equal(int32(100)))
+		Expect(*policy.CompressedOverheadPercent).To(Equal(int32(400)))
+		Expect(policy.EmptyDirLimit).To(BeNil())
+	})
+
+	It("Should size the scratch space after the target if the source size is unknown", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{}, nil)
+		size := getScratchSpaceSize(pvc, &cdiv1.ScratchSpacePolicy{})
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+	})
+
+	It("Should size the scratch space after the source plus the overhead", func() {
+		overhead := int32(50)
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnScratchSize: "104857600"}, nil)
+		size := getScratchSpaceSize(pvc, &cdiv1.ScratchSpacePolicy{OverheadPercent: &overhead})
+		Expect(size.Value()).To(Equal(int64(157286400)))
+	})
+
+	It("Should size the scratch space of a compressed source with the compressed overhead", func() {
+		overhead := int32(50)
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnScratchSize: "104857600", AnnScratchCompressed: "true"}, nil)
+		size := getScratchSpaceSize(pvc, &cdiv1.ScratchSpacePolicy{OverheadPercent: &overhead})
+		Expect(size.Value()).To(Equal(int64(524288000)))
+
+		By("Capping it at the size of the target")
+		compressedOverhead := int32(1000)
+		size = getScratchSpaceSize(pvc, &cdiv1.ScratchSpacePolicy{OverheadPercent: &overhead, CompressedOverheadPercent: &compressedOverhead})
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+	})
+
+	It("Should cap the scratch space at the size of the target", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnScratchSize: "900000000"}, nil)
+		size := getScratchSpaceSize(pvc, &cdiv1.ScratchSpacePolicy{})
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+	})
+
+	It("Should create the scratch PVC with the size of the policy", func() {
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnScratchSize: "104857600"}, nil)
+		pod := createImporterTestPod(pvc, "testPvc1", scratchPvc)
+		client := createClient(createCDIConfig(common.ConfigName), pvc)
+		res, err := CreateScratchPersistentVolumeClaim(client, pvc, pod, scratchPvc.Name, "")
+		Expect(err).ToNot(HaveOccurred())
+		size := res.Spec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Value()).To(Equal(int64(209715200)))
+		targetSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		Expect(targetSize.Cmp(resource.MustParse("1G"))).To(Equal(0))
+	})
+
+	It("Should use an emptyDir for scratch space within the limit", func() {
+		limit := resource.MustParse("500Mi")
+		policy := &cdiv1.ScratchSpacePolicy{EmptyDirLimit: &limit}
+		pvc := createPvc("testPvc1", "default", map[string]string{AnnScratchSize: "104857600"}, nil)
+		pod := createImporterTestPod(pvc, "testPvc1", scratchPvc)
+		Expect(applyScratchSpacePolicy(pod, pvc, policy)).To(BeTrue())
+		Expect(hasScratchVolume(pod)).To(BeTrue())
+		_, exists := getScratchNameFromPod(pod)
+		Expect(exists).To(BeFalse())
+		for _, vol := range pod.Spec.Volumes {
+			if vol.Name == ScratchVolName {
+				Expect(vol.EmptyDir).ToNot(BeNil())
+				Expect(vol.EmptyDir.SizeLimit.Value()).To(Equal(int64(209715200)))
+			}
+		}
+
+		By("Keeping the scratch PVC when the source size is unknown")
+		pvc = createPvc("testPvc1", "default", map[string]string{}, nil)
+		pod = createImporterTestPod(pvc, "testPvc1", scratchPvc)
+		Expect(applyScratchSpacePolicy(pod, pvc, policy)).To(BeFalse())
+		name, exists := getScratchNameFromPod(pod)
+		Expect(exists).To(BeTrue())
+		Expect(name).To(Equal(scratchPvc.Name))
+	})
+})
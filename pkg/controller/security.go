@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+// capabilityDacReadSearch allows reading any file and listing any directory
+const capabilityDacReadSearch = corev1.Capability("DAC_READ_SEARCH")
+
+// GetWorkloadSecurityPolicy gets the user and group of the worker pods on filesystem volumes from the cdi config spec,
+// the qemu user and group if not set
+func GetWorkloadSecurityPolicy(c client.Client) (*cdiv1.WorkloadSecurityPolicy, error) {
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		return nil, err
+	}
+	policy := &cdiv1.WorkloadSecurityPolicy{}
+	if cdiconfig.Spec.WorkloadSecurity != nil {
+		policy = cdiconfig.Spec.WorkloadSecurity.DeepCopy()
+	}
+	if policy.RunAsUser == nil {
+		runAsUser := common.QemuUID
+		policy.RunAsUser = &runAsUser
+	}
+	if policy.FSGroup == nil {
+		fsGroup := common.QemuSubGid
+		policy.FSGroup = &fsGroup
+	}
+	return policy, nil
+}
+
+// applySecurityContext hardens a worker pod: its containers drop all capabilities and can't escalate privileges, it
+// runs with the runtime default seccomp profile, and as the non root user of the policy on filesystem volumes.
+// Pods accessing block volumes run as root, the owner of the device.
+func applySecurityContext(pod *corev1.Pod, policy *cdiv1.WorkloadSecurityPolicy, volumeMode corev1.PersistentVolumeMode) {
+	if volumeMode == corev1.PersistentVolumeBlock {
+		applyRootSecurityContext(pod)
+		return
+	}
+	hardenPod(pod, true)
+	runAsNonRoot := true
+	pod.Spec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsUser:    policy.RunAsUser,
+		RunAsGroup:   policy.FSGroup,
+		RunAsNonRoot: &runAsNonRoot,
+		FSGroup:      policy.FSGroup,
+	}
+}
+
+// applyCloneSourceSecurityContext hardens a clone source pod without changing the source PVC, which an fsGroup would
+// give to the group of the policy. The owner of the files of a filesystem source isn't known, the pod reads them as
+// root with the capability to read any file, its only capability. The source is mounted read-only.
+func applyCloneSourceSecurityContext(pod *corev1.Pod, volumeMode corev1.PersistentVolumeMode) {
+	applyRootSecurityContext(pod)
+	if volumeMode == corev1.PersistentVolumeBlock {
+		return
+	}
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].SecurityContext.Capabilities.Add = []corev1.Capability{capabilityDacReadSearch}
+	}
+}
+
+// applyRootSecurityContext hardens a worker pod running as root
+func applyRootSecurityContext(pod *corev1.Pod) {
+	hardenPod(pod, false)
+	runAsUser := int64(0)
+	pod.Spec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsUser: &runAsUser,
+	}
+}
+
+func hardenPod(pod *corev1.Pod, runAsNonRoot bool) {
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	// the seccompProfile field of the security context requires kubernetes 1.19
+	pod.Annotations[corev1.SeccompPodAnnotationKey] = corev1.SeccompProfileRuntimeDefault
+
+	allowPrivilegeEscalation := false
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].SecurityContext = &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+			RunAsNonRoot: &runAsNonRoot,
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+var _ = Describe("Security context", func() {
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "worker"}},
+			},
+		}
+	}
+
+	It("Should default to the qemu user and group", func() {
+		policy, err := GetWorkloadSecurityPolicy(createClient(createCDIConfig(common.ConfigName)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*policy.RunAsUser).To(Equal(common.QemuUID))
+		Expect(*policy.FSGroup).To(Equal(common.QemuSubGid))
+	})
+
+	It("Should use the workload security policy of the cdi config", func() {
+		runAsUser, fsGroup := int64(1000), int64(2000)
+		config := createCDIConfig(common.ConfigName)
+		config.Spec.WorkloadSecurity = &cdiv1.WorkloadSecurityPolicy{RunAsUser: &runAsUser}
+		policy, err := GetWorkloadSecurityPolicy(createClient(config))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*policy.RunAsUser).To(Equal(runAsUser))
+		Expect(*policy.FSGroup).To(Equal(common.QemuSubGid))
+
+		pod := newPod()
+		applySecurityContext(pod, &cdiv1.WorkloadSecurityPolicy{RunAsUser: &runAsUser, FSGroup: &fsGroup}, corev1.PersistentVolumeFilesystem)
+		Expect(*pod.Spec.SecurityContext.RunAsUser).To(Equal(runAsUser))
+		Expect(*pod.Spec.SecurityContext.RunAsGroup).To(Equal(fsGroup))
+		Expect(*pod.Spec.SecurityContext.FSGroup).To(Equal(fsGroup))
+		Expect(*pod.Spec.SecurityContext.RunAsNonRoot).To(BeTrue())
+	})
+
+	It("Should harden the containers of the pod", func() {
+		policy, err := GetWorkloadSecurityPolicy(createClient(createCDIConfig(common.ConfigName)))
+		Expect(err).ToNot(HaveOccurred())
+		for _, volumeMode := range []corev1.PersistentVolumeMode{corev1.PersistentVolumeFilesystem, corev1.PersistentVolumeBlock} {
+			pod := newPod()
+			applySecurityContext(pod, policy, volumeMode)
+			Expect(pod.Annotations[corev1.SeccompPodAnnotationKey]).To(Equal(corev1.SeccompProfileRuntimeDefault))
+			securityContext := pod.Spec.Containers[0].SecurityContext
+			Expect(*securityContext.AllowPrivilegeEscalation).To(BeFalse())
+			Expect(securityContext.Capabilities.Drop).To(ConsistOf(corev1.Capability("ALL")))
+		}
+	})
+
+	It("Should run as root on block volumes", func() {
+		policy, err := GetWorkloadSecurityPolicy(createClient(createCDIConfig(common.ConfigName)))
+		Expect(err).ToNot(HaveOccurred())
+		pod := newPod()
+		applySecurityContext(pod, policy, corev1.PersistentVolumeBlock)
+		Expect(*pod.Spec.SecurityContext.RunAsUser).To(BeZero())
+		Expect(*pod.Spec.Containers[0].SecurityContext.RunAsNonRoot).To(BeFalse())
+	})
+
+	It("Should read clone sources as root without giving them to a group", func() {
+		for _, volumeMode := range []corev1.PersistentVolumeMode{corev1.PersistentVolumeFilesystem, corev1.PersistentVolumeBlock} {
+			pod := newPod()
+			applyCloneSourceSecurityContext(pod, volumeMode)
+			Expect(pod.Annotations[corev1.SeccompPodAnnotationKey]).To(Equal(corev1.SeccompProfileRuntimeDefault))
+			Expect(*pod.Spec.SecurityContext.RunAsUser).To(BeZero())
+			Expect(pod.Spec.SecurityContext.FSGroup).To(BeNil())
+			securityContext := pod.Spec.Containers[0].SecurityContext
+			Expect(*securityContext.AllowPrivilegeEscalation).To(BeFalse())
+			Expect(securityContext.Capabilities.Drop).To(ConsistOf(corev1.Capability("ALL")))
+			if volumeMode == corev1.PersistentVolumeBlock {
+				Expect(securityContext.Capabilities.Add).To(BeEmpty())
+			} else {
+				Expect(securityContext.Capabilities.Add).To(ConsistOf(capabilityDacReadSearch))
+			}
+		}
+	})
+})
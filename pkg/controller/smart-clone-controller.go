@@ -11,9 +11,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -26,28 +30,49 @@ import (
 
 	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
 	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/util/naming"
 )
 
 const (
 	//AnnSmartCloneRequest sets our expected annotation for a CloneRequest
 	AnnSmartCloneRequest = "k8s.io/SmartCloneRequest"
+	// AnnSmartCloneTarget is the namespace/name of the DataVolume a cross namespace smart clone object belongs to
+	AnnSmartCloneTarget = "cdi.kubevirt.io/storage.smartclone.target"
+	// AnnSmartCloneReclaimPolicy keeps the reclaim policy of a PersistentVolume while it is moved to the target namespace
+	AnnSmartCloneReclaimPolicy = "cdi.kubevirt.io/storage.smartclone.reclaimPolicy"
+	// AnnSmartCloneExpansion is the state of the expansion of a PVC restored from a smaller snapshot
+	AnnSmartCloneExpansion = "cdi.kubevirt.io/storage.smartclone.expansion"
+
+	smartCloneTmpPrefix = "cdi-tmp-"
+
+	smartCloneExpansionInProgress = "InProgress"
+	smartCloneExpansionComplete   = "Complete"
+
+	// smartCloneExpanderMaxRetries bounds the retries of the expander pod of DataVolumes without retry policy
+	smartCloneExpanderMaxRetries = 3
 )
 
 // SmartCloneReconciler members
 type SmartCloneReconciler struct {
-	client   client.Client
-	recorder record.EventRecorder
-	scheme   *runtime.Scheme
-	log      logr.Logger
+	client     client.Client
+	recorder   record.EventRecorder
+	scheme     *runtime.Scheme
+	log        logr.Logger
+	image      string
+	verbose    string
+	pullPolicy string
 }
 
 // NewSmartCloneController creates a new instance of the Smart clone controller.
-func NewSmartCloneController(mgr manager.Manager, log logr.Logger) (controller.Controller, error) {
+func NewSmartCloneController(mgr manager.Manager, log logr.Logger, importerImage, pullPolicy, verbose string) (controller.Controller, error) {
 	reconciler := &SmartCloneReconciler{
-		client:   mgr.GetClient(),
-		scheme:   mgr.GetScheme(),
-		log:      log.WithName("smartclone-controller"),
-		recorder: mgr.GetEventRecorderFor("smartclone-controller"),
+		client:     mgr.GetClient(),
+		scheme:     mgr.GetScheme(),
+		log:        log.WithName("smartclone-controller"),
+		recorder:   mgr.GetEventRecorderFor("smartclone-controller"),
+		image:      importerImage,
+		verbose:    verbose,
+		pullPolicy: pullPolicy,
 	}
 	smartCloneController, err := controller.New("smartclone-controller", mgr, controller.Options{
 		Reconciler: reconciler,
@@ -89,6 +114,60 @@ func addSmartCloneControllerWatches(mgr manager.Manager, smartCloneController co
 	}); err != nil {
 		return err
 	}
+	if err := smartCloneController.Watch(&source.Kind{Type: &corev1.PersistentVolumeClaim{}}, smartCloneTargetHandler(), predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return shouldReconcilePvc(e.Object.(*corev1.PersistentVolumeClaim))
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return shouldReconcilePvc(e.ObjectNew.(*corev1.PersistentVolumeClaim))
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return shouldReconcilePvc(e.Object.(*corev1.PersistentVolumeClaim))
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return shouldReconcilePvc(e.Object.(*corev1.PersistentVolumeClaim))
+		},
+	}); err != nil {
+		return err
+	}
+	// the pod resizing the image of an expanded PVC
+	if err := smartCloneController.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
+		OwnerType:    &corev1.PersistentVolumeClaim{},
+		IsController: true,
+	}, predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isSmartCloneExpanderPod(e.Meta)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isSmartCloneExpanderPod(e.MetaNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isSmartCloneExpanderPod(e.Meta)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return isSmartCloneExpanderPod(e.Meta)
+		},
+	}); err != nil {
+		return err
+	}
+	// clean up the objects of cross namespace clones, they aren't garbage collected with the DataVolume
+	if err := smartCloneController.Watch(&source.Kind{Type: &cdiv1.DataVolume{}}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			dv := e.Object.(*cdiv1.DataVolume)
+			return isCrossNamespaceClone(dv)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}); err != nil {
+		return err
+	}
 
 	// check if volume snapshots exist
 	err := mgr.GetClient().List(context.TODO(), &snapshotv1.VolumeSnapshotList{})
@@ -119,10 +198,64 @@ func addSmartCloneControllerWatches(mgr manager.Manager, smartCloneController co
 	}); err != nil {
 		return err
 	}
+	if err := smartCloneController.Watch(&source.Kind{Type: &snapshotv1.VolumeSnapshot{}}, smartCloneTargetHandler(), predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return shouldReconcileSnapshot(e.Object.(*snapshotv1.VolumeSnapshot))
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return shouldReconcileSnapshot(e.ObjectNew.(*snapshotv1.VolumeSnapshot))
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return shouldReconcileSnapshot(e.Object.(*snapshotv1.VolumeSnapshot))
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return shouldReconcileSnapshot(e.Object.(*snapshotv1.VolumeSnapshot))
+		},
+	}); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// smartCloneTargetHandler maps the objects a cross namespace smart clone creates in the source namespace to the DataVolume
+func smartCloneTargetHandler() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			target, ok := obj.Meta.GetAnnotations()[AnnSmartCloneTarget]
+			if !ok {
+				return nil
+			}
+			namespace, name, err := cache.SplitMetaNamespaceKey(target)
+			if err != nil {
+				return nil
+			}
+			return []reconcile.Request{{
+				NamespacedName: types.NamespacedName{Namespace: namespace, Name: name},
+			}}
+		}),
+	}
+}
+
+// isCrossNamespaceClone returns true if the source PVC of the DataVolume is in another namespace
+func isCrossNamespaceClone(dataVolume *cdiv1.DataVolume) bool {
+	sourcePVC := getCloneSourcePVC(dataVolume)
+	return sourcePVC != nil && sourcePVC.Namespace != "" && sourcePVC.Namespace != dataVolume.Namespace
+}
+
+// smartCloneSourceKey returns the key of the snapshot of a smart clone. Cross namespace clones create the snapshot
+// and the temporary PVC restored from it in the source namespace, they are named after the DataVolume UID.
+func smartCloneSourceKey(dataVolume *cdiv1.DataVolume) types.NamespacedName {
+	if !isCrossNamespaceClone(dataVolume) {
+		return types.NamespacedName{Namespace: dataVolume.Namespace, Name: dataVolume.Name}
+	}
+	return types.NamespacedName{Namespace: getCloneSourcePVC(dataVolume).Namespace, Name: smartCloneTmpPrefix + string(dataVolume.UID)}
+}
+
+func isSmartCloneExpanderPod(obj metav1.Object) bool {
+	return obj.GetLabels()[common.CDIComponentLabel] == common.SmartCloneExpanderPodName
+}
+
 func shouldReconcileSnapshot(snapshot *snapshotv1.VolumeSnapshot) bool {
 	_, ok := snapshot.GetAnnotations()[AnnSmartCloneRequest]
 	if !ok {
@@ -151,7 +284,7 @@ func (r *SmartCloneReconciler) Reconcile(req reconcile.Request) (reconcile.Resul
 			snapshot := &snapshotv1.VolumeSnapshot{}
 			if err := r.client.Get(context.TODO(), req.NamespacedName, snapshot); err != nil {
 				if k8serrors.IsNotFound(err) {
-					return reconcile.Result{}, nil
+					return r.reconcileCrossNamespace(log, req.NamespacedName)
 				}
 				return reconcile.Result{}, err
 			}
@@ -162,14 +295,194 @@ func (r *SmartCloneReconciler) Reconcile(req reconcile.Request) (reconcile.Resul
 	return r.reconcilePvc(log, pvc)
 }
 
+// reconcileCrossNamespace restores the snapshot of a cross namespace clone to a temporary PVC in the source namespace,
+// and creates the target PVC for its volume once it is bound. The objects in the source namespace are deleted when
+// the DataVolume is gone.
+func (r *SmartCloneReconciler) reconcileCrossNamespace(log logr.Logger, key types.NamespacedName) (reconcile.Result, error) {
+	datavolume := &cdiv1.DataVolume{}
+	if err := r.client.Get(context.TODO(), key, datavolume); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return reconcile.Result{}, r.cleanupCrossNamespace(log, key)
+		}
+		return reconcile.Result{}, err
+	}
+	if !isCrossNamespaceClone(datavolume) {
+		return reconcile.Result{}, nil
+	}
+
+	sourceKey := smartCloneSourceKey(datavolume)
+	tmpPvc := &corev1.PersistentVolumeClaim{}
+	if err := r.client.Get(context.TODO(), sourceKey, tmpPvc); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		snapshot := &snapshotv1.VolumeSnapshot{}
+		if err := r.client.Get(context.TODO(), sourceKey, snapshot); err != nil {
+			if k8serrors.IsNotFound(err) {
+				return reconcile.Result{}, nil
+			}
+			return reconcile.Result{}, err
+		}
+		if !shouldReconcileSnapshot(snapshot) {
+			return reconcile.Result{}, nil
+		}
+		return r.reconcileSnapshot(log, snapshot)
+	}
+
+	if tmpPvc.Status.Phase != corev1.ClaimBound {
+		log.V(3).Info("Waiting for temporary PVC to be bound", "pvc.Namespace", tmpPvc.Namespace, "pvc.Name", tmpPvc.Name)
+		return reconcile.Result{}, nil
+	}
+
+	// keep the volume when the temporary PVC is deleted
+	pv := &corev1.PersistentVolume{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: tmpPvc.Spec.VolumeName}, pv); err != nil {
+		return reconcile.Result{}, err
+	}
+	if _, ok := pv.Annotations[AnnSmartCloneReclaimPolicy]; !ok {
+		if pv.Annotations == nil {
+			pv.Annotations = make(map[string]string)
+		}
+		pv.Annotations[AnnSmartCloneReclaimPolicy] = string(pv.Spec.PersistentVolumeReclaimPolicy)
+		pv.Annotations[AnnSmartCloneTarget] = key.String()
+		pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+		if err := r.client.Update(context.TODO(), pv); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	pvcSpec, err := renderPvcSpec(r.client, datavolume)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	rendered := datavolume.DeepCopy()
+	rendered.Spec.PVC = pvcSpec
+	newPvc := newPvcForVolume(pv, rendered)
+	log.V(3).Info("Creating PVC for volume", "pvc.Namespace", newPvc.Namespace, "pvc.Name", newPvc.Name, "pv.Name", pv.Name)
+	if err := r.client.Create(context.TODO(), newPvc); err != nil && !k8serrors.IsAlreadyExists(err) {
+		log.Error(err, "error creating pvc for volume")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// rebindVolume moves the volume of the temporary PVC to the target PVC of a cross namespace clone
+func (r *SmartCloneReconciler) rebindVolume(log logr.Logger, datavolume *cdiv1.DataVolume, pvc *corev1.PersistentVolumeClaim) (reconcile.Result, error) {
+	tmpPvc := &corev1.PersistentVolumeClaim{}
+	if err := r.client.Get(context.TODO(), smartCloneSourceKey(datavolume), tmpPvc); err == nil {
+		if tmpPvc.DeletionTimestamp == nil {
+			log.V(3).Info("Deleting temporary PVC", "pvc.Namespace", tmpPvc.Namespace, "pvc.Name", tmpPvc.Name)
+			if err := r.client.Delete(context.TODO(), tmpPvc); err != nil && !k8serrors.IsNotFound(err) {
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{}, nil
+	} else if !k8serrors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+
+	pv := &corev1.PersistentVolume{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+		return reconcile.Result{}, err
+	}
+	if pv.Spec.ClaimRef != nil && pv.Spec.ClaimRef.Namespace == pvc.Namespace && pv.Spec.ClaimRef.Name == pvc.Name {
+		// binding in progress
+		return reconcile.Result{}, nil
+	}
+	pv.Spec.ClaimRef = &corev1.ObjectReference{
+		Kind:       "PersistentVolumeClaim",
+		APIVersion: "v1",
+		Namespace:  pvc.Namespace,
+		Name:       pvc.Name,
+		UID:        pvc.UID,
+	}
+	log.V(3).Info("Binding volume to PVC", "pv.Name", pv.Name, "pvc.Namespace", pvc.Namespace, "pvc.Name", pvc.Name)
+	return reconcile.Result{}, r.client.Update(context.TODO(), pv)
+}
+
+// restoreReclaimPolicy restores the reclaim policy of a volume moved to another namespace
+func (r *SmartCloneReconciler) restoreReclaimPolicy(pv *corev1.PersistentVolume) error {
+	policy, ok := pv.Annotations[AnnSmartCloneReclaimPolicy]
+	if !ok {
+		return nil
+	}
+	pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimPolicy(policy)
+	delete(pv.Annotations, AnnSmartCloneReclaimPolicy)
+	delete(pv.Annotations, AnnSmartCloneTarget)
+	return r.client.Update(context.TODO(), pv)
+}
+
+// cleanupCrossNamespace deletes the snapshot and temporary PVC of a deleted cross namespace clone DataVolume
+func (r *SmartCloneReconciler) cleanupCrossNamespace(log logr.Logger, key types.NamespacedName) error {
+	selector := &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{common.CDIComponentLabel: common.SmartClonerCDILabel}),
+	}
+	pvs := &corev1.PersistentVolumeList{}
+	if err := r.client.List(context.TODO(), pvs); err != nil {
+		return err
+	}
+	for i := range pvs.Items {
+		if pvs.Items[i].Annotations[AnnSmartCloneTarget] == key.String() {
+			if err := r.restoreReclaimPolicy(&pvs.Items[i]); err != nil {
+				return err
+			}
+		}
+	}
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.client.List(context.TODO(), pvcs, selector); err != nil {
+		return err
+	}
+	for i := range pvcs.Items {
+		if pvcs.Items[i].Annotations[AnnSmartCloneTarget] == key.String() {
+			log.V(3).Info("Deleting temporary PVC", "pvc.Namespace", pvcs.Items[i].Namespace, "pvc.Name", pvcs.Items[i].Name)
+			if err := r.client.Delete(context.TODO(), &pvcs.Items[i]); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	snapshots := &snapshotv1.VolumeSnapshotList{}
+	if err := r.client.List(context.TODO(), snapshots, selector); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	for i := range snapshots.Items {
+		if snapshots.Items[i].Annotations[AnnSmartCloneTarget] == key.String() {
+			log.V(3).Info("Deleting snapshot", "snapshot.Namespace", snapshots.Items[i].Namespace, "snapshot.Name", snapshots.Items[i].Name)
+			if err := r.client.Delete(context.TODO(), &snapshots.Items[i]); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (r *SmartCloneReconciler) reconcilePvc(log logr.Logger, pvc *corev1.PersistentVolumeClaim) (reconcile.Result, error) {
 	log.WithValues("pvc.Name", pvc.Name).WithValues("pvc.Namespace", pvc.Namespace).Info("PVC created from snapshot, updating datavolume status")
-	snapshotName := pvc.Spec.DataSource.Name
 
 	datavolume := &cdiv1.DataVolume{}
-	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: snapshotName, Namespace: pvc.Namespace}, datavolume); err != nil {
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, datavolume); err != nil {
 		return reconcile.Result{}, err
 	}
+	crossNamespace := isCrossNamespaceClone(datavolume)
+	if crossNamespace && pvc.Status.Phase != corev1.ClaimBound {
+		return r.rebindVolume(log, datavolume, pvc)
+	}
+
+	if pvc.Status.Phase == corev1.ClaimBound && datavolume.Status.Phase != cdiv1.Succeeded {
+		expanded, err := r.expandPvc(log, datavolume, pvc)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if isPVCFailed(pvc) {
+			return reconcile.Result{}, r.updateSmartCloneStatusPhase(cdiv1.Failed, datavolume, pvc)
+		}
+		if !expanded {
+			// a failed expander pod is recreated once the retry backoff elapsed
+			return reconcile.Result{RequeueAfter: retryDelay(pvc)}, nil
+		}
+	}
 
 	// Update DV phase and emit PVC in progress event
 	if err := r.updateSmartCloneStatusPhase(cdiv1.Succeeded, datavolume, pvc); err != nil {
@@ -180,8 +493,17 @@ func (r *SmartCloneReconciler) reconcilePvc(log logr.Logger, pvc *corev1.Persist
 
 	// Don't delete snapshot unless the PVC is bound.
 	if pvc.Status.Phase == corev1.ClaimBound {
+		if crossNamespace {
+			pv := &corev1.PersistentVolume{}
+			if err := r.client.Get(context.TODO(), types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+				return reconcile.Result{}, err
+			}
+			if err := r.restoreReclaimPolicy(pv); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
 		snapshotToDelete := &snapshotv1.VolumeSnapshot{}
-		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: snapshotName, Namespace: pvc.Namespace}, snapshotToDelete); err != nil {
+		if err := r.client.Get(context.TODO(), smartCloneSourceKey(datavolume), snapshotToDelete); err != nil {
 			if k8serrors.IsNotFound(err) {
 				// Already gone, so no need to try a delete.
 				return reconcile.Result{}, nil
@@ -203,8 +525,16 @@ func (r *SmartCloneReconciler) reconcilePvc(log logr.Logger, pvc *corev1.Persist
 
 func (r *SmartCloneReconciler) reconcileSnapshot(log logr.Logger, snapshot *snapshotv1.VolumeSnapshot) (reconcile.Result, error) {
 	log.WithValues("snapshot.Name", snapshot.Name).WithValues("snapshot.Namespace", snapshot.Namespace).Info("Updating datavolume status using snapshot")
+	dvKey := types.NamespacedName{Name: snapshot.Name, Namespace: snapshot.Namespace}
+	if target, ok := snapshot.Annotations[AnnSmartCloneTarget]; ok {
+		namespace, name, err := cache.SplitMetaNamespaceKey(target)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		dvKey = types.NamespacedName{Name: name, Namespace: namespace}
+	}
 	datavolume := &cdiv1.DataVolume{}
-	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: snapshot.Name, Namespace: snapshot.Namespace}, datavolume); err != nil {
+	if err := r.client.Get(context.TODO(), dvKey, datavolume); err != nil {
 		return reconcile.Result{}, err
 	}
 
@@ -215,10 +545,27 @@ func (r *SmartCloneReconciler) reconcileSnapshot(log logr.Logger, snapshot *snap
 		return reconcile.Result{}, err
 	}
 
-	newPvc := newPvcFromSnapshot(snapshot, datavolume)
+	pvcSpec, err := renderPvcSpec(r.client, datavolume)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	rendered := datavolume.DeepCopy()
+	rendered.Spec.PVC = pvcSpec
+	newPvc := newPvcFromSnapshot(snapshot, rendered)
 	if newPvc == nil {
 		return reconcile.Result{}, errors.New("error creating new pvc from snapshot object, snapshot has no owner")
 	}
+	if err := setPodPlacementAnnotation(newPvc, datavolume.Spec.Placement); err != nil {
+		return reconcile.Result{}, err
+	}
+	expandable, err := r.allowsVolumeExpansion(newPvc.Spec.StorageClassName)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if expandable {
+		// not all CSI drivers restore a snapshot to a larger volume, the PVC is expanded once bound
+		restoreWithSnapshotSize(newPvc, snapshot)
+	}
 
 	log.V(3).Info("Creating PVC from snapshot", "pvc.Namespace", newPvc.Namespace, "pvc.Name", newPvc.Name)
 	if err := r.client.Create(context.TODO(), newPvc); err != nil {
@@ -229,6 +576,186 @@ func (r *SmartCloneReconciler) reconcileSnapshot(log logr.Logger, snapshot *snap
 	return reconcile.Result{}, nil
 }
 
+// allowsVolumeExpansion returns true if the storage class, or the default storage class if the name is nil, allows
+// volume expansion
+func (r *SmartCloneReconciler) allowsVolumeExpansion(storageClassName *string) (bool, error) {
+	storageClass, err := GetStorageClassByName(r.client, storageClassName)
+	if err != nil {
+		return false, err
+	}
+	return storageClass != nil && storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
+
+// expandPvc expands a PVC restored from a snapshot smaller than the DataVolume requests, and resizes the image on
+// filesystem volumes with a pod. It returns true once the PVC is expanded, or if it cannot be expanded.
+func (r *SmartCloneReconciler) expandPvc(log logr.Logger, datavolume *cdiv1.DataVolume, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	// a clone without size has the size of the source
+	requestedSize, ok := getDataVolumeRequestedSize(datavolume)
+	if !ok {
+		return true, nil
+	}
+	switch pvc.Annotations[AnnSmartCloneExpansion] {
+	case smartCloneExpansionComplete:
+		return true, nil
+	case smartCloneExpansionInProgress:
+	default:
+		pvcSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if pvcSize.Cmp(requestedSize) >= 0 {
+			return true, nil
+		}
+		expandable, err := r.allowsVolumeExpansion(pvc.Spec.StorageClassName)
+		if err != nil {
+			return false, err
+		}
+		if !expandable {
+			var storageClassName string
+			if pvc.Spec.StorageClassName != nil {
+				storageClassName = *pvc.Spec.StorageClassName
+			}
+			r.recorder.Eventf(datavolume, corev1.EventTypeWarning, SmartCloneExpansionNotPossible, MessageSmartCloneExpansionNotPossible, storageClassName, pvc.Name)
+			return true, nil
+		}
+		log.V(3).Info("Expanding PVC", "pvc.Namespace", pvc.Namespace, "pvc.Name", pvc.Name, "size", requestedSize.String())
+		if pvc.Annotations == nil {
+			pvc.Annotations = make(map[string]string)
+		}
+		pvc.Annotations[AnnSmartCloneExpansion] = smartCloneExpansionInProgress
+		if pvc.Spec.Resources.Requests == nil {
+			pvc.Spec.Resources.Requests = make(corev1.ResourceList)
+		}
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = requestedSize
+		if err := r.client.Update(context.TODO(), pvc); err != nil {
+			return false, err
+		}
+		r.recorder.Eventf(datavolume, corev1.EventTypeNormal, SmartCloneExpansionInProgress, MessageSmartCloneExpansionInProgress, pvc.Name, requestedSize.String())
+		return false, nil
+	}
+
+	filesystem := getVolumeMode(pvc) == corev1.PersistentVolumeFilesystem
+	capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	// the filesystem is resized once a pod mounts the volume
+	if capacity.Cmp(requestedSize) < 0 && !(filesystem && isFileSystemResizePending(pvc)) {
+		log.V(3).Info("Waiting for PVC expansion", "pvc.Namespace", pvc.Namespace, "pvc.Name", pvc.Name)
+		return false, nil
+	}
+	if filesystem && datavolume.Spec.ContentType != cdiv1.DataVolumeArchive {
+		resized, err := r.resizeImage(log, datavolume, pvc, requestedSize)
+		if err != nil || !resized {
+			return false, err
+		}
+	}
+	pvc.Annotations[AnnSmartCloneExpansion] = smartCloneExpansionComplete
+	if err := r.client.Update(context.TODO(), pvc); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// resizeImage resizes the image on an expanded filesystem volume with a pod, it returns true once the pod succeeded.
+// Failed pods are retried with the retry policy of the DataVolume, the PVC is marked failed once it gives up.
+func (r *SmartCloneReconciler) resizeImage(log logr.Logger, datavolume *cdiv1.DataVolume, pvc *corev1.PersistentVolumeClaim, size resource.Quantity) (bool, error) {
+	if isPVCFailed(pvc) {
+		return false, nil
+	}
+	if !hasRetryPolicy(pvc) {
+		setRetryPolicyAnnotations(pvc.Annotations, smartCloneExpanderRetryPolicy(datavolume))
+		if err := r.client.Update(context.TODO(), pvc); err != nil {
+			return false, err
+		}
+	}
+	pod := &corev1.Pod{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: pvc.Namespace, Name: smartCloneExpanderPodName(pvc)}, pod); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		if delay := retryDelay(pvc); delay > 0 {
+			log.V(3).Info("Waiting for the retry backoff to elapse", "delay", delay)
+			return false, nil
+		}
+		podResourceRequirements, err := GetDefaultPodResourceRequirements(r.client)
+		if err != nil {
+			return false, err
+		}
+		workloadPlacement, err := GetWorkloadPlacement(r.client, pvc)
+		if err != nil {
+			return false, err
+		}
+		securityPolicy, err := GetWorkloadSecurityPolicy(r.client)
+		if err != nil {
+			return false, err
+		}
+		filesystemOverhead, err := GetFilesystemOverhead(r.client, pvc)
+		if err != nil {
+			return false, err
+		}
+		pod = newSmartCloneExpanderPod(pvc, r.image, r.verbose, r.pullPolicy, size.String(), string(filesystemOverhead), podResourceRequirements)
+		applyNodePlacement(&pod.Spec, workloadPlacement)
+		applySecurityContext(pod, securityPolicy, corev1.PersistentVolumeFilesystem)
+		log.V(3).Info("Creating pod to resize image", "pod.Namespace", pod.Namespace, "pod.Name", pod.Name)
+		if err := r.client.Create(context.TODO(), pod); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	if failure := smartCloneExpanderFailure(pod); failure != nil {
+		retry := retryFailedPod(pvc, failure, r.recorder)
+		if err := r.client.Update(context.TODO(), pvc); err != nil {
+			return false, err
+		}
+		if !retry {
+			// keep the failed pod around for its logs
+			log.V(1).Info("Resizing image failed, not retrying", "pod.Name", pod.Name, "reason", failure.reason)
+			return false, nil
+		}
+		log.V(1).Info("Resizing image failed, deleting pod to retry", "pod.Name", pod.Name, "reason", failure.reason, "retries", pvc.Annotations[AnnPodRestarts])
+		if err := r.client.Delete(context.TODO(), pod); err != nil && !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	if pod.Status.Phase != corev1.PodSucceeded {
+		return false, nil
+	}
+	log.V(3).Info("Image resized, deleting pod", "pod.Namespace", pod.Namespace, "pod.Name", pod.Name)
+	if err := r.client.Delete(context.TODO(), pod); err != nil && !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// smartCloneExpanderRetryPolicy returns the retry policy of the expander pod, the one of the DataVolume if it has one
+func smartCloneExpanderRetryPolicy(datavolume *cdiv1.DataVolume) *cdiv1.DataVolumeRetryPolicy {
+	if datavolume.Spec.RetryPolicy != nil {
+		return datavolume.Spec.RetryPolicy
+	}
+	maxRetries := int32(smartCloneExpanderMaxRetries)
+	return &cdiv1.DataVolumeRetryPolicy{MaxRetries: &maxRetries}
+}
+
+// smartCloneExpanderFailure returns the failure of the expander pod, nil if it didn't fail. Expander pods created before
+// the retry policy applied to them are restarted by the kubelet, a restart is a failure too.
+func smartCloneExpanderFailure(pod *corev1.Pod) *podFailure {
+	if failure := getPodFailure(pod); failure != nil {
+		return failure
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.LastTerminationState.Terminated
+		if status.RestartCount > 0 && terminated != nil && terminated.ExitCode != 0 {
+			return containerFailure(terminated)
+		}
+	}
+	return nil
+}
+
+func isFileSystemResizePending(pvc *corev1.PersistentVolumeClaim) bool {
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Type == corev1.PersistentVolumeClaimFileSystemResizePending && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *SmartCloneReconciler) updateSmartCloneStatusPhase(phase cdiv1.DataVolumePhase, dataVolume *cdiv1.DataVolume, newPVC *corev1.PersistentVolumeClaim) error {
 	var dataVolumeCopy = dataVolume.DeepCopy()
 	var event DataVolumeEvent
@@ -238,7 +765,7 @@ func (r *SmartCloneReconciler) updateSmartCloneStatusPhase(phase cdiv1.DataVolum
 		dataVolumeCopy.Status.Phase = cdiv1.SmartClonePVCInProgress
 		event.eventType = corev1.EventTypeNormal
 		event.reason = SmartClonePVCInProgress
-		event.message = fmt.Sprintf(MessageSmartClonePVCInProgress, dataVolumeCopy.Spec.Source.PVC.Namespace, dataVolumeCopy.Spec.Source.PVC.Name)
+		event.message = fmt.Sprintf(MessageSmartClonePVCInProgress, getCloneSourcePVC(dataVolumeCopy).Namespace, getCloneSourcePVC(dataVolumeCopy).Name)
 		dataVolume.Status.Conditions = updateBoundCondition(dataVolume.Status.Conditions, newPVC)
 		dataVolume.Status.Conditions = updateReadyCondition(dataVolume.Status.Conditions, corev1.ConditionFalse, "", "")
 		dataVolume.Status.Conditions = updateCondition(dataVolume.Status.Conditions, cdiv1.DataVolumeRunning, corev1.ConditionTrue, MessageSmartClonePVCInProgress, SmartClonePVCInProgress)
@@ -246,10 +773,17 @@ func (r *SmartCloneReconciler) updateSmartCloneStatusPhase(phase cdiv1.DataVolum
 		dataVolumeCopy.Status.Phase = cdiv1.Succeeded
 		event.eventType = corev1.EventTypeNormal
 		event.reason = CloneSucceeded
-		event.message = fmt.Sprintf(MessageCloneSucceeded, dataVolumeCopy.Spec.Source.PVC.Namespace, dataVolumeCopy.Spec.Source.PVC.Name, newPVC.Namespace, newPVC.Name)
+		event.message = fmt.Sprintf(MessageCloneSucceeded, getCloneSourcePVC(dataVolumeCopy).Namespace, getCloneSourcePVC(dataVolumeCopy).Name, newPVC.Namespace, newPVC.Name)
 		dataVolume.Status.Conditions = updateBoundCondition(dataVolume.Status.Conditions, newPVC)
 		dataVolume.Status.Conditions = updateReadyCondition(dataVolume.Status.Conditions, corev1.ConditionTrue, "", "")
 		dataVolume.Status.Conditions = updateCondition(dataVolume.Status.Conditions, cdiv1.DataVolumeRunning, corev1.ConditionFalse, cloneComplete, "Completed")
+	case cdiv1.Failed:
+		dataVolumeCopy.Status.Phase = cdiv1.Failed
+		event.eventType = corev1.EventTypeWarning
+		event.reason = SmartCloneExpansionFailed
+		event.message = fmt.Sprintf(MessageSmartCloneExpansionFailed, newPVC.Name, newPVC.Annotations[AnnRunningConditionMessage])
+		dataVolumeCopy.Status.Conditions = updateReadyCondition(dataVolumeCopy.Status.Conditions, corev1.ConditionFalse, "", "")
+		dataVolumeCopy.Status.Conditions = updateCondition(dataVolumeCopy.Status.Conditions, cdiv1.DataVolumeRunning, corev1.ConditionFalse, newPVC.Annotations[AnnRunningConditionMessage], newPVC.Annotations[AnnRunningConditionReason])
 	}
 
 	return r.emitEvent(dataVolume, dataVolumeCopy, &event, newPVC)
@@ -276,16 +810,22 @@ func newPvcFromSnapshot(snapshot *snapshotv1.VolumeSnapshot, dataVolume *cdiv1.D
 		common.CDILabelKey:       common.CDILabelValue,
 		common.CDIComponentLabel: common.SmartClonerCDILabel,
 	}
+	annotations := make(map[string]string)
+	annotations[AnnSmartCloneRequest] = "true"
 	ownerRef := metav1.GetControllerOf(snapshot)
-	if ownerRef == nil {
+	var ownerRefs []metav1.OwnerReference
+	if ownerRef != nil {
+		ownerRefs = []metav1.OwnerReference{*ownerRef}
+		annotations[AnnCloneOf] = "true"
+		annotations[AnnRunningCondition] = string(corev1.ConditionFalse)
+		annotations[AnnRunningConditionMessage] = cloneComplete
+		annotations[AnnRunningConditionReason] = "Completed"
+	} else if target, ok := snapshot.Annotations[AnnSmartCloneTarget]; ok {
+		// temporary PVC of a cross namespace clone
+		annotations[AnnSmartCloneTarget] = target
+	} else {
 		return nil
 	}
-	annotations := make(map[string]string)
-	annotations[AnnSmartCloneRequest] = "true"
-	annotations[AnnCloneOf] = "true"
-	annotations[AnnRunningCondition] = string(corev1.ConditionFalse)
-	annotations[AnnRunningConditionMessage] = cloneComplete
-	annotations[AnnRunningConditionReason] = "Completed"
 
 	return &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
@@ -293,7 +833,7 @@ func newPvcFromSnapshot(snapshot *snapshotv1.VolumeSnapshot, dataVolume *cdiv1.D
 			Namespace:       snapshot.Namespace,
 			Labels:          labels,
 			Annotations:     annotations,
-			OwnerReferences: []metav1.OwnerReference{*ownerRef},
+			OwnerReferences: ownerRefs,
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			DataSource: &corev1.TypedLocalObjectReference{
@@ -310,3 +850,119 @@ func newPvcFromSnapshot(snapshot *snapshotv1.VolumeSnapshot, dataVolume *cdiv1.D
 		},
 	}
 }
+
+// restoreWithSnapshotSize requests the restore size of the snapshot if it is smaller than the PVC requests
+func restoreWithSnapshotSize(pvc *corev1.PersistentVolumeClaim, snapshot *snapshotv1.VolumeSnapshot) {
+	if snapshot.Status == nil || snapshot.Status.RestoreSize == nil || snapshot.Status.RestoreSize.IsZero() {
+		return
+	}
+	requestedSize, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if ok && snapshot.Status.RestoreSize.Cmp(requestedSize) < 0 {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: *snapshot.Status.RestoreSize}
+	}
+}
+
+func smartCloneExpanderPodName(pvc *corev1.PersistentVolumeClaim) string {
+	return naming.GetResourceName("cdi-expand", pvc.Name)
+}
+
+// newSmartCloneExpanderPod creates the pod resizing the image on an expanded PVC to the requested size
+func newSmartCloneExpanderPod(pvc *corev1.PersistentVolumeClaim, image, verbose, pullPolicy, imageSize, filesystemOverhead string, podResourceRequirements *corev1.ResourceRequirements) *corev1.Pod {
+	podEnvVar := &importPodEnvVar{
+		source:             SourceResize,
+		contentType:        string(cdiv1.DataVolumeKubeVirt),
+		imageSize:          imageSize,
+		filesystemOverhead: filesystemOverhead,
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      smartCloneExpanderPodName(pvc),
+			Namespace: pvc.Namespace,
+			Annotations: map[string]string{
+				AnnCreatedBy: "yes",
+			},
+			Labels: map[string]string{
+				common.CDILabelKey:       common.CDILabelValue,
+				common.CDIComponentLabel: common.SmartCloneExpanderPodName,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(pvc, corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim")),
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:            common.SmartCloneExpanderPodName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullPolicy(pullPolicy),
+					Args:            []string{"-v=" + verbose},
+					Env:             makeImportEnv(podEnvVar, pvc.UID),
+					VolumeMounts:    addImportVolumeMounts(),
+				},
+			},
+			RestartPolicy: podRestartPolicy(pvc),
+			Volumes: []corev1.Volume{
+				{
+					Name: DataVolName,
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: pvc.Name,
+						},
+					},
+				},
+			},
+		},
+	}
+	if podResourceRequirements != nil {
+		pod.Spec.Containers[0].Resources = *podResourceRequirements
+	}
+	return pod
+}
+
+// newPvcForVolume creates the target PVC of a cross namespace clone, bound to the volume restored in the source namespace
+func newPvcForVolume(pv *corev1.PersistentVolume, dataVolume *cdiv1.DataVolume) *corev1.PersistentVolumeClaim {
+	labels := map[string]string{
+		"cdi-controller":         dataVolume.Name,
+		common.CDILabelKey:       common.CDILabelValue,
+		common.CDIComponentLabel: common.SmartClonerCDILabel,
+	}
+	annotations := make(map[string]string)
+	annotations[AnnSmartCloneRequest] = "true"
+	annotations[AnnCloneOf] = "true"
+	annotations[AnnRunningCondition] = string(corev1.ConditionFalse)
+	annotations[AnnRunningConditionMessage] = cloneComplete
+	annotations[AnnRunningConditionReason] = "Completed"
+
+	// a volume smaller than the request does not bind, the PVC is expanded once bound
+	requests := dataVolume.Spec.PVC.Resources.Requests
+	requestedSize, hasRequest := requests[corev1.ResourceStorage]
+	capacity, hasCapacity := pv.Spec.Capacity[corev1.ResourceStorage]
+	if hasRequest && hasCapacity && capacity.Cmp(requestedSize) < 0 {
+		requests = corev1.ResourceList{corev1.ResourceStorage: capacity}
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        dataVolume.Name,
+			Namespace:   dataVolume.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(dataVolume, schema.GroupVersionKind{
+					Group:   cdiv1.SchemeGroupVersion.Group,
+					Version: cdiv1.SchemeGroupVersion.Version,
+					Kind:    "DataVolume",
+				}),
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			VolumeName:       pv.Name,
+			VolumeMode:       dataVolume.Spec.PVC.VolumeMode,
+			AccessModes:      dataVolume.Spec.PVC.AccessModes,
+			StorageClassName: &pv.Spec.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: requests,
+			},
+		},
+	}
+}
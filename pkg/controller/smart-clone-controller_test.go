@@ -24,7 +24,9 @@ import (
 	"github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -215,6 +217,381 @@ var _ = Describe("Smart-clone controller reconcileSnapshot loop", func() {
 	})
 })
 
+var _ = Describe("Smart-clone controller PVC expansion", func() {
+	var (
+		reconciler *SmartCloneReconciler
+		dvKey      = types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}
+		podKey     = types.NamespacedName{Name: "cdi-expand-test-dv", Namespace: metav1.NamespaceDefault}
+	)
+	AfterEach(func() {
+		if reconciler != nil {
+			close(reconciler.recorder.(*record.FakeRecorder).Events)
+			reconciler = nil
+		}
+	})
+
+	newStorageClass := func(allowExpansion bool) *storagev1.StorageClass {
+		sc := createStorageClassWithProvisioner("testsc", nil, "csi-plugin")
+		sc.AllowVolumeExpansion = &allowExpansion
+		return sc
+	}
+
+	newDataVolume := func(size string) *cdiv1.DataVolume {
+		dv := newCloneDataVolume("test-dv")
+		dv.Spec.PVC.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}
+		return dv
+	}
+
+	newRestoredPvc := func(annotations map[string]string, volumeMode corev1.PersistentVolumeMode, capacity string) *corev1.PersistentVolumeClaim {
+		pvc := createPVCWithSnapshotSource("test-dv", "test-dv")
+		for k, v := range annotations {
+			pvc.Annotations[k] = v
+		}
+		pvc.Spec.StorageClassName = &[]string{"testsc"}[0]
+		pvc.Spec.VolumeMode = &volumeMode
+		pvc.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)}
+		return pvc
+	}
+
+	getPvc := func() *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := reconciler.client.Get(context.TODO(), dvKey, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		return pvc
+	}
+
+	getDataVolume := func() *cdiv1.DataVolume {
+		datavolume := &cdiv1.DataVolume{}
+		err := reconciler.client.Get(context.TODO(), dvKey, datavolume)
+		Expect(err).ToNot(HaveOccurred())
+		return datavolume
+	}
+
+	It("Should restore the PVC with the snapshot size if the storage class allows expansion", func() {
+		controller := true
+		snapshot := createSnapshotVolume("test-dv", metav1.NamespaceDefault, &metav1.OwnerReference{
+			Controller: &controller,
+		})
+		restoreSize := resource.MustParse("1G")
+		snapshot.Status = &snapshotv1.VolumeSnapshotStatus{RestoreSize: &restoreSize}
+		dv := newDataVolume("2G")
+		dv.Spec.PVC.StorageClassName = &[]string{"testsc"}[0]
+		reconciler = createSmartCloneReconciler(dv, newStorageClass(true))
+		_, err := reconciler.reconcileSnapshot(reconciler.log, snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		pvc := getPvc()
+		Expect(pvc.Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(restoreSize))
+	})
+
+	It("Should expand the PVC and resize the image on a filesystem volume", func() {
+		pvc := newRestoredPvc(nil, corev1.PersistentVolumeFilesystem, "1G")
+		reconciler = createSmartCloneReconciler(newDataVolume("2G"), newStorageClass(true), pvc)
+		_, err := reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		By("Checking the PVC requests the DataVolume size")
+		pvc = getPvc()
+		Expect(pvc.Annotations[AnnSmartCloneExpansion]).To(Equal(smartCloneExpansionInProgress))
+		Expect(pvc.Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(resource.MustParse("2G")))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring("Expanding PVC test-dv restored from a snapshot to 2G"))
+		Expect(getDataVolume().Status.Phase).ToNot(Equal(cdiv1.Succeeded))
+
+		By("Checking the expander pod is created once the volume is expanded")
+		pvc.Status.Capacity[corev1.ResourceStorage] = resource.MustParse("2G")
+		err = reconciler.client.Update(context.TODO(), pvc)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		pod := &corev1.Pod{}
+		err = reconciler.client.Get(context.TODO(), podKey, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod.Spec.Containers[0].Image).To(Equal("test-image"))
+		Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: common.ImporterSource, Value: SourceResize}))
+		Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: common.ImporterImageSize, Value: "2G"}))
+		Expect(pod.OwnerReferences[0].UID).To(Equal(pvc.UID))
+		Expect(getDataVolume().Status.Phase).ToNot(Equal(cdiv1.Succeeded))
+
+		By("Checking the DataVolume succeeds once the pod completed")
+		pod.Status.Phase = corev1.PodSucceeded
+		err = reconciler.client.Update(context.TODO(), pod)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = reconciler.reconcilePvc(reconciler.log, getPvc())
+		Expect(err).ToNot(HaveOccurred())
+		err = reconciler.client.Get(context.TODO(), podKey, pod)
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		Expect(getPvc().Annotations[AnnSmartCloneExpansion]).To(Equal(smartCloneExpansionComplete))
+		Expect(getDataVolume().Status.Phase).To(Equal(cdiv1.Succeeded))
+	})
+
+	It("Should wait for the filesystem resize before creating the expander pod", func() {
+		pvc := newRestoredPvc(map[string]string{AnnSmartCloneExpansion: smartCloneExpansionInProgress}, corev1.PersistentVolumeFilesystem, "1G")
+		reconciler = createSmartCloneReconciler(newDataVolume("2G"), newStorageClass(true), pvc)
+		_, err := reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		err = reconciler.client.Get(context.TODO(), podKey, &corev1.Pod{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+
+		pvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{
+			{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue},
+		}
+		_, err = reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		err = reconciler.client.Get(context.TODO(), podKey, &corev1.Pod{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Should not resize the image on a block volume", func() {
+		pvc := newRestoredPvc(map[string]string{AnnSmartCloneExpansion: smartCloneExpansionInProgress}, corev1.PersistentVolumeBlock, "2G")
+		reconciler = createSmartCloneReconciler(newDataVolume("2G"), newStorageClass(true), pvc)
+		_, err := reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		err = reconciler.client.Get(context.TODO(), podKey, &corev1.Pod{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		Expect(getPvc().Annotations[AnnSmartCloneExpansion]).To(Equal(smartCloneExpansionComplete))
+		Expect(getDataVolume().Status.Phase).To(Equal(cdiv1.Succeeded))
+	})
+
+	It("Should succeed without expanding if the storage class does not allow expansion", func() {
+		reconciler = createSmartCloneReconciler(newDataVolume("2G"), newStorageClass(false))
+		_, err := reconciler.reconcilePvc(reconciler.log, newRestoredPvc(nil, corev1.PersistentVolumeFilesystem, "1G"))
+		Expect(err).ToNot(HaveOccurred())
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring("Storage class testsc does not allow volume expansion"))
+		Expect(getDataVolume().Status.Phase).To(Equal(cdiv1.Succeeded))
+	})
+
+	failExpanderPod := func() {
+		pod := &corev1.Pod{}
+		err := reconciler.client.Get(context.TODO(), podKey, pod)
+		Expect(err).ToNot(HaveOccurred())
+		pod.Status.Phase = corev1.PodFailed
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", Message: "resize failed"}}},
+		}
+		err = reconciler.client.Update(context.TODO(), pod)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("Should retry a failed expander pod with the default retry policy", func() {
+		pvc := newRestoredPvc(map[string]string{AnnSmartCloneExpansion: smartCloneExpansionInProgress}, corev1.PersistentVolumeFilesystem, "2G")
+		reconciler = createSmartCloneReconciler(newDataVolume("2G"), newStorageClass(true), pvc)
+		_, err := reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		pvc = getPvc()
+		Expect(pvc.Annotations[AnnRetryMaxRetries]).To(Equal("3"))
+		pod := &corev1.Pod{}
+		err = reconciler.client.Get(context.TODO(), podKey, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+
+		failExpanderPod()
+		result, err := reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(RetryScheduled))
+		err = reconciler.client.Get(context.TODO(), podKey, pod)
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		Expect(getPvc().Annotations[AnnPodRestarts]).To(Equal("1"))
+
+		By("Waiting for the backoff before recreating the pod")
+		_, err = reconciler.reconcilePvc(reconciler.log, getPvc())
+		Expect(err).ToNot(HaveOccurred())
+		err = reconciler.client.Get(context.TODO(), podKey, pod)
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		Expect(getDataVolume().Status.Phase).ToNot(Equal(cdiv1.Failed))
+	})
+
+	It("Should fail the DataVolume once the retry policy of the expander pod gives up", func() {
+		maxRetries := int32(0)
+		dv := newDataVolume("2G")
+		dv.Spec.RetryPolicy = &cdiv1.DataVolumeRetryPolicy{MaxRetries: &maxRetries}
+		pvc := newRestoredPvc(map[string]string{AnnSmartCloneExpansion: smartCloneExpansionInProgress}, corev1.PersistentVolumeFilesystem, "2G")
+		reconciler = createSmartCloneReconciler(dv, newStorageClass(true), pvc)
+		_, err := reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getPvc().Annotations[AnnRetryMaxRetries]).To(Equal("0"))
+
+		failExpanderPod()
+		_, err = reconciler.reconcilePvc(reconciler.log, getPvc())
+		Expect(err).ToNot(HaveOccurred())
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(RetryLimitReached))
+		event = <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring("Resizing the image on PVC test-dv failed: resize failed"))
+		Expect(isPVCFailed(getPvc())).To(BeTrue())
+		By("Keeping the failed pod for its logs")
+		err = reconciler.client.Get(context.TODO(), podKey, &corev1.Pod{})
+		Expect(err).ToNot(HaveOccurred())
+		datavolume := getDataVolume()
+		Expect(datavolume.Status.Phase).To(Equal(cdiv1.Failed))
+		running := findConditionByType(cdiv1.DataVolumeRunning, datavolume.Status.Conditions)
+		Expect(running).ToNot(BeNil())
+		Expect(running.Status).To(Equal(corev1.ConditionFalse))
+		Expect(running.Reason).To(Equal("Error"))
+		Expect(running.Message).To(Equal("resize failed"))
+	})
+
+	It("Should retry a restarting expander pod created without retry policy", func() {
+		pvc := newRestoredPvc(map[string]string{AnnSmartCloneExpansion: smartCloneExpansionInProgress}, corev1.PersistentVolumeFilesystem, "2G")
+		pod := newSmartCloneExpanderPod(pvc, "test-image", "1", "", "2G", "0.055", nil)
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyOnFailure))
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{
+				RestartCount:         1,
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", Message: "resize failed"}},
+			},
+		}
+		reconciler = createSmartCloneReconciler(newDataVolume("2G"), newStorageClass(true), pvc, pod)
+		_, err := reconciler.reconcilePvc(reconciler.log, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		event := <-reconciler.recorder.(*record.FakeRecorder).Events
+		Expect(event).To(ContainSubstring(RetryScheduled))
+		err = reconciler.client.Get(context.TODO(), podKey, &corev1.Pod{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Smart-clone controller cross namespace clone", func() {
+	var (
+		reconciler *SmartCloneReconciler
+		dvKey      = types.NamespacedName{Name: "test-dv", Namespace: metav1.NamespaceDefault}
+		tmpKey     = types.NamespacedName{Name: "cdi-tmp-dv-uid", Namespace: "source-ns"}
+	)
+	AfterEach(func() {
+		if reconciler != nil {
+			close(reconciler.recorder.(*record.FakeRecorder).Events)
+			reconciler = nil
+		}
+	})
+
+	newCrossNamespaceDataVolume := func() *cdiv1.DataVolume {
+		dv := newCloneDataVolumeWithPVCNS("test-dv", "source-ns")
+		dv.UID = "dv-uid"
+		return dv
+	}
+
+	newTmpPvc := func(phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		pvc := createPvc(tmpKey.Name, tmpKey.Namespace, map[string]string{
+			AnnSmartCloneRequest: "true",
+			AnnSmartCloneTarget:  dvKey.String(),
+		}, map[string]string{common.CDIComponentLabel: common.SmartClonerCDILabel})
+		pvc.Spec.VolumeName = "test-pv"
+		pvc.Status.Phase = phase
+		return pvc
+	}
+
+	newPv := func(annotations map[string]string, claimRef *corev1.ObjectReference) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-pv",
+				Annotations: annotations,
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+				StorageClassName:              "testsc",
+				ClaimRef:                      claimRef,
+			},
+		}
+	}
+
+	getPv := func() *corev1.PersistentVolume {
+		pv := &corev1.PersistentVolume{}
+		err := reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "test-pv"}, pv)
+		Expect(err).ToNot(HaveOccurred())
+		return pv
+	}
+
+	It("Should restore the snapshot to a temporary PVC in the source namespace", func() {
+		ready := true
+		snapshot := createSnapshotVolume(tmpKey.Name, tmpKey.Namespace, nil)
+		snapshot.Annotations = map[string]string{AnnSmartCloneRequest: "true", AnnSmartCloneTarget: dvKey.String()}
+		snapshot.Status = &snapshotv1.VolumeSnapshotStatus{ReadyToUse: &ready}
+		reconciler = createSmartCloneReconciler(newCrossNamespaceDataVolume(), snapshot)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), tmpKey, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.OwnerReferences).To(BeEmpty())
+		Expect(pvc.Annotations[AnnSmartCloneTarget]).To(Equal(dvKey.String()))
+		Expect(pvc.Spec.DataSource.Name).To(Equal(tmpKey.Name))
+		datavolume := &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), dvKey, datavolume)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(datavolume.Status.Phase).To(Equal(cdiv1.SmartClonePVCInProgress))
+	})
+
+	It("Should retain the volume and create the target PVC once the temporary PVC is bound", func() {
+		reconciler = createSmartCloneReconciler(newCrossNamespaceDataVolume(), newTmpPvc(corev1.ClaimBound), newPv(nil, nil))
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		pv := getPv()
+		Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimRetain))
+		Expect(pv.Annotations[AnnSmartCloneReclaimPolicy]).To(Equal(string(corev1.PersistentVolumeReclaimDelete)))
+		pvc := &corev1.PersistentVolumeClaim{}
+		err = reconciler.client.Get(context.TODO(), dvKey, pvc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvc.Spec.VolumeName).To(Equal("test-pv"))
+		Expect(*pvc.Spec.StorageClassName).To(Equal("testsc"))
+		Expect(metav1.GetControllerOf(pvc).Name).To(Equal("test-dv"))
+	})
+
+	It("Should delete the temporary PVC and then bind the volume to the target PVC", func() {
+		target := newPvcForVolume(newPv(nil, nil), newCrossNamespaceDataVolume())
+		target.UID = "target-uid"
+		pv := newPv(map[string]string{AnnSmartCloneReclaimPolicy: "Delete"}, &corev1.ObjectReference{Namespace: tmpKey.Namespace, Name: tmpKey.Name})
+		reconciler = createSmartCloneReconciler(newCrossNamespaceDataVolume(), newTmpPvc(corev1.ClaimBound), pv, target)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		err = reconciler.client.Get(context.TODO(), tmpKey, &corev1.PersistentVolumeClaim{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		Expect(getPv().Spec.ClaimRef.Name).To(Equal(tmpKey.Name))
+
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		claimRef := getPv().Spec.ClaimRef
+		Expect(claimRef.Namespace).To(Equal(dvKey.Namespace))
+		Expect(claimRef.Name).To(Equal(dvKey.Name))
+		Expect(claimRef.UID).To(Equal(target.UID))
+	})
+
+	It("Should restore the reclaim policy and delete the snapshot once the target PVC is bound", func() {
+		target := newPvcForVolume(newPv(nil, nil), newCrossNamespaceDataVolume())
+		target.Status.Phase = corev1.ClaimBound
+		pv := newPv(map[string]string{AnnSmartCloneReclaimPolicy: "Delete", AnnSmartCloneTarget: dvKey.String()}, nil)
+		pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+		reconciler = createSmartCloneReconciler(newCrossNamespaceDataVolume(), pv, target, createSnapshotVolume(tmpKey.Name, tmpKey.Namespace, nil))
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		pv = getPv()
+		Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimDelete))
+		Expect(pv.Annotations).ToNot(HaveKey(AnnSmartCloneReclaimPolicy))
+		err = reconciler.client.Get(context.TODO(), tmpKey, &snapshotv1.VolumeSnapshot{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		datavolume := &cdiv1.DataVolume{}
+		err = reconciler.client.Get(context.TODO(), dvKey, datavolume)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(datavolume.Status.Phase).To(Equal(cdiv1.Succeeded))
+	})
+
+	It("Should clean up the source namespace when the DataVolume is gone", func() {
+		snapshot := createSnapshotVolume(tmpKey.Name, tmpKey.Namespace, nil)
+		snapshot.Annotations = map[string]string{AnnSmartCloneTarget: dvKey.String()}
+		snapshot.Labels = map[string]string{common.CDIComponentLabel: common.SmartClonerCDILabel}
+		pv := newPv(map[string]string{AnnSmartCloneReclaimPolicy: "Delete", AnnSmartCloneTarget: dvKey.String()}, nil)
+		reconciler = createSmartCloneReconciler(newTmpPvc(corev1.ClaimBound), snapshot, pv)
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: dvKey})
+		Expect(err).ToNot(HaveOccurred())
+		err = reconciler.client.Get(context.TODO(), tmpKey, &corev1.PersistentVolumeClaim{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		err = reconciler.client.Get(context.TODO(), tmpKey, &snapshotv1.VolumeSnapshot{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		Expect(getPv().Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimDelete))
+	})
+})
+
 func createSmartCloneReconciler(objects ...runtime.Object) *SmartCloneReconciler {
 	objs := []runtime.Object{}
 	objs = append(objs, objects...)
@@ -229,16 +606,19 @@ func createSmartCloneReconciler(objects ...runtime.Object) *SmartCloneReconciler
 		ScratchSpaceStorageClass: testStorageClass,
 	}
 
+	objs = append(objs, cdiConfig)
+
 	// Create a fake client to mock API calls.
 	cl := fake.NewFakeClientWithScheme(s, objs...)
 
-	rec := record.NewFakeRecorder(1)
+	rec := record.NewFakeRecorder(10)
 	// Create a ReconcileMemcached object with the scheme and fake client.
 	r := &SmartCloneReconciler{
 		client:   cl,
 		scheme:   s,
 		log:      scLog,
 		recorder: rec,
+		image:    "test-image",
 	}
 	return r
 }
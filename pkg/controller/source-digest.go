@@ -0,0 +1,289 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/util"
+	"kubevirt.io/containerized-data-importer/pkg/util/naming"
+)
+
+const (
+	dockerHubRegistry = "docker.io"
+
+	// a poller pod stuck, e.g. on a source not answering, fails after this deadline
+	sourceDigestPollerDeadlineSeconds = int64(300)
+)
+
+// registryReference is a parsed docker://[registry/]repository[:tag|@digest] url
+type registryReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+func parseRegistryURL(registryURL string) (*registryReference, error) {
+	if !strings.HasPrefix(registryURL, "docker://") {
+		return nil, errors.Errorf("unsupported registry url %q, expected docker://", registryURL)
+	}
+	name := strings.TrimPrefix(registryURL, "docker://")
+	ref := &registryReference{registry: dockerHubRegistry}
+	if i := strings.Index(name, "/"); i >= 0 {
+		if first := name[:i]; strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.registry, name = first, name[i+1:]
+		}
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	} else {
+		ref.tag = "latest"
+	}
+	if name == "" {
+		return nil, errors.Errorf("invalid registry url %q, no repository", registryURL)
+	}
+	if ref.registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.repository = name
+	return ref, nil
+}
+
+// withDigest returns the url of the image pinned to the digest
+func (ref *registryReference) withDigest(digest string) string {
+	return fmt.Sprintf("docker://%s/%s@%s", ref.registry, ref.repository, digest)
+}
+
+// pollSourceDigest polls the digest of the source of the DataImportCron from a poller pod in its namespace, the
+// controller doesn't connect to the source. It returns false while the pod is running, the pod is watched. The digest
+// of a registry source is the digest of its manifest, the digest of an http source is its ETag or Last-Modified
+// header.
+func (r *DataImportCronReconciler) pollSourceDigest(log logr.Logger, dataImportCron *cdiv1.DataImportCron) (string, bool, error) {
+	if registry := dataImportCron.Spec.Template.Spec.Source.Registry; registry != nil {
+		ref, err := parseRegistryURL(registry.URL)
+		if err != nil {
+			return "", false, err
+		}
+		if ref.digest != "" {
+			return ref.digest, true, nil
+		}
+	}
+
+	pod := &corev1.Pod{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: dataImportCron.Namespace, Name: sourceDigestPollerPodName(dataImportCron)}, pod); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", false, err
+		}
+		return "", false, r.createSourceDigestPollerPod(log, dataImportCron)
+	}
+	if pod.DeletionTimestamp != nil || !metav1.IsControlledBy(pod, dataImportCron) {
+		// the pod of the previous poll
+		return "", false, nil
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		var msg *util.TerminationMessage
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil {
+				msg = util.ParseTerminationMessage(status.State.Terminated.Message)
+			}
+		}
+		if err := r.deleteSourceDigestPollerPod(log, pod); err != nil {
+			return "", false, err
+		}
+		if msg == nil {
+			return "", false, errors.Errorf("poller pod %s reported no digest", pod.Name)
+		}
+		return msg.Digest, true, nil
+	case corev1.PodFailed:
+		if err := r.deleteSourceDigestPollerPod(log, pod); err != nil {
+			return "", false, err
+		}
+		if failure := getPodFailure(pod); failure != nil {
+			return "", false, errors.Errorf("poller pod %s failed: %s: %s", pod.Name, failure.reason, failure.message)
+		}
+		return "", false, errors.Errorf("poller pod %s failed", pod.Name)
+	}
+	return "", false, nil
+}
+
+func (r *DataImportCronReconciler) createSourceDigestPollerPod(log logr.Logger, dataImportCron *cdiv1.DataImportCron) error {
+	podEnvVar, err := r.sourceDigestPollerEnvVar(dataImportCron)
+	if err != nil {
+		return err
+	}
+	podResourceRequirements, err := GetDefaultPodResourceRequirements(r.client)
+	if err != nil {
+		return err
+	}
+	// the pod placement annotation of the template applies to the poller pod too
+	workloadPlacement, err := GetWorkloadPlacement(r.client, &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Annotations: dataImportCron.Spec.Template.Annotations}})
+	if err != nil {
+		return err
+	}
+	securityPolicy, err := GetWorkloadSecurityPolicy(r.client)
+	if err != nil {
+		return err
+	}
+	pod := newSourceDigestPollerPod(dataImportCron, r.image, r.verbose, r.pullPolicy, podEnvVar, podResourceRequirements)
+	applyNodePlacement(&pod.Spec, workloadPlacement)
+	applySecurityContext(pod, securityPolicy, corev1.PersistentVolumeFilesystem)
+	log.V(3).Info("Creating pod to poll the source digest", "pod.Namespace", pod.Namespace, "pod.Name", pod.Name)
+	if err := r.client.Create(context.TODO(), pod); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *DataImportCronReconciler) deleteSourceDigestPollerPod(log logr.Logger, pod *corev1.Pod) error {
+	log.V(3).Info("Deleting source digest poller pod", "pod.Namespace", pod.Namespace, "pod.Name", pod.Name)
+	if err := r.client.Delete(context.TODO(), pod); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// sourceDigestPollerEnvVar returns the importer environment of the poller pod, the credentials and certs are read
+// by the pod
+func (r *DataImportCronReconciler) sourceDigestPollerEnvVar(dataImportCron *cdiv1.DataImportCron) (*importPodEnvVar, error) {
+	podEnvVar := &importPodEnvVar{contentType: string(cdiv1.DataVolumeKubeVirt)}
+	source := dataImportCron.Spec.Template.Spec.Source
+	switch {
+	case source.Registry != nil:
+		insecureTLS, err := isInsecureRegistry(r.uncachedClient, source.Registry.URL)
+		if err != nil {
+			return nil, err
+		}
+		podEnvVar.source = SourceRegistry
+		podEnvVar.ep = source.Registry.URL
+		podEnvVar.secretName = source.Registry.SecretRef
+		podEnvVar.certConfigMap = source.Registry.CertConfigMap
+		podEnvVar.insecureTLS = insecureTLS
+	case source.HTTP != nil:
+		podEnvVar.source = SourceHTTP
+		podEnvVar.ep = source.HTTP.URL
+		podEnvVar.secretName = source.HTTP.SecretRef
+		podEnvVar.certConfigMap = source.HTTP.CertConfigMap
+	default:
+		return nil, errors.New("only registry and http sources have a digest")
+	}
+	return podEnvVar, nil
+}
+
+// isInsecureRegistry returns true if the host of the registry url is listed in the insecure registries config map,
+// like the import controller checks it
+func isInsecureRegistry(c client.Client, registryURL string) (bool, error) {
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return false, err
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: common.InsecureRegistryConfigMap, Namespace: util.GetNamespace()}, cm); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, value := range cm.Data {
+		if value == u.Host {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func sourceDigestPollerPodName(dataImportCron *cdiv1.DataImportCron) string {
+	return naming.GetResourceName("cdi-poll", dataImportCron.Name)
+}
+
+// newSourceDigestPollerPod creates the importer pod polling the source digest of the DataImportCron
+func newSourceDigestPollerPod(dataImportCron *cdiv1.DataImportCron, image, verbose, pullPolicy string, podEnvVar *importPodEnvVar, podResourceRequirements *corev1.ResourceRequirements) *corev1.Pod {
+	deadline := sourceDigestPollerDeadlineSeconds
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sourceDigestPollerPodName(dataImportCron),
+			Namespace: dataImportCron.Namespace,
+			Annotations: map[string]string{
+				AnnCreatedBy: "yes",
+			},
+			Labels: map[string]string{
+				common.CDILabelKey:       common.CDILabelValue,
+				common.CDIComponentLabel: common.SourceDigestPollerPodName,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(dataImportCron, cdiv1.SchemeGroupVersion.WithKind("DataImportCron")),
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:            common.SourceDigestPollerPodName,
+					Image:           image,
+					ImagePullPolicy: corev1.PullPolicy(pullPolicy),
+					Args:            []string{"-v=" + verbose},
+					Env: append(makeImportEnv(podEnvVar, dataImportCron.UID), corev1.EnvVar{
+						Name:  common.ImporterPollDigest,
+						Value: "true",
+					}),
+				},
+			},
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: &deadline,
+		},
+	}
+	if podResourceRequirements != nil {
+		pod.Spec.Containers[0].Resources = *podResourceRequirements
+	}
+	if podEnvVar.certConfigMap != "" {
+		pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
+			{
+				Name:      CertVolName,
+				MountPath: common.ImporterCertDir,
+			},
+		}
+		pod.Spec.Volumes = []corev1.Volume{
+			{
+				Name: CertVolName,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: podEnvVar.certConfigMap,
+						},
+					},
+				},
+			},
+		}
+	}
+	return pod
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/util"
+)
+
+var _ = Describe("Registry url", func() {
+	table.DescribeTable("Should be parsed", func(registryURL string, expected *registryReference) {
+		ref, err := parseRegistryURL(registryURL)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref).To(Equal(expected))
+	},
+		table.Entry("with registry and tag", "docker://quay.io/kubevirt/fedora:32", &registryReference{registry: "quay.io", repository: "kubevirt/fedora", tag: "32"}),
+		table.Entry("with registry port", "docker://localhost:5000/fedora", &registryReference{registry: "localhost:5000", repository: "fedora", tag: "latest"}),
+		table.Entry("with digest", "docker://quay.io/kubevirt/fedora@sha256:1234", &registryReference{registry: "quay.io", repository: "kubevirt/fedora", digest: "sha256:1234"}),
+		table.Entry("from docker hub", "docker://fedora", &registryReference{registry: "docker.io", repository: "library/fedora", tag: "latest"}),
+		table.Entry("from docker hub with user", "docker://kubevirt/fedora:latest", &registryReference{registry: "docker.io", repository: "kubevirt/fedora", tag: "latest"}),
+	)
+
+	It("Should reject an url without the docker scheme", func() {
+		_, err := parseRegistryURL("oci-archive://fedora.tar")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should pin the image to a digest", func() {
+		ref, err := parseRegistryURL("docker://quay.io/kubevirt/fedora:latest")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref.withDigest("sha256:1234")).To(Equal("docker://quay.io/kubevirt/fedora@sha256:1234"))
+	})
+})
+
+var _ = Describe("Insecure registry", func() {
+	newClient := func(objects ...runtime.Object) client.Client {
+		s := scheme.Scheme
+		cdiv1.AddToScheme(s)
+		return fake.NewFakeClientWithScheme(s, objects...)
+	}
+
+	insecureRegistries := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: common.InsecureRegistryConfigMap, Namespace: util.GetNamespace()},
+		Data:       map[string]string{"test-registry": "registry.local:5000"},
+	}
+
+	It("Should be listed in the insecure registries config map", func() {
+		insecure, err := isInsecureRegistry(newClient(insecureRegistries), "docker://registry.local:5000/kubevirt/fedora:latest")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(insecure).To(BeTrue())
+		insecure, err = isInsecureRegistry(newClient(insecureRegistries), "docker://quay.io/kubevirt/fedora:latest")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(insecure).To(BeFalse())
+	})
+
+	It("Should not be insecure without the config map", func() {
+		insecure, err := isInsecureRegistry(newClient(), "docker://registry.local:5000/kubevirt/fedora:latest")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(insecure).To(BeFalse())
+	})
+})
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+// GetStorageProfile returns the profile of the storage class from the storage profiles of the cdi config spec, nil if
+// the storage class has none
+func GetStorageProfile(profiles []cdiv1.StorageProfile, storageClassName string) *cdiv1.StorageProfile {
+	for i := range profiles {
+		if profiles[i].StorageClass == storageClassName {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// ApplyStorageProfile fills in the access modes and volume mode the PVC spec omits from the storage profile, and
+// returns true if it changed the spec
+func ApplyStorageProfile(spec *corev1.PersistentVolumeClaimSpec, profile *cdiv1.StorageProfile) bool {
+	if profile == nil {
+		return false
+	}
+	changed := false
+	if len(spec.AccessModes) == 0 && len(profile.AccessModes) > 0 {
+		spec.AccessModes = append([]corev1.PersistentVolumeAccessMode(nil), profile.AccessModes...)
+		changed = true
+	}
+	if spec.VolumeMode == nil && profile.VolumeMode != nil {
+		volumeMode := *profile.VolumeMode
+		spec.VolumeMode = &volumeMode
+		changed = true
+	}
+	return changed
+}
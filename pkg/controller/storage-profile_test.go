@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+var _ = Describe("Storage profiles", func() {
+	volumeMode := corev1.PersistentVolumeBlock
+	profiles := []cdiv1.StorageProfile{
+		{
+			StorageClass: "local",
+			AccessModes:  []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			VolumeMode:   &volumeMode,
+		},
+	}
+
+	It("Should find the profile of the storage class", func() {
+		Expect(GetStorageProfile(profiles, "local")).To(Equal(&profiles[0]))
+		Expect(GetStorageProfile(profiles, "ceph")).To(BeNil())
+	})
+
+	It("Should fill in the settings the PVC omits", func() {
+		spec := &corev1.PersistentVolumeClaimSpec{}
+		Expect(ApplyStorageProfile(spec, &profiles[0])).To(BeTrue())
+		Expect(spec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}))
+		Expect(*spec.VolumeMode).To(Equal(corev1.PersistentVolumeBlock))
+
+		By("Keeping the settings the PVC sets")
+		filesystem := corev1.PersistentVolumeFilesystem
+		spec = &corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			VolumeMode:  &filesystem,
+		}
+		Expect(ApplyStorageProfile(spec, &profiles[0])).To(BeFalse())
+		Expect(spec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}))
+		Expect(*spec.VolumeMode).To(Equal(corev1.PersistentVolumeFilesystem))
+		Expect(ApplyStorageProfile(spec, nil)).To(BeFalse())
+	})
+})
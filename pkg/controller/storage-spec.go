@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+// GetDataVolumeStorageClassName returns the storage class the DataVolume asks for, nil for the default storage class
+func GetDataVolumeStorageClassName(dataVolume *cdiv1.DataVolume) *string {
+	if dataVolume.Spec.PVC != nil {
+		return dataVolume.Spec.PVC.StorageClassName
+	}
+	if dataVolume.Spec.Storage != nil {
+		return dataVolume.Spec.Storage.StorageClassName
+	}
+	return nil
+}
+
+// getDataVolumeRequestedSize returns the size the DataVolume asks for, false if it leaves it to the source of a clone
+func getDataVolumeRequestedSize(dataVolume *cdiv1.DataVolume) (resource.Quantity, bool) {
+	var requests corev1.ResourceList
+	if dataVolume.Spec.PVC != nil {
+		requests = dataVolume.Spec.PVC.Resources.Requests
+	} else if dataVolume.Spec.Storage != nil {
+		requests = dataVolume.Spec.Storage.Resources.Requests
+	}
+	size, ok := requests[corev1.ResourceStorage]
+	return size, ok
+}
+
+// renderPvcSpec returns the PVC spec of the DataVolume. The settings a storage spec omits are resolved: the access
+// mode and volume mode from the storage profile of the storage class, then the size and volume mode of clones from
+// the source PVC, ReadWriteOnce and Filesystem otherwise. A clone with a PVC spec may only omit the size. The
+// DataVolume is left unchanged.
+func renderPvcSpec(c client.Client, dataVolume *cdiv1.DataVolume) (*corev1.PersistentVolumeClaimSpec, error) {
+	if dataVolume.Spec.PVC != nil {
+		pvcSpec := dataVolume.Spec.PVC.DeepCopy()
+		if dataVolume.Spec.Source.PVC != nil {
+			if err := renderCloneSpec(c, dataVolume, pvcSpec); err != nil {
+				return nil, err
+			}
+		}
+		return pvcSpec, nil
+	}
+	storage := dataVolume.Spec.Storage
+	if storage == nil {
+		return nil, errors.Errorf("datavolume.pvc or datavolume.storage field is required")
+	}
+	pvcSpec := &corev1.PersistentVolumeClaimSpec{
+		AccessModes:      append([]corev1.PersistentVolumeAccessMode(nil), storage.AccessModes...),
+		Resources:        *storage.Resources.DeepCopy(),
+		StorageClassName: storage.StorageClassName,
+		VolumeMode:       storage.VolumeMode,
+	}
+
+	storageClass, err := GetStorageClassByName(c, storage.StorageClassName)
+	if err != nil {
+		return nil, err
+	}
+	if storageClass != nil {
+		cdiconfig := &cdiv1.CDIConfig{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil && !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+		ApplyStorageProfile(pvcSpec, GetStorageProfile(cdiconfig.Spec.StorageProfiles, storageClass.Name))
+	}
+
+	if dataVolume.Spec.Source.PVC != nil {
+		if err := renderCloneSpec(c, dataVolume, pvcSpec); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pvcSpec.AccessModes) == 0 {
+		// all provisioners support it
+		pvcSpec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	if _, ok := pvcSpec.Resources.Requests[corev1.ResourceStorage]; !ok {
+		return nil, errors.Errorf("datavolume.storage size is required")
+	}
+	return pvcSpec, nil
+}
+
+// renderCloneSpec fills in the size and volume mode the PVC spec of a clone omits from the source PVC
+func renderCloneSpec(c client.Client, dataVolume *cdiv1.DataVolume, pvcSpec *corev1.PersistentVolumeClaimSpec) error {
+	_, hasSize := pvcSpec.Resources.Requests[corev1.ResourceStorage]
+	if hasSize && (pvcSpec.VolumeMode != nil || dataVolume.Spec.PVC != nil) {
+		return nil
+	}
+	sourceNamespace := dataVolume.Spec.Source.PVC.Namespace
+	if sourceNamespace == "" {
+		sourceNamespace = dataVolume.Namespace
+	}
+	sourcePvc := &corev1.PersistentVolumeClaim{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: sourceNamespace, Name: dataVolume.Spec.Source.PVC.Name}, sourcePvc); err != nil {
+		if k8serrors.IsNotFound(err) {
+			// the clone reports the missing source
+			return nil
+		}
+		return errors.Wrap(err, "unable to get the source PVC of the clone")
+	}
+	if pvcSpec.VolumeMode == nil && dataVolume.Spec.PVC == nil {
+		volumeMode := getVolumeMode(sourcePvc)
+		pvcSpec.VolumeMode = &volumeMode
+	}
+	if !hasSize {
+		size, err := inferCloneSize(c, sourcePvc, pvcSpec)
+		if err != nil {
+			return err
+		}
+		if pvcSpec.Resources.Requests == nil {
+			pvcSpec.Resources.Requests = make(corev1.ResourceList)
+		}
+		pvcSpec.Resources.Requests[corev1.ResourceStorage] = *size
+	}
+	return nil
+}
+
+// inferCloneSize returns the target size of a clone, the smallest size the data of the source fits in
+func inferCloneSize(c client.Client, sourcePvc *corev1.PersistentVolumeClaim, targetSpec *corev1.PersistentVolumeClaimSpec) (*resource.Quantity, error) {
+	sourceSize, ok := sourcePvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if !ok {
+		return nil, errors.Errorf("source PVC %s/%s has no size", sourcePvc.Namespace, sourcePvc.Name)
+	}
+	sourceOverhead, targetOverhead, err := getCloneFilesystemOverheads(c, &sourcePvc.Spec, targetSpec)
+	if err != nil {
+		return nil, err
+	}
+	size := MinCloneTargetSize(sourceSize, sourceOverhead, targetOverhead)
+	return &size, nil
+}
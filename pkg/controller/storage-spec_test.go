@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+var _ = Describe("Storage spec", func() {
+	newStorageDataVolume := func(source cdiv1.DataVolumeSource, size string) *cdiv1.DataVolume {
+		dv := newImportDataVolume("test-dv")
+		dv.Spec.Source = source
+		dv.Spec.PVC = nil
+		dv.Spec.Storage = &cdiv1.StorageSpec{}
+		if size != "" {
+			dv.Spec.Storage.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}
+		}
+		return dv
+	}
+	httpSource := cdiv1.DataVolumeSource{HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "http://example.com/data"}}
+	cloneSource := cdiv1.DataVolumeSource{PVC: &cdiv1.DataVolumeSourcePVC{Namespace: "default", Name: "test"}}
+
+	It("Should return a copy of the PVC spec", func() {
+		dv := newImportDataVolume("test-dv")
+		pvcSpec, err := renderPvcSpec(createClient(), dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvcSpec).To(Equal(dv.Spec.PVC))
+		Expect(pvcSpec).ToNot(BeIdenticalTo(dv.Spec.PVC))
+	})
+
+	It("Should default to a ReadWriteOnce filesystem volume of the default storage class", func() {
+		dv := newStorageDataVolume(httpSource, "1Gi")
+		storageClass := createStorageClass("local", map[string]string{AnnDefaultStorageClass: "true"})
+		pvcSpec, err := renderPvcSpec(createClient(createCDIConfig(common.ConfigName), storageClass), dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvcSpec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}))
+		Expect(pvcSpec.VolumeMode).To(BeNil())
+		Expect(pvcSpec.StorageClassName).To(BeNil())
+		size := pvcSpec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("1Gi"))).To(Equal(0))
+	})
+
+	It("Should resolve the access mode and volume mode from the storage profile", func() {
+		storageClassName := "ceph"
+		dv := newStorageDataVolume(httpSource, "1Gi")
+		dv.Spec.Storage.StorageClassName = &storageClassName
+		volumeMode := corev1.PersistentVolumeBlock
+		config := createCDIConfig(common.ConfigName)
+		config.Spec.StorageProfiles = []cdiv1.StorageProfile{{
+			StorageClass: storageClassName,
+			AccessModes:  []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			VolumeMode:   &volumeMode,
+		}}
+		pvcSpec, err := renderPvcSpec(createClient(config, createStorageClass(storageClassName, nil)), dv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvcSpec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}))
+		Expect(*pvcSpec.VolumeMode).To(Equal(corev1.PersistentVolumeBlock))
+		Expect(*pvcSpec.StorageClassName).To(Equal(storageClassName))
+		Expect(dv.Spec.PVC).To(BeNil())
+	})
+
+	It("Should infer the size and volume mode of a clone from the source PVC", func() {
+		dv := newStorageDataVolume(cloneSource, "")
+		sourcePvc := createPvc("test", "default", nil, nil)
+		volumeMode := corev1.PersistentVolumeBlock
+		sourcePvc.Spec.VolumeMode = &volumeMode
+		pvcSpec, err := renderPvcSpec(createClient(createCDIConfig(common.ConfigName), sourcePvc), dv)
+		Expect(err).ToNot(HaveOccurred())
+		size := pvcSpec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+		Expect(*pvcSpec.VolumeMode).To(Equal(corev1.PersistentVolumeBlock))
+
+		By("Keeping the size the DataVolume asks for")
+		dv = newStorageDataVolume(cloneSource, "2G")
+		pvcSpec, err = renderPvcSpec(createClient(createCDIConfig(common.ConfigName), sourcePvc), dv)
+		Expect(err).ToNot(HaveOccurred())
+		size = pvcSpec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("2G"))).To(Equal(0))
+	})
+
+	It("Should infer the size of a clone with a PVC spec from the source PVC", func() {
+		dv := newImportDataVolume("test-dv")
+		dv.Spec.Source = cloneSource
+		dv.Spec.PVC.Resources.Requests = nil
+		sourcePvc := createPvc("test", "default", nil, nil)
+		pvcSpec, err := renderPvcSpec(createClient(createCDIConfig(common.ConfigName), sourcePvc), dv)
+		Expect(err).ToNot(HaveOccurred())
+		size := pvcSpec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+		Expect(pvcSpec.VolumeMode).To(BeNil())
+		Expect(dv.Spec.PVC.Resources.Requests).To(BeNil())
+	})
+
+	It("Should make room for the filesystem overhead of the target of a clone", func() {
+		dv := newStorageDataVolume(cloneSource, "")
+		volumeMode := corev1.PersistentVolumeFilesystem
+		dv.Spec.Storage.VolumeMode = &volumeMode
+		sourcePvc := createPvc("test", "default", nil, nil)
+		blockMode := corev1.PersistentVolumeBlock
+		sourcePvc.Spec.VolumeMode = &blockMode
+		pvcSpec, err := renderPvcSpec(createClient(createCDIConfig(common.ConfigName), sourcePvc), dv)
+		Expect(err).ToNot(HaveOccurred())
+		size := pvcSpec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Value()).To(Equal(int64(1058201059)))
+
+		By("Keeping the size of the source if the target has less overhead")
+		dv.Spec.Storage.VolumeMode = &blockMode
+		sourcePvc.Spec.VolumeMode = &volumeMode
+		pvcSpec, err = renderPvcSpec(createClient(createCDIConfig(common.ConfigName), sourcePvc), dv)
+		Expect(err).ToNot(HaveOccurred())
+		size = pvcSpec.Resources.Requests[corev1.ResourceStorage]
+		Expect(size.Cmp(resource.MustParse("1G"))).To(Equal(0))
+	})
+
+	It("Should fail without size unless cloning", func() {
+		_, err := renderPvcSpec(createClient(createCDIConfig(common.ConfigName)), newStorageDataVolume(httpSource, ""))
+		Expect(err).To(HaveOccurred())
+		_, err = renderPvcSpec(createClient(createCDIConfig(common.ConfigName)), newStorageDataVolume(cloneSource, ""))
+		Expect(err).To(HaveOccurred())
+	})
+})
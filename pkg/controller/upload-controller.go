@@ -93,6 +93,7 @@ type UploadPodArgs struct {
 	PVC                             *v1.PersistentVolumeClaim
 	ScratchPVCName                  string
 	ClientName                      string
+	FilesystemOverhead              string
 	ServerCert, ServerKey, ClientCA []byte
 }
 
@@ -121,13 +122,22 @@ func (r *UploadReconciler) Reconcile(req reconcile.Request) (reconcile.Result, e
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	// force cleanup if PVC pending delete and pod running or the upload/clone annotation was removed
-	if !shouldReconcile || podSucceededFromPVC(pvc) || pvc.DeletionTimestamp != nil {
+	if shouldReconcile && pvc.DeletionTimestamp == nil && failPVCIfDeadlineExceeded(pvc, r.recorder) {
+		log.V(1).Info("Deadline exceeded, cleaning up", "deadline", pvc.Annotations[AnnDeadline])
+		if err := r.updatePVC(pvc); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	// force cleanup if PVC pending delete and pod running, the upload/clone annotation was removed or the upload was
+	// cancelled
+	if !shouldReconcile || podSucceededFromPVC(pvc) || isPVCFailed(pvc) || isPopulationCancelled(pvc) || pvc.DeletionTimestamp != nil {
 		log.V(1).Info("not doing anything with PVC",
 			"isUpload", isUpload,
 			"isCloneTarget", isCloneTarget,
 			"isBound", isBound(pvc, log),
 			"podSucceededFromPVC", podSucceededFromPVC(pvc),
+			"isPVCFailed", isPVCFailed(pvc),
+			"isPopulationCancelled", isPopulationCancelled(pvc),
 			"deletionTimeStamp set?", pvc.DeletionTimestamp != nil)
 		if err := r.cleanup(pvc); err != nil {
 			return reconcile.Result{}, err
@@ -136,7 +146,8 @@ func (r *UploadReconciler) Reconcile(req reconcile.Request) (reconcile.Result, e
 	}
 
 	log.Info("Calling Upload reconcile PVC")
-	return r.reconcilePVC(log, pvc, isCloneTarget)
+	result, err := r.reconcilePVC(log, pvc, isCloneTarget)
+	return requeueAtDeadline(pvc, result), err
 }
 
 func (r *UploadReconciler) shouldReconcile(isUpload bool, isCloneTarget bool, pvc *v1.PersistentVolumeClaim, log logr.Logger) (bool, error) {
@@ -161,7 +172,7 @@ func (r *UploadReconciler) reconcilePVC(log logr.Logger, pvc *corev1.PersistentV
 			return reconcile.Result{}, err
 		}
 
-		if err = ValidateCanCloneSourceAndTargetSpec(&source.Spec, &pvc.Spec); err != nil {
+		if err = validateCanClone(r.client, &source.Spec, &pvc.Spec); err != nil {
 			log.Error(err, "Error validating clone spec, ignoring")
 			return reconcile.Result{}, nil
 		}
@@ -204,14 +215,40 @@ func (r *UploadReconciler) reconcilePVC(log logr.Logger, pvc *corev1.PersistentV
 			}
 			return reconcile.Result{Requeue: true}, nil
 		}
+		if delay := retryDelay(pvc); delay > 0 {
+			log.V(1).Info("Waiting for the retry backoff to elapse", "delay", delay)
+			return reconcile.Result{RequeueAfter: delay}, nil
+		}
 		pod, err = r.createUploadPodForPvc(pvc, podName, scratchPVCName, uploadClientName)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
 	}
 
-	// Always try to get or create the scratch PVC for a pod that is not successful yet, if it exists nothing happens otherwise attempt to create.
+	if hasRetryPolicy(pvc) {
+		if failure := getPodFailure(pod); failure != nil {
+			return r.retryFailedPod(pvcCopy, pod, failure, log)
+		}
+	}
+
 	scratchPVCName, exists := getScratchNameFromPod(pod)
+	if !hasScratchVolume(pod) && !isCloneTarget && podRequiresScratch(pod) {
+		// The upload could not be converted without scratch space, recreate the pod with scratch space.
+		log.V(1).Info("Pod requires scratch space, deleting pod, and recreating with scratch space", "pod.Name", pod.Name)
+		anno[AnnRequiresScratch] = "true"
+		// the kubelet may have restarted the pod without scratch space, keep uploads waiting for the new pod
+		anno[AnnPodReady] = "false"
+		setScratchSizeFromPod(pvcCopy, pod)
+		if err := r.updatePVC(pvcCopy); err != nil {
+			return reconcile.Result{}, err
+		}
+		if err := r.client.Delete(context.TODO(), pod); IgnoreNotFound(err) != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// Always try to get or create the scratch PVC for a pod that is not successful yet, if it exists nothing happens otherwise attempt to create.
 	if exists {
 		_, err := r.getOrCreateScratchPvc(pvcCopy, pod, scratchPVCName)
 		if err != nil {
@@ -252,6 +289,24 @@ func (r *UploadReconciler) reconcilePVC(log logr.Logger, pvc *corev1.PersistentV
 	return reconcile.Result{}, nil
 }
 
+// retryFailedPod deletes the failed upload pod so it is recreated once the backoff elapsed, or fails the PVC if the
+// retry policy allows no more retries, the upload resources are then cleaned up on the next reconcile
+func (r *UploadReconciler) retryFailedPod(pvc *corev1.PersistentVolumeClaim, pod *corev1.Pod, failure *podFailure, log logr.Logger) (reconcile.Result, error) {
+	retry := retryFailedPod(pvc, failure, r.recorder)
+	if err := r.updatePVC(pvc); err != nil {
+		return reconcile.Result{}, err
+	}
+	if !retry {
+		log.V(1).Info("Upload pod failed, not retrying", "pod.Name", pod.Name, "reason", failure.reason)
+		return reconcile.Result{}, nil
+	}
+	log.V(1).Info("Upload pod failed, deleting pod to retry", "pod.Name", pod.Name, "reason", failure.reason, "retries", pvc.Annotations[AnnPodRestarts])
+	if err := r.client.Delete(context.TODO(), pod); IgnoreNotFound(err) != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: retryDelay(pvc)}, nil
+}
+
 func (r *UploadReconciler) updatePvcPodName(pvc *v1.PersistentVolumeClaim, podName string, log logr.Logger) error {
 	currentPvcCopy := pvc.DeepCopyObject()
 
@@ -277,9 +332,6 @@ func (r *UploadReconciler) updatePVC(pvc *corev1.PersistentVolumeClaim) error {
 }
 
 func (r *UploadReconciler) getCloneRequestSourcePVC(targetPvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
-	sourceVolumeMode := corev1.PersistentVolumeFilesystem
-	targetVolumeMode := corev1.PersistentVolumeFilesystem
-
 	exists, namespace, name := ParseCloneRequestAnnotation(targetPvc)
 	if !exists {
 		return nil, errors.New("error parsing clone request annotation")
@@ -288,15 +340,6 @@ func (r *UploadReconciler) getCloneRequestSourcePVC(targetPvc *corev1.Persistent
 	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, sourcePvc); err != nil {
 		return nil, errors.Wrap(err, "error getting clone source PVC")
 	}
-	if sourcePvc.Spec.VolumeMode != nil {
-		sourceVolumeMode = *sourcePvc.Spec.VolumeMode
-	}
-	if targetPvc.Spec.VolumeMode != nil {
-		targetVolumeMode = *targetPvc.Spec.VolumeMode
-	}
-	if sourceVolumeMode != targetVolumeMode {
-		return nil, errors.New("Source and target volume Modes do not match")
-	}
 	return sourcePvc, nil
 }
 
@@ -354,14 +397,20 @@ func (r *UploadReconciler) createUploadPodForPvc(pvc *v1.PersistentVolumeClaim,
 		return nil, err
 	}
 
+	filesystemOverhead, err := GetFilesystemOverhead(r.client, pvc)
+	if err != nil {
+		return nil, err
+	}
+
 	args := UploadPodArgs{
-		Name:           podName,
-		PVC:            pvc,
-		ScratchPVCName: scratchPVCName,
-		ClientName:     clientName,
-		ServerCert:     serverCert,
-		ServerKey:      serverKey,
-		ClientCA:       clientCA,
+		Name:               podName,
+		PVC:                pvc,
+		ScratchPVCName:     scratchPVCName,
+		ClientName:         clientName,
+		FilesystemOverhead: string(filesystemOverhead),
+		ServerCert:         serverCert,
+		ServerKey:          serverKey,
+		ClientCA:           clientCA,
 	}
 
 	r.log.V(3).Info("Creating upload pod")
@@ -515,7 +564,26 @@ func (r *UploadReconciler) createUploadPod(args UploadPodArgs) (*v1.Pod, error)
 		return nil, err
 	}
 
+	workloadPlacement, err := GetWorkloadPlacement(r.client, args.PVC)
+	if err != nil {
+		return nil, err
+	}
+
+	securityPolicy, err := GetWorkloadSecurityPolicy(r.client)
+	if err != nil {
+		return nil, err
+	}
+
 	pod := r.makeUploadPodSpec(args, podResourceRequirements)
+	applyNodePlacement(&pod.Spec, workloadPlacement)
+	applySecurityContext(pod, securityPolicy, getVolumeMode(args.PVC))
+	if args.ScratchPVCName != "" {
+		scratchPolicy, err := GetScratchSpacePolicy(r.client)
+		if err != nil {
+			return nil, err
+		}
+		applyScratchSpacePolicy(pod, args.PVC, scratchPolicy)
+	}
 
 	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: args.Name, Namespace: ns}, pod); err != nil {
 		if !k8serrors.IsNotFound(err) {
@@ -583,6 +651,10 @@ func createScratchPvcNameFromPvc(pvc *v1.PersistentVolumeClaim, isCloneTarget bo
 	if isCloneTarget {
 		return ""
 	}
+	// Uploads are converted while streaming, scratch space is only needed if that failed before.
+	if requiresScratch, _ := strconv.ParseBool(pvc.Annotations[AnnRequiresScratch]); !requiresScratch {
+		return ""
+	}
 
 	return naming.GetResourceName(pvc.Name, common.ScratchNameSuffix)
 }
@@ -599,6 +671,18 @@ func getUploadResourceNameFromPvc(pvc *corev1.PersistentVolumeClaim) string {
 	return naming.GetResourceName("cdi-upload", pvc.Name)
 }
 
+// podRequiresScratch returns true if the upload server exited because it requires scratch space
+func podRequiresScratch(pod *v1.Pod) bool {
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return false
+	}
+	status := pod.Status.ContainerStatuses[0]
+	if status.State.Terminated != nil && status.State.Terminated.ExitCode == common.ScratchSpaceNeededExitCode {
+		return true
+	}
+	return status.LastTerminationState.Terminated != nil && status.LastTerminationState.Terminated.ExitCode == common.ScratchSpaceNeededExitCode
+}
+
 // createUploadResourceName returns the name given to upload resources
 func createUploadResourceName(name string) string {
 	return naming.GetResourceName("cdi-upload", name)
@@ -626,7 +710,6 @@ func createUploadServiceNameFromPvcName(pvc string) string {
 func (r *UploadReconciler) makeUploadPodSpec(args UploadPodArgs, resourceRequirements *v1.ResourceRequirements) *v1.Pod {
 	requestImageSize, _ := getRequestedImageSize(args.PVC)
 	serviceName := naming.GetServiceNameFromResourceName(args.Name)
-	fsGroup := common.QemuSubGid
 	pod := &v1.Pod{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Pod",
@@ -648,9 +731,6 @@ func (r *UploadReconciler) makeUploadPodSpec(args UploadPodArgs, resourceRequire
 			},
 		},
 		Spec: v1.PodSpec{
-			SecurityContext: &v1.PodSecurityContext{
-				RunAsUser: &[]int64{0}[0],
-			},
 			Containers: []v1.Container{
 				{
 					Name:            common.UploadServerPodname,
@@ -694,7 +774,7 @@ func (r *UploadReconciler) makeUploadPodSpec(args UploadPodArgs, resourceRequire
 					},
 				},
 			},
-			RestartPolicy: v1.RestartPolicyOnFailure,
+			RestartPolicy: podRestartPolicy(args.PVC),
 			Volumes: []v1.Volume{
 				{
 					Name: DataVolName,
@@ -709,8 +789,45 @@ func (r *UploadReconciler) makeUploadPodSpec(args UploadPodArgs, resourceRequire
 		},
 	}
 
-	if !checkPVC(args.PVC, AnnCloneRequest, r.log.WithValues("Name", args.PVC.Name, "Namspace", args.PVC.Namespace)) {
-		pod.Spec.SecurityContext.FSGroup = &fsGroup
+	if args.FilesystemOverhead != "" {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, v1.EnvVar{
+			Name:  common.FilesystemOverheadVar,
+			Value: args.FilesystemOverhead,
+		})
+	}
+
+	isCloneTarget := checkPVC(args.PVC, AnnCloneRequest, r.log.WithValues("Name", args.PVC.Name, "Namspace", args.PVC.Namespace))
+	if !isCloneTarget {
+		// the clone source pod reports the progress of a clone
+		ownerUID := args.PVC.UID
+		if owner := metav1.GetControllerOf(args.PVC); owner != nil {
+			ownerUID = owner.UID
+		}
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, v1.EnvVar{
+			Name:  common.OwnerUID,
+			Value: string(ownerUID),
+		}, v1.EnvVar{
+			Name:  common.ProgressURL,
+			Value: GetProgressURL(),
+		})
+	} else {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, v1.EnvVar{
+			Name:  common.UploadCloneTarget,
+			Value: "true",
+		})
+		if checkpoint, ok := args.PVC.Annotations[AnnCloneCheckpoint]; ok {
+			pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, v1.EnvVar{
+				Name:  common.UploadCloneCheckpoint,
+				Value: checkpoint,
+			})
+		}
+		// keeps the checkpoint of a block clone across container restarts
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: CloneCheckpointVolName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		})
 	}
 
 	if resourceRequirements != nil {
@@ -737,6 +854,13 @@ func (r *UploadReconciler) makeUploadPodSpec(args UploadPodArgs, resourceRequire
 		}
 	}
 
+	if isCloneTarget {
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+			Name:      CloneCheckpointVolName,
+			MountPath: common.CloneCheckpointDir,
+		})
+	}
+
 	if args.ScratchPVCName != "" {
 		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
 			Name: ScratchVolName,
@@ -32,6 +32,8 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -215,17 +217,30 @@ var _ = Describe("Upload controller reconcile loop", func() {
 
 	})
 
-	It("Should return err and not clone if validation error occurs", func() {
+	It("Should return err and not clone if the source PVC is missing", func() {
 		storageClassName := "test"
 		testPvc := createPvcInStorageClass("testPvc1", "default", &storageClassName, map[string]string{cloneRequestAnnotation: "default/sourcePvc"}, nil, corev1.ClaimBound)
+		reconciler := createUploadReconciler(testPvc)
+
+		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("error getting clone source PVC"))
+	})
+
+	It("Should create an upload pod for a clone between volume modes", func() {
+		storageClassName := "test"
+		testPvc := createPvcInStorageClass("testPvc1", "default", &storageClassName, map[string]string{cloneRequestAnnotation: "default/sourcePvc", AnnUploadPod: createUploadResourceName("testPvc1")}, nil, corev1.ClaimBound)
+		testPvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2G")
 		sourcePvc := createPvcInStorageClass("sourcePvc", "default", &storageClassName, nil, nil, corev1.ClaimBound)
 		vm := corev1.PersistentVolumeBlock
 		sourcePvc.Spec.VolumeMode = &vm
 		reconciler := createUploadReconciler(testPvc, sourcePvc)
 
 		_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "testPvc1", Namespace: "default"}})
-		Expect(err).To(HaveOccurred())
-		Expect(err.Error()).To(ContainSubstring("Source and target volume Modes do not match"))
+		Expect(err).ToNot(HaveOccurred())
+		uploadPod := &corev1.Pod{}
+		err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: createUploadResourceName("testPvc1"), Namespace: "default"}, uploadPod)
+		Expect(err).ToNot(HaveOccurred())
 	})
 
 	It("Should return nil and create a pod and service when a clone pvc", func() {
@@ -322,6 +337,28 @@ var _ = Describe("reconcilePVC loop", func() {
 			Expect(resultPvc.GetAnnotations()[AnnPodReady]).To(Equal("false"))
 		})
 
+		It("Should create the pod with the clone checkpoint", func() {
+			testPvc := createPvc(testPvcName, "default", map[string]string{AnnCloneRequest: "default/testPvc2", AnnUploadPod: uploadResourceName, AnnCloneCheckpoint: "1073741824"}, nil)
+			testPvcSource := createPvc("testPvc2", "default", map[string]string{}, nil)
+			reconciler := createUploadReconciler(testPvc, testPvcSource)
+
+			_, err := reconciler.reconcilePVC(reconciler.log, testPvc, isClone)
+			Expect(err).ToNot(HaveOccurred())
+
+			uploadPod := &corev1.Pod{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: uploadResourceName, Namespace: "default"}, uploadPod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(uploadPod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: common.UploadCloneCheckpoint, Value: "1073741824"}))
+			Expect(uploadPod.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: CloneCheckpointVolName, MountPath: common.CloneCheckpointDir}))
+			found := false
+			for _, vol := range uploadPod.Spec.Volumes {
+				if vol.Name == CloneCheckpointVolName {
+					found = vol.EmptyDir != nil
+				}
+			}
+			Expect(found).To(BeTrue())
+		})
+
 		It("Should error if a POD with the same name exists, but is not owned by the PVC, if a PVC with all needed annotations is passed", func() {
 			pod := &corev1.Pod{
 				TypeMeta: metav1.TypeMeta{
@@ -404,9 +441,153 @@ var _ = Describe("reconcilePVC loop", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(uploadService.Name).To(Equal(uploadResourceName))
 
+			By("Verifying no scratch space is created")
 			scratchPvc := &corev1.PersistentVolumeClaim{}
 			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1-scratch", Namespace: "default"}, scratchPvc)
+			Expect(err).To(HaveOccurred())
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should create the pod with scratch space if the upload requires scratch", func() {
+			testPvc := createPvc(testPvcName, "default", map[string]string{AnnUploadRequest: "", AnnUploadPod: uploadResourceName, AnnRequiresScratch: "true"}, nil)
+			reconciler := createUploadReconciler(testPvc)
+
+			_, err := reconciler.reconcilePVC(reconciler.log, testPvc, isClone)
+			Expect(err).ToNot(HaveOccurred())
+			uploadPod := &corev1.Pod{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: uploadResourceName, Namespace: "default"}, uploadPod)
+			Expect(err).ToNot(HaveOccurred())
+			scratchPVCName, exists := getScratchNameFromPod(uploadPod)
+			Expect(exists).To(BeTrue())
+			Expect(scratchPVCName).To(Equal("testPvc1-scratch"))
+
+			scratchPvc := &corev1.PersistentVolumeClaim{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: "testPvc1-scratch", Namespace: "default"}, scratchPvc)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Should delete the failed pod and schedule a retry if the PVC has a retry policy", func() {
+			testPvc := createPvc(testPvcName, "default", map[string]string{AnnUploadRequest: "", AnnUploadPod: "cdi-upload-" + testPvcName, AnnPodRestarts: "0", AnnRetryBackoff: "10s"}, nil)
+			pod := createUploadClonePod(testPvc, "client.upload-server.cdi.kubevirt.io")
+			pod.Status = corev1.PodStatus{
+				Phase: corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{
+								ExitCode: 1,
+								Reason:   "Error",
+								Message:  "Upload failed",
+							},
+						},
+					},
+				},
+			}
+			reconciler := createUploadReconciler(testPvc, pod, createUploadService(testPvc))
+
+			result, err := reconciler.reconcilePVC(reconciler.log, testPvc, isClone)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			actualPvc := &corev1.PersistentVolumeClaim{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: testPvcName, Namespace: "default"}, actualPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualPvc.Annotations[AnnPodRestarts]).To(Equal("1"))
+			Expect(actualPvc.Annotations[AnnPodPhase]).ToNot(Equal(string(corev1.PodFailed)))
+
+			uploadPod := &corev1.Pod{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "default"}, uploadPod)
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should remove the service and pod of a PVC failed by its retry policy", func() {
+			testPvc := createPvc(testPvcName, "default", map[string]string{AnnUploadRequest: "", AnnUploadPod: "cdi-upload-" + testPvcName, AnnRetryBackoff: "10s", AnnPodPhase: string(corev1.PodFailed)}, nil)
+			pod := createUploadClonePod(testPvc, "client.upload-server.cdi.kubevirt.io")
+			reconciler := createUploadReconciler(testPvc, pod, createUploadService(testPvc))
+
+			_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testPvcName, Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			uploadPod := &corev1.Pod{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "default"}, uploadPod)
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+			uploadService := &corev1.Service{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "default"}, uploadService)
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should fail the PVC and remove the idle upload pod once its deadline passed", func() {
+			testPvc := createPvc(testPvcName, "default", map[string]string{AnnUploadRequest: "", AnnUploadPod: "cdi-upload-" + testPvcName, AnnPodPhase: string(corev1.PodRunning), AnnDeadline: "1h0m0s"}, nil)
+			testPvc.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			pod := createUploadClonePod(testPvc, "client.upload-server.cdi.kubevirt.io")
+			reconciler := createUploadReconciler(testPvc, pod, createUploadService(testPvc))
+
+			_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testPvcName, Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			actualPvc := &corev1.PersistentVolumeClaim{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: testPvcName, Namespace: "default"}, actualPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualPvc.Annotations[AnnPodPhase]).To(Equal(string(corev1.PodFailed)))
+			Expect(actualPvc.Annotations[AnnRunningConditionReason]).To(Equal(DeadlineExceeded))
+			uploadPod := &corev1.Pod{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "default"}, uploadPod)
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+			uploadService := &corev1.Service{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "default"}, uploadService)
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should remove the upload pod and service of a cancelled upload", func() {
+			testPvc := createPvc(testPvcName, "default", map[string]string{AnnUploadRequest: "", AnnUploadPod: "cdi-upload-" + testPvcName, AnnPodPhase: string(corev1.PodRunning), AnnPopulationAction: string(cdiv1.DataVolumeCancel)}, nil)
+			pod := createUploadClonePod(testPvc, "client.upload-server.cdi.kubevirt.io")
+			reconciler := createUploadReconciler(testPvc, pod, createUploadService(testPvc))
+
+			_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: testPvcName, Namespace: "default"}})
 			Expect(err).ToNot(HaveOccurred())
+
+			uploadPod := &corev1.Pod{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "default"}, uploadPod)
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+			uploadService := &corev1.Service{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "default"}, uploadService)
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should delete the pod and require scratch if the upload server exited with the scratch space exit code", func() {
+			testPvc := createPvc(testPvcName, "default", map[string]string{AnnUploadRequest: "", AnnUploadPod: "cdi-upload-" + testPvcName}, nil)
+			pod := createUploadClonePod(testPvc, "client.upload-server.cdi.kubevirt.io")
+			pod.Status = corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						RestartCount: 1,
+						LastTerminationState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{
+								ExitCode: common.ScratchSpaceNeededExitCode,
+								Message:  `{"reason":"ScratchRequired","message":"scratch space required","scratchSize":1048576,"scratchCompressed":true}`,
+							},
+						},
+					},
+				},
+			}
+			reconciler := createUploadReconciler(testPvc, pod, createUploadService(testPvc))
+
+			result, err := reconciler.reconcilePVC(reconciler.log, testPvc, isClone)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+
+			actualPvc := &corev1.PersistentVolumeClaim{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: testPvcName, Namespace: "default"}, actualPvc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualPvc.Annotations[AnnRequiresScratch]).To(Equal("true"))
+			Expect(actualPvc.Annotations[AnnScratchSize]).To(Equal("1048576"))
+			Expect(actualPvc.Annotations[AnnScratchCompressed]).To(Equal("true"))
+			Expect(actualPvc.Annotations[AnnPodReady]).To(Equal("false"))
+
+			uploadPod := &corev1.Pod{}
+			err = reconciler.client.Get(context.TODO(), types.NamespacedName{Name: pod.Name, Namespace: "default"}, uploadPod)
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
 		})
 	})
 })
@@ -3,12 +3,14 @@ package controller
 import (
 	"context"
 	"crypto/rsa"
+	"fmt"
 	"strings"
 
 	"github.com/go-logr/logr"
 	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	extclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,6 +21,7 @@ import (
 
 	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
 	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/util"
 	"kubevirt.io/containerized-data-importer/pkg/util/cert"
 	"kubevirt.io/containerized-data-importer/pkg/util/naming"
 )
@@ -33,6 +36,9 @@ const (
 	// ScratchVolName provides a const to use for creating scratch pvc volumes in pod specs
 	ScratchVolName = "cdi-scratch-vol"
 
+	// CloneCheckpointVolName provides a const to use for the clone checkpoint volume in upload pod specs
+	CloneCheckpointVolName = "cdi-clone-checkpoint-vol"
+
 	// ImagePathName provides a const to use for creating volumes in pod specs
 	ImagePathName  = "image-path"
 	socketPathName = "socket-path"
@@ -53,6 +59,18 @@ const (
 	AnnPopulatedFor = AnnAPIGroup + "/storage.populatedFor"
 	// AnnPrePopulated is a PVC annotation telling the datavolume controller that the PVC is already populated
 	AnnPrePopulated = AnnAPIGroup + "/storage.prePopulated"
+	// AnnCSICloneRequest is a PVC annotation telling the datavolume controller that the CSI driver clones the PVC from its data source
+	AnnCSICloneRequest = AnnAPIGroup + "/storage.csiCloneRequest"
+	// AnnCloneStrategy is a DataVolume annotation requesting a clone strategy, auto or a CloneStrategy
+	AnnCloneStrategy = AnnAPIGroup + "/storage.clone.strategy"
+	// CloneStrategyAuto lets the datavolume controller choose the clone strategy, the default
+	CloneStrategyAuto = "auto"
+	// AnnImportCache is the key of the import cache entry of a cache PVC, and of a PVC cloned from the cache
+	AnnImportCache = AnnAPIGroup + "/storage.import.cache"
+	// AnnImportCacheLastUsed is a cache PVC annotation telling when a DataVolume was last cloned from it
+	AnnImportCacheLastUsed = AnnAPIGroup + "/storage.import.cache.lastUsed"
+	// AnnImportChecksum is a DataVolume annotation identifying the image at the source URL, part of the import cache key
+	AnnImportChecksum = AnnAPIGroup + "/storage.import.checksum"
 
 	// AnnRunningCondition provides a const for the running condition
 	AnnRunningCondition = AnnAPIGroup + "/storage.condition.running"
@@ -199,6 +217,15 @@ func newScratchPersistentVolumeClaimSpec(pvc *v1.PersistentVolumeClaim, pod *v1.
 // CreateScratchPersistentVolumeClaim creates and returns a pointer to a scratch PVC which is created based on the passed-in pvc and storage class name.
 func CreateScratchPersistentVolumeClaim(client client.Client, pvc *v1.PersistentVolumeClaim, pod *v1.Pod, name, storageClassName string) (*v1.PersistentVolumeClaim, error) {
 	scratchPvcSpec := newScratchPersistentVolumeClaimSpec(pvc, pod, name, storageClassName)
+	policy, err := GetScratchSpacePolicy(client)
+	if err != nil {
+		return nil, err
+	}
+	scratchPvcSpec.Spec.Resources = *pvc.Spec.Resources.DeepCopy()
+	if scratchPvcSpec.Spec.Resources.Requests == nil {
+		scratchPvcSpec.Spec.Resources.Requests = v1.ResourceList{}
+	}
+	scratchPvcSpec.Spec.Resources.Requests[v1.ResourceStorage] = getScratchSpaceSize(pvc, policy)
 	if err := client.Create(context.TODO(), scratchPvcSpec); err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
 			return nil, errors.Wrap(err, "scratch PVC API create errored")
@@ -212,6 +239,12 @@ func CreateScratchPersistentVolumeClaim(client client.Client, pvc *v1.Persistent
 	return scratchPvc, nil
 }
 
+// GetProgressURL returns the url the worker pods post their progress reports to, the progress service of the
+// controller
+func GetProgressURL() string {
+	return fmt.Sprintf("https://%s.%s.svc%s", common.ProgressServiceName, util.GetNamespace(), common.ProgressPath)
+}
+
 // GetScratchPvcStorageClass tries to determine which storage class to use for use with a scratch persistent
 // volume claim. The order of preference is the following:
 // 1. Defined value in CDI Config field scratchSpaceStorageClass.
@@ -248,6 +281,92 @@ func GetDefaultPodResourceRequirements(client client.Client) (*v1.ResourceRequir
 	return cdiconfig.Status.DefaultPodResourceRequirements, nil
 }
 
+// GetCloneCompression gets the compression of host assisted clones from cdi config spec, gzip if not set
+func GetCloneCompression(client client.Client) cdiv1.CloneCompression {
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		klog.Errorf("Unable to find CDI configuration, %v\n", err)
+		return cdiv1.CloneCompressionGzip
+	}
+	if cdiconfig.Spec.CloneCompression == "" {
+		return cdiv1.CloneCompressionGzip
+	}
+	return cdiconfig.Spec.CloneCompression
+}
+
+// GetStorageClassByName looks up the storage class based on the name. If no storage class is found returns nil
+func GetStorageClassByName(client client.Client, name *string) (*storagev1.StorageClass, error) {
+	// look up storage class by name
+	if name == nil {
+		storageClasses := &storagev1.StorageClassList{}
+		if err := client.List(context.TODO(), storageClasses); err != nil {
+			klog.V(3).Info("Unable to retrieve available storage classes")
+			return nil, errors.New("unable to retrieve storage classes")
+		}
+		for _, storageClass := range storageClasses.Items {
+			if storageClass.Annotations[AnnDefaultStorageClass] == "true" {
+				return &storageClass, nil
+			}
+		}
+	} else {
+		storageClass := &storagev1.StorageClass{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: *name}, storageClass); err != nil {
+			klog.V(3).Infof("Unable to retrieve storage class %s", *name)
+			return nil, errors.New("unable to retrieve storage class")
+		}
+		return storageClass, nil
+	}
+	// No storage class found, just return nil for storage class and let caller deal with it.
+	return nil, nil
+}
+
+// GetCloneStrategies gets the order clone strategies are tried in for the storage class: its clone strategies in the
+// cdi config spec if listed, otherwise the clone strategy of its storage profile followed by host-assisted, otherwise
+// snapshot followed by host-assisted.
+func GetCloneStrategies(client client.Client, storageClassName string) []cdiv1.CloneStrategy {
+	defaultStrategies := []cdiv1.CloneStrategy{cdiv1.CloneStrategySnapshot, cdiv1.CloneStrategyHostAssisted}
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		klog.Errorf("Unable to find CDI configuration, %v\n", err)
+		return defaultStrategies
+	}
+	for _, cs := range cdiconfig.Spec.CloneStrategies {
+		if cs.StorageClass == storageClassName && len(cs.Strategies) > 0 {
+			return cs.Strategies
+		}
+	}
+	if profile := GetStorageProfile(cdiconfig.Spec.StorageProfiles, storageClassName); profile != nil && profile.CloneStrategy != nil {
+		if *profile.CloneStrategy == cdiv1.CloneStrategyHostAssisted {
+			return []cdiv1.CloneStrategy{cdiv1.CloneStrategyHostAssisted}
+		}
+		return []cdiv1.CloneStrategy{*profile.CloneStrategy, cdiv1.CloneStrategyHostAssisted}
+	}
+	return defaultStrategies
+}
+
+// GetImportCache gets the import cache from cdi config spec, nil if the cache is not enabled
+func GetImportCache(client client.Client) *cdiv1.ImportCacheSpec {
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		klog.Errorf("Unable to find CDI configuration, %v\n", err)
+		return nil
+	}
+	if cdiconfig.Spec.ImportCache == nil || cdiconfig.Spec.ImportCache.Namespace == "" {
+		return nil
+	}
+	return cdiconfig.Spec.ImportCache
+}
+
+// GetDataVolumeDeadline returns the default deadline of DataVolumes from the CDIConfig, nil if there is none
+func GetDataVolumeDeadline(client client.Client) *metav1.Duration {
+	cdiconfig := &cdiv1.CDIConfig{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: common.ConfigName}, cdiconfig); err != nil {
+		klog.Errorf("Unable to find CDI configuration, %v\n", err)
+		return nil
+	}
+	return cdiconfig.Spec.DataVolumeDeadline
+}
+
 // this is being called for pods using PV with block volume mode
 func addVolumeDevices() []v1.VolumeDevice {
 	volumeDevices := []v1.VolumeDevice{
@@ -388,6 +507,26 @@ func setConditionFromPodWithPrefix(anno map[string]string, prefix string, pod *v
 	}
 }
 
+// podTerminationFailure returns the failure a CDI pod reported in its structured termination message if the pod isn't
+// running, nil if the pod didn't report a failure
+func podTerminationFailure(pod *v1.Pod) *util.TerminationMessage {
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return nil
+	}
+	status := pod.Status.ContainerStatuses[0]
+	if status.State.Running != nil {
+		return nil
+	}
+	terminated := status.State.Terminated
+	if terminated == nil {
+		terminated = status.LastTerminationState.Terminated
+	}
+	if terminated == nil || terminated.ExitCode == 0 {
+		return nil
+	}
+	return util.ParseTerminationMessage(terminated.Message)
+}
+
 func setBoundConditionFromPVC(anno map[string]string, prefix string, pvc *v1.PersistentVolumeClaim) {
 	switch pvc.Status.Phase {
 	case v1.ClaimBound:
@@ -411,7 +550,7 @@ func setBoundConditionFromPVC(anno map[string]string, prefix string, pvc *v1.Per
 
 func getScratchNameFromPod(pod *v1.Pod) (string, bool) {
 	for _, vol := range pod.Spec.Volumes {
-		if vol.Name == ScratchVolName {
+		if vol.Name == ScratchVolName && vol.PersistentVolumeClaim != nil {
 			return vol.PersistentVolumeClaim.ClaimName, true
 		}
 	}
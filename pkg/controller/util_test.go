@@ -144,6 +144,58 @@ var _ = Describe("GetScratchPVCStorageClass", func() {
 	})
 })
 
+var _ = Describe("GetCloneCompression", func() {
+	It("Should return gzip if CDIConfig not there", func() {
+		Expect(GetCloneCompression(createClient())).To(Equal(cdiv1.CloneCompressionGzip))
+	})
+
+	It("Should return gzip if not set in CDIConfig", func() {
+		client := createClient(createCDIConfigWithStorageClass(common.ConfigName, ""))
+		Expect(GetCloneCompression(client)).To(Equal(cdiv1.CloneCompressionGzip))
+	})
+
+	It("Should return the compression from CDIConfig", func() {
+		config := createCDIConfigWithStorageClass(common.ConfigName, "")
+		config.Spec.CloneCompression = cdiv1.CloneCompressionNone
+		Expect(GetCloneCompression(createClient(config))).To(Equal(cdiv1.CloneCompressionNone))
+	})
+})
+
+var _ = Describe("GetCloneStrategies", func() {
+	defaultStrategies := []cdiv1.CloneStrategy{cdiv1.CloneStrategySnapshot, cdiv1.CloneStrategyHostAssisted}
+
+	It("Should return the default order if CDIConfig not there", func() {
+		Expect(GetCloneStrategies(createClient(), "testsc")).To(Equal(defaultStrategies))
+	})
+
+	It("Should return the order of the storage class from CDIConfig", func() {
+		config := createCDIConfigWithStorageClass(common.ConfigName, "")
+		config.Spec.CloneStrategies = []cdiv1.StorageClassCloneStrategies{
+			{
+				StorageClass: "testsc",
+				Strategies:   []cdiv1.CloneStrategy{cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategySnapshot},
+			},
+		}
+		client := createClient(config)
+		Expect(GetCloneStrategies(client, "testsc")).To(Equal([]cdiv1.CloneStrategy{cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategySnapshot}))
+		Expect(GetCloneStrategies(client, "othersc")).To(Equal(defaultStrategies))
+	})
+
+	It("Should fall back to host-assisted after the strategy of the storage profile", func() {
+		csiClone := cdiv1.CloneStrategyCsiClone
+		hostAssisted := cdiv1.CloneStrategyHostAssisted
+		config := createCDIConfigWithStorageClass(common.ConfigName, "")
+		config.Spec.StorageProfiles = []cdiv1.StorageProfile{
+			{StorageClass: "testsc", CloneStrategy: &csiClone},
+			{StorageClass: "hostsc", CloneStrategy: &hostAssisted},
+		}
+		client := createClient(config)
+		Expect(GetCloneStrategies(client, "testsc")).To(Equal([]cdiv1.CloneStrategy{cdiv1.CloneStrategyCsiClone, cdiv1.CloneStrategyHostAssisted}))
+		Expect(GetCloneStrategies(client, "hostsc")).To(Equal([]cdiv1.CloneStrategy{cdiv1.CloneStrategyHostAssisted}))
+		Expect(GetCloneStrategies(client, "othersc")).To(Equal(defaultStrategies))
+	})
+})
+
 func createClient(objs ...runtime.Object) client.Client {
 	// Register cdi types with the runtime scheme.
 	s := scheme.Scheme
@@ -66,6 +66,18 @@ func CopyKnownHdrs() Headers {
 	return m
 }
 
+// IsCompressed checks if the passed in file header is the header of a gz or xz compressed file, which may be shorter
+// than the magic numbers
+func IsCompressed(b []byte) bool {
+	for _, format := range []string{"gz", "xz"} {
+		h := knownHeaders[format]
+		if len(b) >= h.mgOffset+len(h.magicNumber) && h.Match(b) {
+			return true
+		}
+	}
+	return false
+}
+
 // Match performs a check to see if the provided byte slice matches the bytes in our header data
 func (h Header) Match(b []byte) bool {
 	return bytes.Equal(b[h.mgOffset:h.mgOffset+len(h.magicNumber)], h.magicNumber)
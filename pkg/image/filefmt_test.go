@@ -29,6 +29,16 @@ var _ = Describe("File format tests", func() {
 	rand.Read(token)
 	tarbyte := append(token, tarheader...)
 
+	table.DescribeTable("Is compressed", func(b []byte, want bool) {
+		Expect(IsCompressed(b)).To(Equal(want))
+	},
+		table.Entry("gz", []byte{0x1F, 0x8B, 0x08}, true),
+		table.Entry("xz", []byte{0xFD, 0x37, 0x7A, 0x58, 0x5A, 0x00}, true),
+		table.Entry("qcow2", []byte{'Q', 'F', 'I', 0xfb}, false),
+		table.Entry("short", []byte{0xFD, 0x37}, false),
+		table.Entry("empty", []byte{}, false),
+	)
+
 	table.DescribeTable("Header match", func(fields fields, b []byte, want bool) {
 		h := Header{
 			Format:      fields.Format,
@@ -0,0 +1,515 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+
+	"kubevirt.io/containerized-data-importer/pkg/util"
+)
+
+const (
+	qcow2Magic       = 0x514649fb
+	qcow2HeaderLen   = 72
+	qcow2V3HeaderLen = 104
+
+	minClusterBits = 9
+	maxClusterBits = 21
+
+	// incompatible feature bits the converter can handle, only the dirty bit which affects refcounts
+	qcow2IncompatDirty = uint64(1)
+
+	qcow2OffsetMask     = uint64(0x00fffffffffffe00)
+	qcow2CompressedFlag = uint64(1) << 62
+	qcow2ZeroFlag       = uint64(1)
+)
+
+// maximum bytes of clusters kept in memory while the L1 or L2 tables referencing them have not been read
+var maxStreamBuffer = 64 * 1024 * 1024
+
+// ErrQcow2NotStreamable indicates the qcow2 image uses features or a layout that can't be converted while streaming,
+// a seekable copy of the image has to be converted with qemu-img instead.
+var ErrQcow2NotStreamable = errors.New("qcow2 image can not be converted while streaming")
+
+// ImageTooLargeError indicates the virtual size of an image is larger than the space available for it.
+type ImageTooLargeError struct {
+	VirtualSize   int64
+	AvailableSize int64
+}
+
+func (e ImageTooLargeError) Error() string {
+	return fmt.Sprintf("Virtual image size %d is larger than available size %d. A larger PVC is required.", e.VirtualSize, e.AvailableSize)
+}
+
+type qcow2Header struct {
+	version              uint32
+	backingFileOffset    uint64
+	clusterBits          uint32
+	size                 uint64
+	cryptMethod          uint32
+	l1Size               uint32
+	l1TableOffset        uint64
+	incompatibleFeatures uint64
+}
+
+func parseQcow2Header(b []byte) (*qcow2Header, error) {
+	if len(b) < qcow2HeaderLen || binary.BigEndian.Uint32(b[0:4]) != qcow2Magic {
+		return nil, errors.New("invalid qcow2 header")
+	}
+	h := &qcow2Header{
+		version:           binary.BigEndian.Uint32(b[4:8]),
+		backingFileOffset: binary.BigEndian.Uint64(b[8:16]),
+		clusterBits:       binary.BigEndian.Uint32(b[20:24]),
+		size:              binary.BigEndian.Uint64(b[24:32]),
+		cryptMethod:       binary.BigEndian.Uint32(b[32:36]),
+		l1Size:            binary.BigEndian.Uint32(b[36:40]),
+		l1TableOffset:     binary.BigEndian.Uint64(b[40:48]),
+	}
+	if h.version >= 3 {
+		if len(b) < qcow2V3HeaderLen {
+			return nil, errors.New("invalid qcow2 v3 header")
+		}
+		h.incompatibleFeatures = binary.BigEndian.Uint64(b[72:80])
+	}
+	return h, nil
+}
+
+// streamable returns ErrQcow2NotStreamable if the image uses a feature the converter doesn't implement.
+func (h *qcow2Header) streamable() error {
+	switch {
+	case h.version != 2 && h.version != 3:
+		return errors.Wrapf(ErrQcow2NotStreamable, "unsupported version %d", h.version)
+	case h.backingFileOffset != 0:
+		return errors.Wrap(ErrQcow2NotStreamable, "image has a backing file")
+	case h.cryptMethod != 0:
+		return errors.Wrap(ErrQcow2NotStreamable, "image is encrypted")
+	case h.incompatibleFeatures&^qcow2IncompatDirty != 0:
+		return errors.Wrapf(ErrQcow2NotStreamable, "unsupported incompatible features %#x", h.incompatibleFeatures)
+	case h.clusterBits < minClusterBits || h.clusterBits > maxClusterBits:
+		return errors.Wrapf(ErrQcow2NotStreamable, "unsupported cluster bits %d", h.clusterBits)
+	case h.l1TableOffset == 0 || h.l1TableOffset%(uint64(1)<<h.clusterBits) != 0:
+		return errors.Wrap(ErrQcow2NotStreamable, "invalid L1 table offset")
+	}
+	return nil
+}
+
+// IsStreamableQcow2 checks if the qcow2 image starting with hdr can be converted by ConvertQcow2StreamToRaw.
+func IsStreamableQcow2(hdr []byte) bool {
+	h, err := parseQcow2Header(hdr)
+	if err != nil {
+		return false
+	}
+	return h.streamable() == nil
+}
+
+// compressedCluster is a compressed guest cluster stored in host bytes [offset, offset+size)
+type compressedCluster struct {
+	offset uint64
+	size   uint64
+	guest  uint64
+}
+
+// qcow2StreamConverter converts a qcow2 image read sequentially, writing the guest clusters to the target as soon as both
+// the cluster and the L2 table entry mapping it have been read.
+type qcow2StreamConverter struct {
+	header      *qcow2Header
+	clusterSize uint64
+	target      *os.File
+	isBlock     bool
+	// guest clusters written, used to zero the rest of block devices
+	written []bool
+
+	// host cluster index -> guest base offset of the L2 table stored there, for L2 tables not read yet
+	pendingL2 map[uint64]uint64
+	// host cluster index -> guest offsets of the data stored there, for data clusters not read yet
+	pendingData map[uint64][]uint64
+	// compressed clusters not decompressed yet
+	pendingCompressed []compressedCluster
+	// host clusters already read that may still be needed
+	held     map[uint64][]byte
+	heldSize int
+	// held host clusters whose contents have been used as the tables or guest data mapped there
+	consumed map[uint64]bool
+	// host cluster index -> bytes of decompressed compressed clusters stored there, for clusters not fully consumed yet
+	compressedBytes map[uint64]uint64
+	// host offsets of the compressed clusters decompressed
+	decompressed map[uint64]bool
+	// host clusters holding the L1 table
+	l1Start    uint64
+	l1Clusters uint64
+	// host clusters of the L1 table not read yet
+	l1Remaining int
+	// current host cluster index
+	current uint64
+}
+
+// ConvertQcow2StreamToRaw converts the qcow2 image read from r to a raw image in dest, which is either a block device or a
+// file that is created. The virtual size of the image has to fit in availableSize. ErrQcow2NotStreamable is returned
+// if the image can't be converted without random access, no data has been consumed from r if that is detected in the header.
+func ConvertQcow2StreamToRaw(r io.Reader, dest string, availableSize int64) error {
+	hdr := make([]byte, MaxExpectedHdrSize)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return errors.Wrap(err, "unable to read qcow2 header")
+	}
+	h, err := parseQcow2Header(hdr)
+	if err != nil {
+		return err
+	}
+	if err = h.streamable(); err != nil {
+		return err
+	}
+	if int64(h.size) > availableSize {
+		return ImageTooLargeError{VirtualSize: int64(h.size), AvailableSize: availableSize}
+	}
+
+	blockSize, err := util.GetAvailableSpaceBlock(dest)
+	if err != nil {
+		return errors.Wrapf(err, "error determining if block device exists")
+	}
+	var target *os.File
+	if blockSize >= 0 {
+		target, err = os.OpenFile(dest, os.O_EXCL|os.O_WRONLY, os.ModePerm)
+	} else {
+		target, err = os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.ModePerm)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not open file %q", dest)
+	}
+	defer target.Close()
+
+	c := newQcow2StreamConverter(h, target, blockSize >= 0)
+	if !c.isBlock {
+		// unallocated clusters stay sparse holes in the file
+		if err = target.Truncate(int64(h.size)); err != nil {
+			return errors.Wrap(err, "unable to size target file")
+		}
+	}
+	klog.V(1).Infof("Converting qcow2 stream, virtual size %d, cluster size %d\n", h.size, c.clusterSize)
+	if err = c.convert(io.MultiReader(bytes.NewReader(hdr), r)); err != nil {
+		if !c.isBlock {
+			os.Remove(dest)
+		}
+		return err
+	}
+	if c.isBlock {
+		if err = c.zeroUnwritten(); err != nil {
+			return err
+		}
+	}
+	return target.Sync()
+}
+
+func newQcow2StreamConverter(h *qcow2Header, target *os.File, isBlock bool) *qcow2StreamConverter {
+	clusterSize := uint64(1) << h.clusterBits
+	c := &qcow2StreamConverter{
+		header:      h,
+		clusterSize: clusterSize,
+		target:      target,
+		isBlock:     isBlock,
+		written:     make([]bool, (h.size+clusterSize-1)/clusterSize),
+		pendingL2:   make(map[uint64]uint64),
+		pendingData: make(map[uint64][]uint64),
+		held:        make(map[uint64][]byte),
+		consumed:    make(map[uint64]bool),
+
+		compressedBytes: make(map[uint64]uint64),
+		decompressed:    make(map[uint64]bool),
+	}
+	c.l1Start = h.l1TableOffset / clusterSize
+	c.l1Clusters = (uint64(h.l1Size)*8 + clusterSize - 1) / clusterSize
+	c.l1Remaining = int(c.l1Clusters)
+	return c
+}
+
+func (c *qcow2StreamConverter) convert(r io.Reader) error {
+	// the first cluster holds the header and its extensions
+	for c.current = 0; ; c.current++ {
+		buf := make([]byte, c.clusterSize)
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return errors.Wrap(err, "unable to read qcow2 stream")
+		}
+		if c.current > 0 {
+			if procErr := c.processCluster(buf); procErr != nil {
+				return procErr
+			}
+		}
+		if n < len(buf) {
+			break
+		}
+	}
+	if c.l1Remaining > 0 || len(c.pendingL2) > 0 || len(c.pendingData) > 0 || len(c.pendingCompressed) > 0 {
+		return errors.New("qcow2 stream ended before all clusters were read")
+	}
+	return nil
+}
+
+func (c *qcow2StreamConverter) metadataComplete() bool {
+	return c.l1Remaining == 0 && len(c.pendingL2) == 0
+}
+
+func (c *qcow2StreamConverter) processCluster(buf []byte) error {
+	idx := c.current
+	if idx >= c.l1Start && idx < c.l1Start+c.l1Clusters {
+		if err := c.processL1(buf, idx-c.l1Start); err != nil {
+			return err
+		}
+		c.consumed[idx] = true
+	}
+	if guestBase, ok := c.pendingL2[idx]; ok {
+		delete(c.pendingL2, idx)
+		if err := c.processL2(buf, guestBase); err != nil {
+			return err
+		}
+		c.consumed[idx] = true
+	}
+	if guests, ok := c.pendingData[idx]; ok {
+		delete(c.pendingData, idx)
+		for _, guest := range guests {
+			if err := c.writeGuest(buf, guest); err != nil {
+				return err
+			}
+		}
+		c.consumed[idx] = true
+	}
+
+	c.hold(idx, buf)
+	if err := c.processCompressed(); err != nil {
+		return err
+	}
+	c.release()
+	if c.heldSize > maxStreamBuffer {
+		return errors.Wrap(ErrQcow2NotStreamable, "too many clusters precede the tables mapping them")
+	}
+	return nil
+}
+
+func (c *qcow2StreamConverter) processL1(buf []byte, l1Cluster uint64) error {
+	c.l1Remaining--
+	entriesPerCluster := c.clusterSize / 8
+	l2Coverage := entriesPerCluster * c.clusterSize
+	for i := uint64(0); i < entriesPerCluster; i++ {
+		index := l1Cluster*entriesPerCluster + i
+		if index >= uint64(c.header.l1Size) {
+			break
+		}
+		l2Offset := binary.BigEndian.Uint64(buf[i*8:]) & qcow2OffsetMask
+		if l2Offset == 0 {
+			continue
+		}
+		guestBase := index * l2Coverage
+		l2Idx := l2Offset / c.clusterSize
+		if l2Idx > c.current {
+			c.pendingL2[l2Idx] = guestBase
+			continue
+		}
+		l2, ok := c.held[l2Idx]
+		if !ok {
+			return errors.Wrap(ErrQcow2NotStreamable, "L2 table precedes the L1 table")
+		}
+		if err := c.processL2(l2, guestBase); err != nil {
+			return err
+		}
+		c.consumed[l2Idx] = true
+	}
+	return nil
+}
+
+func (c *qcow2StreamConverter) processL2(buf []byte, guestBase uint64) error {
+	entries := c.clusterSize / 8
+	for i := uint64(0); i < entries; i++ {
+		guest := guestBase + i*c.clusterSize
+		if guest >= c.header.size {
+			break
+		}
+		entry := binary.BigEndian.Uint64(buf[i*8:])
+		if entry&qcow2CompressedFlag != 0 {
+			offsetBits := 62 - (c.header.clusterBits - 8)
+			offset := entry & (uint64(1)<<offsetBits - 1)
+			sectors := (entry >> offsetBits) & (uint64(1)<<(c.header.clusterBits-8) - 1)
+			c.pendingCompressed = append(c.pendingCompressed, compressedCluster{
+				offset: offset,
+				size:   (sectors+1)*512 - offset%512,
+				guest:  guest,
+			})
+			continue
+		}
+		hostOffset := entry & qcow2OffsetMask
+		if hostOffset == 0 || entry&qcow2ZeroFlag != 0 {
+			// unallocated or zero cluster
+			continue
+		}
+		hostIdx := hostOffset / c.clusterSize
+		if hostIdx > c.current {
+			c.pendingData[hostIdx] = append(c.pendingData[hostIdx], guest)
+			continue
+		}
+		data, ok := c.held[hostIdx]
+		if !ok {
+			return errors.Wrap(ErrQcow2NotStreamable, "data cluster precedes its L2 table")
+		}
+		if err := c.writeGuest(data, guest); err != nil {
+			return err
+		}
+		c.consumed[hostIdx] = true
+	}
+	return nil
+}
+
+// processCompressed decompresses the compressed clusters whose data has been read completely
+func (c *qcow2StreamConverter) processCompressed() error {
+	remaining := c.pendingCompressed[:0]
+	for _, cc := range c.pendingCompressed {
+		first := cc.offset / c.clusterSize
+		last := (cc.offset + cc.size - 1) / c.clusterSize
+		if last > c.current {
+			remaining = append(remaining, cc)
+			continue
+		}
+		compressed := make([]byte, 0, cc.size)
+		for idx := first; idx <= last; idx++ {
+			data, ok := c.held[idx]
+			if !ok {
+				return errors.Wrap(ErrQcow2NotStreamable, "compressed cluster precedes its L2 table")
+			}
+			compressed = append(compressed, data...)
+		}
+		start := cc.offset % c.clusterSize
+		compressed = compressed[start : start+cc.size]
+		data := make([]byte, c.clusterSize)
+		// compressed clusters are raw deflate streams, trailing bytes of the last sector are ignored
+		compressedReader := bytes.NewReader(compressed)
+		deflateReader := flate.NewReader(compressedReader)
+		if _, err := io.ReadFull(deflateReader, data); err != nil {
+			return errors.Wrapf(err, "unable to decompress cluster at guest offset %d", cc.guest)
+		}
+		if err := c.writeGuest(data, cc.guest); err != nil {
+			return err
+		}
+		// the sector count of the L2 entry only bounds the compressed data, reading to the end of the deflate stream
+		// gives the bytes it occupies
+		if _, err := io.Copy(ioutil.Discard, deflateReader); err == nil {
+			c.consumeCompressed(cc.offset, cc.size-uint64(compressedReader.Len()))
+		}
+	}
+	c.pendingCompressed = remaining
+	return nil
+}
+
+func (c *qcow2StreamConverter) hold(idx uint64, buf []byte) {
+	c.held[idx] = buf
+	c.heldSize += len(buf)
+}
+
+// consumeCompressed marks the host clusters filled completely by decompressed compressed clusters as consumed
+func (c *qcow2StreamConverter) consumeCompressed(offset, length uint64) {
+	if c.decompressed[offset] {
+		return
+	}
+	c.decompressed[offset] = true
+	for pos := offset; pos < offset+length; {
+		idx := pos / c.clusterSize
+		end := (idx + 1) * c.clusterSize
+		if end > offset+length {
+			end = offset + length
+		}
+		c.compressedBytes[idx] += end - pos
+		if c.compressedBytes[idx] == c.clusterSize {
+			delete(c.compressedBytes, idx)
+			c.consumed[idx] = true
+		}
+		pos = end
+	}
+}
+
+// release drops the held clusters that can't be referenced anymore: the clusters that have been consumed, and once
+// all the tables have been read every cluster not needed by a pending compressed cluster. A table read later that
+// references a released cluster fails the conversion as not streamable.
+func (c *qcow2StreamConverter) release() {
+	complete := c.metadataComplete()
+	needed := make(map[uint64]bool)
+	for _, cc := range c.pendingCompressed {
+		for idx := cc.offset / c.clusterSize; idx <= (cc.offset+cc.size-1)/c.clusterSize; idx++ {
+			needed[idx] = true
+		}
+	}
+	for idx, buf := range c.held {
+		if needed[idx] || !(complete || c.consumed[idx]) {
+			continue
+		}
+		delete(c.held, idx)
+		delete(c.consumed, idx)
+		delete(c.compressedBytes, idx)
+		c.heldSize -= len(buf)
+	}
+}
+
+func (c *qcow2StreamConverter) writeGuest(data []byte, guest uint64) error {
+	length := c.clusterSize
+	if guest+length > c.header.size {
+		length = c.header.size - guest
+	}
+	c.written[guest/c.clusterSize] = true
+	if !c.isBlock && isZero(data[:length]) {
+		// keep the file sparse
+		return nil
+	}
+	if _, err := c.target.WriteAt(data[:length], int64(guest)); err != nil {
+		return errors.Wrapf(err, "unable to write guest offset %d", guest)
+	}
+	return nil
+}
+
+// zeroUnwritten writes zeroes to the guest clusters that are unallocated or zero in the image
+func (c *qcow2StreamConverter) zeroUnwritten() error {
+	zeroes := make([]byte, c.clusterSize)
+	for i, written := range c.written {
+		if written {
+			continue
+		}
+		guest := uint64(i) * c.clusterSize
+		length := c.clusterSize
+		if guest+length > c.header.size {
+			length = c.header.size - guest
+		}
+		if _, err := c.target.WriteAt(zeroes[:length], int64(guest)); err != nil {
+			return errors.Wrapf(err, "unable to zero guest offset %d", guest)
+		}
+	}
+	return nil
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
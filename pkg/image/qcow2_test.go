@@ -0,0 +1,317 @@
+package image
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+const testClusterBits = 16
+
+// testQcow2 builds a minimal qcow2 v3 image with a single L2 table
+type testQcow2 struct {
+	size       uint64
+	clusters   map[uint64][]byte
+	compressed map[uint64]bool
+	zero       map[uint64]bool
+	// write the data clusters before the L1 and L2 tables
+	dataFirst   bool
+	backingFile bool
+}
+
+func (q *testQcow2) build() []byte {
+	clusterSize := uint64(1) << testClusterBits
+	var data []byte
+	entries := make(map[uint64]uint64)
+	dataStart := uint64(3)
+	l1Idx, l2Idx := uint64(1), uint64(2)
+	if q.dataFirst {
+		dataStart = 1
+	}
+	for guest := uint64(0); guest*clusterSize < q.size; guest++ {
+		content, ok := q.clusters[guest]
+		if q.zero[guest] {
+			entries[guest] = qcow2ZeroFlag
+			continue
+		}
+		if !ok {
+			continue
+		}
+		host := dataStart*clusterSize + uint64(len(data))
+		if q.compressed[guest] {
+			var buf bytes.Buffer
+			w, _ := flate.NewWriter(&buf, flate.BestCompression)
+			w.Write(content)
+			w.Close()
+			sectors := (host+uint64(buf.Len())-1)>>9 - host>>9
+			offsetBits := uint(62 - (testClusterBits - 8))
+			entries[guest] = qcow2CompressedFlag | sectors<<offsetBits | host
+			data = append(data, buf.Bytes()...)
+			// keep the following clusters aligned
+			data = append(data, make([]byte, clusterSize-uint64(len(data))%clusterSize)...)
+			continue
+		}
+		entries[guest] = host
+		data = append(data, content...)
+	}
+	dataClusters := uint64(len(data)) / clusterSize
+	if q.dataFirst {
+		l1Idx, l2Idx = dataStart+dataClusters, dataStart+dataClusters+1
+	}
+
+	header := make([]byte, clusterSize)
+	binary.BigEndian.PutUint32(header[0:], qcow2Magic)
+	binary.BigEndian.PutUint32(header[4:], 3)
+	if q.backingFile {
+		binary.BigEndian.PutUint64(header[8:], 512)
+	}
+	binary.BigEndian.PutUint32(header[20:], testClusterBits)
+	binary.BigEndian.PutUint64(header[24:], q.size)
+	binary.BigEndian.PutUint32(header[36:], 1)
+	binary.BigEndian.PutUint64(header[40:], l1Idx*clusterSize)
+	binary.BigEndian.PutUint32(header[100:], qcow2V3HeaderLen)
+
+	l1 := make([]byte, clusterSize)
+	binary.BigEndian.PutUint64(l1, l2Idx*clusterSize|uint64(1)<<63)
+	l2 := make([]byte, clusterSize)
+	for guest, entry := range entries {
+		binary.BigEndian.PutUint64(l2[guest*8:], entry)
+	}
+
+	image := header
+	if q.dataFirst {
+		image = append(image, data...)
+		image = append(image, l1...)
+		return append(image, l2...)
+	}
+	image = append(image, l1...)
+	image = append(image, l2...)
+	return append(image, data...)
+}
+
+func (q *testQcow2) expected() []byte {
+	clusterSize := uint64(1) << testClusterBits
+	raw := make([]byte, q.size)
+	for guest, content := range q.clusters {
+		if !q.zero[guest] {
+			copy(raw[guest*clusterSize:], content)
+		}
+	}
+	return raw
+}
+
+// multiL2Qcow2 builds a qcow2 v3 image with small clusters and several L2 tables, each followed by the data clusters it
+// maps like qemu-img writes them, returning the image and its raw contents
+func multiL2Qcow2(l2Tables int, compressed bool) ([]byte, []byte) {
+	const clusterBits = minClusterBits
+	clusterSize := uint64(1) << clusterBits
+	entriesPerL2 := clusterSize / 8
+	size := uint64(l2Tables) * entriesPerL2 * clusterSize
+
+	header := make([]byte, clusterSize)
+	binary.BigEndian.PutUint32(header[0:], qcow2Magic)
+	binary.BigEndian.PutUint32(header[4:], 3)
+	binary.BigEndian.PutUint32(header[20:], clusterBits)
+	binary.BigEndian.PutUint64(header[24:], size)
+	binary.BigEndian.PutUint32(header[36:], uint32(l2Tables))
+	binary.BigEndian.PutUint64(header[40:], clusterSize)
+	binary.BigEndian.PutUint32(header[100:], qcow2V3HeaderLen)
+
+	l1 := make([]byte, clusterSize)
+	image := append(header, l1...)
+	raw := make([]byte, size)
+	for table := uint64(0); table < uint64(l2Tables); table++ {
+		l2Offset := uint64(len(image))
+		binary.BigEndian.PutUint64(image[clusterSize+table*8:], l2Offset|uint64(1)<<63)
+		image = append(image, make([]byte, clusterSize)...)
+		for i := uint64(0); i < entriesPerL2; i++ {
+			guest := (table*entriesPerL2 + i) * clusterSize
+			// half random so compressed clusters take a few hundred bytes
+			content := bytes.Repeat([]byte{byte(guest / clusterSize)}, int(clusterSize))
+			rand.New(rand.NewSource(int64(guest))).Read(content[clusterSize/2:])
+			copy(raw[guest:], content)
+			host := uint64(len(image))
+			entry := host
+			if compressed {
+				// compressed clusters are packed, they share and span host clusters
+				var buf bytes.Buffer
+				w, _ := flate.NewWriter(&buf, flate.BestCompression)
+				w.Write(content)
+				w.Close()
+				sectors := (host+uint64(buf.Len())-1)>>9 - host>>9
+				entry = qcow2CompressedFlag | sectors<<(62-(clusterBits-8)) | host
+				content = buf.Bytes()
+			}
+			binary.BigEndian.PutUint64(image[l2Offset+i*8:], entry)
+			image = append(image, content...)
+		}
+		if len(image)%int(clusterSize) != 0 {
+			image = append(image, make([]byte, int(clusterSize)-len(image)%int(clusterSize))...)
+		}
+	}
+	return image, raw
+}
+
+func filledCluster(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 1<<testClusterBits)
+}
+
+var _ = Describe("Qcow2 stream conversion", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "qcow2")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	convert := func(q *testQcow2, availableSize int64) (string, error) {
+		dest := filepath.Join(tmpDir, "disk.img")
+		return dest, ConvertQcow2StreamToRaw(bytes.NewReader(q.build()), dest, availableSize)
+	}
+
+	It("Should convert allocated, unallocated and zero clusters", func() {
+		q := &testQcow2{
+			size: 6 << testClusterBits,
+			clusters: map[uint64][]byte{
+				0: filledCluster(1),
+				2: filledCluster(2),
+				3: filledCluster(3),
+				5: filledCluster(5),
+			},
+			zero: map[uint64]bool{3: true},
+		}
+		Expect(IsStreamableQcow2(q.build())).To(BeTrue())
+		dest, err := convert(q, 1<<30)
+		Expect(err).NotTo(HaveOccurred())
+		raw, err := ioutil.ReadFile(dest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(raw).To(Equal(q.expected()))
+	})
+
+	It("Should convert compressed clusters", func() {
+		q := &testQcow2{
+			size: 3 << testClusterBits,
+			clusters: map[uint64][]byte{
+				0: filledCluster(7),
+				1: append(filledCluster(8)[:100], filledCluster(9)[100:]...),
+			},
+			compressed: map[uint64]bool{0: true, 1: true},
+		}
+		dest, err := convert(q, 1<<30)
+		Expect(err).NotTo(HaveOccurred())
+		raw, err := ioutil.ReadFile(dest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(raw).To(Equal(q.expected()))
+	})
+
+	It("Should convert data clusters preceding the tables", func() {
+		q := &testQcow2{
+			size: 4 << testClusterBits,
+			clusters: map[uint64][]byte{
+				1: filledCluster(1),
+				3: filledCluster(3),
+			},
+			dataFirst: true,
+		}
+		dest, err := convert(q, 1<<30)
+		Expect(err).NotTo(HaveOccurred())
+		raw, err := ioutil.ReadFile(dest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(raw).To(Equal(q.expected()))
+	})
+
+	It("Should convert a virtual size that isn't cluster aligned", func() {
+		q := &testQcow2{
+			size:     1<<testClusterBits + 4096,
+			clusters: map[uint64][]byte{1: filledCluster(4)},
+		}
+		dest, err := convert(q, 1<<30)
+		Expect(err).NotTo(HaveOccurred())
+		raw, err := ioutil.ReadFile(dest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(raw).To(Equal(q.expected()))
+	})
+
+	Context("with a small stream buffer", func() {
+		var origMaxStreamBuffer int
+
+		BeforeEach(func() {
+			origMaxStreamBuffer = maxStreamBuffer
+			maxStreamBuffer = 16 << minClusterBits
+		})
+
+		AfterEach(func() {
+			maxStreamBuffer = origMaxStreamBuffer
+		})
+
+		table.DescribeTable("Should release the clusters of images with several L2 tables", func(compressed bool) {
+			image, expected := multiL2Qcow2(8, compressed)
+			dest := filepath.Join(tmpDir, "disk.img")
+			Expect(ConvertQcow2StreamToRaw(bytes.NewReader(image), dest, 1<<30)).To(Succeed())
+			raw, err := ioutil.ReadFile(dest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(raw).To(Equal(expected))
+		},
+			table.Entry("with data clusters", false),
+			table.Entry("with compressed clusters", true),
+		)
+
+		It("Should not stream images with more data preceding the tables than the buffer", func() {
+			q := &testQcow2{
+				size: 4 << testClusterBits,
+				clusters: map[uint64][]byte{
+					1: filledCluster(1),
+					3: filledCluster(3),
+				},
+				dataFirst: true,
+			}
+			_, err := convert(q, 1<<30)
+			Expect(errors.Cause(err)).To(Equal(ErrQcow2NotStreamable))
+		})
+	})
+
+	It("Should not stream images with a backing file", func() {
+		q := &testQcow2{
+			size:        1 << testClusterBits,
+			backingFile: true,
+		}
+		Expect(IsStreamableQcow2(q.build())).To(BeFalse())
+		dest, err := convert(q, 1<<30)
+		Expect(errors.Cause(err)).To(Equal(ErrQcow2NotStreamable))
+		_, err = os.Stat(dest)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("Should fail if the virtual size is larger than the available size", func() {
+		q := &testQcow2{size: 4 << testClusterBits}
+		_, err := convert(q, 1<<testClusterBits)
+		Expect(err).To(BeAssignableToTypeOf(ImageTooLargeError{}))
+	})
+
+	It("Should fail on a truncated stream", func() {
+		q := &testQcow2{
+			size:     2 << testClusterBits,
+			clusters: map[uint64][]byte{1: filledCluster(1)},
+		}
+		image := q.build()
+		dest := filepath.Join(tmpDir, "disk.img")
+		err := ConvertQcow2StreamToRaw(bytes.NewReader(image[:len(image)-(1<<testClusterBits)]), dest, 1<<30)
+		Expect(err).To(HaveOccurred())
+		_, err = os.Stat(dest)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
@@ -36,6 +36,7 @@ const whFilePrefix string = ".wh."
 // SkopeoOperations defines the interface for executing skopeo subprocesses
 type SkopeoOperations interface {
 	CopyImage(string, string, string, string, string, bool) error
+	ImageDigest(string, string, string, string, bool) (string, error)
 }
 
 type skopeoOperations struct{}
@@ -82,6 +83,35 @@ func (o *skopeoOperations) CopyImage(url, dest, accessKey, secKey, certDir strin
 	return nil
 }
 
+// ImageDigest returns the digest of the manifest of the image, the digest of the manifest list of multi arch images
+func (o *skopeoOperations) ImageDigest(url, accessKey, secKey, certDir string, insecureRegistry bool) (string, error) {
+	args := []string{"inspect", url}
+	if accessKey != "" && secKey != "" {
+		args = append(args, "--creds="+accessKey+":"+secKey)
+	}
+	if certDir != "" {
+		klog.Infof("Using user specified TLS certs at %s", certDir)
+		args = append(args, "--cert-dir="+certDir)
+	} else if insecureRegistry {
+		klog.Infof("Disabling TLS verification for URL %s", url)
+		args = append(args, "--tls-verify=false")
+	}
+	out, err := skopeoExecFunction(nil, nil, "skopeo", args...)
+	if err != nil {
+		return "", errors.Wrap(err, "could not inspect image")
+	}
+	inspect := struct {
+		Digest string `json:"Digest"`
+	}{}
+	if err := json.Unmarshal(out, &inspect); err != nil {
+		return "", errors.Wrap(err, "could not parse the image inspection")
+	}
+	if inspect.Digest == "" {
+		return "", errors.New("the image inspection has no digest")
+	}
+	return inspect.Digest, nil
+}
+
 // CopyRegistryImage download image from registry with skopeo
 // url: source registry url.
 // dest: the scratch space destination.
@@ -57,6 +57,24 @@ var _ = Describe("Registry Importer", func() {
 		table.Entry("copy failure", mockExecFunction("", "Failed to find VM disk image file in the container image", nil), "Failed to find VM disk image file in the container image", func() error { return CopyRegistryImage(source, dest, "", "", "", "", false) }),
 	)
 
+	table.DescribeTable("inspecting the image digest should", func(execfunc execFunctionType, expectedDigest, errString string, insecureRegistry bool) {
+		replaceSkopeoFunctions(execfunc, func() {
+			digest, err := SkopeoInterface.ImageDigest(source, "user", "pass", "", insecureRegistry)
+			if errString == "" {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(digest).To(Equal(expectedDigest))
+			} else {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(errString))
+			}
+		})
+	},
+		table.Entry("return the digest", mockExecFunction("{\"Name\": \"docker.io/fedora\",\n\"Digest\": \"sha256:1234\"}\n", "", nil, "inspect", "--creds=user:pass"), "sha256:1234", "", false),
+		table.Entry("disable tls verification of insecure registries", mockExecFunction("{\"Digest\": \"sha256:1234\"}", "", nil, "--tls-verify=false"), "sha256:1234", "", true),
+		table.Entry("fail without a digest", mockExecFunction("{\"Name\": \"docker.io/fedora\"}", "", nil), "", "the image inspection has no digest", false),
+		table.Entry("fail when skopeo fails", mockExecFunction("", "manifest unknown", nil), "", "manifest unknown", false),
+	)
+
 })
 
 var _ = Describe("Extract image layers", func() {
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const sha256ChecksumPrefix = "sha256:"
+
+// ParseSha256Checksum returns the hex encoded digest of a checksum of the form sha256:<64 hex characters>, the only
+// form of checksum the importer verifies
+func ParseSha256Checksum(checksum string) (string, error) {
+	if !strings.HasPrefix(checksum, sha256ChecksumPrefix) {
+		return "", errors.Errorf("unsupported checksum %q, expected %s<digest>", checksum, sha256ChecksumPrefix)
+	}
+	digest := strings.ToLower(strings.TrimPrefix(checksum, sha256ChecksumPrefix))
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != sha256.Size {
+		return "", errors.Errorf("invalid sha256 digest in checksum %q", checksum)
+	}
+	return digest, nil
+}
+
+// checksumReader hashes the data read from the source, so it can be verified once the import read it
+type checksumReader struct {
+	io.ReadCloser
+	hash   hash.Hash
+	digest string
+}
+
+func newChecksumReader(reader io.ReadCloser, checksum string) (*checksumReader, error) {
+	digest, err := ParseSha256Checksum(checksum)
+	if err != nil {
+		return nil, NewImportError(ReasonInvalidConfiguration, err)
+	}
+	return &checksumReader{ReadCloser: reader, hash: sha256.New(), digest: digest}, nil
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	return n, err
+}
+
+// verify reads the data the import didn't need, like the padding after a compressed stream, and compares the
+// checksum of all the data with the expected one
+func (r *checksumReader) verify() error {
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return errors.Wrap(err, "unable to read the source to verify its checksum")
+	}
+	if actual := hex.EncodeToString(r.hash.Sum(nil)); actual != r.digest {
+		err := errors.Errorf("checksum mismatch, expected %s%s, got %s%s", sha256ChecksumPrefix, r.digest, sha256ChecksumPrefix, actual)
+		return NewImportError(ReasonChecksumMismatch, err)
+	}
+	return nil
+}
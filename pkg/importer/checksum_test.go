@@ -0,0 +1,50 @@
+package importer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checksum", func() {
+	// sha256 of "data"
+	const dataChecksum = "sha256:3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"
+
+	table.DescribeTable("ParseSha256Checksum should", func(checksum string, valid bool) {
+		_, err := ParseSha256Checksum(checksum)
+		Expect(err == nil).To(Equal(valid))
+	},
+		table.Entry("accept a sha256 checksum", dataChecksum, true),
+		table.Entry("accept an upper case digest", "sha256:"+strings.ToUpper(dataChecksum[7:]), true),
+		table.Entry("reject an empty checksum", "", false),
+		table.Entry("reject another algorithm", "md5:8d777f385d3dfec8815d20f7496026dc", false),
+		table.Entry("reject a short digest", "sha256:3a6eb079", false),
+		table.Entry("reject an etag", "etag:\"1234\"", false),
+	)
+
+	It("should verify the data read and the data left", func() {
+		reader, err := newChecksumReader(ioutil.NopCloser(bytes.NewBufferString("data")), dataChecksum)
+		Expect(err).ToNot(HaveOccurred())
+		buf := make([]byte, 2)
+		_, err = reader.Read(buf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reader.verify()).To(Succeed())
+	})
+
+	It("should fail verifying other data", func() {
+		reader, err := newChecksumReader(ioutil.NopCloser(bytes.NewBufferString("other data")), dataChecksum)
+		Expect(err).ToNot(HaveOccurred())
+		err = reader.verify()
+		Expect(err).To(HaveOccurred())
+		Expect(FailureReason(err)).To(Equal(ReasonChecksumMismatch))
+	})
+
+	It("should fail creating a reader with an invalid checksum", func() {
+		_, err := newChecksumReader(ioutil.NopCloser(bytes.NewBufferString("data")), "sha256:1234")
+		Expect(FailureReason(err)).To(Equal(ReasonInvalidConfiguration))
+	})
+})
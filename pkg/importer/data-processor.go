@@ -28,6 +28,7 @@ import (
 
 	"kubevirt.io/containerized-data-importer/pkg/image"
 	"kubevirt.io/containerized-data-importer/pkg/util"
+	progressutil "kubevirt.io/containerized-data-importer/pkg/util/progress"
 )
 
 var qemuOperations = image.NewQEMUOperations()
@@ -44,6 +45,8 @@ const (
 	ProcessingPhaseTransferDataDir ProcessingPhase = "TransferDataDir"
 	// ProcessingPhaseTransferDataFile is the phase in which the data source writes data directly to the target file without conversion.
 	ProcessingPhaseTransferDataFile ProcessingPhase = "TransferDataFile"
+	// ProcessingPhaseStreamConvert is the phase in which the data source converts the data stream directly to the target RAW disk image, used when no scratch space is available.
+	ProcessingPhaseStreamConvert ProcessingPhase = "StreamConvert"
 	// ProcessingPhaseValidatePause is the phase in which the data processor should validate and then pause.
 	ProcessingPhaseValidatePause ProcessingPhase = "ValidatePause"
 	// ProcessingPhaseProcess is the phase in which the data source processes the data just written to the scratch space.
@@ -94,6 +97,24 @@ type DataSourceInterface interface {
 	Close() error
 }
 
+// StreamConvertDataSource is the interface data sources implement if they can convert their data stream without scratch space.
+type StreamConvertDataSource interface {
+	DataSourceInterface
+	// StreamConvert is called to convert the data from the source to a RAW disk image in the file passed in. ErrRequiresScratchSpace
+	// is returned if the data can't be converted without scratch space.
+	StreamConvert(fileName string, availableSpace int64) (ProcessingPhase, error)
+}
+
+// SizedDataSource is the interface data sources implement if they know the size of their data before reading it, it
+// sizes the scratch space
+type SizedDataSource interface {
+	DataSourceInterface
+	// SourceSize returns the size of the data of the source, 0 if unknown
+	SourceSize() int64
+	// SourceCompressed returns true if the data of the source is gz or xz compressed, it expands in scratch space
+	SourceCompressed() bool
+}
+
 //ResumableDataSource is the interface all resumeable data sources should implement
 type ResumableDataSource interface {
 	DataSourceInterface
@@ -116,17 +137,20 @@ type DataProcessor struct {
 	requestImageSize string
 	// available space is the available space before downloading the image
 	availableSpace int64
+	// filesystemOverhead is the fraction of a filesystem volume reserved for filesystem metadata
+	filesystemOverhead float64
 }
 
 // NewDataProcessor create a new instance of a data processor using the passed in data provider.
-func NewDataProcessor(dataSource DataSourceInterface, dataFile, dataDir, scratchDataDir, requestImageSize string) *DataProcessor {
+func NewDataProcessor(dataSource DataSourceInterface, dataFile, dataDir, scratchDataDir, requestImageSize, filesystemOverhead string) *DataProcessor {
 	dp := &DataProcessor{
-		currentPhase:     ProcessingPhaseInfo,
-		source:           dataSource,
-		dataFile:         dataFile,
-		dataDir:          dataDir,
-		scratchDataDir:   scratchDataDir,
-		requestImageSize: requestImageSize,
+		currentPhase:       ProcessingPhaseInfo,
+		source:             dataSource,
+		dataFile:           dataFile,
+		dataDir:            dataDir,
+		scratchDataDir:     scratchDataDir,
+		requestImageSize:   requestImageSize,
+		filesystemOverhead: util.ParseFilesystemOverhead(filesystemOverhead),
 	}
 	// Calculate available space before doing anything.
 	dp.availableSpace = dp.calculateTargetSize()
@@ -168,6 +192,7 @@ func (dp *DataProcessor) ProcessDataResume() error {
 func (dp *DataProcessor) ProcessDataWithPause() error {
 	var err error
 	for dp.currentPhase != ProcessingPhaseComplete && dp.currentPhase != ProcessingPhasePause {
+		progressutil.SetPhase(string(dp.currentPhase))
 		switch dp.currentPhase {
 		case ProcessingPhaseInfo:
 			dp.currentPhase, err = dp.source.Info()
@@ -177,8 +202,13 @@ func (dp *DataProcessor) ProcessDataWithPause() error {
 		case ProcessingPhaseTransferScratch:
 			dp.currentPhase, err = dp.source.Transfer(dp.scratchDataDir)
 			if err == ErrInvalidPath {
-				// Passed in invalid scratch space path, return scratch space needed error.
-				err = ErrRequiresScratchSpace
+				if _, ok := dp.source.(StreamConvertDataSource); ok {
+					// Passed in invalid scratch space path, attempt to convert the stream directly to the target.
+					dp.currentPhase, err = ProcessingPhaseStreamConvert, nil
+				} else {
+					// Passed in invalid scratch space path, return scratch space needed error.
+					err = ErrRequiresScratchSpace
+				}
 			} else if err != nil {
 				err = errors.Wrap(err, "Unable to transfer source data to scratch space")
 			}
@@ -192,6 +222,8 @@ func (dp *DataProcessor) ProcessDataWithPause() error {
 			if err != nil {
 				err = errors.Wrap(err, "Unable to transfer source data to target file")
 			}
+		case ProcessingPhaseStreamConvert:
+			dp.currentPhase, err = dp.streamConvert()
 		case ProcessingPhaseValidatePause:
 			validateErr := dp.validate(dp.source.GetURL())
 			if validateErr != nil {
@@ -250,6 +282,28 @@ func (dp *DataProcessor) convert(url *url.URL) (ProcessingPhase, error) {
 	return ProcessingPhaseResize, nil
 }
 
+// streamConvert is called when there is no scratch space to convert the data stream directly to the target. Images that can't
+// be converted while streaming still require scratch space.
+func (dp *DataProcessor) streamConvert() (ProcessingPhase, error) {
+	scds, ok := dp.source.(StreamConvertDataSource)
+	if !ok {
+		return ProcessingPhaseError, ErrRequiresScratchSpace
+	}
+	klog.V(3).Infoln("Converting stream to Raw")
+	phase, err := scds.StreamConvert(dp.dataFile, dp.availableSpace)
+	if err == nil || err == ErrRequiresScratchSpace {
+		return phase, err
+	}
+	if _, ok := errors.Cause(err).(image.ImageTooLargeError); ok {
+		return ProcessingPhaseError, ValidationSizeError{err: err}
+	}
+	if errors.Cause(err) == image.ErrQcow2NotStreamable {
+		klog.Warningf("Unable to convert stream, scratch space required: %v", err)
+		return ProcessingPhaseError, ErrRequiresScratchSpace
+	}
+	return ProcessingPhaseError, errors.Wrap(err, "Unable to convert source data stream to target format")
+}
+
 func (dp *DataProcessor) resize() (ProcessingPhase, error) {
 	// Resize only if we have a resize request, and if the image is on a file system pvc.
 	size, _ := getAvailableSpaceBlockFunc(dp.dataFile)
@@ -292,6 +346,11 @@ func ResizeImage(dataFile, imageSize string, totalTargetSpace int64) error {
 			klog.V(1).Infof("No need to resize image. Requested size: %s, Image size: %d.\n", imageSize, info.VirtualSize)
 			return nil
 		}
+		if currentImageSizeQuantity.Cmp(minSizeQuantity) > 0 {
+			// a cloned image may exceed the usable space of a target with a larger filesystem overhead, never shrink it
+			klog.Warningf("Image size %d larger than the space it may use %s, not resizing.\n", info.VirtualSize, minSizeQuantity.String())
+			return nil
+		}
 		klog.V(1).Infof("Expanding image size to: %s\n", minSizeQuantity.String())
 		return qemuOperations.Resize(dataFile, minSizeQuantity)
 	}
@@ -316,7 +375,8 @@ func (dp *DataProcessor) calculateTargetSize() int64 {
 		if err != nil {
 			klog.Error(err)
 		}
-		targetQuantity = resource.NewScaledQuantity(size, 0)
+		// leave the filesystem overhead to the filesystem metadata
+		targetQuantity = resource.NewScaledQuantity(util.GetUsableSpace(dp.filesystemOverhead, size), 0)
 	}
 	if dp.requestImageSize != "" {
 		klog.V(1).Infof("Request image size not empty.\n")
@@ -145,6 +145,22 @@ func (madp *MockAsyncDataProvider) GetResumePhase() ProcessingPhase {
 	return madp.ResumePhase
 }
 
+type MockStreamConvertDataProvider struct {
+	MockDataProvider
+	streamConvertErr  error
+	streamConvertFile string
+}
+
+// StreamConvert is called to convert the data from the source to a RAW disk image in the passed in file.
+func (mscdp *MockStreamConvertDataProvider) StreamConvert(fileName string, availableSpace int64) (ProcessingPhase, error) {
+	mscdp.calledPhases = append(mscdp.calledPhases, ProcessingPhaseStreamConvert)
+	mscdp.streamConvertFile = fileName
+	if mscdp.streamConvertErr != nil {
+		return ProcessingPhaseError, mscdp.streamConvertErr
+	}
+	return ProcessingPhaseComplete, nil
+}
+
 var _ = Describe("Data Processor", func() {
 	It("should call the right phases based on the responses from the provider, Transfer should pass the scratch data dir as a path", func() {
 		mdp := &MockDataProvider{
@@ -152,7 +168,7 @@ var _ = Describe("Data Processor", func() {
 			transferResponse: ProcessingPhaseProcess,
 			processResponse:  ProcessingPhaseComplete,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		err := dp.ProcessData()
 		Expect(err).ToNot(HaveOccurred())
 		Expect(3).To(Equal(len(mdp.calledPhases)))
@@ -168,7 +184,7 @@ var _ = Describe("Data Processor", func() {
 			transferResponse: ProcessingPhaseProcess,
 			processResponse:  ProcessingPhaseComplete,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		err := dp.ProcessData()
 		Expect(err).ToNot(HaveOccurred())
 		Expect(3).To(Equal(len(mdp.calledPhases)))
@@ -183,7 +199,7 @@ var _ = Describe("Data Processor", func() {
 			infoResponse:     ProcessingPhaseTransferScratch,
 			transferResponse: ProcessingPhaseError,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		err := dp.ProcessData()
 		Expect(err).To(HaveOccurred())
 		Expect(2).To(Equal(len(mdp.calledPhases)))
@@ -197,7 +213,7 @@ var _ = Describe("Data Processor", func() {
 			transferResponse: ProcessingPhaseError,
 			needsScratch:     true,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		err := dp.ProcessData()
 		Expect(err).To(HaveOccurred())
 		Expect(ErrRequiresScratchSpace).To(Equal(err))
@@ -206,12 +222,58 @@ var _ = Describe("Data Processor", func() {
 		Expect(ProcessingPhaseTransferScratch).To(Equal(mdp.calledPhases[1]))
 	})
 
+	It("should stream convert to the data file if scratch space is required", func() {
+		mdp := &MockStreamConvertDataProvider{
+			MockDataProvider: MockDataProvider{
+				infoResponse:     ProcessingPhaseTransferScratch,
+				transferResponse: ProcessingPhaseError,
+				needsScratch:     true,
+			},
+		}
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
+		err := dp.ProcessData()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(3).To(Equal(len(mdp.calledPhases)))
+		Expect(ProcessingPhaseInfo).To(Equal(mdp.calledPhases[0]))
+		Expect(ProcessingPhaseTransferScratch).To(Equal(mdp.calledPhases[1]))
+		Expect(ProcessingPhaseStreamConvert).To(Equal(mdp.calledPhases[2]))
+		Expect("dest").To(Equal(mdp.streamConvertFile))
+	})
+
+	table.DescribeTable("should map stream convert errors", func(streamConvertErr error, validate func(error)) {
+		mdp := &MockStreamConvertDataProvider{
+			MockDataProvider: MockDataProvider{
+				infoResponse:     ProcessingPhaseTransferScratch,
+				transferResponse: ProcessingPhaseError,
+				needsScratch:     true,
+			},
+			streamConvertErr: streamConvertErr,
+		}
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
+		err := dp.ProcessData()
+		Expect(err).To(HaveOccurred())
+		validate(err)
+	},
+		table.Entry("scratch space required", ErrRequiresScratchSpace, func(err error) {
+			Expect(ErrRequiresScratchSpace).To(Equal(err))
+		}),
+		table.Entry("image not streamable", errors.Wrap(image.ErrQcow2NotStreamable, "image has a backing file"), func(err error) {
+			Expect(ErrRequiresScratchSpace).To(Equal(err))
+		}),
+		table.Entry("image too large", image.ImageTooLargeError{VirtualSize: 2048, AvailableSize: 1024}, func(err error) {
+			Expect(err).To(BeAssignableToTypeOf(ValidationSizeError{}))
+		}),
+		table.Entry("conversion failure", errors.New("write failed"), func(err error) {
+			Expect(err.Error()).To(ContainSubstring("write failed"))
+		}),
+	)
+
 	It("should call the right phases based on the responses from the provider, TransferDataFile should pass the data file", func() {
 		mdp := &MockDataProvider{
 			infoResponse:     ProcessingPhaseTransferDataFile,
 			transferResponse: ProcessingPhaseComplete,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		qemuOperations := NewFakeQEMUOperations(nil, nil, fakeInfoOpRetVal{&fakeZeroImageInfo, errors.New("Scratch space required, and none found ")}, nil, nil, nil)
 		replaceQEMUOperations(qemuOperations, func() {
 			err := dp.ProcessData()
@@ -227,7 +289,7 @@ var _ = Describe("Data Processor", func() {
 			infoResponse:     ProcessingPhaseTransferDataFile,
 			transferResponse: ProcessingPhaseError,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		qemuOperations := NewQEMUAllErrors()
 		replaceQEMUOperations(qemuOperations, func() {
 			err := dp.ProcessData()
@@ -242,7 +304,7 @@ var _ = Describe("Data Processor", func() {
 		mdp := &MockDataProvider{
 			infoResponse: ProcessingPhase("invalidphase"),
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		err := dp.ProcessData()
 		Expect(err).To(HaveOccurred())
 		Expect(1).To(Equal(len(mdp.calledPhases)))
@@ -262,7 +324,7 @@ var _ = Describe("Data Processor", func() {
 			processResponse:  ProcessingPhaseConvert,
 			url:              url,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", tmpDir, "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", tmpDir, "1G", "")
 		dp.availableSpace = int64(1500)
 		qemuOperations := NewFakeQEMUOperations(nil, nil, fakeInfoRet, nil, nil, resource.NewScaledQuantity(int64(1500), 0))
 		replaceQEMUOperations(qemuOperations, func() {
@@ -284,7 +346,7 @@ var _ = Describe("Convert", func() {
 		mdp := &MockDataProvider{
 			url: url,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		qemuOperations := NewFakeQEMUOperations(nil, nil, fakeInfoOpRetVal{&fakeZeroImageInfo, errors.New("Scratch space required, and none found ")}, nil, nil, nil)
 		replaceQEMUOperations(qemuOperations, func() {
 			nextPhase, err := dp.convert(mdp.GetURL())
@@ -299,7 +361,7 @@ var _ = Describe("Convert", func() {
 		mdp := &MockDataProvider{
 			url: url,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		qemuOperations := NewFakeQEMUOperations(nil, nil, fakeInfoOpRetVal{&fakeZeroImageInfo, errors.New("Scratch space required, and none found ")}, errors.New("Validation failure"), nil, nil)
 		replaceQEMUOperations(qemuOperations, func() {
 			nextPhase, err := dp.convert(mdp.GetURL())
@@ -314,7 +376,7 @@ var _ = Describe("Convert", func() {
 		mdp := &MockDataProvider{
 			url: url,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 		qemuOperations := NewFakeQEMUOperations(errors.New("Conversion failure"), nil, fakeInfoOpRetVal{&fakeZeroImageInfo, errors.New("Scratch space required, and none found ")}, nil, nil, nil)
 		replaceQEMUOperations(qemuOperations, func() {
 			nextPhase, err := dp.convert(mdp.GetURL())
@@ -331,7 +393,7 @@ var _ = Describe("Resize", func() {
 		mdp := &MockDataProvider{
 			url: url,
 		}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "", "")
 		nextPhase, err := dp.resize()
 		Expect(err).ToNot(HaveOccurred())
 		Expect(ProcessingPhaseComplete).To(Equal(nextPhase))
@@ -347,7 +409,7 @@ var _ = Describe("Resize", func() {
 			mdp := &MockDataProvider{
 				url: url,
 			}
-			dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G")
+			dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "1G", "")
 			nextPhase, err := dp.resize()
 			Expect(err).ToNot(HaveOccurred())
 			Expect(ProcessingPhaseComplete).To(Equal(nextPhase))
@@ -362,7 +424,7 @@ var _ = Describe("Resize", func() {
 		mdp := &MockDataProvider{
 			url: url,
 		}
-		dp := NewDataProcessor(mdp, "dest", tmpDir, "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", tmpDir, "scratchDataDir", "1G", "")
 		qemuOperations := NewFakeQEMUOperations(nil, nil, fakeInfoOpRetVal{&fakeZeroImageInfo, nil}, nil, nil, nil)
 		replaceQEMUOperations(qemuOperations, func() {
 			nextPhase, err := dp.resize()
@@ -379,7 +441,7 @@ var _ = Describe("Resize", func() {
 		mdp := &MockDataProvider{
 			url: url,
 		}
-		dp := NewDataProcessor(mdp, "dest", tmpDir, "scratchDataDir", "1G")
+		dp := NewDataProcessor(mdp, "dest", tmpDir, "scratchDataDir", "1G", "")
 		qemuOperations := NewQEMUAllErrors()
 		replaceQEMUOperations(qemuOperations, func() {
 			nextPhase, err := dp.resize()
@@ -393,17 +455,33 @@ var _ = Describe("Resize", func() {
 			return int64(100000), nil
 		}, func() {
 			mdp := &MockDataProvider{}
-			dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "")
+			dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "", "")
 			Expect(int64(100000)).To(Equal(dp.calculateTargetSize()))
 		})
 	})
 
+	It("Should leave the filesystem overhead out of the target size of a filesystem volume", func() {
+		replaceAvailableSpaceBlockFunc(func(dataFile string) (int64, error) {
+			return int64(-1), nil
+		}, func() {
+			replaceAvailableSpaceFunc(func(dataDir string) (int64, error) {
+				return int64(1024 * 1024 * 1024), nil
+			}, func() {
+				mdp := &MockDataProvider{}
+				dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "", "0.1")
+				Expect(dp.calculateTargetSize()).To(Equal(int64(966367232)))
+				dp = NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "500Mi", "0.1")
+				Expect(dp.calculateTargetSize()).To(Equal(int64(500 * 1024 * 1024)))
+			})
+		})
+	})
+
 	It("Should fail if calculate size returns failure", func() {
 		replaceAvailableSpaceBlockFunc(func(dataDir string) (int64, error) {
 			return int64(-1), errors.New("error")
 		}, func() {
 			mdp := &MockDataProvider{}
-			dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "")
+			dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "", "")
 			// We just log the error if one happens.
 			Expect(int64(-1)).To(Equal(dp.calculateTargetSize()))
 
@@ -426,6 +504,7 @@ var _ = Describe("ResizeImage", func() {
 		table.Entry("successfully resize to imageSize when imageSize > info.VirtualSize and < totalSize", NewFakeQEMUOperations(nil, nil, fakeInfoRet, nil, nil, resource.NewScaledQuantity(int64(1500), 0)), "1500", int64(2048), false),
 		table.Entry("successfully resize to totalSize when imageSize > info.VirtualSize and > totalSize", NewFakeQEMUOperations(nil, nil, fakeInfoRet, nil, nil, resource.NewScaledQuantity(int64(2048), 0)), "2500", int64(2048), false),
 		table.Entry("successfully do nothing when imageSize = info.VirtualSize and > totalSize", NewFakeQEMUOperations(nil, nil, fakeInfoRet, nil, nil, resource.NewScaledQuantity(int64(1024), 0)), "1024", int64(1024), false),
+		table.Entry("successfully do nothing when info.VirtualSize > totalSize", NewFakeQEMUOperations(nil, errors.New("shrinking"), fakeInfoRet, nil, nil, nil), "2048", int64(512), false),
 		table.Entry("fail to resize to with blank imageSize", NewFakeQEMUOperations(nil, nil, fakeInfoRet, nil, nil, resource.NewScaledQuantity(int64(2048), 0)), "", int64(2048), true),
 		table.Entry("fail to resize to with blank imageSize", NewQEMUAllErrors(), "", int64(2048), true),
 	)
@@ -434,7 +513,7 @@ var _ = Describe("ResizeImage", func() {
 var _ = Describe("DataProcessorResume", func() {
 	It("Should fail with an error if the data provider cannot resume", func() {
 		mdp := &MockDataProvider{}
-		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "")
+		dp := NewDataProcessor(mdp, "dest", "dataDir", "scratchDataDir", "", "")
 		err := dp.ProcessDataResume()
 		Expect(err).To(HaveOccurred())
 	})
@@ -443,7 +522,7 @@ var _ = Describe("DataProcessorResume", func() {
 		amdp := &MockAsyncDataProvider{
 			ResumePhase: ProcessingPhaseComplete,
 		}
-		dp := NewDataProcessor(amdp, "dest", "dataDir", "scratchDataDir", "")
+		dp := NewDataProcessor(amdp, "dest", "dataDir", "scratchDataDir", "", "")
 		err := dp.ProcessDataResume()
 		Expect(err).ToNot(HaveOccurred())
 	})
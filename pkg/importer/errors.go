@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/minio/minio-go"
+)
+
+// The reasons the importer reports in the structured termination message of a failed import. They are stable, so the
+// Running condition of a DataVolume and its events can be alerted on.
+const (
+	// ReasonNotFound indicates the source image doesn't exist
+	ReasonNotFound = "NotFound"
+	// ReasonUnauthorized indicates the credentials are missing or don't grant access to the source image
+	ReasonUnauthorized = "Unauthorized"
+	// ReasonInvalidImage indicates the source isn't a disk image CDI can import
+	ReasonInvalidImage = "InvalidImage"
+	// ReasonInsufficientSpace indicates the target PVC is too small for the image
+	ReasonInsufficientSpace = "InsufficientSpace"
+	// ReasonChecksumMismatch indicates the imported data doesn't match the checksum the importer verifies it against
+	ReasonChecksumMismatch = "ChecksumMismatch"
+	// ReasonScratchRequired indicates the import can't continue without scratch space
+	ReasonScratchRequired = "ScratchRequired"
+	// ReasonTimeout indicates the source stopped sending data or didn't respond in time
+	ReasonTimeout = "Timeout"
+	// ReasonInvalidConfiguration indicates the importer pod was started with a configuration it doesn't support
+	ReasonInvalidConfiguration = "InvalidConfiguration"
+	// ReasonImportFailed indicates an import failure of any other kind
+	ReasonImportFailed = "ImportFailed"
+)
+
+// ImportError is an import failure with a known reason
+type ImportError struct {
+	// Reason is one of the Reason constants
+	Reason string
+	err    error
+}
+
+// NewImportError returns an error with the message of err failing the import for the passed in reason
+func NewImportError(reason string, err error) error {
+	return &ImportError{Reason: reason, err: err}
+}
+
+func (e *ImportError) Error() string { return e.err.Error() }
+
+// Cause returns the underlying error
+func (e *ImportError) Cause() error { return e.err }
+
+// Unwrap returns the underlying error
+func (e *ImportError) Unwrap() error { return e.err }
+
+// Format formats the underlying error, so %+v includes its stack trace
+func (e *ImportError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%+v", e.err)
+		return
+	}
+	io.WriteString(s, e.Error())
+}
+
+// FailureReason returns the reason an import failed with err, the reason of the first ImportError in the chain of
+// errors err wraps or the reason of a well known error in that chain. It returns ReasonImportFailed if the reason is
+// unknown.
+func FailureReason(err error) string {
+	for err != nil {
+		if reason := knownFailureReason(err); reason != "" {
+			return reason
+		}
+		switch e := err.(type) {
+		case interface{ Cause() error }:
+			err = e.Cause()
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			err = nil
+		}
+	}
+	return ReasonImportFailed
+}
+
+func knownFailureReason(err error) string {
+	switch err {
+	case ErrRequiresScratchSpace:
+		return ReasonScratchRequired
+	case context.DeadlineExceeded, context.Canceled:
+		// the transfer is only canceled when the source stopped sending data
+		return ReasonTimeout
+	case syscall.ENOSPC:
+		return ReasonInsufficientSpace
+	}
+	switch e := err.(type) {
+	case *ImportError:
+		return e.Reason
+	case ValidationSizeError:
+		if strings.Contains(e.Error(), "A larger PVC is required") {
+			return ReasonInsufficientSpace
+		}
+		return ReasonInvalidImage
+	case minio.ErrorResponse:
+		return s3FailureReason(e)
+	case net.Error:
+		if e.Timeout() {
+			return ReasonTimeout
+		}
+	}
+	return ""
+}
+
+// httpStatusFailureReason returns the reason of a failed import for an unexpected http status code
+func httpStatusFailureReason(statusCode int) string {
+	switch statusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return ReasonNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ReasonUnauthorized
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ReasonTimeout
+	}
+	return ReasonImportFailed
+}
+
+func s3FailureReason(resp minio.ErrorResponse) string {
+	switch resp.Code {
+	case "NoSuchKey", "NoSuchBucket":
+		return ReasonNotFound
+	case "AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch":
+		return ReasonUnauthorized
+	}
+	return ""
+}
+
+// registryFailureReason returns the reason of a failed registry import from the error output of skopeo
+func registryFailureReason(err error) string {
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "unauthorized"), strings.Contains(message, "authentication required"),
+		strings.Contains(message, "access to the resource is denied"):
+		return ReasonUnauthorized
+	case strings.Contains(message, "manifest unknown"), strings.Contains(message, "name unknown"):
+		return ReasonNotFound
+	case strings.Contains(message, "failed to find vm disk image file"):
+		return ReasonInvalidImage
+	}
+	return ReasonImportFailed
+}
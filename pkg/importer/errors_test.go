@@ -0,0 +1,50 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"syscall"
+
+	"github.com/minio/minio-go"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+var _ = Describe("Failure reason", func() {
+	table.DescribeTable("should return the reason of", func(err error, expected string) {
+		Expect(FailureReason(err)).To(Equal(expected))
+	},
+		table.Entry("an import error", NewImportError(ReasonUnauthorized, errors.New("denied")), ReasonUnauthorized),
+		table.Entry("a wrapped import error", errors.Wrap(NewImportError(ReasonNotFound, errors.New("gone")), "Unable to transfer"), ReasonNotFound),
+		table.Entry("the first import error", NewImportError(ReasonInvalidImage, NewImportError(ReasonTimeout, errors.New("slow"))), ReasonInvalidImage),
+		table.Entry("missing scratch space", ErrRequiresScratchSpace, ReasonScratchRequired),
+		table.Entry("an invalid image", errors.Wrap(ValidationSizeError{err: errors.New("Invalid format iso for image /data/disk.img")}, "Unable to convert"), ReasonInvalidImage),
+		table.Entry("a too small PVC", ValidationSizeError{err: errors.New("Virtual image size 2 is larger than available size 1. A larger PVC is required.")}, ReasonInsufficientSpace),
+		table.Entry("a full disk", errors.Wrap(&os.PathError{Op: "write", Path: "/data/disk.img", Err: syscall.ENOSPC}, "Unable to write"), ReasonInsufficientSpace),
+		table.Entry("a canceled transfer", errors.Wrap(&url.Error{Op: "Get", URL: "http://example.com", Err: context.Canceled}, "HTTP request errored"), ReasonTimeout),
+		table.Entry("a missing s3 object", errors.Wrap(minio.ErrorResponse{Code: "NoSuchKey"}, "could not read"), ReasonNotFound),
+		table.Entry("denied access to s3", minio.ErrorResponse{Code: "AccessDenied"}, ReasonUnauthorized),
+		table.Entry("an unknown s3 error", minio.ErrorResponse{Code: "SlowDown"}, ReasonImportFailed),
+		table.Entry("an unknown error", errors.New("exit status 2"), ReasonImportFailed),
+	)
+
+	table.DescribeTable("should classify the registry error", func(message, expected string) {
+		Expect(registryFailureReason(errors.New(message))).To(Equal(expected))
+	},
+		table.Entry("unauthorized", "Failed to download from registry: unauthorized: authentication required", ReasonUnauthorized),
+		table.Entry("missing image", "Failed to download from registry: manifest unknown: manifest unknown", ReasonNotFound),
+		table.Entry("without a disk image", "Failed to find VM disk image file in the container image", ReasonInvalidImage),
+		table.Entry("unknown", "could not copy image: exit status 1", ReasonImportFailed),
+	)
+
+	It("should keep the message and stack trace of the underlying error", func() {
+		err := NewImportError(ReasonNotFound, errors.New("not found"))
+		Expect(err.Error()).To(Equal("not found"))
+		Expect(errors.Cause(err).Error()).To(Equal("not found"))
+		Expect(fmt.Sprintf("%+v", err)).To(ContainSubstring("errors_test.go"))
+	})
+})
@@ -72,6 +72,7 @@ type FormatReaders struct {
 	buf            []byte // holds file headers
 	Convert        bool
 	Archived       bool
+	Streamable     bool // qcow2 image that can be converted while streaming
 	progressReader *prometheusutil.ProgressReader
 }
 
@@ -169,6 +170,7 @@ func (fr *FormatReaders) fileFormatSelector(hdr *image.Header) {
 	case "qcow2":
 		r, err = fr.qcow2NopReader(hdr)
 		fr.Convert = true
+		fr.Streamable = image.IsStreamableQcow2(fr.buf)
 	case "xz":
 		r, err = fr.xzReader()
 		if err == nil {
@@ -180,6 +182,15 @@ func (fr *FormatReaders) fileFormatSelector(hdr *image.Header) {
 	}
 }
 
+// StreamConvert converts the qcow2 image read through the readers to a RAW disk image in fileName. ErrRequiresScratchSpace
+// is returned if the image can't be converted while streaming.
+func (fr *FormatReaders) StreamConvert(fileName string, availableSpace int64) error {
+	if !fr.Streamable {
+		return ErrRequiresScratchSpace
+	}
+	return image.ConvertQcow2StreamToRaw(fr.TopReader(), fileName, availableSpace)
+}
+
 // Return the gz reader and the size of the endpoint "through the eye" of the previous reader.
 // Assumes a single file was gzipped.
 //NOTE: size in gz is stored in the last 4 bytes of the file. This probably requires the file
@@ -45,12 +45,14 @@ const (
 
 // HTTPDataSource is the data provider for http(s) endpoints.
 // Sequence of phases:
-// 1a. Info -> Convert (In Info phase the format readers are configured), if the source Reader image is not archived, and no custom CA is used, and can be converted by QEMU-IMG (RAW/QCOW2)
+// 1a. Info -> Convert (In Info phase the format readers are configured), if the source Reader image is not archived, and no custom CA is used, and no checksum is verified, and can be converted by QEMU-IMG (RAW/QCOW2)
 // 1b. Info -> TransferArchive if the content type is archive
 // 1c. Info -> Transfer in all other cases.
 // 2a. Transfer -> Process if content type is kube virt
 // 2b. Transfer -> Complete if content type is archive (Transfer is called with the target instead of the scratch space). Non block PVCs only.
-// 3. Process -> Convert
+// 2c. Transfer -> StreamConvert if content type is kube virt and there is no scratch space.
+// 3a. Process -> Convert
+// 3b. StreamConvert -> Resize
 type HTTPDataSource struct {
 	httpReader io.ReadCloser
 	ctx        context.Context
@@ -70,10 +72,13 @@ type HTTPDataSource struct {
 	brokenForQemuImg bool
 	// the content length reported by the http server.
 	contentLength uint64
+	// verifies the checksum of the data read from the endpoint, nil if there is no checksum to verify.
+	checksum *checksumReader
 }
 
-// NewHTTPDataSource creates a new instance of the http data provider.
-func NewHTTPDataSource(endpoint, accessKey, secKey, certDir string, contentType cdiv1.DataVolumeContentType) (*HTTPDataSource, error) {
+// NewHTTPDataSource creates a new instance of the http data provider. If checksum isn't empty the data read from the
+// endpoint is verified against it, the checksum must be of the form sha256:<digest>.
+func NewHTTPDataSource(endpoint, accessKey, secKey, certDir string, contentType cdiv1.DataVolumeContentType, checksum string) (*HTTPDataSource, error) {
 	ep, err := ParseEndpoint(endpoint)
 	if err != nil {
 		return nil, errors.Wrapf(err, fmt.Sprintf("unable to parse endpoint %q", endpoint))
@@ -98,6 +103,14 @@ func NewHTTPDataSource(endpoint, accessKey, secKey, certDir string, contentType
 		brokenForQemuImg: brokenForQemuImg,
 		contentLength:    contentLength,
 	}
+	if checksum != "" {
+		httpSource.checksum, err = newChecksumReader(httpReader, checksum)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		httpSource.httpReader = httpSource.checksum
+	}
 	// We know this is a counting reader, so no need to check.
 	countingReader := httpReader.(*util.CountingReader)
 	go httpSource.pollProgress(countingReader, 10*time.Minute, time.Second)
@@ -117,7 +130,7 @@ func (hs *HTTPDataSource) Info() (ProcessingPhase, error) {
 	}
 	// The readers now contain all the information needed to determine if we can stream directly or if we need scratch space to download
 	// the file to, before converting.
-	if !hs.readers.Archived && !hs.customCA && !hs.brokenForQemuImg && hs.readers.Convert {
+	if !hs.readers.Archived && !hs.customCA && !hs.brokenForQemuImg && hs.checksum == nil && hs.readers.Convert {
 		// We can pass straight to conversion from the endpoint. No scratch required.
 		hs.url = hs.endpoint
 		return ProcessingPhaseConvert, nil
@@ -141,6 +154,9 @@ func (hs *HTTPDataSource) Transfer(path string) (ProcessingPhase, error) {
 		if err != nil {
 			return ProcessingPhaseError, err
 		}
+		if err := hs.verifyChecksum(); err != nil {
+			return ProcessingPhaseError, err
+		}
 		// If we successfully wrote to the file, then the parse will succeed.
 		hs.url, _ = url.Parse(file)
 		return ProcessingPhaseProcess, nil
@@ -148,6 +164,9 @@ func (hs *HTTPDataSource) Transfer(path string) (ProcessingPhase, error) {
 		if err := util.UnArchiveTar(hs.readers.TopReader(), path); err != nil {
 			return ProcessingPhaseError, errors.Wrap(err, "unable to untar files from endpoint")
 		}
+		if err := hs.verifyChecksum(); err != nil {
+			return ProcessingPhaseError, err
+		}
 		hs.url = nil
 		return ProcessingPhaseComplete, nil
 	}
@@ -161,9 +180,43 @@ func (hs *HTTPDataSource) TransferFile(fileName string) (ProcessingPhase, error)
 	if err != nil {
 		return ProcessingPhaseError, err
 	}
+	if err := hs.verifyChecksum(); err != nil {
+		return ProcessingPhaseError, err
+	}
+	return ProcessingPhaseResize, nil
+}
+
+// StreamConvert is called to convert the data from the source to a RAW disk image in the passed in file.
+func (hs *HTTPDataSource) StreamConvert(fileName string, availableSpace int64) (ProcessingPhase, error) {
+	hs.readers.StartProgressUpdate()
+	err := hs.readers.StreamConvert(fileName, availableSpace)
+	if err != nil {
+		return ProcessingPhaseError, err
+	}
+	if err := hs.verifyChecksum(); err != nil {
+		return ProcessingPhaseError, err
+	}
 	return ProcessingPhaseResize, nil
 }
 
+// verifyChecksum verifies the checksum of the data read from the endpoint, if there is one
+func (hs *HTTPDataSource) verifyChecksum() error {
+	if hs.checksum == nil {
+		return nil
+	}
+	return hs.checksum.verify()
+}
+
+// SourceSize returns the content length reported by the http server, 0 if unknown
+func (hs *HTTPDataSource) SourceSize() int64 {
+	return int64(hs.contentLength)
+}
+
+// SourceCompressed returns true if the content is gz or xz compressed
+func (hs *HTTPDataSource) SourceCompressed() bool {
+	return hs.readers != nil && hs.readers.Archived
+}
+
 // Process is called to do any special processing before giving the URI to the data back to the processor
 func (hs *HTTPDataSource) Process() (ProcessingPhase, error) {
 	return ProcessingPhaseConvert, nil
@@ -269,7 +322,8 @@ func createHTTPReader(ctx context.Context, ep *url.URL, accessKey, secKey, certD
 	}
 	if resp.StatusCode != 200 {
 		klog.Errorf("http: expected status code 200, got %d", resp.StatusCode)
-		return nil, uint64(0), true, errors.Errorf("expected status code 200, got %d. Status: %s", resp.StatusCode, resp.Status)
+		err = errors.Errorf("expected status code 200, got %d. Status: %s", resp.StatusCode, resp.Status)
+		return nil, uint64(0), true, NewImportError(httpStatusFailureReason(resp.StatusCode), err)
 	}
 
 	acceptRanges, ok := resp.Header["Accept-Ranges"]
@@ -333,7 +387,8 @@ func getContentLength(client *http.Client, ep *url.URL, accessKey, secKey string
 
 	if resp.StatusCode != 200 {
 		klog.Errorf("http: expected status code 200, got %d", resp.StatusCode)
-		return uint64(0), errors.Errorf("expected status code 200, got %d. Status: %s", resp.StatusCode, resp.Status)
+		err = errors.Errorf("expected status code 200, got %d. Status: %s", resp.StatusCode, resp.Status)
+		return uint64(0), NewImportError(httpStatusFailureReason(resp.StatusCode), err)
 	}
 
 	for k, v := range resp.Header {
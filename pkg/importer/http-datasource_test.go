@@ -2,7 +2,9 @@ package importer
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/x509"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -70,14 +72,14 @@ var _ = Describe("Http data source", func() {
 	})
 
 	It("NewHTTPDataSource should fail when called with an invalid endpoint", func() {
-		_, err = NewHTTPDataSource("httpd://!@#$%^&*()dgsdd&3r53/invalid", "", "", "", cdiv1.DataVolumeKubeVirt)
+		_, err = NewHTTPDataSource("httpd://!@#$%^&*()dgsdd&3r53/invalid", "", "", "", cdiv1.DataVolumeKubeVirt, "")
 		Expect(err).To(HaveOccurred())
 		Expect(strings.Contains(err.Error(), "unable to parse endpoint")).To(BeTrue())
 	})
 
 	It("endpoint User object should be set when accessKey and secKey are not blank", func() {
 		image := ts.URL + "/" + cirrosFileName
-		dp, err = NewHTTPDataSource(image, "user", "password", "", cdiv1.DataVolumeKubeVirt)
+		dp, err = NewHTTPDataSource(image, "user", "password", "", cdiv1.DataVolumeKubeVirt, "")
 		Expect(err).NotTo(HaveOccurred())
 		user := dp.endpoint.User
 		Expect("user").To(Equal(user.Username()))
@@ -88,7 +90,7 @@ var _ = Describe("Http data source", func() {
 
 	It("NewHTTPDataSource should fail when called with an invalid certdir", func() {
 		image := ts.URL + "/" + cirrosFileName
-		_, err = NewHTTPDataSource(image, "", "", "/invaliddir", cdiv1.DataVolumeKubeVirt)
+		_, err = NewHTTPDataSource(image, "", "", "/invaliddir", cdiv1.DataVolumeKubeVirt, "")
 		Expect(err).To(HaveOccurred())
 	})
 
@@ -97,7 +99,7 @@ var _ = Describe("Http data source", func() {
 		if image != "" {
 			image = ts.URL + "/" + image
 		}
-		dp, err = NewHTTPDataSource(image, "", "", "", contentType)
+		dp, err = NewHTTPDataSource(image, "", "", "", contentType, "")
 		Expect(err).NotTo(HaveOccurred())
 		newPhase, err := dp.Info()
 		if !wantErr {
@@ -118,7 +120,7 @@ var _ = Describe("Http data source", func() {
 	)
 
 	It("calling info with raw image should return TransferDataFile", func() {
-		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreGz, "", "", "", cdiv1.DataVolumeKubeVirt)
+		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreGz, "", "", "", cdiv1.DataVolumeKubeVirt, "")
 		Expect(err).NotTo(HaveOccurred())
 		newPhase, err := dp.Info()
 		Expect(err).NotTo(HaveOccurred())
@@ -133,7 +135,7 @@ var _ = Describe("Http data source", func() {
 		if image != "" {
 			image = ts.URL + "/" + image
 		}
-		dp, err = NewHTTPDataSource(image, "", "", "", contentType)
+		dp, err = NewHTTPDataSource(image, "", "", "", contentType, "")
 		Expect(err).NotTo(HaveOccurred())
 		_, err := dp.Info()
 		Expect(err).NotTo(HaveOccurred())
@@ -164,7 +166,7 @@ var _ = Describe("Http data source", func() {
 	)
 
 	It("TransferFile should succeed when writing to valid file, and reading raw gz", func() {
-		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreGz, "", "", "", cdiv1.DataVolumeKubeVirt)
+		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreGz, "", "", "", cdiv1.DataVolumeKubeVirt, "")
 		Expect(err).NotTo(HaveOccurred())
 		result, err := dp.Info()
 		Expect(err).NotTo(HaveOccurred())
@@ -175,7 +177,7 @@ var _ = Describe("Http data source", func() {
 	})
 
 	It("TransferFile should succeed when writing to valid file and reading raw xz", func() {
-		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreXz, "", "", "", cdiv1.DataVolumeKubeVirt)
+		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreXz, "", "", "", cdiv1.DataVolumeKubeVirt, "")
 		Expect(err).NotTo(HaveOccurred())
 		result, err := dp.Info()
 		Expect(err).NotTo(HaveOccurred())
@@ -186,7 +188,7 @@ var _ = Describe("Http data source", func() {
 	})
 
 	It("TransferFile should fail on streaming error", func() {
-		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreGz, "", "", "", cdiv1.DataVolumeKubeVirt)
+		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreGz, "", "", "", cdiv1.DataVolumeKubeVirt, "")
 		Expect(err).NotTo(HaveOccurred())
 		result, err := dp.Info()
 		Expect(err).NotTo(HaveOccurred())
@@ -196,9 +198,46 @@ var _ = Describe("Http data source", func() {
 		Expect(ProcessingPhaseError).To(Equal(result))
 	})
 
+	It("TransferFile should succeed when the checksum of the source matches", func() {
+		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreGz, "", "", "", cdiv1.DataVolumeKubeVirt, fileChecksum(filepath.Join(imageDir, tinyCoreGz)))
+		Expect(err).NotTo(HaveOccurred())
+		result, err := dp.Info()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ProcessingPhaseTransferDataFile).To(Equal(result))
+		result, err = dp.TransferFile(filepath.Join(tmpDir, "file"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ProcessingPhaseResize).To(Equal(result))
+	})
+
+	It("TransferFile should fail when the checksum of the source doesn't match", func() {
+		dp, err = NewHTTPDataSource(ts.URL+"/"+tinyCoreGz, "", "", "", cdiv1.DataVolumeKubeVirt, fileChecksum(filepath.Join(imageDir, tinyCoreXz)))
+		Expect(err).NotTo(HaveOccurred())
+		_, err := dp.Info()
+		Expect(err).NotTo(HaveOccurred())
+		result, err := dp.TransferFile(filepath.Join(tmpDir, "file"))
+		Expect(err).To(HaveOccurred())
+		Expect(FailureReason(err)).To(Equal(ReasonChecksumMismatch))
+		Expect(ProcessingPhaseError).To(Equal(result))
+	})
+
+	It("Info should not convert directly from the endpoint when verifying a checksum", func() {
+		flushRead = cirrosData
+		dp, err = NewHTTPDataSource(ts.URL+"/"+cirrosFileName, "", "", "", cdiv1.DataVolumeKubeVirt, fileChecksum(filepath.Join(imageDir, cirrosFileName)))
+		Expect(err).NotTo(HaveOccurred())
+		result, err := dp.Info()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ProcessingPhaseTransferScratch).To(Equal(result))
+	})
+
+	It("should fail with an unsupported checksum", func() {
+		_, err = NewHTTPDataSource(ts.URL+"/"+cirrosFileName, "", "", "", cdiv1.DataVolumeKubeVirt, "md5:1234")
+		Expect(err).To(HaveOccurred())
+		Expect(FailureReason(err)).To(Equal(ReasonInvalidConfiguration))
+	})
+
 	It("calling Process should return Convert", func() {
 		flushRead = cirrosData
-		dp, err = NewHTTPDataSource(ts.URL+"/"+cirrosFileName, "", "", "", cdiv1.DataVolumeKubeVirt)
+		dp, err = NewHTTPDataSource(ts.URL+"/"+cirrosFileName, "", "", "", cdiv1.DataVolumeKubeVirt, "")
 		Expect(err).NotTo(HaveOccurred())
 		_, err := dp.Info()
 		Expect(err).NotTo(HaveOccurred())
@@ -399,6 +438,19 @@ var _ = Describe("Http reader", func() {
 		Expect(uint64(0)).To(Equal(total))
 		Expect("expected status code 200, got 500. Status: 500 Internal Server Error").To(Equal(err.Error()))
 	})
+
+	It("should report a missing object as not found", func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(404)
+		}))
+		defer ts.Close()
+		ep, err := url.Parse(ts.URL)
+		Expect(err).ToNot(HaveOccurred())
+		_, _, _, err = createHTTPReader(context.Background(), ep, "", "", "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("expected status code 200, got 404. Status: 404 Not Found"))
+		Expect(FailureReason(err)).To(Equal(ReasonNotFound))
+	})
 })
 
 var _ = Describe("http pollprogress", func() {
@@ -437,6 +489,12 @@ func createTestServer(imageDir string) *httptest.Server {
 }
 
 // Read the contents of the file into a byte array, don't use this on really huge files.
+func fileChecksum(fileName string) string {
+	data, err := readFile(fileName)
+	Expect(err).NotTo(HaveOccurred())
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
 func readFile(fileName string) ([]byte, error) {
 	f, err := os.Open(fileName)
 	if err != nil {
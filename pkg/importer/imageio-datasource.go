@@ -124,6 +124,16 @@ func (is *ImageioDataSource) Process() (ProcessingPhase, error) {
 	return ProcessingPhaseConvert, nil
 }
 
+// SourceSize returns the content length of the disk reported by imageio, 0 if unknown
+func (is *ImageioDataSource) SourceSize() int64 {
+	return int64(is.contentLength)
+}
+
+// SourceCompressed returns true if the disk is gz or xz compressed
+func (is *ImageioDataSource) SourceCompressed() bool {
+	return is.readers != nil && is.readers.Archived
+}
+
 // GetURL returns the URI that the data processor can use when converting the data.
 func (is *ImageioDataSource) GetURL() *url.URL {
 	return is.url
@@ -233,7 +243,7 @@ func getTransfer(conn ConnectionInterface, diskID string) (*ovirtsdk4.ImageTrans
 	}
 	disk, success := diskResponse.Disk()
 	if !success {
-		return nil, uint64(0), errors.New("Error disk not found")
+		return nil, uint64(0), NewImportError(ReasonNotFound, errors.New("Error disk not found"))
 	}
 
 	totalSize, available := disk.TotalSize()
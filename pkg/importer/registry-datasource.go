@@ -84,7 +84,7 @@ func (rd *RegistryDataSource) Transfer(path string) (ProcessingPhase, error) {
 	klog.V(1).Infof("Copying registry image to scratch space.")
 	err = image.CopyRegistryImage(rd.endpoint, path, containerDiskImageDir, rd.accessKey, rd.secKey, rd.certDir, rd.insecureTLS)
 	if err != nil {
-		return ProcessingPhaseError, errors.Wrapf(err, "Failed to read registry image")
+		return ProcessingPhaseError, NewImportError(registryFailureReason(err), errors.Wrapf(err, "Failed to read registry image"))
 	}
 
 	return ProcessingPhaseProcess, nil
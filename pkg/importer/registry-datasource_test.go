@@ -212,3 +212,15 @@ func (o *fakeSkopeoOperations) CopyImage(url, dest, accessKey, secKey, certDir s
 	Expect(o.insecureRegistry).To(Equal(insecureRegistry))
 	return nil
 }
+
+func (o *fakeSkopeoOperations) ImageDigest(url, accessKey, secKey, certDir string, insecureRegistry bool) (string, error) {
+	if o.e1 != nil {
+		return "", o.e1
+	}
+	Expect(o.ep).To(Equal(url))
+	Expect(o.accKey).To(Equal(accessKey))
+	Expect(o.secKey).To(Equal(secKey))
+	Expect(o.certDir).To(Equal(certDir))
+	Expect(o.insecureRegistry).To(Equal(insecureRegistry))
+	return testDigest, nil
+}
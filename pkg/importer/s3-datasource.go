@@ -112,6 +112,25 @@ func (sd *S3DataSource) Process() (ProcessingPhase, error) {
 	return ProcessingPhaseConvert, nil
 }
 
+// SourceSize returns the size of the S3 object, 0 if unknown
+func (sd *S3DataSource) SourceSize() int64 {
+	object, ok := sd.s3Reader.(*minio.Object)
+	if !ok {
+		return 0
+	}
+	info, err := object.Stat()
+	if err != nil {
+		klog.Warningf("Unable to get the size of the s3 object: %v", err)
+		return 0
+	}
+	return info.Size
+}
+
+// SourceCompressed returns true if the object is gz or xz compressed
+func (sd *S3DataSource) SourceCompressed() bool {
+	return sd.readers != nil && sd.readers.Archived
+}
+
 // GetURL returns the url that the data processor can use when converting the data.
 func (sd *S3DataSource) GetURL() *url.URL {
 	return sd.url
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+
+	"kubevirt.io/containerized-data-importer/pkg/image"
+)
+
+const sourceDigestTimeout = 30 * time.Second
+
+// HTTPSourceDigest returns the digest of an http source, its ETag or Last-Modified header. It returns an empty digest
+// if the server sends neither.
+func HTTPSourceDigest(endpoint, accessKey, secKey, certDir string) (string, error) {
+	client, err := createHTTPClient(certDir)
+	if err != nil {
+		return "", errors.Wrap(err, "Error creating http client")
+	}
+	client.Timeout = sourceDigestTimeout
+	client.CheckRedirect = func(r *http.Request, via []*http.Request) error {
+		if len(accessKey) > 0 && len(secKey) > 0 {
+			r.SetBasicAuth(accessKey, secKey) // Redirects will lose basic auth, so reset them manually
+		}
+		return nil
+	}
+
+	resp, err := sourceDigestRequest(client, http.MethodHead, endpoint, accessKey, secKey)
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		// only the headers are read, the body isn't downloaded
+		resp, err = sourceDigestRequest(client, http.MethodGet, endpoint, accessKey, secKey)
+	}
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = errors.Errorf("expected status code 200, got %d. Status: %s", resp.StatusCode, resp.Status)
+		return "", NewImportError(httpStatusFailureReason(resp.StatusCode), err)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return "etag:" + strings.Trim(strings.TrimPrefix(etag, "W/"), `"`), nil
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		return "last-modified:" + lastModified, nil
+	}
+	return "", nil
+}
+
+func sourceDigestRequest(client *http.Client, method, endpoint, accessKey, secKey string) (*http.Response, error) {
+	req, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create HTTP request")
+	}
+	if len(accessKey) > 0 && len(secKey) > 0 {
+		req.SetBasicAuth(accessKey, secKey)
+	}
+	klog.V(2).Infof("Attempting to %s %q via http client\n", method, endpoint)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "HTTP request errored")
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// RegistrySourceDigest returns the digest of the manifest of a registry source, the digest of the manifest list of
+// multi arch images.
+func RegistrySourceDigest(endpoint, accessKey, secKey, certDir string, insecureTLS bool) (string, error) {
+	digest, err := image.SkopeoInterface.ImageDigest(endpoint, accessKey, secKey, certDir, insecureTLS)
+	if err != nil {
+		return "", NewImportError(registryFailureReason(err), errors.Wrap(err, "Failed to inspect registry image"))
+	}
+	return digest, nil
+}
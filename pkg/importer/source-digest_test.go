@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+const testDigest = "sha256:abcd"
+
+var _ = Describe("Source digest", func() {
+	table.DescribeTable("Should get the digest of an http source from", func(headers map[string]string, expected string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodHead))
+			for key, value := range headers {
+				w.Header().Set(key, value)
+			}
+		}))
+		defer server.Close()
+		Expect(HTTPSourceDigest(server.URL+"/disk.img", "", "", "")).To(Equal(expected))
+	},
+		table.Entry("the ETag", map[string]string{"ETag": `"1234"`, "Last-Modified": "Wed, 15 Jul 2020 10:00:00 GMT"}, "etag:1234"),
+		table.Entry("a weak ETag", map[string]string{"ETag": `W/"1234"`}, "etag:1234"),
+		table.Entry("the Last-Modified header", map[string]string{"Last-Modified": "Wed, 15 Jul 2020 10:00:00 GMT"}, "last-modified:Wed, 15 Jul 2020 10:00:00 GMT"),
+		table.Entry("nothing", map[string]string{}, ""),
+	)
+
+	It("Should send the credentials and fall back to GET if HEAD is not allowed", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(user).To(Equal("user"))
+			Expect(password).To(Equal("password"))
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("ETag", `"1234"`)
+		}))
+		defer server.Close()
+		Expect(HTTPSourceDigest(server.URL+"/disk.img", "user", "password", "")).To(Equal("etag:1234"))
+	})
+
+	It("Should fail if the http source is not found", func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		_, err := HTTPSourceDigest(server.URL+"/disk.img", "", "", "")
+		Expect(err).To(HaveOccurred())
+		Expect(FailureReason(err)).To(Equal(ReasonNotFound))
+	})
+
+	It("Should get the digest of a registry source", func() {
+		ep := "docker://quay.io/kubevirt/fedora:latest"
+		replaceSkopeoOperations(NewFakeSkopeoOperations(ep, "user", "password", "/certs", true, nil), func() {
+			Expect(RegistrySourceDigest(ep, "user", "password", "/certs", true)).To(Equal(testDigest))
+		})
+	})
+
+	It("Should report why inspecting a registry source failed", func() {
+		replaceSkopeoOperations(NewFakeSkopeoOperations("", "", "", "", false, errors.New("manifest unknown")), func() {
+			_, err := RegistrySourceDigest("docker://quay.io/kubevirt/fedora:latest", "", "", "", false)
+			Expect(err).To(HaveOccurred())
+			Expect(FailureReason(err)).To(Equal(ReasonNotFound))
+		})
+	})
+})
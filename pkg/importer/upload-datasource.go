@@ -16,7 +16,9 @@ import (
 // 1b. ProcessingPhaseInfo -> ProcessingPhaseTransferDataFile, in the case the readers contain a raw file.
 // 2a. ProcessingPhaseTransferScratch -> ProcessingPhaseProcess
 // 2b. ProcessingPhaseTransferDataFile -> ProcessingPhaseResize
-// 3. ProcessingPhaseProcess -> ProcessingPhaseConvert
+// 2c. ProcessingPhaseTransferScratch -> ProcessingPhaseStreamConvert, in the case there is no scratch space.
+// 3a. ProcessingPhaseProcess -> ProcessingPhaseConvert
+// 3b. ProcessingPhaseStreamConvert -> ProcessingPhaseResize
 type UploadDataSource struct {
 	// Data strean
 	stream io.ReadCloser
@@ -51,16 +53,13 @@ func (ud *UploadDataSource) Info() (ProcessingPhase, error) {
 
 // Transfer is called to transfer the data from the source to the passed in path.
 func (ud *UploadDataSource) Transfer(path string) (ProcessingPhase, error) {
-	size, err := util.GetAvailableSpace(path)
-	if err != nil {
-		return ProcessingPhaseError, err
-	}
+	size, _ := util.GetAvailableSpace(path)
 	if size <= int64(0) {
 		//Path provided is invalid.
 		return ProcessingPhaseError, ErrInvalidPath
 	}
 	file := filepath.Join(path, tempFile)
-	err = util.StreamDataToFile(ud.readers.TopReader(), file)
+	err := util.StreamDataToFile(ud.readers.TopReader(), file)
 	if err != nil {
 		return ProcessingPhaseError, err
 	}
@@ -80,6 +79,17 @@ func (ud *UploadDataSource) TransferFile(fileName string) (ProcessingPhase, erro
 	return ProcessingPhaseResize, nil
 }
 
+// StreamConvert is called to convert the data from the source to a RAW disk image in the passed in file.
+func (ud *UploadDataSource) StreamConvert(fileName string, availableSpace int64) (ProcessingPhase, error) {
+	err := ud.readers.StreamConvert(fileName, availableSpace)
+	if err != nil {
+		return ProcessingPhaseError, err
+	}
+	// If we successfully wrote to the file, then the parse will succeed.
+	ud.url, _ = url.Parse(fileName)
+	return ProcessingPhaseResize, nil
+}
+
 // Process is called to do any special processing before giving the url to the data back to the processor
 func (ud *UploadDataSource) Process() (ProcessingPhase, error) {
 	return ProcessingPhaseConvert, nil
@@ -123,16 +133,13 @@ func (aud *AsyncUploadDataSource) Info() (ProcessingPhase, error) {
 
 // Transfer is called to transfer the data from the source to the passed in path.
 func (aud *AsyncUploadDataSource) Transfer(path string) (ProcessingPhase, error) {
-	size, err := util.GetAvailableSpace(path)
-	if err != nil {
-		return ProcessingPhaseError, err
-	}
+	size, _ := util.GetAvailableSpace(path)
 	if size <= int64(0) {
 		//Path provided is invalid.
 		return ProcessingPhaseError, ErrInvalidPath
 	}
 	file := filepath.Join(path, tempFile)
-	err = util.StreamDataToFile(aud.uploadDataSource.readers.TopReader(), file)
+	err := util.StreamDataToFile(aud.uploadDataSource.readers.TopReader(), file)
 	if err != nil {
 		return ProcessingPhaseError, err
 	}
@@ -154,6 +161,18 @@ func (aud *AsyncUploadDataSource) TransferFile(fileName string) (ProcessingPhase
 	return ProcessingPhaseValidatePause, nil
 }
 
+// StreamConvert is called to convert the data from the source to a RAW disk image in the passed in file.
+func (aud *AsyncUploadDataSource) StreamConvert(fileName string, availableSpace int64) (ProcessingPhase, error) {
+	err := aud.uploadDataSource.readers.StreamConvert(fileName, availableSpace)
+	if err != nil {
+		return ProcessingPhaseError, err
+	}
+	// If we successfully wrote to the file, then the parse will succeed.
+	aud.uploadDataSource.url, _ = url.Parse(fileName)
+	aud.ResumePhase = ProcessingPhaseResize
+	return ProcessingPhaseValidatePause, nil
+}
+
 // Process is called to do any special processing before giving the url to the data back to the processor
 func (aud *AsyncUploadDataSource) Process() (ProcessingPhase, error) {
 	return ProcessingPhaseConvert, nil
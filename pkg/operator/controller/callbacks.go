@@ -87,6 +87,7 @@ func addReconcileCallbacks(r *ReconcileCDI) {
 	r.addCallback(&corev1.ServiceAccount{}, reconcileServiceAccounts)
 	r.addCallback(&corev1.ServiceAccount{}, reconcileCreateSCC)
 	r.addCallback(&appsv1.Deployment{}, reconcileCreateRoute)
+	r.addCallback(&appsv1.Deployment{}, reconcileCreatePrometheusResources)
 	r.addCallback(&appsv1.Deployment{}, reconcileDeleteSecrets)
 	r.addCallback(&extv1.CustomResourceDefinition{}, reconcileInitializeCRD)
 }
@@ -151,6 +152,23 @@ func reconcileCreateRoute(args *ReconcileCallbackArgs) error {
 	return nil
 }
 
+func reconcileCreatePrometheusResources(args *ReconcileCallbackArgs) error {
+	if args.State != ReconcileStatePostRead {
+		return nil
+	}
+
+	deployment := args.CurrentObject.(*appsv1.Deployment)
+	if !isControllerDeployment(deployment) || !checkDeploymentReady(deployment) {
+		return nil
+	}
+
+	if err := ensurePrometheusResourcesExist(args.Logger, args.Client, args.Scheme, deployment); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func reconcileCreateSCC(args *ReconcileCallbackArgs) error {
 	switch args.State {
 	case ReconcileStatePreCreate, ReconcileStatePostRead:
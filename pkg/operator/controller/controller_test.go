@@ -262,6 +262,34 @@ var _ = Describe("Controller", func() {
 					}
 					Expect(found).To(BeTrue())
 				}
+				Expect(scc.SeccompProfiles).To(ContainElement(corev1.SeccompProfileRuntimeDefault))
+				Expect(scc.AllowedCapabilities).To(ContainElement(corev1.Capability("DAC_READ_SEARCH")))
+			})
+
+			It("should keep the worker settings an admin removed from the securitycontextconstraint", func() {
+				args := createArgs()
+				doReconcile(args)
+				Expect(setDeploymentsReady(args)).To(BeTrue())
+
+				scc := &secv1.SecurityContextConstraints{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "containerized-data-importer",
+					},
+				}
+
+				scc, err := getSCC(args.client, scc)
+				Expect(err).ToNot(HaveOccurred())
+				scc.SeccompProfiles = nil
+				scc.AllowedCapabilities = nil
+				err = args.client.Update(context.TODO(), scc)
+				Expect(err).ToNot(HaveOccurred())
+
+				doReconcile(args)
+
+				scc, err = getSCC(args.client, scc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(scc.SeccompProfiles).To(BeEmpty())
+				Expect(scc.AllowedCapabilities).To(BeEmpty())
 			})
 
 			It("should create all resources", func() {
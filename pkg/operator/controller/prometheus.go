@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+const (
+	prometheusRuleName = "prometheus-cdi-rules"
+	serviceMonitorName = "service-monitor-cdi"
+)
+
+var (
+	prometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+	serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+)
+
+// ensurePrometheusResourcesExist creates the ServiceMonitor scraping the CDI metrics and the PrometheusRule alerting on
+// them, if the prometheus operator is installed
+func ensurePrometheusResourcesExist(logger logr.Logger, c client.Client, scheme *runtime.Scheme, owner metav1.Object) error {
+	namespace := owner.GetNamespace()
+	if namespace == "" {
+		return fmt.Errorf("cluster scoped owner not supported")
+	}
+
+	for _, desired := range []*unstructured.Unstructured{newPrometheusRule(namespace), newServiceMonitor(namespace)} {
+		if err := ensureMonitoringResourceExists(logger, c, scheme, owner, desired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ensureMonitoringResourceExists(logger logr.Logger, c client.Client, scheme *runtime.Scheme, owner metav1.Object, desired *unstructured.Unstructured) error {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(desired.GroupVersionKind())
+	key := client.ObjectKey{Namespace: desired.GetNamespace(), Name: desired.GetName()}
+	err := c.Get(context.TODO(), key, current)
+	if err == nil {
+		if !reflect.DeepEqual(current.Object["spec"], desired.Object["spec"]) {
+			current.Object["spec"] = desired.Object["spec"]
+			return c.Update(context.TODO(), current)
+		}
+
+		return nil
+	}
+
+	if meta.IsNoMatchError(err) {
+		logger.V(3).Info("No match error, prometheus operator must not be installed", "kind", desired.GetKind())
+		return nil
+	}
+
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err = controllerutil.SetControllerReference(owner, desired, scheme); err != nil {
+		return err
+	}
+
+	return c.Create(context.TODO(), desired)
+}
+
+func newMonitoringResource(gvk schema.GroupVersionKind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(map[string]string{
+		"cdi.kubevirt.io": "",
+	})
+	return obj
+}
+
+func newServiceMonitor(namespace string) *unstructured.Unstructured {
+	return newMonitoringResource(serviceMonitorGVK, namespace, serviceMonitorName, map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				common.PrometheusLabel: "",
+			},
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{namespace},
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"port":   "metrics",
+				"scheme": "https",
+				// the pods serve their metrics with self signed certificates
+				"tlsConfig": map[string]interface{}{
+					"insecureSkipVerify": true,
+				},
+			},
+		},
+	})
+}
+
+func newPrometheusRule(namespace string) *unstructured.Unstructured {
+	return newMonitoringResource(prometheusRuleGVK, namespace, prometheusRuleName, map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name": "cdi.rules",
+				"rules": []interface{}{
+					newAlertRule("CDIDataVolumeFailed",
+						"increase(cdi_datavolume_failures_total[10m]) > 0", "",
+						"DataVolumes failed",
+						"{{ $value }} {{ $labels.operation }} DataVolumes failed with reason {{ $labels.reason }} in the last 10 minutes"),
+					newAlertRule("CDIDataVolumeHighRestartCount",
+						`cdi_datavolume_restarts_count - ignoring(le) cdi_datavolume_restarts_bucket{le="3"} > 0`, "10m",
+						"DataVolume pods keep restarting",
+						"The pods populating {{ $value }} DataVolumes in namespace {{ $labels.namespace }} restarted more than 3 times"),
+					newAlertRule("CDIDataVolumeSlow",
+						`histogram_quantile(0.9, sum(rate(cdi_datavolume_duration_seconds_bucket{result="succeeded"}[6h])) by (le, operation)) > 3600`, "1h",
+						"DataVolumes take long to populate",
+						"90% of the {{ $labels.operation }} DataVolumes of the last 6 hours took up to {{ $value }} seconds to succeed"),
+				},
+			},
+		},
+	})
+}
+
+func newAlertRule(name, expr, duration, summary, description string) map[string]interface{} {
+	rule := map[string]interface{}{
+		"alert": name,
+		"expr":  expr,
+		"labels": map[string]interface{}{
+			"severity": "warning",
+		},
+		"annotations": map[string]interface{}{
+			"summary":     summary,
+			"description": description,
+		},
+	}
+	if duration != "" {
+		rule["for"] = duration
+	}
+	return rule
+}
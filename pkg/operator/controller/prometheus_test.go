@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Prometheus resources", func() {
+	var owner *appsv1.Deployment
+
+	BeforeEach(func() {
+		owner = &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "cdi-deployment", Namespace: "cdi", UID: "1234"},
+		}
+	})
+
+	getResource := func(c client.Client, desired *unstructured.Unstructured) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(desired.GroupVersionKind())
+		err := c.Get(context.TODO(), client.ObjectKey{Namespace: desired.GetNamespace(), Name: desired.GetName()}, obj)
+		Expect(err).ToNot(HaveOccurred())
+		return obj
+	}
+
+	It("Should create the PrometheusRule and ServiceMonitor owned by the controller deployment", func() {
+		c := createClient()
+		err := ensurePrometheusResourcesExist(log, c, scheme.Scheme, owner)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, desired := range []*unstructured.Unstructured{newPrometheusRule("cdi"), newServiceMonitor("cdi")} {
+			obj := getResource(c, desired)
+			Expect(obj.Object["spec"]).To(Equal(desired.Object["spec"]))
+			Expect(obj.GetOwnerReferences()).To(HaveLen(1))
+			Expect(obj.GetOwnerReferences()[0].Name).To(Equal(owner.Name))
+		}
+	})
+
+	It("Should restore a modified PrometheusRule", func() {
+		rule := newPrometheusRule("cdi")
+		rule.Object["spec"] = map[string]interface{}{"groups": []interface{}{}}
+		c := createClient(rule)
+		err := ensurePrometheusResourcesExist(log, c, scheme.Scheme, owner)
+		Expect(err).ToNot(HaveOccurred())
+
+		obj := getResource(c, rule)
+		Expect(obj.Object["spec"]).To(Equal(newPrometheusRule("cdi").Object["spec"]))
+	})
+})
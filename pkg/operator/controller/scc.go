@@ -34,6 +34,13 @@ import (
 
 const sccName = "containerized-data-importer"
 
+// cloneSourceCapability allows the clone source pods to read the files of the source PVC whoever owns them
+const cloneSourceCapability = corev1.Capability("DAC_READ_SEARCH")
+
+// workerSettingsAnnotation marks SCCs the settings of the worker pods were added to, they are added to SCCs created
+// by older versions once, settings an admin removes afterwards aren't added back
+const workerSettingsAnnotation = "operator.cdi.kubevirt.io/workerSettings"
+
 func ensureSCCExists(logger logr.Logger, c client.Client, saNamespace, saName string) error {
 	scc := &secv1.SecurityContextConstraints{}
 	userName := fmt.Sprintf("system:serviceaccount:%s:%s", saNamespace, saName)
@@ -50,6 +57,9 @@ func ensureSCCExists(logger logr.Logger, c client.Client, saNamespace, saName st
 				Labels: map[string]string{
 					"cdi.kubevirt.io": "",
 				},
+				Annotations: map[string]string{
+					workerSettingsAnnotation: "true",
+				},
 			},
 			Priority: &[]int32{10}[0],
 			FSGroup: secv1.FSGroupStrategyOptions{
@@ -58,9 +68,15 @@ func ensureSCCExists(logger logr.Logger, c client.Client, saNamespace, saName st
 			RequiredDropCapabilities: []corev1.Capability{
 				"MKNOD",
 			},
+			AllowedCapabilities: []corev1.Capability{
+				cloneSourceCapability,
+			},
 			RunAsUser: secv1.RunAsUserStrategyOptions{
 				Type: secv1.RunAsUserStrategyRunAsAny,
 			},
+			SeccompProfiles: []string{
+				corev1.SeccompProfileRuntimeDefault,
+			},
 			SELinuxContext: secv1.SELinuxContextStrategyOptions{
 				Type: secv1.SELinuxStrategyMustRunAs,
 			},
@@ -89,15 +105,43 @@ func ensureSCCExists(logger logr.Logger, c client.Client, saNamespace, saName st
 		return err
 	}
 
+	update := false
 	if !containsStringValue(scc.Users, userName) {
 		scc.Users = append(scc.Users, userName)
+		update = true
+	}
 
+	if _, ok := scc.Annotations[workerSettingsAnnotation]; !ok {
+		// the worker pods run with the runtime default seccomp profile
+		if !containsStringValue(scc.SeccompProfiles, corev1.SeccompProfileRuntimeDefault) {
+			scc.SeccompProfiles = append(scc.SeccompProfiles, corev1.SeccompProfileRuntimeDefault)
+		}
+		if !containsCapability(scc.AllowedCapabilities, cloneSourceCapability) {
+			scc.AllowedCapabilities = append(scc.AllowedCapabilities, cloneSourceCapability)
+		}
+		if scc.Annotations == nil {
+			scc.Annotations = map[string]string{}
+		}
+		scc.Annotations[workerSettingsAnnotation] = "true"
+		update = true
+	}
+
+	if update {
 		return c.Update(context.TODO(), scc)
 	}
 
 	return nil
 }
 
+func containsCapability(capabilities []corev1.Capability, capability corev1.Capability) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *ReconcileCDI) watchSecurityContextConstraints() error {
 	err := r.controller.Watch(
 		&source.Kind{Type: &secv1.SecurityContextConstraints{}},
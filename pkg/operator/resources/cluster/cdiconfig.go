@@ -256,6 +256,245 @@ func createCDIConfigCRD() *extv1.CustomResourceDefinition {
 												},
 											},
 										},
+										"uploadProxyLimits": {
+											Description: "UploadProxyLimits defines the per namespace limits enforced by the upload proxy, an unset limit is not enforced",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"maxConcurrentUploadsPerNamespace": {
+													Description: "MaxConcurrentUploadsPerNamespace is the maximum number of uploads that can be in progress in a namespace at the same time",
+													Type:        "integer",
+													Format:      "int32",
+													Minimum:     &[]float64{1}[0],
+												},
+												"bandwidthPerNamespace": {
+													Description: "BandwidthPerNamespace is the maximum rate, in bytes per second, shared by all uploads in a namespace",
+													AnyOf: []extv1.JSONSchemaProps{
+														{
+															Type: "integer",
+														},
+														{
+															Type: "string",
+														},
+													},
+													Pattern:      "^(\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))))?$",
+													XIntOrString: true,
+												},
+											},
+										},
+										"cloneCompression": {
+											Description: "CloneCompression is the compression used to transfer host assisted clones, options: \"gzip\", \"none\", defaults to \"gzip\". zstd isn't supported, no zstd implementation is available to the cloner and the upload server",
+											Type:        "string",
+											Enum: []extv1.JSON{
+												{
+													Raw: []byte(`"gzip"`),
+												},
+												{
+													Raw: []byte(`"none"`),
+												},
+											},
+										},
+										"cloneStrategies": {
+											Description: "CloneStrategies overrides the order clone strategies are tried in for storage classes. Storage classes that aren't listed try \"snapshot\", then \"host-assisted\"",
+											Type:        "array",
+											Items: &extv1.JSONSchemaPropsOrArray{
+												Schema: &extv1.JSONSchemaProps{
+													Description: "StorageClassCloneStrategies defines the order clone strategies are tried in for a storage class",
+													Type:        "object",
+													Properties: map[string]extv1.JSONSchemaProps{
+														"storageClass": {
+															Description: "StorageClass is the name of the storage class of the target PVC",
+															Type:        "string",
+														},
+														"strategies": {
+															Description: "Strategies are tried in order, the first possible strategy is used, \"host-assisted\" if none is possible. Options: \"csi-clone\", \"snapshot\", \"host-assisted\"",
+															Type:        "array",
+															Items: &extv1.JSONSchemaPropsOrArray{
+																Schema: &extv1.JSONSchemaProps{
+																	Type: "string",
+																	Enum: []extv1.JSON{
+																		{
+																			Raw: []byte(`"csi-clone"`),
+																		},
+																		{
+																			Raw: []byte(`"snapshot"`),
+																		},
+																		{
+																			Raw: []byte(`"host-assisted"`),
+																		},
+																	},
+																},
+															},
+														},
+													},
+													Required: []string{
+														"storageClass",
+														"strategies",
+													},
+												},
+											},
+										},
+										"importCache": {
+											Description: "ImportCacheSpec defines the import cache, its PVCs are evicted when they are older than MaxAge, or when their total size exceeds MaxSize",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"namespace": {
+													Description: "Namespace holding the cache PVCs",
+													Type:        "string",
+												},
+												"maxAge": {
+													Description: "MaxAge is the time a cache PVC is kept after it was created, unlimited if not set",
+													Type:        "string",
+												},
+												"maxSize": {
+													Description: "MaxSize is the total requested storage of the cache PVCs, the least recently used PVCs are evicted when it is exceeded, unlimited if not set",
+													AnyOf: []extv1.JSONSchemaProps{
+														{
+															Type: "integer",
+														},
+														{
+															Type: "string",
+														},
+													},
+													Pattern:      "^(\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))))?$",
+													XIntOrString: true,
+												},
+											},
+											Required: []string{
+												"namespace",
+											},
+										},
+										"dataVolumeDeadline": {
+											Description: "DataVolumeDeadline is the default deadline of DataVolumes which don't set one, unlimited if not set",
+											Type:        "string",
+										},
+										"workloads": nodePlacementSchema("Workloads is the default placement of the importer, cloner and upload server pods"),
+										"workloadSecurity": {
+											Description: "WorkloadSecurity is the user and group the importer, cloner and upload server pods run as on filesystem volumes. Pods on block volumes and clone source pods still run as root, they are rejected by the restricted Pod Security Standard",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"runAsUser": {
+													Description: "RunAsUser is the non root UID of the worker pods, defaults to 107 (qemu)",
+													Type:        "integer",
+													Format:      "int64",
+													Minimum:     &[]float64{1}[0],
+												},
+												"fsGroup": {
+													Description: "FSGroup is the GID owning the volumes of the worker pods, defaults to 107 (qemu)",
+													Type:        "integer",
+													Format:      "int64",
+													Minimum:     &[]float64{0}[0],
+												},
+											},
+										},
+										"scratchSpace": {
+											Description: "ScratchSpace sizes the scratch space of imports and uploads after the size of their source",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"overheadPercent": {
+													Description: "OverheadPercent is the percentage of the source size added for the expansion of uncompressed sources, defaults to 100",
+													Type:        "integer",
+													Format:      "int32",
+													Minimum:     &[]float64{0}[0],
+												},
+												"compressedOverheadPercent": {
+													Description: "CompressedOverheadPercent is the percentage of the source size added for gz and xz compressed sources, which are decompressed into scratch space, defaults to 400",
+													Type:        "integer",
+													Format:      "int32",
+													Minimum:     &[]float64{0}[0],
+												},
+												"emptyDirLimit": {
+													Description: "EmptyDirLimit is the largest scratch space backed by an emptyDir of the pod instead of a PVC, scratch space is always a PVC if not set",
+													AnyOf: []extv1.JSONSchemaProps{
+														{
+															Type: "integer",
+														},
+														{
+															Type: "string",
+														},
+													},
+													Pattern:      "^(\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))))?$",
+													XIntOrString: true,
+												},
+											},
+										},
+										"filesystemOverhead": {
+											Description: "FilesystemOverhead is the fraction of filesystem PVCs reserved for filesystem metadata, the image is sized to the rest",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"global": {
+													Description: "Global is the overhead of storage classes not listed in StorageClass, defaults to \"0.055\"",
+													Type:        "string",
+													Pattern:     `^0(\.[0-9]{1,3})?$`,
+												},
+												"storageClass": {
+													Description: "StorageClass is the overhead of specific storage classes",
+													Type:        "object",
+													AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+														Schema: &extv1.JSONSchemaProps{
+															Description: "Percent is a fraction in [0, 1), with up to three decimals",
+															Type:        "string",
+															Pattern:     `^0(\.[0-9]{1,3})?$`,
+														},
+													},
+												},
+												"inflateRequests": {
+													Description: "InflateRequests makes DataVolumes request filesystem PVCs larger by the overhead, so the image is the requested size",
+													Type:        "boolean",
+												},
+											},
+										},
+										"storageProfiles": {
+											Description: "StorageProfiles are the defaults of the PVCs of DataVolumes in specific storage classes",
+											Type:        "array",
+											Items: &extv1.JSONSchemaPropsOrArray{
+												Schema: &extv1.JSONSchemaProps{
+													Description: "StorageProfile defines the defaults of the PVCs of DataVolumes in a storage class, the DataVolume webhook fills in the fields a DataVolume omits",
+													Type:        "object",
+													Properties: map[string]extv1.JSONSchemaProps{
+														"storageClass": {
+															Description: "StorageClass is the name of the storage class",
+															Type:        "string",
+														},
+														"accessModes": {
+															Description: "AccessModes are the access modes of PVCs which don't set any",
+															Type:        "array",
+															Items: &extv1.JSONSchemaPropsOrArray{
+																Schema: &extv1.JSONSchemaProps{
+																	Type: "string",
+																},
+															},
+														},
+														"volumeMode": {
+															Description: "VolumeMode is the volume mode of PVCs which don't set one",
+															Type:        "string",
+														},
+														"cloneStrategy": {
+															Description: "CloneStrategy is tried before \"host-assisted\" when cloneStrategies doesn't list the storage class. Options: \"csi-clone\", \"snapshot\", \"host-assisted\"",
+															Type:        "string",
+															Enum: []extv1.JSON{
+																{
+																	Raw: []byte(`"csi-clone"`),
+																},
+																{
+																	Raw: []byte(`"snapshot"`),
+																},
+																{
+																	Raw: []byte(`"host-assisted"`),
+																},
+															},
+														},
+														"filesystemOverhead": {
+															Description: "FilesystemOverhead is the overhead of the storage class when filesystemOverhead doesn't list it",
+															Type:        "string",
+															Pattern:     `^0(\.[0-9]{1,3})?$`,
+														},
+													},
+													Required: []string{
+														"storageClass",
+													},
+												},
+											},
+										},
 									},
 								},
 								"status": {
@@ -329,3 +568,64 @@ func createCDIConfigCRD() *extv1.CustomResourceDefinition {
 		},
 	}
 }
+
+// nodePlacementSchema creates the schema of the NodePlacement of the worker pods, the affinity isn't pruned
+func nodePlacementSchema(description string) extv1.JSONSchemaProps {
+	return extv1.JSONSchemaProps{
+		Description: description,
+		Type:        "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"nodeSelector": {
+				Description: "NodeSelector is the node selector of the worker pods",
+				Type:        "object",
+				AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+					Schema: &extv1.JSONSchemaProps{
+						Type: "string",
+					},
+				},
+			},
+			"affinity": {
+				Description:            "Affinity is the affinity of the worker pods",
+				Type:                   "object",
+				XPreserveUnknownFields: &[]bool{true}[0],
+			},
+			"tolerations": {
+				Description: "Tolerations are the tolerations of the worker pods",
+				Type:        "array",
+				Items: &extv1.JSONSchemaPropsOrArray{
+					Schema: &extv1.JSONSchemaProps{
+						Description: "The pod this Toleration is attached to tolerates any taint that matches the triple <key,value,effect> using the matching operator <operator>.",
+						Type:        "object",
+						Properties: map[string]extv1.JSONSchemaProps{
+							"effect": {
+								Description: "Effect indicates the taint effect to match. Empty means match all taint effects. When specified, allowed values are NoSchedule, PreferNoSchedule and NoExecute.",
+								Type:        "string",
+							},
+							"key": {
+								Description: "Key is the taint key that the toleration applies to. Empty means match all taint keys. If the key is empty, operator must be Exists; this combination means to match all values and all keys.",
+								Type:        "string",
+							},
+							"operator": {
+								Description: "Operator represents a key's relationship to the value. Valid operators are Exists and Equal. Defaults to Equal.",
+								Type:        "string",
+							},
+							"tolerationSeconds": {
+								Description: "TolerationSeconds represents the period of time the toleration (which must be of effect NoExecute, otherwise this field is ignored) tolerates the taint.",
+								Type:        "integer",
+								Format:      "int64",
+							},
+							"value": {
+								Description: "Value is the taint value the toleration matches to. If the operator is Exists, the value should be empty, otherwise just a regular string.",
+								Type:        "string",
+							},
+						},
+					},
+				},
+			},
+			"priorityClassName": {
+				Description: "PriorityClassName is the priority class of the worker pods",
+				Type:        "string",
+			},
+		},
+	}
+}
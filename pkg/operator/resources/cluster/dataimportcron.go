@@ -0,0 +1,263 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubevirt.io/containerized-data-importer/pkg/operator/resources/utils"
+)
+
+// NewDataImportCronCrd - provides DataImportCron CRD
+func NewDataImportCronCrd() *extv1.CustomResourceDefinition {
+	return createDataImportCronCRD()
+}
+
+// dataVolumeSpecSchema returns the v1beta1 DataVolume spec schema, it is the schema of the DataImportCron template spec
+func dataVolumeSpecSchema() extv1.JSONSchemaProps {
+	for _, version := range createDataVolumeCRD().Spec.Versions {
+		if version.Name == "v1beta1" {
+			return version.Schema.OpenAPIV3Schema.Properties["spec"]
+		}
+	}
+	panic("no v1beta1 DataVolume schema")
+}
+
+// createDataImportCronCRD creates the DataImportCron schema
+func createDataImportCronCRD() *extv1.CustomResourceDefinition {
+	return &extv1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apiextensions.k8s.io/v1",
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "dataimportcrons.cdi.kubevirt.io",
+			Labels: utils.WithCommonLabels(nil),
+		},
+		Spec: extv1.CustomResourceDefinitionSpec{
+			Group: "cdi.kubevirt.io",
+			Names: extv1.CustomResourceDefinitionNames{
+				Kind:   "DataImportCron",
+				Plural: "dataimportcrons",
+				ShortNames: []string{
+					"dic",
+					"dics",
+				},
+				ListKind: "DataImportCronList",
+				Singular: "dataimportcron",
+				Categories: []string{
+					"all",
+				},
+			},
+			Versions: []extv1.CustomResourceDefinitionVersion{
+				{
+					Name:         "v1beta1",
+					Served:       true,
+					Storage:      true,
+					Subresources: &extv1.CustomResourceSubresources{},
+					Schema: &extv1.CustomResourceValidation{
+						OpenAPIV3Schema: &extv1.JSONSchemaProps{
+							Description: "DataImportCron re-imports a registry or http source on a schedule, keeps the last imported PVCs and points to the newest one",
+							Type:        "object",
+							Properties: map[string]extv1.JSONSchemaProps{
+								// We are aware apiVersion, kind, and metadata are technically not needed, but to make comparision with
+								// kubebuilder easier, we add it here.
+								"apiVersion": {
+									Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+									Type:        "string",
+								},
+								"kind": {
+									Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+									Type:        "string",
+								},
+								"metadata": {
+									Type: "object",
+								},
+								"spec": {
+									Description: "DataImportCronSpec defines the DataImportCron type specification",
+									Type:        "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"template": {
+											Description: "Template is the DataVolume created for every new digest of the source, its source must be a registry or http source",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"apiVersion": {
+													Type: "string",
+												},
+												"kind": {
+													Type: "string",
+												},
+												"metadata": {
+													Type:                   "object",
+													XPreserveUnknownFields: &[]bool{true}[0],
+												},
+												"spec": dataVolumeSpecSchema(),
+												"status": {
+													Type:                   "object",
+													XPreserveUnknownFields: &[]bool{true}[0],
+												},
+											},
+											Required: []string{
+												"spec",
+											},
+										},
+										"schedule": {
+											Description: "Schedule is the cron schedule the source digest is polled on, e.g. \"0 */12 * * *\" or \"@daily\"",
+											Type:        "string",
+										},
+										"importsToKeep": {
+											Description: "ImportsToKeep is the number of successfully imported PVCs kept, the older ones are garbage collected once they are not in use by pods. Defaults to 3",
+											Type:        "integer",
+											Format:      "int32",
+											Minimum:     &[]float64{1}[0],
+										},
+									},
+									Required: []string{
+										"template",
+										"schedule",
+									},
+								},
+								"status": {
+									Description: "DataImportCronStatus provides the most recently observed status of the DataImportCron",
+									Type:        "object",
+									Properties: map[string]extv1.JSONSchemaProps{
+										"lastImportedPVC": {
+											Description: "LastImportedPVC is the PVC of the newest successful import, it is updated once the import of a new digest succeeds",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"namespace": {
+													Description: "The namespace of the source PVC",
+													Type:        "string",
+												},
+												"name": {
+													Description: "The name of the source PVC",
+													Type:        "string",
+												},
+											},
+											Required: []string{
+												"namespace",
+												"name",
+											},
+										},
+										"lastImportedDigest": {
+											Description: "LastImportedDigest is the source digest imported to LastImportedPVC",
+											Type:        "string",
+										},
+										"lastImportTimestamp": {
+											Description: "LastImportTimestamp is the time the newest successful import was started",
+											Type:        "string",
+											Format:      "date-time",
+										},
+										"lastExecutionTimestamp": {
+											Description: "LastExecutionTimestamp is the time the source digest was last polled",
+											Type:        "string",
+											Format:      "date-time",
+										},
+										"currentImports": {
+											Description: "CurrentImports are the imports in progress",
+											Items: &extv1.JSONSchemaPropsOrArray{
+												Schema: &extv1.JSONSchemaProps{
+													Description: "DataImportCronImport describes an import started by the DataImportCron",
+													Type:        "object",
+													Properties: map[string]extv1.JSONSchemaProps{
+														"dataVolumeName": {
+															Description: "DataVolumeName is the name of the DataVolume importing the digest",
+															Type:        "string",
+														},
+														"digest": {
+															Description: "Digest is the source digest being imported",
+															Type:        "string",
+														},
+													},
+													Required: []string{
+														"dataVolumeName",
+														"digest",
+													},
+												},
+											},
+											Type: "array",
+										},
+										"conditions": {
+											Items: &extv1.JSONSchemaPropsOrArray{
+												Schema: &extv1.JSONSchemaProps{
+													Description: "DataImportCronCondition represents the state of a DataImportCron condition",
+													Type:        "object",
+													Properties: map[string]extv1.JSONSchemaProps{
+														"lastHeartbeatTime": {
+															Type:   "string",
+															Format: "date-time",
+														},
+														"lastTransitionTime": {
+															Type:   "string",
+															Format: "date-time",
+														},
+														"message": {
+															Type: "string",
+														},
+														"reason": {
+															Type: "string",
+														},
+														"status": {
+															Type: "string",
+														},
+														"type": {
+															Description: "DataImportCronConditionType is the string representation of known condition types",
+															Type:        "string",
+														},
+													},
+													Required: []string{
+														"status",
+														"type",
+													},
+												},
+											},
+											Type: "array",
+										},
+									},
+								},
+							},
+							Required: []string{
+								"spec",
+							},
+						},
+					},
+					AdditionalPrinterColumns: []extv1.CustomResourceColumnDefinition{
+						{
+							Name:        "Schedule",
+							Type:        "string",
+							Description: "The schedule the source is polled on",
+							JSONPath:    ".spec.schedule",
+						},
+						{
+							Name:        "Last Imported PVC",
+							Type:        "string",
+							Description: "The PVC of the newest import",
+							JSONPath:    ".status.lastImportedPVC.name",
+						},
+						{
+							Name:     "Age",
+							Type:     "date",
+							JSONPath: ".metadata.creationTimestamp",
+						},
+					},
+				},
+			},
+			Scope: "Namespaced",
+		},
+	}
+}
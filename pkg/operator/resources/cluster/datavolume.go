@@ -470,6 +470,16 @@ func createDataVolumeCRD() *extv1.CustomResourceDefinition {
 									Description: "DataVolumeSpec defines the DataVolume type specification",
 									Type:        "object",
 									Properties: map[string]extv1.JSONSchemaProps{
+										"placement": nodePlacementSchema("Placement overrides the workloads placement of the CDIConfig for the pods populating the DataVolume, each field that is set replaces the field of the CDIConfig"),
+										"action": {
+											Description: "Action cancels populating the DataVolume: Cancel removes the pods populating it for good and leaves the DataVolume Cancelled. DataVolumes can't be paused, the pods populating them can't resume where they stopped",
+											Type:        "string",
+											Enum: []extv1.JSON{
+												{
+													Raw: []byte(`"Cancel"`),
+												},
+											},
+										},
 										"contentType": {
 											Description: "DataVolumeContentType options: \"kubevirt\", \"archive\"",
 											Type:        "string",
@@ -482,6 +492,26 @@ func createDataVolumeCRD() *extv1.CustomResourceDefinition {
 												},
 											},
 										},
+										"retryPolicy": {
+											Description: "RetryPolicy bounds the retries of the pods populating the DataVolume, failed pods are restarted forever if it isn't set",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"maxRetries": {
+													Description: "MaxRetries is the number of times a failed pod is recreated before the DataVolume fails, 0 fails the DataVolume on the first error. Failed pods are recreated forever if it isn't set",
+													Type:        "integer",
+													Format:      "int32",
+													Minimum:     &[]float64{0}[0],
+												},
+												"backoff": {
+													Description: "Backoff is the delay before the first retry, it doubles with every retry up to 5 minutes. Defaults to 10s",
+													Type:        "string",
+												},
+											},
+										},
+										"deadline": {
+											Description: "Deadline is the maximum time populating the DataVolume may take, counted from the creation of its PVC. The pods populating it are terminated and the DataVolume fails once it passes. Defaults to the dataVolumeDeadline of the CDIConfig, unlimited if neither is set",
+											Type:        "string",
+										},
 										"source": {
 											Description: "Source is the src of the data for the requested DataVolume",
 											Type:        "object",
@@ -599,6 +629,7 @@ func createDataVolumeCRD() *extv1.CustomResourceDefinition {
 												},
 											},
 										},
+										"storage": storageSpecSchema(),
 										"pvc": {
 											Description: "PVC is the PVC specification",
 											Type:        "object",
@@ -740,7 +771,6 @@ func createDataVolumeCRD() *extv1.CustomResourceDefinition {
 										},
 									},
 									Required: []string{
-										"pvc",
 										"source",
 									},
 								},
@@ -761,6 +791,57 @@ func createDataVolumeCRD() *extv1.CustomResourceDefinition {
 											Type:        "integer",
 											Format:      "int32",
 										},
+										"cloneStrategy": {
+											Description: "CloneStrategy is the strategy chosen to clone the source PVC",
+											Type:        "string",
+										},
+										"cloneStrategyReason": {
+											Description: "CloneStrategyReason explains why the clone strategy was chosen",
+											Type:        "string",
+										},
+										"importCache": {
+											Description: "DataVolumeImportCacheStatus describes the import cache PVC a DataVolume is cloned from",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"namespace": {
+													Description: "Namespace of the cache PVC",
+													Type:        "string",
+												},
+												"name": {
+													Description: "Name of the cache PVC",
+													Type:        "string",
+												},
+												"hit": {
+													Description: "Hit is true if the cache PVC was already populated, false if the DataVolume populated it",
+													Type:        "boolean",
+												},
+											},
+											Required: []string{
+												"namespace",
+												"name",
+												"hit",
+											},
+										},
+										"transfer": {
+											Description: "DataVolumeTransferStatus describes the transfer reported by the pod populating a DataVolume",
+											Type:        "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"phase": {
+													Description: "Phase is the phase of the transfer, as reported by the pod",
+													Type:        "string",
+												},
+												"bytes": {
+													Description: "Bytes is the number of bytes transferred",
+													Type:        "integer",
+													Format:      "int64",
+												},
+												"totalBytes": {
+													Description: "TotalBytes is the number of bytes to transfer, if known",
+													Type:        "integer",
+													Format:      "int64",
+												},
+											},
+										},
 										"conditions": {
 											Items: &extv1.JSONSchemaPropsOrArray{
 												Schema: &extv1.JSONSchemaProps{
@@ -839,3 +920,72 @@ func createDataVolumeCRD() *extv1.CustomResourceDefinition {
 		},
 	}
 }
+
+// storageSpecSchema creates the schema of the simplified PVC specification of a DataVolume
+func storageSpecSchema() extv1.JSONSchemaProps {
+	return extv1.JSONSchemaProps{
+		Description: "Storage is a simplified PVC specification, only the size is required. The access mode, volume mode and storage class are resolved from the storage class defaults and storage profiles, the size of clones from the source PVC. Either PVC or Storage must be set",
+		Type:        "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"accessModes": {
+				Description: "AccessModes contains the desired access modes the volume should have, resolved from the storage profile if not set",
+				Type:        "array",
+				Items: &extv1.JSONSchemaPropsOrArray{
+					Schema: &extv1.JSONSchemaProps{
+						Type: "string",
+					},
+				},
+			},
+			"resources": {
+				Description: "Resources represents the minimum resources the volume should have, the size of the source PVC of clones if not set",
+				Type:        "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"limits": {
+						Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
+						Type:        "object",
+						AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+							Schema: &extv1.JSONSchemaProps{
+								AnyOf: []extv1.JSONSchemaProps{
+									{
+										Type: "integer",
+									},
+									{
+										Type: "string",
+									},
+								},
+								Pattern:      "^(\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))))?$",
+								XIntOrString: true,
+							},
+						},
+					},
+					"requests": {
+						Description: "Requests describes the minimum amount of compute resources required. If Requests is omitted for a container, it defaults to Limits if that is explicitly specified, otherwise to an implementation-defined value. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
+						Type:        "object",
+						AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+							Schema: &extv1.JSONSchemaProps{
+								AnyOf: []extv1.JSONSchemaProps{
+									{
+										Type: "integer",
+									},
+									{
+										Type: "string",
+									},
+								},
+								Pattern:      "^(\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\\+|-)?(([0-9]+(\\.[0-9]*)?)|(\\.[0-9]+))))?$",
+								XIntOrString: true,
+							},
+						},
+					},
+				},
+			},
+			"storageClassName": {
+				Description: "StorageClassName is the name of the StorageClass of the volume, the default storage class if not set",
+				Type:        "string",
+			},
+			"volumeMode": {
+				Description: "VolumeMode defines what type of volume is required, resolved from the storage profile if not set",
+				Type:        "string",
+			},
+		},
+	}
+}
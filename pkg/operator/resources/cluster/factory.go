@@ -54,6 +54,7 @@ func createCRDResources(args *FactoryArgs) []runtime.Object {
 	return []runtime.Object{
 		createDataVolumeCRD(),
 		createCDIConfigCRD(),
+		createDataImportCronCRD(),
 	}
 }
 
@@ -109,6 +109,7 @@ func getAdminPolicyRules() []rbacv1.PolicyRule {
 			},
 			Resources: []string{
 				"datavolumes",
+				"dataimportcrons",
 			},
 			Verbs: []string{
 				"*",
@@ -168,6 +169,7 @@ func getViewPolicyRules() []rbacv1.PolicyRule {
 			},
 			Resources: []string{
 				"datavolumes",
+				"dataimportcrons",
 			},
 			Verbs: []string{
 				"get",
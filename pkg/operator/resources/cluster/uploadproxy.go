@@ -45,6 +45,19 @@ func getUploadProxyClusterPolicyRules() []rbacv1.PolicyRule {
 				"get",
 			},
 		},
+		{
+			APIGroups: []string{
+				"cdi.kubevirt.io",
+			},
+			Resources: []string{
+				"cdiconfigs",
+			},
+			Verbs: []string{
+				"get",
+				"list",
+				"watch",
+			},
+		},
 	}
 }
 
@@ -34,6 +34,8 @@ const (
 	controllerServiceAccount = "cdi-sa"
 	prometheusLabel          = common.PrometheusLabel
 	prometheusServiceName    = common.PrometheusServiceName
+	progressLabel            = common.ProgressLabel
+	progressServiceName      = common.ProgressServiceName
 )
 
 func createControllerResources(args *FactoryArgs) []runtime.Object {
@@ -49,6 +51,7 @@ func createControllerResources(args *FactoryArgs) []runtime.Object {
 			args.PullPolicy),
 		createInsecureRegConfigMap(),
 		createPrometheusService(),
+		createProgressService(),
 	}
 }
 
@@ -94,7 +97,21 @@ func createControllerServiceAccount() *corev1.ServiceAccount {
 func createControllerDeployment(controllerImage, importerImage, clonerImage, uploadServerImage, verbosity, pullPolicy string) *appsv1.Deployment {
 	defaultMode := corev1.ConfigMapVolumeSourceDefaultMode
 	deployment := utils.CreateDeployment(controllerResourceName, "app", "containerized-data-importer", common.ControllerServiceAccountName, int32(1))
+	deployment.Spec.Template.ObjectMeta.Labels[prometheusLabel] = ""
+	deployment.Spec.Template.ObjectMeta.Labels[progressLabel] = ""
 	container := utils.CreateContainer("cdi-controller", controllerImage, verbosity, corev1.PullPolicy(pullPolicy))
+	container.Ports = []corev1.ContainerPort{
+		{
+			Name:          "metrics",
+			ContainerPort: 8443,
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "progress",
+			ContainerPort: common.ProgressPort,
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
 	container.Env = []corev1.EnvVar{
 		{
 			Name:  "IMPORTER_IMAGE",
@@ -272,3 +289,19 @@ func createPrometheusService() *corev1.Service {
 	}
 	return service
 }
+
+func createProgressService() *corev1.Service {
+	service := utils.CreateService(progressServiceName, progressLabel, "")
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name: "progress",
+			Port: 443,
+			TargetPort: intstr.IntOrString{
+				Type:   intstr.String,
+				StrVal: "progress",
+			},
+			Protocol: corev1.ProtocolTCP,
+		},
+	}
+	return service
+}
@@ -84,7 +84,15 @@ func createUploadProxyRole() *rbacv1.Role {
 func createUploadProxyDeployment(image, verbosity, pullPolicy string) *appsv1.Deployment {
 	defaultMode := corev1.ConfigMapVolumeSourceDefaultMode
 	deployment := utils.CreateDeployment(uploadProxyResourceName, cdiLabel, uploadProxyResourceName, uploadProxyResourceName, int32(1))
-	container := utils.CreateContainer(uploadProxyResourceName, image, verbosity, corev1.PullPolicy(pullPolicy))
+	// the metrics port is only exposed by the prometheus service, the upload proxy service exposes the uploads
+	deployment.Spec.Template.ObjectMeta.Labels[prometheusLabel] = ""
+	container := utils.CreatePortsContainer(uploadProxyResourceName, image, verbosity, corev1.PullPolicy(pullPolicy), &[]corev1.ContainerPort{
+		{
+			Name:          "metrics",
+			ContainerPort: 8444,
+			Protocol:      corev1.ProtocolTCP,
+		},
+	})
 	container.Env = []corev1.EnvVar{
 		{
 			Name: "APISERVER_PUBLIC_KEY",
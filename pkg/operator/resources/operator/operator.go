@@ -207,6 +207,18 @@ func getNamespacedPolicyRules() []rbacv1.PolicyRule {
 				"*",
 			},
 		},
+		{
+			APIGroups: []string{
+				"monitoring.coreos.com",
+			},
+			Resources: []string{
+				"prometheusrules",
+				"servicemonitors",
+			},
+			Verbs: []string{
+				"*",
+			},
+		},
 	}
 	return rules
 }
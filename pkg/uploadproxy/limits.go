@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uploadproxy
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	"k8s.io/klog"
+
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+const (
+	// minimum burst so small bandwidth limits don't turn every read into a wait
+	minBandwidthBurst = 32 * 1024
+)
+
+var (
+	activeUploads = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cdi_upload_proxy_active_uploads",
+			Help: "The number of uploads currently forwarded by the upload proxy",
+		},
+		[]string{"namespace"},
+	)
+	uploadedBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cdi_upload_proxy_uploaded_bytes_total",
+			Help: "The number of bytes forwarded by the upload proxy",
+		},
+		[]string{"namespace"},
+	)
+	rejectedUploads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cdi_upload_proxy_rejected_uploads_total",
+			Help: "The number of uploads rejected because the namespace reached its concurrent upload limit",
+		},
+		[]string{"namespace"},
+	)
+)
+
+func init() {
+	activeUploads = registerCollector(activeUploads).(*prometheus.GaugeVec)
+	uploadedBytes = registerCollector(uploadedBytes).(*prometheus.CounterVec)
+	rejectedUploads = registerCollector(rejectedUploads).(*prometheus.CounterVec)
+}
+
+func registerCollector(c prometheus.Collector) prometheus.Collector {
+	if err := prometheus.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			// A collector for that metric has been registered before.
+			// Use the old collector from now on.
+			return are.ExistingCollector
+		}
+		klog.Errorf("Unable to register prometheus collector: %v", err)
+	}
+	return c
+}
+
+// uploadLimiter tracks the uploads in progress per namespace and shares a bandwidth budget between them
+type uploadLimiter struct {
+	mutex     sync.Mutex
+	active    map[string]int32
+	bandwidth map[string]*rate.Limiter
+}
+
+func newUploadLimiter() *uploadLimiter {
+	return &uploadLimiter{
+		active:    make(map[string]int32),
+		bandwidth: make(map[string]*rate.Limiter),
+	}
+}
+
+// acquire reserves an upload slot in the namespace, it returns false if the namespace is at its limit
+func (l *uploadLimiter) acquire(namespace string, limits *cdiv1.UploadProxyLimits) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if limits != nil && limits.MaxConcurrentUploadsPerNamespace != nil &&
+		l.active[namespace] >= *limits.MaxConcurrentUploadsPerNamespace {
+		rejectedUploads.WithLabelValues(namespace).Inc()
+		return false
+	}
+
+	l.active[namespace]++
+	activeUploads.WithLabelValues(namespace).Set(float64(l.active[namespace]))
+	return true
+}
+
+// release frees an upload slot previously reserved with acquire
+func (l *uploadLimiter) release(namespace string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.active[namespace]--
+	activeUploads.WithLabelValues(namespace).Set(float64(l.active[namespace]))
+	if l.active[namespace] <= 0 {
+		delete(l.active, namespace)
+		delete(l.bandwidth, namespace)
+	}
+}
+
+// bandwidthLimiter returns the rate limiter shared by all uploads in the namespace, or nil if bandwidth is unlimited
+func (l *uploadLimiter) bandwidthLimiter(namespace string, limits *cdiv1.UploadProxyLimits) *rate.Limiter {
+	if limits == nil || limits.BandwidthPerNamespace == nil || limits.BandwidthPerNamespace.Value() <= 0 {
+		return nil
+	}
+
+	bytesPerSecond := limits.BandwidthPerNamespace.Value()
+	burst := int(bytesPerSecond)
+	if burst < minBandwidthBurst {
+		burst = minBandwidthBurst
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	limiter, ok := l.bandwidth[namespace]
+	if !ok || limiter.Limit() != rate.Limit(bytesPerSecond) {
+		// uploads already in progress keep the limiter they started with
+		limiter = rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+		l.bandwidth[namespace] = limiter
+	}
+	return limiter
+}
+
+// limitedReader throttles reads with an optional rate limiter and counts the bytes read
+type limitedReader struct {
+	ctx       context.Context
+	reader    io.ReadCloser
+	limiter   *rate.Limiter
+	namespace string
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.limiter != nil && len(p) > r.limiter.Burst() {
+		// WaitN fails for more than burst bytes
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		uploadedBytes.WithLabelValues(r.namespace).Add(float64(n))
+		if r.limiter != nil {
+			if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
+
+func (r *limitedReader) Close() error {
+	return r.reader.Close()
+}
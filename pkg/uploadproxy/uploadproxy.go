@@ -20,8 +20,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
 
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	cdiclient "kubevirt.io/containerized-data-importer/pkg/client/clientset/versioned"
+	cdiinformers "kubevirt.io/containerized-data-importer/pkg/client/informers/externalversions"
+	cdilisters "kubevirt.io/containerized-data-importer/pkg/client/listers/core/v1beta1"
 	"kubevirt.io/containerized-data-importer/pkg/common"
 	"kubevirt.io/containerized-data-importer/pkg/controller"
 	"kubevirt.io/containerized-data-importer/pkg/token"
@@ -38,6 +43,9 @@ const (
 	proxyRequestTimeout = 24 * time.Hour
 
 	uploadTokenLeeway = 10 * time.Second
+
+	// seconds a client is asked to wait when its namespace is at the concurrent upload limit
+	uploadRetryAfterSeconds = 30
 )
 
 // Server is the public interface to the upload proxy
@@ -64,6 +72,10 @@ type uploadProxyApp struct {
 
 	client kubernetes.Interface
 
+	cdiConfigLister cdilisters.CDIConfigLister
+
+	limiter *uploadLimiter
+
 	certWatcher CertWatcher
 
 	clientCreator ClientCreator
@@ -91,16 +103,20 @@ func NewUploadProxy(bindAddress string,
 	certWatcher CertWatcher,
 	clientCertFetcher fetcher.CertFetcher,
 	serverCAFetcher fetcher.CertBundleFetcher,
-	client kubernetes.Interface) (Server, error) {
+	client kubernetes.Interface,
+	cdiClient cdiclient.Interface,
+	stopCh <-chan struct{}) (Server, error) {
 	var err error
 	app := &uploadProxyApp{
-		bindAddress:    bindAddress,
-		bindPort:       bindPort,
-		certWatcher:    certWatcher,
-		clientCreator:  &clientCreator{certFetcher: clientCertFetcher, bundleFetcher: serverCAFetcher},
-		client:         client,
-		urlResolver:    controller.GetUploadServerURL,
-		uploadPossible: controller.UploadPossibleForPVC,
+		bindAddress:     bindAddress,
+		bindPort:        bindPort,
+		certWatcher:     certWatcher,
+		clientCreator:   &clientCreator{certFetcher: clientCertFetcher, bundleFetcher: serverCAFetcher},
+		client:          client,
+		cdiConfigLister: newCDIConfigLister(cdiClient, stopCh),
+		limiter:         newUploadLimiter(),
+		urlResolver:     controller.GetUploadServerURL,
+		uploadPossible:  controller.UploadPossibleForPVC,
 	}
 	// retrieve RSA key used by apiserver to sign tokens
 	err = app.getSigningKey(apiServerPublicKey)
@@ -113,6 +129,29 @@ func NewUploadProxy(bindAddress string,
 	return app, nil
 }
 
+// newCDIConfigLister watches the CDIConfig so the upload limits are not fetched from the apiserver on every upload
+func newCDIConfigLister(cdiClient cdiclient.Interface, stopCh <-chan struct{}) cdilisters.CDIConfigLister {
+	informerFactory := cdiinformers.NewFilteredSharedInformerFactory(cdiClient,
+		common.DefaultResyncPeriod,
+		metav1.NamespaceAll,
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = "metadata.name=" + common.ConfigName
+		},
+	)
+
+	configInformer := informerFactory.Cdi().V1beta1().CDIConfigs()
+	// register the informer before the factory is started
+	configInformer.Informer()
+
+	go informerFactory.Start(stopCh)
+
+	klog.V(3).Infoln("Waiting for cache sync")
+	cache.WaitForCacheSync(stopCh, configInformer.Informer().HasSynced)
+	klog.V(3).Infoln("Cache sync complete")
+
+	return configInformer.Lister()
+}
+
 func (c *clientCreator) CreateClient() (*http.Client, error) {
 	clientCertBytes, err := c.certFetcher.CertBytes()
 	if err != nil {
@@ -205,9 +244,41 @@ func (app *uploadProxyApp) handleUploadRequest(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if r.Method == http.MethodHead {
+		app.proxyUploadRequest(tokenData.Namespace, tokenData.Name, w, r)
+		return
+	}
+
+	limits := app.getUploadProxyLimits()
+	if !app.limiter.acquire(tokenData.Namespace, limits) {
+		klog.V(1).Infof("Too many concurrent uploads in namespace %s, rejecting upload to pvc %s", tokenData.Namespace, tokenData.Name)
+		w.Header().Set("Retry-After", strconv.Itoa(uploadRetryAfterSeconds))
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(fmt.Sprintf("too many concurrent uploads in namespace %s", tokenData.Namespace)))
+		return
+	}
+	defer app.limiter.release(tokenData.Namespace)
+
+	r.Body = &limitedReader{
+		ctx:       r.Context(),
+		reader:    r.Body,
+		limiter:   app.limiter.bandwidthLimiter(tokenData.Namespace, limits),
+		namespace: tokenData.Namespace,
+	}
+
 	app.proxyUploadRequest(tokenData.Namespace, tokenData.Name, w, r)
 }
 
+func (app *uploadProxyApp) getUploadProxyLimits() *cdiv1.UploadProxyLimits {
+	config, err := app.cdiConfigLister.Get(common.ConfigName)
+	if err != nil {
+		// don't block uploads because the config is not available
+		klog.Errorf("Unable to get CDIConfig, upload limits will not be enforced: %v", err)
+		return nil
+	}
+	return config.Spec.UploadProxyLimits
+}
+
 func (app *uploadProxyApp) uploadReady(pvcName, pvcNamespace string) error {
 	return wait.PollImmediate(waitReadyImterval, waitReadyTime, func() (bool, error) {
 		pvc, err := app.client.CoreV1().PersistentVolumeClaims(pvcNamespace).Get(context.TODO(), pvcName, metav1.GetOptions{})
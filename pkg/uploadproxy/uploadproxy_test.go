@@ -14,10 +14,15 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 
+	cdiv1 "kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+	cdilisters "kubevirt.io/containerized-data-importer/pkg/client/listers/core/v1beta1"
+	"kubevirt.io/containerized-data-importer/pkg/common"
 	"kubevirt.io/containerized-data-importer/pkg/token"
 	"kubevirt.io/containerized-data-importer/pkg/uploadserver"
 	"kubevirt.io/containerized-data-importer/pkg/util/cert"
@@ -118,7 +123,7 @@ func submitRequestAndCheckStatusAndCORS(request *http.Request, expectedCode int,
 }
 
 func createApp() *uploadProxyApp {
-	app := &uploadProxyApp{}
+	app := &uploadProxyApp{limiter: newUploadLimiter()}
 	app.initHandler()
 	return app
 }
@@ -152,7 +157,7 @@ func (fcc *fakeClientCreator) CreateClient() (*http.Client, error) {
 	return fcc.client, nil
 }
 
-func setupProxyTests(handler http.HandlerFunc) *uploadProxyApp {
+func setupProxyTests(handler http.HandlerFunc, cdiObjects ...runtime.Object) *uploadProxyApp {
 	server := httptest.NewServer(handler)
 
 	urlResolver := func(string, string, string) string {
@@ -174,6 +179,11 @@ func setupProxyTests(handler http.HandlerFunc) *uploadProxyApp {
 	objects = append(objects, pvc)
 	app := createApp()
 	app.client = k8sfake.NewSimpleClientset(objects...)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, obj := range cdiObjects {
+		Expect(indexer.Add(obj)).To(Succeed())
+	}
+	app.cdiConfigLister = cdilisters.NewCDIConfigLister(indexer)
 	app.tokenValidator = &validateSuccess{}
 	app.urlResolver = urlResolver
 	app.clientCreator = &fakeClientCreator{client: server.Client()}
@@ -270,4 +280,75 @@ var _ = Describe("submit request and check status", func() {
 		Expect(err).ToNot(HaveOccurred())
 		submitRequestAndCheckStatus(req, http.StatusOK, nil)
 	})
+
+	It("Should not serve metrics on the upload port", func() {
+		req, err := http.NewRequest("GET", "/metrics", nil)
+		Expect(err).ToNot(HaveOccurred())
+		submitRequestAndCheckStatus(req, http.StatusNotFound, nil)
+	})
+})
+
+func createCDIConfigWithLimits(limits *cdiv1.UploadProxyLimits) *cdiv1.CDIConfig {
+	return &cdiv1.CDIConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: common.ConfigName,
+		},
+		Spec: cdiv1.CDIConfigSpec{
+			UploadProxyLimits: limits,
+		},
+	}
+}
+
+var _ = Describe("Upload limits", func() {
+	var maxUploads = int32(1)
+
+	It("Should reject uploads over the concurrent upload limit", func() {
+		config := createCDIConfigWithLimits(&cdiv1.UploadProxyLimits{MaxConcurrentUploadsPerNamespace: &maxUploads})
+		app := setupProxyTests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}), config)
+		app.uploadPossible = func(*v1.PersistentVolumeClaim) error { return nil }
+		Expect(app.limiter.acquire("default", nil)).To(BeTrue())
+
+		rr := httptest.NewRecorder()
+		app.ServeHTTP(rr, newProxyRequest(uploadserver.UploadPathSync, "Bearer valid"))
+		Expect(rr.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(rr.Header().Get("Retry-After")).To(Equal(fmt.Sprintf("%d", uploadRetryAfterSeconds)))
+
+		app.limiter.release("default")
+		submitRequestAndCheckStatus(newProxyRequest(uploadserver.UploadPathSync, "Bearer valid"), http.StatusOK, app)
+	})
+
+	It("Should not count head requests against the concurrent upload limit", func() {
+		config := createCDIConfigWithLimits(&cdiv1.UploadProxyLimits{MaxConcurrentUploadsPerNamespace: &maxUploads})
+		app := setupProxyTests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}), config)
+		app.uploadPossible = func(*v1.PersistentVolumeClaim) error { return nil }
+		Expect(app.limiter.acquire("default", nil)).To(BeTrue())
+
+		submitRequestAndCheckStatus(newProxyHeadRequest("Bearer valid"), http.StatusOK, app)
+	})
+
+	It("Should track concurrent uploads per namespace", func() {
+		limits := &cdiv1.UploadProxyLimits{MaxConcurrentUploadsPerNamespace: &maxUploads}
+		limiter := newUploadLimiter()
+		Expect(limiter.acquire("ns1", limits)).To(BeTrue())
+		Expect(limiter.acquire("ns1", limits)).To(BeFalse())
+		Expect(limiter.acquire("ns2", limits)).To(BeTrue())
+		limiter.release("ns1")
+		Expect(limiter.acquire("ns1", limits)).To(BeTrue())
+	})
+
+	It("Should only limit bandwidth when configured", func() {
+		bandwidth := resource.MustParse("1Mi")
+		limiter := newUploadLimiter()
+		Expect(limiter.bandwidthLimiter("default", nil)).To(BeNil())
+		Expect(limiter.bandwidthLimiter("default", &cdiv1.UploadProxyLimits{})).To(BeNil())
+
+		l := limiter.bandwidthLimiter("default", &cdiv1.UploadProxyLimits{BandwidthPerNamespace: &bandwidth})
+		Expect(l).ToNot(BeNil())
+		Expect(float64(l.Limit())).To(Equal(float64(bandwidth.Value())))
+		Expect(limiter.bandwidthLimiter("default", &cdiv1.UploadProxyLimits{BandwidthPerNamespace: &bandwidth})).To(BeIdenticalTo(l))
+	})
 })
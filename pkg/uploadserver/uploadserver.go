@@ -20,25 +20,34 @@
 package uploadserver
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
 	"net"
 	"net/http"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"k8s.io/klog"
 
 	"kubevirt.io/containerized-data-importer/pkg/common"
+	"kubevirt.io/containerized-data-importer/pkg/image"
 	"kubevirt.io/containerized-data-importer/pkg/importer"
 	"kubevirt.io/containerized-data-importer/pkg/util"
+	prometheusutil "kubevirt.io/containerized-data-importer/pkg/util/prometheus"
 )
 
 const (
@@ -48,6 +57,9 @@ const (
 	// FilesystemCloneContentType is the content type when cloning a filesystem
 	FilesystemCloneContentType = "filesystem-clone"
 
+	// BlockdeviceCloneContentType is the content type when cloning a block device
+	BlockdeviceCloneContentType = "blockdevice-clone"
+
 	// UploadPathSync is the path to POST CDI uploads
 	UploadPathSync = "/v1beta1/upload"
 
@@ -60,8 +72,19 @@ const (
 	// UploadFormAsync is the path to POST CDI uploads as form datain async mode
 	UploadFormAsync = "/v1beta1/upload-form-async"
 
+	// UploadPathCloneCheckpoint is the path to GET the checkpoint of a block device clone
+	UploadPathCloneCheckpoint = "/v1beta1/clone-checkpoint"
+
+	cloneCheckpointFile = "offset"
+
 	healthzPort = 8080
 	healthzPath = "/healthz"
+
+	// seconds a client is asked to wait before retrying an upload that requires scratch space, the pod is recreated
+	// with scratch space in the meantime
+	scratchRetryAfterSeconds = 30
+	// time the responses of the uploads requiring scratch space are given to complete before the server exits
+	scratchShutdownTimeout = 10 * time.Second
 )
 
 // ProxyPaths are all supported paths
@@ -90,6 +113,11 @@ var asyncUploadFormPaths = []string{
 	"/v1alpha1/upload-form-async",
 }
 
+// CloneCheckpoint is the checkpoint of a block device clone, a resumed clone starts at Offset
+type CloneCheckpoint struct {
+	Offset int64 `json:"offset"`
+}
+
 // UploadServer is the interface to uploadServerApp
 type UploadServer interface {
 	Run() error
@@ -106,17 +134,30 @@ type uploadServerApp struct {
 	keyFile     string
 	certFile    string
 	imageSize   string
-	mux         *http.ServeMux
-	uploading   bool
-	processing  bool
-	done        bool
-	doneChan    chan struct{}
-	errChan     chan error
-	mutex       sync.Mutex
+	// fsOverhead is the fraction of a filesystem volume reserved for filesystem metadata
+	fsOverhead string
+	mux        *http.ServeMux
+	uploading  bool
+	processing bool
+	done       bool
+	// requiresScratch is set once an upload could not be converted without scratch space
+	requiresScratch bool
+	doneChan        chan struct{}
+	errChan         chan error
+	mutex           sync.Mutex
 }
 
 type imageReadCloser func(*http.Request) (io.ReadCloser, error)
 
+// gzipMagic is the header of a clone stream the cloner compressed with gzip
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// may be overridden in tests
+var cloneCheckpointDir = common.CloneCheckpointDir
+
+// may be overridden in tests
+var writeTerminationMessage = util.WriteFailureTerminationMessage
+
 // may be overridden in tests
 var uploadProcessorFunc = newUploadStreamProcessor
 var uploadProcessorFuncAsync = newAsyncUploadStreamProcessor
@@ -149,8 +190,30 @@ func formReadCloser(r *http.Request) (io.ReadCloser, error) {
 	return filePart, nil
 }
 
+// withProgress pushes the progress of reading the upload to the controller, when the size of the upload is known
+func withProgress(readCloser io.ReadCloser, contentLength int64) io.ReadCloser {
+	if contentLength <= 0 {
+		return readCloser
+	}
+	progressReader := prometheusutil.NewProgressReader(readCloser, uint64(contentLength), nil, "")
+	progressReader.StartTimedUpdate()
+	return progressReader
+}
+
+// withCompression returns a reader of the whole upload and true if the upload is gz or xz compressed, it expands in
+// scratch space
+func withCompression(readCloser io.ReadCloser) (io.ReadCloser, bool) {
+	bufReader := bufio.NewReader(readCloser)
+	hdr, _ := bufReader.Peek(image.MaxExpectedHdrSize)
+	reader := struct {
+		io.Reader
+		io.Closer
+	}{bufReader, readCloser}
+	return reader, image.IsCompressed(hdr)
+}
+
 // NewUploadServer returns a new instance of uploadServerApp
-func NewUploadServer(bindAddress string, bindPort int, destination, tlsKey, tlsCert, clientCert, clientName, imageSize string) UploadServer {
+func NewUploadServer(bindAddress string, bindPort int, destination, tlsKey, tlsCert, clientCert, clientName, imageSize, filesystemOverhead string) UploadServer {
 	server := &uploadServerApp{
 		bindAddress: bindAddress,
 		bindPort:    bindPort,
@@ -160,6 +223,7 @@ func NewUploadServer(bindAddress string, bindPort int, destination, tlsKey, tlsC
 		clientCert:  clientCert,
 		clientName:  clientName,
 		imageSize:   imageSize,
+		fsOverhead:  filesystemOverhead,
 		mux:         http.NewServeMux(),
 		uploading:   false,
 		done:        false,
@@ -179,6 +243,7 @@ func NewUploadServer(bindAddress string, bindPort int, destination, tlsKey, tlsC
 	for _, path := range asyncUploadFormPaths {
 		server.mux.HandleFunc(path, server.uploadHandlerAsync(formReadCloser))
 	}
+	server.mux.HandleFunc(UploadPathCloneCheckpoint, server.cloneCheckpointHandler)
 
 	return server
 }
@@ -228,6 +293,12 @@ func (app *uploadServerApp) Run() error {
 	select {
 	case err = <-app.errChan:
 		klog.Errorf("HTTP server returned error %s", err.Error())
+		if err == importer.ErrRequiresScratchSpace {
+			// deliver the responses asking the clients to retry before the pod exits
+			ctx, cancel := context.WithTimeout(context.Background(), scratchShutdownTimeout)
+			uploadServer.Shutdown(ctx)
+			cancel()
+		}
 	case <-app.doneChan:
 		klog.Info("Shutting down http server after successful upload")
 		healthzServer.Shutdown(context.Background())
@@ -297,6 +368,38 @@ func (app *uploadServerApp) validateShouldHandleRequest(w http.ResponseWriter, r
 		return false
 	}
 
+	if !app.validateClient(w, r) {
+		return false
+	}
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	if app.requiresScratch {
+		klog.Warning("Got upload request after scratch space was required")
+		w.Header().Set("Retry-After", strconv.Itoa(scratchRetryAfterSeconds))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return false
+	}
+
+	if app.uploading || app.processing {
+		klog.Warning("Got concurrent upload request")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return false
+	}
+
+	if app.done {
+		klog.Warning("Got upload request after already done")
+		w.WriteHeader(http.StatusConflict)
+		return false
+	}
+
+	app.uploading = true
+
+	return true
+}
+
+func (app *uploadServerApp) validateClient(w http.ResponseWriter, r *http.Request) bool {
 	if r.TLS != nil {
 		found := false
 
@@ -315,24 +418,25 @@ func (app *uploadServerApp) validateShouldHandleRequest(w http.ResponseWriter, r
 		klog.V(3).Infof("Handling HTTP connection")
 	}
 
-	app.mutex.Lock()
-	defer app.mutex.Unlock()
+	return true
+}
 
-	if app.uploading || app.processing {
-		klog.Warning("Got concurrent upload request")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		return false
+func (app *uploadServerApp) cloneCheckpointHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusNotFound)
+		return
 	}
 
-	if app.done {
-		klog.Warning("Got upload request after already done")
-		w.WriteHeader(http.StatusConflict)
-		return false
+	if !app.validateClient(w, r) {
+		return
 	}
 
-	app.uploading = true
-
-	return true
+	checkpoint := &CloneCheckpoint{Offset: readCloneCheckpoint()}
+	klog.Infof("Returning clone checkpoint %d", checkpoint.Offset)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(checkpoint); err != nil {
+		klog.Errorf("Error writing clone checkpoint: %v", err)
+	}
 }
 
 func (app *uploadServerApp) uploadHandlerAsync(irc imageReadCloser) http.HandlerFunc {
@@ -350,12 +454,16 @@ func (app *uploadServerApp) uploadHandlerAsync(irc imageReadCloser) http.Handler
 
 		klog.Infof("Content type header is %q\n", cdiContentType)
 
+		var compressed bool
 		readCloser, err := irc(r)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			readCloser = withProgress(readCloser, r.ContentLength)
+			readCloser, compressed = withCompression(readCloser)
 		}
 
-		processor, err := uploadProcessorFuncAsync(readCloser, app.destination, app.imageSize, cdiContentType)
+		processor, err := uploadProcessorFuncAsync(readCloser, app.destination, app.imageSize, app.fsOverhead, cdiContentType)
 
 		app.mutex.Lock()
 
@@ -363,6 +471,8 @@ func (app *uploadServerApp) uploadHandlerAsync(irc imageReadCloser) http.Handler
 			klog.Errorf("Saving stream failed: %s", err)
 			if _, ok := err.(importer.ValidationSizeError); ok {
 				w.WriteHeader(http.StatusBadRequest)
+			} else if err == importer.ErrRequiresScratchSpace {
+				app.requireScratchSpace(w, r.ContentLength, compressed)
 			} else {
 				w.WriteHeader(http.StatusInternalServerError)
 			}
@@ -404,19 +514,27 @@ func (app *uploadServerApp) uploadHandler(irc imageReadCloser) http.HandlerFunc
 
 		klog.Infof("Content type header is %q\n", cdiContentType)
 
+		var compressed bool
 		readCloser, err := irc(r)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			readCloser = withProgress(readCloser, r.ContentLength)
+			readCloser, compressed = withCompression(readCloser)
 		}
 
-		err = uploadProcessorFunc(readCloser, app.destination, app.imageSize, cdiContentType)
+		err = uploadProcessorFunc(readCloser, app.destination, app.imageSize, app.fsOverhead, cdiContentType)
 
 		app.mutex.Lock()
 		defer app.mutex.Unlock()
 
 		if err != nil {
 			klog.Errorf("Saving stream failed: %s", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			if err == importer.ErrRequiresScratchSpace {
+				app.requireScratchSpace(w, r.ContentLength, compressed)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
 			app.uploading = false
 			return
 		}
@@ -430,39 +548,182 @@ func (app *uploadServerApp) uploadHandler(irc imageReadCloser) http.HandlerFunc
 	}
 }
 
-func newAsyncUploadStreamProcessor(stream io.ReadCloser, dest, imageSize, contentType string) (*importer.DataProcessor, error) {
+// requireScratchSpace rejects the upload and stops the server, the pod is recreated with scratch space and the client has to retry
+// after the Retry-After delay. Later uploads to this pod are rejected the same way.
+// The size of the upload and if it's compressed are reported in the termination message, the controller sizes the scratch
+// space after them.
+func (app *uploadServerApp) requireScratchSpace(w http.ResponseWriter, contentLength int64, compressed bool) {
+	msg := &util.TerminationMessage{
+		Reason:  importer.ReasonScratchRequired,
+		Message: importer.ErrRequiresScratchSpace.Error(),
+	}
+	if contentLength > 0 {
+		msg.ScratchSize = contentLength
+		msg.ScratchCompressed = compressed
+	}
+	if err := writeTerminationMessage(msg); err != nil {
+		klog.Errorf("%+v", err)
+	}
+	app.requiresScratch = true
+	w.Header().Set("Retry-After", strconv.Itoa(scratchRetryAfterSeconds))
+	w.WriteHeader(http.StatusServiceUnavailable)
+	go func() {
+		app.errChan <- importer.ErrRequiresScratchSpace
+	}()
+}
+
+func newAsyncUploadStreamProcessor(stream io.ReadCloser, dest, imageSize, filesystemOverhead, contentType string) (*importer.DataProcessor, error) {
 	if contentType == FilesystemCloneContentType {
 		return nil, fmt.Errorf("async filesystem clone not supported")
 	}
 
 	uds := importer.NewAsyncUploadDataSource(stream)
-	processor := importer.NewDataProcessor(uds, dest, common.ImporterVolumePath, common.ScratchDataDir, imageSize)
+	processor := importer.NewDataProcessor(uds, dest, common.ImporterVolumePath, common.ScratchDataDir, imageSize, filesystemOverhead)
 	return processor, processor.ProcessDataWithPause()
 }
 
-func newUploadStreamProcessor(stream io.ReadCloser, dest, imageSize, contentType string) error {
+func newUploadStreamProcessor(stream io.ReadCloser, dest, imageSize, filesystemOverhead, contentType string) error {
 	if contentType == FilesystemCloneContentType {
-		return filesystemCloneProcessor(stream, common.ImporterVolumePath)
+		return filesystemCloneProcessor(stream, common.ImporterVolumePath, imageSize, filesystemOverhead)
+	}
+	if contentType == BlockdeviceCloneContentType {
+		return blockdeviceCloneProcessor(stream, dest, imageSize, filesystemOverhead)
 	}
 
 	uds := importer.NewUploadDataSource(stream)
-	processor := importer.NewDataProcessor(uds, dest, common.ImporterVolumePath, common.ScratchDataDir, imageSize)
+	processor := importer.NewDataProcessor(uds, dest, common.ImporterVolumePath, common.ScratchDataDir, imageSize, filesystemOverhead)
 	return processor.ProcessData()
 }
 
-func filesystemCloneProcessor(stream io.ReadCloser, destDir string) error {
+func filesystemCloneProcessor(stream io.ReadCloser, destDir, imageSize, filesystemOverhead string) error {
 	if err := importer.CleanDir(destDir); err != nil {
 		return errors.Wrapf(err, "error removing contents of %s", destDir)
 	}
 
-	gzr, err := gzip.NewReader(stream)
+	reader, err := cloneStreamReader(stream)
 	if err != nil {
-		return errors.Wrap(err, "error creating gzip reader")
+		return err
 	}
 
-	if err = util.UnArchiveTar(gzr, destDir); err != nil {
+	if err = util.UnArchiveTar(reader, destDir); err != nil {
 		return errors.Wrapf(err, "error unarchiving to %s", destDir)
 	}
 
+	return resizeClonedImage(filepath.Join(destDir, common.DiskImageName), imageSize, filesystemOverhead)
+}
+
+// resizeClonedImage grows the image cloned into a target larger than the source, archives have no image to resize
+func resizeClonedImage(dataFile, imageSize, filesystemOverhead string) error {
+	if imageSize == "" {
+		return nil
+	}
+	if _, err := os.Stat(dataFile); os.IsNotExist(err) {
+		return nil
+	}
+	availableSpace, err := util.GetAvailableSpace(filepath.Dir(dataFile))
+	if err != nil {
+		return errors.Wrapf(err, "error getting the available space of %s", filepath.Dir(dataFile))
+	}
+	// the image may grow into the available space and the space it already uses
+	allocatedSpace, err := util.GetAllocatedSize(dataFile)
+	if err != nil {
+		return errors.Wrapf(err, "error getting the allocated size of %s", dataFile)
+	}
+	usableSpace := util.GetUsableSpace(util.ParseFilesystemOverhead(filesystemOverhead), availableSpace+allocatedSpace)
+	if err := importer.ResizeImage(dataFile, imageSize, usableSpace); err != nil {
+		return errors.Wrapf(err, "error resizing %s", dataFile)
+	}
 	return nil
 }
+
+// blockdeviceCloneProcessor writes the raw data of a block device or an image, to a block device or to the image of a
+// filesystem target
+func blockdeviceCloneProcessor(stream io.ReadCloser, dest, imageSize, filesystemOverhead string) error {
+	reader, err := cloneStreamReader(stream)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(dest)
+	isFile := err != nil || info.Mode()&os.ModeDevice == 0
+	if isFile && readCloneCheckpoint() == 0 {
+		// the image of an attempt that didn't get far enough to resume
+		if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "error removing %s", dest)
+		}
+	}
+
+	hdr, err := reader.Peek(len(util.SparseStreamMagic))
+	if err == nil && util.IsSparseStream(hdr) {
+		err = util.ReadSparseStream(reader, dest, func(offset int64) error {
+			// without a checkpoint a restarted clone starts over, not worth failing for
+			if err := writeCloneCheckpoint(offset); err != nil {
+				klog.Warningf("%v", err)
+			}
+			return nil
+		})
+	} else {
+		// older cloners send the raw device contents
+		uds := importer.NewUploadDataSource(&cloneStream{Reader: reader, Closer: stream})
+		processor := importer.NewDataProcessor(uds, dest, common.ImporterVolumePath, common.ScratchDataDir, imageSize, filesystemOverhead)
+		return processor.ProcessData()
+	}
+	if err != nil || !isFile {
+		return err
+	}
+	return resizeClonedImage(dest, imageSize, filesystemOverhead)
+}
+
+// cloneStreamReader decompresses the clone stream if the cloner compressed it
+func cloneStreamReader(stream io.Reader) (*bufio.Reader, error) {
+	reader := bufio.NewReader(stream)
+	hdr, err := reader.Peek(2)
+	if err != nil || !bytes.Equal(hdr, gzipMagic) {
+		return reader, nil
+	}
+
+	gzr, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating gzip reader")
+	}
+	return bufio.NewReader(gzr), nil
+}
+
+// InitCloneCheckpoint sets the clone checkpoint unless the one kept across restarts is further along
+func InitCloneCheckpoint(offset int64) error {
+	if offset <= readCloneCheckpoint() {
+		return nil
+	}
+	return writeCloneCheckpoint(offset)
+}
+
+// readCloneCheckpoint returns the offset up to which the clone target is complete, 0 if unknown
+func readCloneCheckpoint() int64 {
+	data, err := ioutil.ReadFile(filepath.Join(cloneCheckpointDir, cloneCheckpointFile))
+	if err != nil {
+		return 0
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		klog.Errorf("Invalid clone checkpoint %q", data)
+		return 0
+	}
+	return offset
+}
+
+func writeCloneCheckpoint(offset int64) error {
+	tmpFile := filepath.Join(cloneCheckpointDir, cloneCheckpointFile+".tmp")
+	if err := ioutil.WriteFile(tmpFile, []byte(strconv.FormatInt(offset, 10)), 0644); err != nil {
+		return errors.Wrap(err, "error writing clone checkpoint")
+	}
+	if err := os.Rename(tmpFile, filepath.Join(cloneCheckpointDir, cloneCheckpointFile)); err != nil {
+		return errors.Wrap(err, "error writing clone checkpoint")
+	}
+	klog.V(1).Infof("Clone checkpoint at offset %d", offset)
+	return nil
+}
+
+type cloneStream struct {
+	io.Reader
+	io.Closer
+}
@@ -21,14 +21,20 @@ package uploadserver
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,12 +43,13 @@ import (
 	. "github.com/onsi/gomega"
 
 	"kubevirt.io/containerized-data-importer/pkg/importer"
+	"kubevirt.io/containerized-data-importer/pkg/util"
 	"kubevirt.io/containerized-data-importer/pkg/util/cert"
 	"kubevirt.io/containerized-data-importer/pkg/util/cert/triple"
 )
 
 func newServer() *uploadServerApp {
-	server := NewUploadServer("127.0.0.1", 0, "disk.img", "", "", "", "", "")
+	server := NewUploadServer("127.0.0.1", 0, "disk.img", "", "", "", "", "", "")
 	return server.(*uploadServerApp)
 }
 
@@ -60,7 +67,7 @@ func newTLSServer(clientCertName, expectedName string) (*uploadServerApp, *tripl
 	tlsCert := string(cert.EncodeCertPEM(serverKeyPair.Cert))
 	clientCert := string(cert.EncodeCertPEM(clientCA.Cert))
 
-	server := NewUploadServer("127.0.0.1", 0, "disk.img", tlsKey, tlsCert, clientCert, expectedName, "").(*uploadServerApp)
+	server := NewUploadServer("127.0.0.1", 0, "disk.img", tlsKey, tlsCert, clientCert, expectedName, "", "").(*uploadServerApp)
 
 	clientKeyPair, err := triple.NewClientKeyPair(clientCA, clientCertName, []string{})
 	Expect(err).ToNot(HaveOccurred())
@@ -87,14 +94,18 @@ func newHTTPClient(clientKeyPair *triple.KeyPair, serverCACert *x509.Certificate
 	return client
 }
 
-func saveProcessorSuccess(stream io.ReadCloser, dest, imageSize, contentType string) error {
+func saveProcessorSuccess(stream io.ReadCloser, dest, imageSize, filesystemOverhead, contentType string) error {
 	return nil
 }
 
-func saveProcessorFailure(stream io.ReadCloser, dest, imageSize, contentType string) error {
+func saveProcessorFailure(stream io.ReadCloser, dest, imageSize, filesystemOverhead, contentType string) error {
 	return fmt.Errorf("Error using datastream")
 }
 
+func saveProcessorScratchRequired(stream io.ReadCloser, dest, imageSize, filesystemOverhead, contentType string) error {
+	return importer.ErrRequiresScratchSpace
+}
+
 func withProcessorSuccess(f func()) {
 	replaceProcessorFunc(saveProcessorSuccess, f)
 }
@@ -103,7 +114,11 @@ func withProcessorFailure(f func()) {
 	replaceProcessorFunc(saveProcessorFailure, f)
 }
 
-func replaceProcessorFunc(replacement func(io.ReadCloser, string, string, string) error, f func()) {
+func withProcessorScratchRequired(f func()) {
+	replaceProcessorFunc(saveProcessorScratchRequired, f)
+}
+
+func replaceProcessorFunc(replacement func(io.ReadCloser, string, string, string, string) error, f func()) {
 	origProcessorFunc := uploadProcessorFunc
 	uploadProcessorFunc = replacement
 	defer func() {
@@ -150,12 +165,16 @@ func (amd *AsyncMockDataSource) GetResumePhase() importer.ProcessingPhase {
 	return importer.ProcessingPhaseComplete
 }
 
-func saveAsyncProcessorSuccess(stream io.ReadCloser, dest, imageSize, contentType string) (*importer.DataProcessor, error) {
-	return importer.NewDataProcessor(&AsyncMockDataSource{}, "", "", "", ""), nil
+func saveAsyncProcessorSuccess(stream io.ReadCloser, dest, imageSize, filesystemOverhead, contentType string) (*importer.DataProcessor, error) {
+	return importer.NewDataProcessor(&AsyncMockDataSource{}, "", "", "", "", ""), nil
+}
+
+func saveAsyncProcessorFailure(stream io.ReadCloser, dest, imageSize, filesystemOverhead, contentType string) (*importer.DataProcessor, error) {
+	return importer.NewDataProcessor(&AsyncMockDataSource{}, "", "", "", "", ""), fmt.Errorf("Error using datastream")
 }
 
-func saveAsyncProcessorFailure(stream io.ReadCloser, dest, imageSize, contentType string) (*importer.DataProcessor, error) {
-	return importer.NewDataProcessor(&AsyncMockDataSource{}, "", "", "", ""), fmt.Errorf("Error using datastream")
+func saveAsyncProcessorScratchRequired(stream io.ReadCloser, dest, imageSize, filesystemOverhead, contentType string) (*importer.DataProcessor, error) {
+	return importer.NewDataProcessor(&AsyncMockDataSource{}, "", "", "", "", ""), importer.ErrRequiresScratchSpace
 }
 
 func withAsyncProcessorSuccess(f func()) {
@@ -166,7 +185,11 @@ func withAsyncProcessorFailure(f func()) {
 	replaceAsyncProcessorFunc(saveAsyncProcessorFailure, f)
 }
 
-func replaceAsyncProcessorFunc(replacement func(io.ReadCloser, string, string, string) (*importer.DataProcessor, error), f func()) {
+func withAsyncProcessorScratchRequired(f func()) {
+	replaceAsyncProcessorFunc(saveAsyncProcessorScratchRequired, f)
+}
+
+func replaceAsyncProcessorFunc(replacement func(io.ReadCloser, string, string, string, string) (*importer.DataProcessor, error), f func()) {
 	origProcessorFuncAsync := uploadProcessorFuncAsync
 	uploadProcessorFuncAsync = replacement
 	defer func() {
@@ -331,6 +354,50 @@ var _ = Describe("Upload server tests", func() {
 		table.Entry("sync", withProcessorFailure, UploadFormSync),
 	)
 
+	table.DescribeTable("Scratch space required", func(processorFunc func(func()), uploadPath, body string, compressed bool) {
+		var terminationMessage *util.TerminationMessage
+		origWriteTerminationMessage := writeTerminationMessage
+		writeTerminationMessage = func(msg *util.TerminationMessage) error {
+			terminationMessage = msg
+			return nil
+		}
+		defer func() {
+			writeTerminationMessage = origWriteTerminationMessage
+		}()
+
+		processorFunc(func() {
+			req, err := http.NewRequest("POST", uploadPath, strings.NewReader(body))
+			Expect(err).ToNot(HaveOccurred())
+
+			rr := httptest.NewRecorder()
+
+			server := newServer()
+			server.ServeHTTP(rr, req)
+
+			status := rr.Code
+			Expect(status).To(Equal(http.StatusServiceUnavailable))
+			Eventually(server.errChan).Should(Receive(Equal(importer.ErrRequiresScratchSpace)))
+			Expect(terminationMessage).ToNot(BeNil())
+			Expect(terminationMessage.Reason).To(Equal(importer.ReasonScratchRequired))
+			Expect(terminationMessage.ScratchSize).To(Equal(int64(len(body))))
+			Expect(terminationMessage.ScratchCompressed).To(Equal(compressed))
+			Expect(rr.Header().Get("Retry-After")).To(Equal(strconv.Itoa(scratchRetryAfterSeconds)))
+
+			By("Rejecting the retries sent to this pod")
+			req, err = http.NewRequest("POST", uploadPath, strings.NewReader(body))
+			Expect(err).ToNot(HaveOccurred())
+			rr = httptest.NewRecorder()
+			server.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rr.Header().Get("Retry-After")).To(Equal(strconv.Itoa(scratchRetryAfterSeconds)))
+		})
+	},
+		table.Entry("async", withAsyncProcessorScratchRequired, UploadPathAsync, "data", false),
+		table.Entry("sync", withProcessorScratchRequired, UploadPathSync, "data", false),
+		table.Entry("async compressed", withAsyncProcessorScratchRequired, UploadPathAsync, "\x1f\x8b\x08data", true),
+		table.Entry("sync compressed", withProcessorScratchRequired, UploadPathSync, "\x1f\x8b\x08data", true),
+	)
+
 	table.DescribeTable("Real upload with client", func(certName string, expectedName string, expectedResponse int) {
 		withProcessorSuccess(func() {
 			server, clientKeyPair, serverCACert := newTLSServer(certName, expectedName)
@@ -372,6 +439,146 @@ var _ = Describe("Upload server tests", func() {
 	)
 })
 
+var _ = Describe("Block device clone processor", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "blockclone")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	table.DescribeTable("should write the sparse stream", func(compress bool) {
+		raw := make([]byte, 4*1024*1024)
+		copy(raw[1024*1024:], bytes.Repeat([]byte{1}, 4096))
+
+		var stream bytes.Buffer
+		var w io.WriteCloser = &nopWriteCloser{&stream}
+		if compress {
+			w = gzip.NewWriter(&stream)
+		}
+		_, err := util.WriteSparseStream(w, bytes.NewReader(raw), 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+
+		dest := filepath.Join(tmpDir, "disk.img")
+		err = blockdeviceCloneProcessor(ioutil.NopCloser(&stream), dest, "", "")
+		Expect(err).ToNot(HaveOccurred())
+		written, err := ioutil.ReadFile(dest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(written).To(Equal(raw))
+	},
+		table.Entry("with gzip", true),
+		table.Entry("without compression", false),
+	)
+
+	It("should replace the image left by a previous attempt without checkpoint", func() {
+		raw := bytes.Repeat([]byte{1}, 4096)
+		var stream bytes.Buffer
+		_, err := util.WriteSparseStream(&nopWriteCloser{&stream}, bytes.NewReader(raw), 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		dest := filepath.Join(tmpDir, "disk.img")
+		Expect(ioutil.WriteFile(dest, []byte("stale"), 0644)).To(Succeed())
+		err = blockdeviceCloneProcessor(ioutil.NopCloser(&stream), dest, "", "")
+		Expect(err).ToNot(HaveOccurred())
+		written, err := ioutil.ReadFile(dest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(written).To(Equal(raw))
+	})
+})
+
+var _ = Describe("Filesystem clone resize", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "fsclone")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("should leave clones without an image alone", func() {
+		Expect(resizeClonedImage(filepath.Join(tmpDir, "disk.img"), "1G", "0.055")).To(Succeed())
+	})
+
+	It("should leave the image alone without a size", func() {
+		dataFile := filepath.Join(tmpDir, "disk.img")
+		Expect(ioutil.WriteFile(dataFile, []byte("data"), 0644)).To(Succeed())
+		Expect(resizeClonedImage(dataFile, "", "0.055")).To(Succeed())
+		data, err := ioutil.ReadFile(dataFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("data")))
+	})
+})
+
+var _ = Describe("Clone checkpoint", func() {
+	var tmpDir, origDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "checkpoint")
+		Expect(err).ToNot(HaveOccurred())
+		origDir = cloneCheckpointDir
+		cloneCheckpointDir = tmpDir
+	})
+
+	AfterEach(func() {
+		cloneCheckpointDir = origDir
+		os.RemoveAll(tmpDir)
+	})
+
+	getCheckpoint := func() int64 {
+		req, err := http.NewRequest("GET", UploadPathCloneCheckpoint, nil)
+		Expect(err).ToNot(HaveOccurred())
+		rr := httptest.NewRecorder()
+		newServer().ServeHTTP(rr, req)
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		checkpoint := &CloneCheckpoint{}
+		Expect(json.NewDecoder(rr.Body).Decode(checkpoint)).To(Succeed())
+		return checkpoint.Offset
+	}
+
+	It("should return zero without a checkpoint", func() {
+		Expect(getCheckpoint()).To(BeZero())
+	})
+
+	It("should return the written checkpoint", func() {
+		Expect(writeCloneCheckpoint(4096)).To(Succeed())
+		Expect(getCheckpoint()).To(Equal(int64(4096)))
+	})
+
+	It("should only seed a checkpoint past the current one", func() {
+		Expect(InitCloneCheckpoint(8192)).To(Succeed())
+		Expect(readCloneCheckpoint()).To(Equal(int64(8192)))
+		Expect(InitCloneCheckpoint(4096)).To(Succeed())
+		Expect(readCloneCheckpoint()).To(Equal(int64(8192)))
+	})
+
+	It("should not return the checkpoint on POST", func() {
+		req, err := http.NewRequest("POST", UploadPathCloneCheckpoint, nil)
+		Expect(err).ToNot(HaveOccurred())
+		rr := httptest.NewRecorder()
+		newServer().ServeHTTP(rr, req)
+		Expect(rr.Code).To(Equal(http.StatusNotFound))
+	})
+})
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
 func newFormRequest(path string) *http.Request {
 	var b bytes.Buffer
 	w := multipart.NewWriter(&b)
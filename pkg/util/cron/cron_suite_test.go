@@ -0,0 +1,15 @@
+package cron
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+
+	"kubevirt.io/containerized-data-importer/tests/reporters"
+)
+
+func TestCron(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Cron Test Suite", reporters.NewReporters())
+}
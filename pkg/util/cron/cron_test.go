@@ -0,0 +1,51 @@
+package cron
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cron schedule", func() {
+	// a wednesday
+	start := time.Date(2020, time.July, 15, 10, 30, 20, 0, time.UTC)
+
+	table.DescribeTable("Should return the next time matching the schedule", func(spec string, expected time.Time) {
+		schedule, err := Parse(spec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schedule.Next(start)).To(Equal(expected))
+	},
+		table.Entry("every minute", "* * * * *", time.Date(2020, time.July, 15, 10, 31, 0, 0, time.UTC)),
+		table.Entry("every 15 minutes", "*/15 * * * *", time.Date(2020, time.July, 15, 10, 45, 0, 0, time.UTC)),
+		table.Entry("every 15 minutes from 5", "5/15 * * * *", time.Date(2020, time.July, 15, 10, 35, 0, 0, time.UTC)),
+		table.Entry("at a minute list", "10,20,40 * * * *", time.Date(2020, time.July, 15, 10, 40, 0, 0, time.UTC)),
+		table.Entry("hourly", "@hourly", time.Date(2020, time.July, 15, 11, 0, 0, 0, time.UTC)),
+		table.Entry("daily", "@daily", time.Date(2020, time.July, 16, 0, 0, 0, 0, time.UTC)),
+		table.Entry("weekly", "@weekly", time.Date(2020, time.July, 19, 0, 0, 0, 0, time.UTC)),
+		table.Entry("monthly", "@monthly", time.Date(2020, time.August, 1, 0, 0, 0, 0, time.UTC)),
+		table.Entry("yearly", "@yearly", time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		table.Entry("week days range", "0 3 * * mon-fri", time.Date(2020, time.July, 16, 3, 0, 0, 0, time.UTC)),
+		table.Entry("sunday as 7", "0 3 * * 7", time.Date(2020, time.July, 19, 3, 0, 0, 0, time.UTC)),
+		table.Entry("month names", "0 0 1 jan,oct *", time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)),
+		table.Entry("day of month or day of week", "0 0 20 * mon", time.Date(2020, time.July, 20, 0, 0, 0, 0, time.UTC)),
+		table.Entry("leap day", "0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)),
+		table.Entry("never", "0 0 30 2 *", time.Time{}),
+	)
+
+	table.DescribeTable("Should reject an invalid schedule", func(spec string) {
+		_, err := Parse(spec)
+		Expect(err).To(HaveOccurred())
+	},
+		table.Entry("empty", ""),
+		table.Entry("too few fields", "* * * *"),
+		table.Entry("too many fields", "* * * * * *"),
+		table.Entry("minute out of range", "60 * * * *"),
+		table.Entry("day of month out of range", "* * 0 * *"),
+		table.Entry("reversed range", "* 10-5 * * *"),
+		table.Entry("zero step", "*/0 * * * *"),
+		table.Entry("unknown name", "* * * foo *"),
+		table.Entry("unknown macro", "@sometimes"),
+	)
+})
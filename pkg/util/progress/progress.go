@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package progress
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+const (
+	// minReportInterval is the minimum time between two progress reports of a worker pod, phase changes and the
+	// final report are sent right away
+	minReportInterval = 5 * time.Second
+	// reportTimeout bounds the time a worker pod waits for the controller to accept a report
+	reportTimeout = 5 * time.Second
+)
+
+// Report is the progress of a worker pod populating the PVC of a DataVolume
+type Report struct {
+	// OwnerUID is the UID of the DataVolume being populated
+	OwnerUID string `json:"ownerUID"`
+	// Phase is the processing phase of the worker pod
+	Phase string `json:"phase,omitempty"`
+	// Progress is the percentage of the data transferred
+	Progress float64 `json:"progress"`
+	// Bytes is the number of bytes transferred
+	Bytes uint64 `json:"bytes"`
+	// Total is the number of bytes to transfer, 0 if unknown
+	Total uint64 `json:"total,omitempty"`
+}
+
+// Client pushes the progress of a worker pod to the progress endpoint of the controller, rate limited to one report
+// every few seconds
+type Client struct {
+	url    string
+	client *http.Client
+
+	mutex    sync.Mutex
+	report   Report
+	lastSent time.Time
+}
+
+var defaultClient = NewClient(os.Getenv(common.ProgressURL), os.Getenv(common.OwnerUID))
+
+// NewClient creates a client pushing the progress of populating the DataVolume with the passed in UID to the url,
+// the client does nothing if either is empty
+func NewClient(url, ownerUID string) *Client {
+	return &Client{
+		url: url,
+		// The controller serves the progress endpoint with a self signed certificate, the reports don't contain
+		// anything worth intercepting.
+		client: &http.Client{
+			Timeout: reportTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		report: Report{OwnerUID: ownerUID},
+	}
+}
+
+// SetPhase reports the processing phase the worker pod moved to
+func SetPhase(phase string) {
+	defaultClient.SetPhase(phase)
+}
+
+// Update reports the progress of the data transfer of the worker pod
+func Update(progress float64, bytes, total uint64) {
+	defaultClient.Update(progress, bytes, total)
+}
+
+// SetPhase reports the processing phase the worker pod moved to
+func (c *Client) SetPhase(phase string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.report.Phase == phase {
+		return
+	}
+	c.report.Phase = phase
+	c.send()
+}
+
+// Update reports the progress of the data transfer, the report is skipped if the last one was sent less than
+// minReportInterval ago, unless the transfer is complete
+func (c *Client) Update(progress float64, bytes, total uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.report.Progress = progress
+	c.report.Bytes = bytes
+	c.report.Total = total
+	if progress < 100.0 && time.Since(c.lastSent) < minReportInterval {
+		return
+	}
+	c.send()
+}
+
+func (c *Client) send() {
+	if c.url == "" || c.report.OwnerUID == "" {
+		return
+	}
+	c.lastSent = time.Now()
+	body, err := json.Marshal(c.report)
+	if err != nil {
+		klog.Errorf("Unable to marshal progress report: %v", err)
+		return
+	}
+	resp, err := c.client.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// the progress is informational, the worker pod goes on without it
+		klog.V(3).Infof("Unable to report progress: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		klog.V(3).Infof("Unable to report progress: unexpected status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,15 @@
+package progress
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+
+	"kubevirt.io/containerized-data-importer/tests/reporters"
+)
+
+func TestProgress(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Progress Test Suite", reporters.NewReporters())
+}
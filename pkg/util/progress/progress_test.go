@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package progress
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+const testOwnerUID = "b856691e-1038-11e9-a5ab-525500d15501"
+
+var _ = Describe("Progress client", func() {
+	var (
+		store  *Store
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		store = NewStore()
+		Expect(store.Track(testOwnerUID, "127.0.0.1")).To(BeTrue())
+		server = httptest.NewTLSServer(store)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("Should push the phase and progress to the store", func() {
+		client := NewClient(server.URL, testOwnerUID)
+		client.Update(13.45, 1345, 10000)
+		client.SetPhase("TransferDataFile")
+
+		report, ok := store.Get(testOwnerUID)
+		Expect(ok).To(BeTrue())
+		Expect(report.Phase).To(Equal("TransferDataFile"))
+		Expect(report.Progress).To(Equal(13.45))
+		Expect(report.Bytes).To(BeEquivalentTo(1345))
+		Expect(report.Total).To(BeEquivalentTo(10000))
+	})
+
+	It("Should rate limit the progress updates, but not the completion", func() {
+		client := NewClient(server.URL, testOwnerUID)
+		client.Update(10, 1000, 10000)
+		client.Update(20, 2000, 10000)
+
+		report, ok := store.Get(testOwnerUID)
+		Expect(ok).To(BeTrue())
+		Expect(report.Progress).To(BeEquivalentTo(10))
+
+		client.Update(100, 10000, 10000)
+		report, ok = store.Get(testOwnerUID)
+		Expect(ok).To(BeTrue())
+		Expect(report.Progress).To(BeEquivalentTo(100))
+	})
+
+	It("Should not report without an url", func() {
+		client := NewClient("", testOwnerUID)
+		client.Update(100, 10000, 10000)
+		_, ok := store.Get(testOwnerUID)
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("Progress store", func() {
+	postReport := func(store *Store, method, body, remoteAddr string) int {
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		store.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	table.DescribeTable("Should reject invalid reports", func(method, body string, expectedCode int) {
+		store := NewStore()
+		Expect(store.Track("1234", "192.0.2.10")).To(BeTrue())
+		Expect(postReport(store, method, body, "192.0.2.10:43210")).To(Equal(expectedCode))
+		_, ok := store.Get("1234")
+		Expect(ok).To(BeFalse())
+	},
+		table.Entry("with another method", http.MethodGet, "", http.StatusMethodNotAllowed),
+		table.Entry("that is not json", http.MethodPost, "progress", http.StatusBadRequest),
+		table.Entry("without owner", http.MethodPost, `{"progress": 10}`, http.StatusBadRequest),
+		table.Entry("with an invalid progress", http.MethodPost, `{"ownerUID": "1234", "progress": 110}`, http.StatusBadRequest),
+		table.Entry("that is too large", http.MethodPost, `{"ownerUID": "1234", "phase": "`+strings.Repeat("a", maxReportSize)+`"}`, http.StatusBadRequest),
+	)
+
+	table.DescribeTable("Should only accept the reports of the tracked pod", func(podIP, remoteAddr string, expectedCode int) {
+		store := NewStore()
+		Expect(store.Track(testOwnerUID, podIP)).To(BeTrue())
+		Expect(postReport(store, http.MethodPost, `{"ownerUID": "`+testOwnerUID+`", "progress": 10}`, remoteAddr)).To(Equal(expectedCode))
+		_, ok := store.Get(testOwnerUID)
+		Expect(ok).To(Equal(expectedCode == http.StatusOK))
+	},
+		table.Entry("from the tracked pod", "192.0.2.10", "192.0.2.10:43210", http.StatusOK),
+		table.Entry("from another pod", "192.0.2.10", "192.0.2.20:43210", http.StatusForbidden),
+		table.Entry("from a pod without IP", "", "192.0.2.10:43210", http.StatusForbidden),
+	)
+
+	It("Should reject the reports of untracked DataVolumes", func() {
+		store := NewStore()
+		Expect(postReport(store, http.MethodPost, `{"ownerUID": "`+testOwnerUID+`", "progress": 10}`, "192.0.2.10:43210")).To(Equal(http.StatusNotFound))
+		Expect(store.dataVolumes).To(BeEmpty())
+	})
+
+	It("Should drop the report of the previous pod", func() {
+		store := NewStore()
+		Expect(store.Track(testOwnerUID, "192.0.2.10")).To(BeTrue())
+		Expect(store.put(Report{OwnerUID: testOwnerUID, Progress: 10}, "192.0.2.10")).To(Equal(http.StatusOK))
+		Expect(store.Track(testOwnerUID, "192.0.2.20")).To(BeTrue())
+		_, ok := store.Get(testOwnerUID)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should stop tracking stale DataVolumes", func() {
+		store := NewStore()
+		store.dataVolumes["stale"] = &trackedDataVolume{podIP: "192.0.2.20", tracked: time.Now().Add(-2 * staleTrackingAge), report: &Report{OwnerUID: "stale"}}
+		Expect(store.Track(testOwnerUID, "192.0.2.10")).To(BeTrue())
+		Expect(store.put(Report{OwnerUID: testOwnerUID}, "192.0.2.10")).To(Equal(http.StatusOK))
+		_, ok := store.Get("stale")
+		Expect(ok).To(BeFalse())
+		_, ok = store.Get(testOwnerUID)
+		Expect(ok).To(BeTrue())
+
+		store.Delete(testOwnerUID)
+		_, ok = store.Get(testOwnerUID)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should not track more than the maximum of DataVolumes", func() {
+		store := NewStore()
+		for i := 0; i < maxTrackedDataVolumes; i++ {
+			store.dataVolumes[strconv.Itoa(i)] = &trackedDataVolume{tracked: time.Now()}
+		}
+		Expect(store.Track(testOwnerUID, "192.0.2.10")).To(BeFalse())
+		Expect(store.Track("0", "192.0.2.10")).To(BeTrue())
+	})
+})
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The CDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog"
+
+	"kubevirt.io/containerized-data-importer/pkg/common"
+)
+
+const (
+	// maxReportSize bounds the size of the body of a progress report
+	maxReportSize = 4 * 1024
+	// maxTrackedDataVolumes bounds the number of DataVolumes whose progress is tracked
+	maxTrackedDataVolumes = 10000
+	// staleTrackingAge is the time after which a DataVolume is no longer tracked if the controller didn't track it again,
+	// in case the DataVolume was deleted before it was populated
+	staleTrackingAge = time.Hour
+	// sweepInterval is the minimum time between two sweeps of the DataVolumes which are no longer tracked
+	sweepInterval = time.Minute
+)
+
+// trackedDataVolume is a DataVolume being populated, whose progress is reported by the pod populating it
+type trackedDataVolume struct {
+	podIP   string
+	tracked time.Time
+	report  *Report
+}
+
+// Store keeps the last progress report of every DataVolume being populated, by the UID of the DataVolume. Only the
+// reports of the DataVolumes the controller tracks, sent from the pod populating them, are accepted.
+type Store struct {
+	mutex       sync.RWMutex
+	dataVolumes map[string]*trackedDataVolume
+	lastSweep   time.Time
+}
+
+// NewStore creates an empty progress store
+func NewStore() *Store {
+	return &Store{dataVolumes: make(map[string]*trackedDataVolume)}
+}
+
+// Track accepts the progress reports for the DataVolume with the passed in UID sent from the passed in pod IP, until
+// the DataVolume is deleted from the store or isn't tracked again within an hour. It returns false if the store
+// tracks too many DataVolumes.
+func (s *Store) Track(ownerUID, podIP string) bool {
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for uid, dataVolume := range s.dataVolumes {
+			if now.Sub(dataVolume.tracked) > staleTrackingAge {
+				delete(s.dataVolumes, uid)
+			}
+		}
+		s.lastSweep = now
+	}
+	dataVolume, ok := s.dataVolumes[ownerUID]
+	if !ok {
+		if len(s.dataVolumes) >= maxTrackedDataVolumes {
+			return false
+		}
+		dataVolume = &trackedDataVolume{}
+		s.dataVolumes[ownerUID] = dataVolume
+	}
+	if dataVolume.podIP != podIP {
+		// another pod populates the DataVolume, the report of the previous one is outdated
+		dataVolume.podIP = podIP
+		dataVolume.report = nil
+	}
+	dataVolume.tracked = now
+	return true
+}
+
+// Get returns the last progress report of the DataVolume with the passed in UID
+func (s *Store) Get(ownerUID string) (Report, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	dataVolume, ok := s.dataVolumes[ownerUID]
+	if !ok || dataVolume.report == nil {
+		return Report{}, false
+	}
+	return *dataVolume.report, true
+}
+
+// Delete stops tracking the DataVolume with the passed in UID, once it is populated
+func (s *Store) Delete(ownerUID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.dataVolumes, ownerUID)
+}
+
+// put stores the report sent from the source IP, it returns the http status of the request
+func (s *Store) put(report Report, sourceIP string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	dataVolume, ok := s.dataVolumes[report.OwnerUID]
+	if !ok {
+		return http.StatusNotFound
+	}
+	if dataVolume.podIP == "" || dataVolume.podIP != sourceIP {
+		return http.StatusForbidden
+	}
+	dataVolume.report = &report
+	return http.StatusOK
+}
+
+// ServeHTTP stores the progress report posted by a worker pod
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := Report{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportSize)).Decode(&report); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid progress report: %v", err), http.StatusBadRequest)
+		return
+	}
+	if report.OwnerUID == "" || report.Progress < 0 || report.Progress > 100 {
+		http.Error(w, "Invalid progress report", http.StatusBadRequest)
+		return
+	}
+	sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		sourceIP = r.RemoteAddr
+	}
+	if status := s.put(report, sourceIP); status != http.StatusOK {
+		klog.V(3).Infof("Rejected progress of %s from %s with status %d", report.OwnerUID, sourceIP, status)
+		w.WriteHeader(status)
+		return
+	}
+	klog.V(3).Infof("Progress of %s from %s: %.2f%% (%d bytes) in phase %q", report.OwnerUID, sourceIP, report.Progress, report.Bytes, report.Phase)
+}
+
+// StartServer starts an https server receiving the progress reports of the worker pods into the store, using the passed
+// in directory to store the self signed certificates that will be generated before starting the server.
+func StartServer(certsDirectory string, store *Store) error {
+	certBytes, keyBytes, err := cert.GenerateSelfSignedCertKey(common.ProgressServiceName, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	certFile := path.Join(certsDirectory, "progress.crt")
+	if err = ioutil.WriteFile(certFile, certBytes, 0600); err != nil {
+		return err
+	}
+
+	keyFile := path.Join(certsDirectory, "progress.key")
+	if err = ioutil.WriteFile(keyFile, keyBytes, 0600); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(common.ProgressPath, store)
+	go func() {
+		if err := http.ListenAndServeTLS(fmt.Sprintf(":%d", common.ProgressPort), certFile, keyFile, mux); err != nil {
+			klog.Errorf("Progress server stopped: %v", err)
+		}
+	}()
+	return nil
+}
@@ -15,9 +15,12 @@ import (
 	"k8s.io/klog"
 
 	"kubevirt.io/containerized-data-importer/pkg/util"
+	progressutil "kubevirt.io/containerized-data-importer/pkg/util/progress"
 )
 
-// ProgressReader is a counting reader that reports progress to prometheus.
+const defaultPrometheusPort = 8443
+
+// ProgressReader is a counting reader that reports progress to prometheus and pushes it to the controller.
 type ProgressReader struct {
 	util.CountingReader
 	total    uint64
@@ -25,7 +28,8 @@ type ProgressReader struct {
 	ownerUID string
 }
 
-// NewProgressReader creates a new instance of a prometheus updating progress reader.
+// NewProgressReader creates a new instance of a prometheus updating progress reader, the progress counter may be nil to
+// only push the progress to the controller.
 func NewProgressReader(r io.ReadCloser, total uint64, progress *prometheus.CounterVec, ownerUID string) *ProgressReader {
 	promReader := &ProgressReader{
 		CountingReader: util.CountingReader{
@@ -61,11 +65,14 @@ func (r *ProgressReader) updateProgress() bool {
 		if !r.Done && r.Current < r.total {
 			currentProgress = float64(r.Current) / float64(r.total) * 100.0
 		}
-		metric := &dto.Metric{}
-		r.progress.WithLabelValues(r.ownerUID).Write(metric)
-		if currentProgress > *metric.Counter.Value {
-			r.progress.WithLabelValues(r.ownerUID).Add(currentProgress - *metric.Counter.Value)
+		if r.progress != nil {
+			metric := &dto.Metric{}
+			r.progress.WithLabelValues(r.ownerUID).Write(metric)
+			if currentProgress > *metric.Counter.Value {
+				r.progress.WithLabelValues(r.ownerUID).Add(currentProgress - *metric.Counter.Value)
+			}
 		}
+		progressutil.Update(currentProgress, r.Current, r.total)
 		klog.V(1).Infoln(fmt.Sprintf("%.2f", currentProgress))
 		return !r.Done
 	}
@@ -76,6 +83,22 @@ func (r *ProgressReader) updateProgress() bool {
 // in directory to store the self signed certificates that will be generated before starting the
 // http server.
 func StartPrometheusEndpoint(certsDirectory string) {
+	startPrometheusEndpoint(certsDirectory, defaultPrometheusPort, promhttp.Handler())
+}
+
+// StartPrometheusEndpointOnPort starts the prometheus endpoint of StartPrometheusEndpoint on the passed in port, for
+// components already serving on the default port.
+func StartPrometheusEndpointOnPort(certsDirectory string, port int) {
+	startPrometheusEndpoint(certsDirectory, port, promhttp.Handler())
+}
+
+// StartGathererPrometheusEndpoint starts the prometheus endpoint of StartPrometheusEndpoint serving the metrics of the
+// passed in gatherer instead of the default registry, like the metrics registry of a controller manager.
+func StartGathererPrometheusEndpoint(certsDirectory string, gatherer prometheus.Gatherer) {
+	startPrometheusEndpoint(certsDirectory, defaultPrometheusPort, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+}
+
+func startPrometheusEndpoint(certsDirectory string, port int, handler http.Handler) {
 	certBytes, keyBytes, err := cert.GenerateSelfSignedCertKey("cloner_target", nil, nil)
 	if err != nil {
 		klog.Error("Error generating cert for prometheus")
@@ -95,8 +118,8 @@ func StartPrometheusEndpoint(certsDirectory string) {
 	}
 
 	go func() {
-		http.Handle("/metrics", promhttp.Handler())
-		if err := http.ListenAndServeTLS(":8443", certFile, keyFile, nil); err != nil {
+		http.Handle("/metrics", handler)
+		if err := http.ListenAndServeTLS(fmt.Sprintf(":%d", port), certFile, keyFile, nil); err != nil {
 			return
 		}
 	}()
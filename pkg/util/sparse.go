@@ -0,0 +1,221 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog"
+)
+
+const (
+	// SparseStreamMagic starts every sparse stream
+	SparseStreamMagic = "CDISPRS1"
+
+	// size of the chunks checked for zeroes
+	sparseChunkSize = 64 * 1024
+	// maximum amount of data sent in a single record
+	sparseMaxRecord = 16 * sparseChunkSize
+	sparseHeaderLen = 16
+)
+
+// amount of data written between checkpoints, may be overridden in tests
+var sparseCheckpointInterval int64 = 1024 * 1024 * 1024
+
+// IsSparseStream returns true if the header starts with the sparse stream magic
+func IsSparseStream(hdr []byte) bool {
+	return bytes.HasPrefix(hdr, []byte(SparseStreamMagic))
+}
+
+// WriteSparseStream reads a raw disk image from r and writes it to w as a sparse stream, leaving out the zero extents.
+// The stream consists of the magic and the big endian offset the stream starts at, followed by records made of a big
+// endian offset and length and the data. A record with a zero length terminates the stream, its offset is the total
+// size of the image. r has to be positioned at start, which is non zero when resuming a partial copy.
+func WriteSparseStream(w io.Writer, r io.Reader, start int64) (int64, error) {
+	hdr := make([]byte, len(SparseStreamMagic)+8)
+	copy(hdr, SparseStreamMagic)
+	binary.BigEndian.PutUint64(hdr[len(SparseStreamMagic):], uint64(start))
+	if _, err := w.Write(hdr); err != nil {
+		return 0, errors.Wrap(err, "unable to write sparse stream header")
+	}
+
+	offset, recordStart := start, start
+	record := make([]byte, 0, sparseMaxRecord)
+	chunk := make([]byte, sparseChunkSize)
+	for {
+		n, readErr := io.ReadFull(r, chunk)
+		if n > 0 {
+			if isZero(chunk[:n]) {
+				if err := flushSparseRecord(w, recordStart, record); err != nil {
+					return offset, err
+				}
+				record = record[:0]
+			} else {
+				if len(record) == 0 {
+					recordStart = offset
+				}
+				record = append(record, chunk[:n]...)
+				if len(record) >= sparseMaxRecord {
+					if err := flushSparseRecord(w, recordStart, record); err != nil {
+						return offset, err
+					}
+					record = record[:0]
+				}
+			}
+			offset += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return offset, errors.Wrap(readErr, "unable to read source")
+		}
+	}
+	if err := flushSparseRecord(w, recordStart, record); err != nil {
+		return offset, err
+	}
+	return offset, writeSparseRecord(w, offset, nil)
+}
+
+func flushSparseRecord(w io.Writer, offset int64, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return writeSparseRecord(w, offset, data)
+}
+
+func writeSparseRecord(w io.Writer, offset int64, data []byte) error {
+	hdr := make([]byte, sparseHeaderLen)
+	binary.BigEndian.PutUint64(hdr[0:], uint64(offset))
+	binary.BigEndian.PutUint64(hdr[8:], uint64(len(data)))
+	if _, err := w.Write(hdr); err != nil {
+		return errors.Wrap(err, "unable to write sparse record header")
+	}
+	if _, err := w.Write(data); err != nil {
+		return errors.Wrap(err, "unable to write sparse record data")
+	}
+	return nil
+}
+
+// ReadSparseStream writes the sparse stream in r to the file or block device fileName. The extents left out of the
+// stream are left as holes in files and discarded or zeroed on block devices. A stream resuming a partial copy is
+// written to the existing file. The data is synced and checkpoint, if not nil, is called with the offset up to which
+// the target is complete about every GiB.
+func ReadSparseStream(r io.Reader, fileName string, checkpoint func(int64) error) error {
+	hdr := make([]byte, len(SparseStreamMagic)+8)
+	if _, err := io.ReadFull(r, hdr); err != nil || !IsSparseStream(hdr) {
+		return errors.New("invalid sparse stream header")
+	}
+	start := int64(binary.BigEndian.Uint64(hdr[len(SparseStreamMagic):]))
+
+	var isBlock bool
+	var blockSize int64
+	if info, err := os.Stat(fileName); err == nil && info.Mode()&os.ModeDevice != 0 {
+		isBlock = true
+		if blockSize, err = GetAvailableSpaceBlock(fileName); err != nil {
+			return errors.Wrapf(err, "error getting size of block device")
+		}
+	}
+	var outFile *os.File
+	var err error
+	if isBlock {
+		outFile, err = os.OpenFile(fileName, os.O_EXCL|os.O_WRONLY, os.ModePerm)
+	} else if start > 0 {
+		outFile, err = os.OpenFile(fileName, os.O_WRONLY, os.ModePerm)
+	} else {
+		outFile, err = os.OpenFile(fileName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.ModePerm)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not open file %q", fileName)
+	}
+	defer outFile.Close()
+
+	if start > 0 {
+		klog.Infof("Resuming sparse data at offset %d\n", start)
+	}
+	if err = readSparseStream(r, outFile, isBlock, blockSize, start, checkpoint); err != nil {
+		if !isBlock && start == 0 {
+			os.Remove(outFile.Name())
+		}
+		return err
+	}
+	return outFile.Sync()
+}
+
+func readSparseStream(r io.Reader, outFile *os.File, isBlock bool, blockSize, start int64, checkpoint func(int64) error) error {
+	klog.V(1).Infof("Writing sparse data...\n")
+	end, lastCheckpoint := start, start
+	hdr := make([]byte, sparseHeaderLen)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return errors.Wrap(err, "unable to read sparse record header")
+		}
+		offset := int64(binary.BigEndian.Uint64(hdr[0:]))
+		length := int64(binary.BigEndian.Uint64(hdr[8:]))
+		if offset < end || (isBlock && offset+length > blockSize) {
+			return errors.Errorf("invalid sparse record at offset %d", offset)
+		}
+		// a resumed copy may have stale data past start
+		if offset > end && (isBlock || start > 0) {
+			if err := zeroRange(outFile, end, offset-end); err != nil {
+				return err
+			}
+		}
+		if length == 0 {
+			if !isBlock {
+				return errors.Wrap(outFile.Truncate(offset), "unable to set file size")
+			}
+			return nil
+		}
+		if _, err := outFile.Seek(offset, io.SeekStart); err != nil {
+			return errors.Wrapf(err, "unable to seek to offset %d", offset)
+		}
+		if _, err := io.CopyN(outFile, r, length); err != nil {
+			return errors.Wrapf(err, "unable to write data at offset %d", offset)
+		}
+		end = offset + length
+		if checkpoint != nil && end-lastCheckpoint >= sparseCheckpointInterval {
+			if err := outFile.Sync(); err != nil {
+				return errors.Wrap(err, "unable to sync data")
+			}
+			if err := checkpoint(end); err != nil {
+				return err
+			}
+			lastCheckpoint = end
+		}
+	}
+}
+
+// zeroRange discards the range of a file or block device, falling back to writing zeroes
+func zeroRange(outFile *os.File, offset, length int64) error {
+	err := unix.Fallocate(int(outFile.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, offset, length)
+	if err == nil {
+		return nil
+	}
+	klog.V(3).Infof("Unable to punch hole at offset %d, writing zeroes: %v", offset, err)
+	zeroes := make([]byte, sparseMaxRecord)
+	for length > 0 {
+		n := int64(len(zeroes))
+		if length < n {
+			n = length
+		}
+		if _, err = outFile.WriteAt(zeroes[:n], offset); err != nil {
+			return errors.Wrapf(err, "unable to zero offset %d", offset)
+		}
+		offset += n
+		length -= n
+	}
+	return nil
+}
+
+func isZero(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sparse stream", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "sparse")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	roundTrip := func(raw []byte) []byte {
+		var stream bytes.Buffer
+		n, err := WriteSparseStream(&stream, bytes.NewReader(raw), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(len(raw))))
+		encoded := append([]byte{}, stream.Bytes()...)
+		Expect(IsSparseStream(encoded)).To(BeTrue())
+
+		dest := filepath.Join(tmpDir, "disk.img")
+		Expect(ReadSparseStream(&stream, dest, nil)).To(Succeed())
+		written, err := ioutil.ReadFile(dest)
+		Expect(err).NotTo(HaveOccurred())
+		return written
+	}
+
+	It("Should leave out zero chunks", func() {
+		raw := make([]byte, 10*sparseChunkSize+123)
+		copy(raw[sparseChunkSize:], bytes.Repeat([]byte{1}, sparseChunkSize))
+		copy(raw[7*sparseChunkSize+5:], []byte("data"))
+		raw[len(raw)-1] = 2
+
+		var stream bytes.Buffer
+		_, err := WriteSparseStream(&stream, bytes.NewReader(raw), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stream.Len()).To(BeNumerically("<", 4*sparseChunkSize))
+
+		written := roundTrip(raw)
+		Expect(written).To(Equal(raw))
+	})
+
+	It("Should keep the size of an all zero image", func() {
+		raw := make([]byte, 3*sparseChunkSize)
+		written := roundTrip(raw)
+		Expect(written).To(Equal(raw))
+	})
+
+	It("Should split large data extents into multiple records", func() {
+		raw := bytes.Repeat([]byte{3}, 2*sparseMaxRecord+sparseChunkSize)
+		written := roundTrip(raw)
+		Expect(written).To(Equal(raw))
+	})
+
+	It("Should resume a partial copy", func() {
+		raw := make([]byte, 8*sparseChunkSize)
+		copy(raw, bytes.Repeat([]byte{1}, sparseChunkSize))
+		copy(raw[5*sparseChunkSize:], bytes.Repeat([]byte{5}, sparseChunkSize))
+		start := int64(2 * sparseChunkSize)
+
+		By("Writing the partial copy with stale data past the resume offset")
+		dest := filepath.Join(tmpDir, "disk.img")
+		partial := append(append([]byte{}, raw[:start]...), bytes.Repeat([]byte{9}, 3*sparseChunkSize)...)
+		Expect(ioutil.WriteFile(dest, partial, 0644)).To(Succeed())
+
+		var stream bytes.Buffer
+		n, err := WriteSparseStream(&stream, bytes.NewReader(raw[start:]), start)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(len(raw))))
+		Expect(ReadSparseStream(&stream, dest, nil)).To(Succeed())
+		written, err := ioutil.ReadFile(dest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(written).To(Equal(raw))
+	})
+
+	It("Should checkpoint the written data", func() {
+		defaultInterval := sparseCheckpointInterval
+		sparseCheckpointInterval = sparseMaxRecord
+		defer func() { sparseCheckpointInterval = defaultInterval }()
+
+		raw := bytes.Repeat([]byte{7}, 3*sparseMaxRecord)
+		var stream bytes.Buffer
+		_, err := WriteSparseStream(&stream, bytes.NewReader(raw), 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		var checkpoints []int64
+		err = ReadSparseStream(&stream, filepath.Join(tmpDir, "disk.img"), func(offset int64) error {
+			checkpoints = append(checkpoints, offset)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoints).To(Equal([]int64{sparseMaxRecord, 2 * sparseMaxRecord, 3 * sparseMaxRecord}))
+	})
+
+	It("Should fail on a truncated stream and remove the file", func() {
+		raw := bytes.Repeat([]byte{4}, sparseChunkSize)
+		var stream bytes.Buffer
+		_, err := WriteSparseStream(&stream, bytes.NewReader(raw), 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		dest := filepath.Join(tmpDir, "disk.img")
+		err = ReadSparseStream(bytes.NewReader(stream.Bytes()[:stream.Len()-20]), dest, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = os.Stat(dest)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("Should fail without the sparse stream magic", func() {
+		err := ReadSparseStream(bytes.NewReader([]byte("not a sparse stream")), filepath.Join(tmpDir, "disk.img"), nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
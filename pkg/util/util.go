@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -26,6 +27,8 @@ import (
 
 const (
 	blockdevFileName = "/usr/sbin/blockdev"
+	// the kubelet truncates longer termination messages
+	maxTerminationMessageSize = 4096
 )
 
 // CountingReader is a reader that keeps track of how much has been read
@@ -105,6 +108,16 @@ func GetAvailableSpace(path string) (int64, error) {
 	return int64(stat.Bavail) * int64(stat.Bsize), nil
 }
 
+// GetAllocatedSize gets the amount of space allocated to the file at the path specified.
+func GetAllocatedSize(path string) (int64, error) {
+	var stat syscall.Stat_t
+	err := syscall.Stat(path, &stat)
+	if err != nil {
+		return int64(-1), err
+	}
+	return stat.Blocks * 512, nil
+}
+
 // GetAvailableSpaceBlock gets the amount of available space at the block device path specified.
 func GetAvailableSpaceBlock(deviceName string) (int64, error) {
 	// Check if device exists.
@@ -140,6 +153,31 @@ func MinQuantity(availableSpace, imageSize *resource.Quantity) resource.Quantity
 	return *imageSize
 }
 
+// ParseFilesystemOverhead parses the fraction of a filesystem volume reserved for filesystem metadata, no overhead if
+// it is blank or invalid
+func ParseFilesystemOverhead(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	overhead, err := strconv.ParseFloat(value, 64)
+	if err != nil || overhead < 0 || overhead >= 1 {
+		klog.Warningf("Ignoring invalid filesystem overhead %q", value)
+		return 0
+	}
+	return overhead
+}
+
+// GetUsableSpace returns the space of a filesystem volume an image can use, the available space minus the filesystem
+// overhead, aligned down to 512 bytes for qemu-img
+func GetUsableSpace(filesystemOverhead float64, availableSpace int64) int64 {
+	if filesystemOverhead <= 0 || availableSpace <= 0 {
+		return availableSpace
+	}
+	const alignment = 512
+	usableSpace := int64(float64(availableSpace) * (1 - filesystemOverhead))
+	return usableSpace / alignment * alignment
+}
+
 // StreamDataToFile provides a function to stream the specified io.Reader to the specified local file
 func StreamDataToFile(r io.Reader, fileName string) error {
 	var outFile *os.File
@@ -246,6 +284,68 @@ func WriteTerminationMessageToFile(file, message string) error {
 	return nil
 }
 
+// TerminationMessage is the structured termination message written by a failed CDI pod, or by a source digest poller pod
+type TerminationMessage struct {
+	// Reason is a CamelCase reason for the failure
+	Reason string `json:"reason"`
+	// Message is a human readable description of the failure
+	Message string `json:"message"`
+	// Checkpoint is the offset up to which the target of a resumable clone is known to be complete
+	Checkpoint int64 `json:"checkpoint,omitempty"`
+	// ScratchSize is the size of the source of an import or upload requiring scratch space, if known
+	ScratchSize int64 `json:"scratchSize,omitempty"`
+	// ScratchCompressed is true if the source of ScratchSize is gz or xz compressed, it expands in scratch space
+	ScratchCompressed bool `json:"scratchCompressed,omitempty"`
+	// Digest is the digest of the source polled by a source digest poller pod, empty if the source has none
+	Digest string `json:"digest,omitempty"`
+}
+
+// WriteFailureTerminationMessage writes a structured termination message. Like WriteTerminationMessage only the first
+// line of the message is written, and it is shortened to keep the termination message within the size kubernetes keeps.
+func WriteFailureTerminationMessage(msg *TerminationMessage) error {
+	data, err := marshalTerminationMessage(msg)
+	if err != nil {
+		return err
+	}
+	return WriteTerminationMessage(string(data))
+}
+
+// WriteDigestTerminationMessage writes the digest polled by a source digest poller pod in a structured termination
+// message
+func WriteDigestTerminationMessage(digest string) error {
+	return WriteFailureTerminationMessage(&TerminationMessage{Reason: "DigestPolled", Message: "Polled the source digest", Digest: digest})
+}
+
+func marshalTerminationMessage(msg *TerminationMessage) ([]byte, error) {
+	m := *msg
+	m.Message = strings.SplitN(m.Message, "\n", 2)[0]
+	for {
+		data, err := json.Marshal(&m)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal termination message")
+		}
+		excess := len(data) - maxTerminationMessageSize
+		if excess <= 0 || m.Message == "" {
+			return data, nil
+		}
+		// an escaped character takes up to 6 bytes, don't cut more than needed
+		cut := (excess + 5) / 6
+		if cut > len(m.Message) {
+			cut = len(m.Message)
+		}
+		m.Message = m.Message[:len(m.Message)-cut]
+	}
+}
+
+// ParseTerminationMessage parses a structured termination message, it returns nil if the message isn't structured
+func ParseTerminationMessage(message string) *TerminationMessage {
+	msg := &TerminationMessage{}
+	if err := json.Unmarshal([]byte(message), msg); err != nil || msg.Reason == "" {
+		return nil
+	}
+	return msg
+}
+
 // CopyDir copies a dir from one location to another.
 func CopyDir(source string, dest string) (err error) {
 	// get properties of source dir
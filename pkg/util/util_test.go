@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/extensions/table"
@@ -88,6 +89,24 @@ var _ = Describe("Compare quantities", func() {
 	})
 })
 
+var _ = Describe("Filesystem overhead", func() {
+	table.DescribeTable("Should parse the filesystem overhead", func(value string, expected float64) {
+		Expect(ParseFilesystemOverhead(value)).To(Equal(expected))
+	},
+		table.Entry("blank", "", float64(0)),
+		table.Entry("valid", "0.055", 0.055),
+		table.Entry("not a number", "five", float64(0)),
+		table.Entry("whole volume", "1", float64(0)),
+		table.Entry("negative", "-0.1", float64(0)),
+	)
+
+	It("Should leave the overhead out of the usable space, aligned to 512 bytes", func() {
+		Expect(GetUsableSpace(0, 1000)).To(Equal(int64(1000)))
+		Expect(GetUsableSpace(0.1, 10000)).To(Equal(int64(8704)))
+		Expect(GetUsableSpace(0.1, -1)).To(Equal(int64(-1)))
+	})
+})
+
 var _ = Describe("Copy files", func() {
 	var destTmp string
 	var err error
@@ -124,6 +143,26 @@ var _ = Describe("Copy files", func() {
 	})
 })
 
+var _ = Describe("GetAllocatedSize", func() {
+	It("Should not count the holes of a sparse file", func() {
+		tmpDir, err := ioutil.TempDir("", "allocated")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(tmpDir)
+		file, err := os.Create(filepath.Join(tmpDir, "sparse.img"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Truncate(1024 * 1024 * 1024)).To(Succeed())
+		Expect(file.Close()).To(Succeed())
+		size, err := GetAllocatedSize(filepath.Join(tmpDir, "sparse.img"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(size).To(BeNumerically("<", 1024*1024*1024))
+	})
+
+	It("Should fail on a missing file", func() {
+		_, err := GetAllocatedSize("/invalidpath/sparse.img")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 func md5sum(filePath string) (string, error) {
 	var returnMD5String string
 
@@ -144,3 +183,35 @@ func md5sum(filePath string) (string, error) {
 
 	return returnMD5String, nil
 }
+
+var _ = Describe("Termination message", func() {
+	It("Should parse a structured termination message", func() {
+		msg := ParseTerminationMessage(`{"reason":"SomeReason","message":"some message"}`)
+		Expect(msg).ToNot(BeNil())
+		Expect(msg.Reason).To(Equal("SomeReason"))
+		Expect(msg.Message).To(Equal("some message"))
+	})
+
+	It("Should not parse a plain termination message", func() {
+		Expect(ParseTerminationMessage("Unable to process data")).To(BeNil())
+		Expect(ParseTerminationMessage(`{"message":"no reason"}`)).To(BeNil())
+	})
+
+	It("Should only keep the first line of the message", func() {
+		data, err := marshalTerminationMessage(&TerminationMessage{Reason: "SomeReason", Message: "some message\nstack trace"})
+		Expect(err).ToNot(HaveOccurred())
+		msg := ParseTerminationMessage(string(data))
+		Expect(msg).ToNot(BeNil())
+		Expect(msg.Message).To(Equal("some message"))
+	})
+
+	It("Should shorten a long message", func() {
+		data, err := marshalTerminationMessage(&TerminationMessage{Reason: "SomeReason", Message: strings.Repeat("\"", 5000)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(data)).To(BeNumerically("<=", maxTerminationMessageSize))
+		msg := ParseTerminationMessage(string(data))
+		Expect(msg).ToNot(BeNil())
+		Expect(msg.Reason).To(Equal("SomeReason"))
+		Expect(msg.Message).ToNot(BeEmpty())
+	})
+})
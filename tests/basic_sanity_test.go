@@ -131,6 +131,7 @@ var _ = Describe("[rfe_id:1347][crit:high][vendor:cnv-qe@redhat.com][level:compo
 			table.Entry("CDIConfigs", "cdiconfigs.cdi.kubevirt.io"),
 			table.Entry("CDIs", "cdis.cdi.kubevirt.io"),
 			table.Entry("Datavolumes", "datavolumes.cdi.kubevirt.io"),
+			table.Entry("DataImportCrons", "dataimportcrons.cdi.kubevirt.io"),
 		)
 	})
 })
@@ -20,6 +20,7 @@ import (
 
 	"kubevirt.io/containerized-data-importer/pkg/common"
 	"kubevirt.io/containerized-data-importer/pkg/controller"
+	"kubevirt.io/containerized-data-importer/pkg/importer"
 	"kubevirt.io/containerized-data-importer/pkg/util/naming"
 	"kubevirt.io/containerized-data-importer/tests/framework"
 	"kubevirt.io/containerized-data-importer/tests/utils"
@@ -256,7 +257,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 				url:          func() string { return "http://i-made-this-up.kube-system/tinyCore.iso" },
 				dvFunc:       utils.NewDataVolumeWithHTTPImport,
 				errorMessage: "Unable to connect to http data source",
-				eventReason:  "Error",
+				eventReason:  importer.ReasonImportFailed,
 				phase:        cdiv1.ImportInProgress,
 				readyCondition: &cdiv1.DataVolumeCondition{
 					Type:   cdiv1.DataVolumeReady,
@@ -272,7 +273,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 					Type:    cdiv1.DataVolumeRunning,
 					Status:  v1.ConditionFalse,
 					Message: "Unable to connect to http data source: Get http://i-made-this-up.kube-system/tinyCore.iso: dial tcp: lookup i-made-this-up.kube-system",
-					Reason:  "Error",
+					Reason:  importer.ReasonImportFailed,
 				}}),
 			table.Entry("[rfe_id:1115][crit:high][posneg:negative][test_id:1359]fail creating import dv due to file not found", dataVolumeTestArguments{
 				name:         "dv-http-import-404",
@@ -280,7 +281,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 				url:          func() string { return tinyCoreIsoURL() + "not.real.file" },
 				dvFunc:       utils.NewDataVolumeWithHTTPImport,
 				errorMessage: "Unable to connect to http data source: expected status code 200, got 404. Status: 404 Not Found",
-				eventReason:  "Error",
+				eventReason:  importer.ReasonNotFound,
 				phase:        cdiv1.ImportInProgress,
 				readyCondition: &cdiv1.DataVolumeCondition{
 					Type:   cdiv1.DataVolumeReady,
@@ -296,7 +297,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 					Type:    cdiv1.DataVolumeRunning,
 					Status:  v1.ConditionFalse,
 					Message: "Unable to connect to http data source: expected status code 200, got 404. Status: 404 Not Found",
-					Reason:  "Error",
+					Reason:  importer.ReasonNotFound,
 				}}),
 			table.Entry("[rfe_id:1120][crit:high][posneg:negative][test_id:2555]fail creating import dv: invalid qcow large size", dataVolumeTestArguments{
 				name:         "dv-invalid-qcow-large-size",
@@ -304,7 +305,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 				url:          invalidQcowLargeSizeURL,
 				dvFunc:       utils.NewDataVolumeWithHTTPImport,
 				errorMessage: "Unable to process data: Invalid format qcow for image",
-				eventReason:  "Error",
+				eventReason:  importer.ReasonInvalidImage,
 				phase:        cdiv1.ImportInProgress,
 				readyCondition: &cdiv1.DataVolumeCondition{
 					Type:   cdiv1.DataVolumeReady,
@@ -320,7 +321,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 					Type:    cdiv1.DataVolumeRunning,
 					Status:  v1.ConditionFalse,
 					Message: "Unable to process data: Invalid format qcow for image",
-					Reason:  "Error",
+					Reason:  importer.ReasonInvalidImage,
 				}}),
 			table.Entry("[rfe_id:1120][crit:high][posneg:negative][test_id:2554]fail creating import dv: invalid qcow large json", dataVolumeTestArguments{
 				name:         "dv-invalid-qcow-large-json",
@@ -328,7 +329,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 				url:          invalidQcowLargeJSONURL,
 				dvFunc:       utils.NewDataVolumeWithHTTPImport,
 				errorMessage: "Unable to process data: qemu-img: curl: The requested URL returned error: 416 Requested Range Not Satisfiable",
-				eventReason:  "Error",
+				eventReason:  importer.ReasonInvalidImage,
 				phase:        cdiv1.ImportInProgress,
 				readyCondition: &cdiv1.DataVolumeCondition{
 					Type:   cdiv1.DataVolumeReady,
@@ -344,7 +345,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 					Type:    cdiv1.DataVolumeRunning,
 					Status:  v1.ConditionFalse,
 					Message: "Unable to process data: qemu-img: curl: The requested URL returned error: 416 Requested Range Not Satisfiable",
-					Reason:  "Error",
+					Reason:  importer.ReasonInvalidImage,
 				}}),
 			table.Entry("[rfe_id:1120][crit:high][posneg:negative][test_id:2253]fail creating import dv: invalid qcow large memory", dataVolumeTestArguments{
 				name:         "dv-invalid-qcow-large-memory",
@@ -352,7 +353,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 				url:          invalidQcowLargeMemoryURL,
 				dvFunc:       utils.NewDataVolumeWithHTTPImport,
 				errorMessage: "Unable to process data: qemu-img: Could not open '/data/disk.img': L1 size too big",
-				eventReason:  "Error",
+				eventReason:  importer.ReasonInvalidImage,
 				phase:        cdiv1.ImportInProgress,
 				readyCondition: &cdiv1.DataVolumeCondition{
 					Type:   cdiv1.DataVolumeReady,
@@ -368,7 +369,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 					Type:    cdiv1.DataVolumeRunning,
 					Status:  v1.ConditionFalse,
 					Message: "Unable to process data: qemu-img: Could not open '/data/disk.img': L1 size too big",
-					Reason:  "Error",
+					Reason:  importer.ReasonInvalidImage,
 				}}),
 			table.Entry("[rfe_id:1120][crit:high][posneg:negative][test_id:2139]fail creating import dv: invalid qcow backing file", dataVolumeTestArguments{
 				name:         "dv-invalid-qcow-backing-file",
@@ -376,7 +377,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 				url:          invalidQcowBackingFileURL,
 				dvFunc:       utils.NewDataVolumeWithHTTPImport,
 				errorMessage: "Unable to process data: qemu-img: Could not open '/data/disk.img': L1 size too big",
-				eventReason:  "Error",
+				eventReason:  importer.ReasonInvalidImage,
 				phase:        cdiv1.ImportInProgress,
 				readyCondition: &cdiv1.DataVolumeCondition{
 					Type:   cdiv1.DataVolumeReady,
@@ -392,7 +393,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 					Type:    cdiv1.DataVolumeRunning,
 					Status:  v1.ConditionFalse,
 					Message: "Unable to process data: qemu-img: Could not open '/data/disk.img': L1 size too big",
-					Reason:  "Error",
+					Reason:  importer.ReasonInvalidImage,
 				}}),
 			table.Entry("[test_id:3931]succeed creating import dv with streaming image conversion", dataVolumeTestArguments{
 				name:             "dv-http-stream-import",
@@ -542,7 +543,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 				url:          tinyCoreIsoURL,
 				dvFunc:       utils.NewDataVolumeWithArchiveContent,
 				errorMessage: "Unable to process data: exit status 2",
-				eventReason:  "Error",
+				eventReason:  importer.ReasonImportFailed,
 				phase:        cdiv1.ImportInProgress,
 				readyCondition: &cdiv1.DataVolumeCondition{
 					Type:   cdiv1.DataVolumeReady,
@@ -558,7 +559,7 @@ var _ = Describe("[vendor:cnv-qe@redhat.com][level:component]DataVolume tests",
 					Type:    cdiv1.DataVolumeRunning,
 					Status:  v1.ConditionFalse,
 					Message: "Unable to process data: exit status 2",
-					Reason:  "Error",
+					Reason:  importer.ReasonImportFailed,
 				}}),
 			table.Entry("[test_id:3932]succeed creating dv from imageio source", dataVolumeTestArguments{
 				name:             "dv-imageio-test",
@@ -170,6 +170,7 @@ var _ = Describe("Aggregated role definition tests", func() {
 			},
 			Resources: []string{
 				"datavolumes",
+				"dataimportcrons",
 			},
 			Verbs: []string{
 				"*",
@@ -223,6 +224,7 @@ var _ = Describe("Aggregated role definition tests", func() {
 			},
 			Resources: []string{
 				"datavolumes",
+				"dataimportcrons",
 			},
 			Verbs: []string{
 				"get",
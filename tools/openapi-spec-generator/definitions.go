@@ -53,6 +53,12 @@ func CoreAPI() []*restful.WebService {
 		Resource: "datavolumes",
 	}
 
+	dicGVR := schema.GroupVersionResource{
+		Group:    cdiv1.SchemeGroupVersion.Group,
+		Version:  cdiv1.SchemeGroupVersion.Version,
+		Resource: "dataimportcrons",
+	}
+
 	cdiGVR := schema.GroupVersionResource{
 		Group:    cdiv1.SchemeGroupVersion.Group,
 		Version:  cdiv1.SchemeGroupVersion.Version,
@@ -75,6 +81,11 @@ func CoreAPI() []*restful.WebService {
 		panic(err)
 	}
 
+	ws, err = genericResourceProxy(ws, dicGVR, &cdiv1.DataImportCron{}, "DataImportCron", &cdiv1.DataImportCronList{})
+	if err != nil {
+		panic(err)
+	}
+
 	ws, err = genericResourceProxy(ws, cdiGVR, &cdiv1.CDI{}, "CDI", &cdiv1.CDIList{})
 	if err != nil {
 		panic(err)